@@ -42,6 +42,7 @@ type Config struct {
 	Extensions   []Module     `mapstructure:"extensions"`
 	Receivers    []Module     `mapstructure:"receivers"`
 	Processors   []Module     `mapstructure:"processors"`
+	Connectors   []Module     `mapstructure:"connectors"`
 	Replaces     []string     `mapstructure:"replaces"`
 	Excludes     []string     `mapstructure:"excludes"`
 }
@@ -58,7 +59,7 @@ type Distribution struct {
 	BuildTags      string `mapstructure:"build_tags"`
 }
 
-// Module represents a receiver, exporter, processor or extension for the distribution
+// Module represents a receiver, exporter, processor, connector or extension for the distribution
 type Module struct {
 	Name   string `mapstructure:"name"`   // if not specified, this is package part of the go mod (last part of the path)
 	Import string `mapstructure:"import"` // if not specified, this is the path part of the go mods
@@ -90,7 +91,7 @@ func NewDefaultConfig() Config {
 
 // Validate checks whether the current configuration is valid
 func (c *Config) Validate() error {
-	return multierr.Combine(validateModules(c.Extensions), validateModules(c.Receivers), validateModules(c.Exporters), validateModules(c.Processors))
+	return multierr.Combine(validateModules(c.Extensions), validateModules(c.Receivers), validateModules(c.Exporters), validateModules(c.Processors), validateModules(c.Connectors))
 }
 
 // SetGoPath sets go path
@@ -133,6 +134,11 @@ func (c *Config) ParseModules() error {
 		return err
 	}
 
+	c.Connectors, err = parseModules(c.Connectors)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -8,12 +8,17 @@ require (
 	github.com/stretchr/testify v1.8.1
 	go.opentelemetry.io/collector v0.68.0
 	go.opentelemetry.io/collector/component v0.68.0
+	go.opentelemetry.io/collector/connector/forwardconnector v0.68.0
+	go.opentelemetry.io/collector/exporter/failoverexporter v0.68.0
 	go.opentelemetry.io/collector/exporter/loggingexporter v0.68.0
 	go.opentelemetry.io/collector/exporter/otlpexporter v0.68.0
 	go.opentelemetry.io/collector/exporter/otlphttpexporter v0.68.0
 	go.opentelemetry.io/collector/extension/ballastextension v0.68.0
+	go.opentelemetry.io/collector/extension/filestorageextension v0.68.0
+	go.opentelemetry.io/collector/extension/memorylimiterextension v0.68.0
 	go.opentelemetry.io/collector/extension/zpagesextension v0.68.0
 	go.opentelemetry.io/collector/processor/batchprocessor v0.68.0
+	go.opentelemetry.io/collector/processor/inflightbyteslimiterprocessor v0.68.0
 	go.opentelemetry.io/collector/processor/memorylimiterprocessor v0.68.0
 	go.opentelemetry.io/collector/receiver/otlpreceiver v0.68.0
 	golang.org/x/sys v0.3.0
@@ -75,6 +80,7 @@ require (
 	github.com/tklauser/numcpus v0.6.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.2 // indirect
 	github.com/zeebo/xxh3 v1.0.2 // indirect
+	go.etcd.io/bbolt v1.3.6 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	go.opentelemetry.io/collector/confmap v0.68.0 // indirect
 	go.opentelemetry.io/collector/consumer v0.68.0 // indirect
@@ -112,8 +118,12 @@ replace go.opentelemetry.io/collector/component => ../../component
 
 replace go.opentelemetry.io/collector/confmap => ../../confmap
 
+replace go.opentelemetry.io/collector/connector/forwardconnector => ../../connector/forwardconnector
+
 replace go.opentelemetry.io/collector/consumer => ../../consumer
 
+replace go.opentelemetry.io/collector/exporter/failoverexporter => ../../exporter/failoverexporter
+
 replace go.opentelemetry.io/collector/exporter/loggingexporter => ../../exporter/loggingexporter
 
 replace go.opentelemetry.io/collector/exporter/otlpexporter => ../../exporter/otlpexporter
@@ -122,6 +132,10 @@ replace go.opentelemetry.io/collector/exporter/otlphttpexporter => ../../exporte
 
 replace go.opentelemetry.io/collector/extension/ballastextension => ../../extension/ballastextension
 
+replace go.opentelemetry.io/collector/extension/filestorageextension => ../../extension/filestorageextension
+
+replace go.opentelemetry.io/collector/extension/memorylimiterextension => ../../extension/memorylimiterextension
+
 replace go.opentelemetry.io/collector/extension/zpagesextension => ../../extension/zpagesextension
 
 replace go.opentelemetry.io/collector/featuregate => ../../featuregate
@@ -130,6 +144,8 @@ replace go.opentelemetry.io/collector/pdata => ../../pdata
 
 replace go.opentelemetry.io/collector/processor/batchprocessor => ../../processor/batchprocessor
 
+replace go.opentelemetry.io/collector/processor/inflightbyteslimiterprocessor => ../../processor/inflightbyteslimiterprocessor
+
 replace go.opentelemetry.io/collector/processor/memorylimiterprocessor => ../../processor/memorylimiterprocessor
 
 replace go.opentelemetry.io/collector/semconv => ../../semconv
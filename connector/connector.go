@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 
+	"go.uber.org/zap"
+
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 )
@@ -85,6 +87,9 @@ type Logs interface {
 
 // CreateSettings configures Connector creators.
 type CreateSettings struct {
+	// ID returns the ID of the component that will be created.
+	ID component.ID
+
 	TelemetrySettings component.TelemetrySettings
 
 	// BuildInfo can be used by components for informational purposes
@@ -453,3 +458,160 @@ func NewFactory(cfgType component.Type, createDefaultConfig component.CreateDefa
 	}
 	return f
 }
+
+// MakeFactoryMap takes a list of connector factories and returns a map with factory type as keys.
+// It returns a non-nil error when there are factories with duplicate type.
+func MakeFactoryMap(factories ...Factory) (map[component.Type]Factory, error) {
+	fMap := map[component.Type]Factory{}
+	for _, f := range factories {
+		if _, ok := fMap[f.Type()]; ok {
+			return fMap, fmt.Errorf("duplicate connector factory %q", f.Type())
+		}
+		fMap[f.Type()] = f
+	}
+	return fMap, nil
+}
+
+// Builder connector is a helper struct that given a set of Configs and Factories helps with creating connectors.
+type Builder struct {
+	cfgs      map[component.ID]component.Config
+	factories map[component.Type]Factory
+}
+
+// NewBuilder creates a new connector.Builder to help with creating components form a set of configs and factories.
+func NewBuilder(cfgs map[component.ID]component.Config, factories map[component.Type]Factory) *Builder {
+	return &Builder{cfgs: cfgs, factories: factories}
+}
+
+// CreateTracesToTraces creates a Traces connector based on the settings and config.
+func (b *Builder) CreateTracesToTraces(ctx context.Context, set CreateSettings, next consumer.Traces) (Traces, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.TracesToTracesStability())
+	return f.CreateTracesToTraces(ctx, set, cfg, next)
+}
+
+// CreateTracesToMetrics creates a Traces connector based on the settings and config.
+func (b *Builder) CreateTracesToMetrics(ctx context.Context, set CreateSettings, next consumer.Metrics) (Traces, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.TracesToMetricsStability())
+	return f.CreateTracesToMetrics(ctx, set, cfg, next)
+}
+
+// CreateTracesToLogs creates a Traces connector based on the settings and config.
+func (b *Builder) CreateTracesToLogs(ctx context.Context, set CreateSettings, next consumer.Logs) (Traces, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.TracesToLogsStability())
+	return f.CreateTracesToLogs(ctx, set, cfg, next)
+}
+
+// CreateMetricsToTraces creates a Metrics connector based on the settings and config.
+func (b *Builder) CreateMetricsToTraces(ctx context.Context, set CreateSettings, next consumer.Traces) (Metrics, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.MetricsToTracesStability())
+	return f.CreateMetricsToTraces(ctx, set, cfg, next)
+}
+
+// CreateMetricsToMetrics creates a Metrics connector based on the settings and config.
+func (b *Builder) CreateMetricsToMetrics(ctx context.Context, set CreateSettings, next consumer.Metrics) (Metrics, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.MetricsToMetricsStability())
+	return f.CreateMetricsToMetrics(ctx, set, cfg, next)
+}
+
+// CreateMetricsToLogs creates a Metrics connector based on the settings and config.
+func (b *Builder) CreateMetricsToLogs(ctx context.Context, set CreateSettings, next consumer.Logs) (Metrics, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.MetricsToLogsStability())
+	return f.CreateMetricsToLogs(ctx, set, cfg, next)
+}
+
+// CreateLogsToTraces creates a Logs connector based on the settings and config.
+func (b *Builder) CreateLogsToTraces(ctx context.Context, set CreateSettings, next consumer.Traces) (Logs, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.LogsToTracesStability())
+	return f.CreateLogsToTraces(ctx, set, cfg, next)
+}
+
+// CreateLogsToMetrics creates a Logs connector based on the settings and config.
+func (b *Builder) CreateLogsToMetrics(ctx context.Context, set CreateSettings, next consumer.Metrics) (Logs, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.LogsToMetricsStability())
+	return f.CreateLogsToMetrics(ctx, set, cfg, next)
+}
+
+// CreateLogsToLogs creates a Logs connector based on the settings and config.
+func (b *Builder) CreateLogsToLogs(ctx context.Context, set CreateSettings, next consumer.Logs) (Logs, error) {
+	cfg, f, err := b.configAndFactory(set.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logStabilityLevel(set.TelemetrySettings.Logger, f.LogsToLogsStability())
+	return f.CreateLogsToLogs(ctx, set, cfg, next)
+}
+
+// IsConfigured returns true if the given ID is the ID of a configured connector.
+func (b *Builder) IsConfigured(componentID component.ID) bool {
+	_, ok := b.cfgs[componentID]
+	return ok
+}
+
+func (b *Builder) Factory(componentType component.Type) component.Factory {
+	return b.factories[componentType]
+}
+
+func (b *Builder) configAndFactory(id component.ID) (component.Config, Factory, error) {
+	cfg, existsCfg := b.cfgs[id]
+	if !existsCfg {
+		return nil, nil, fmt.Errorf("connector %q is not configured", id)
+	}
+
+	f, existsFactory := b.factories[id.Type()]
+	if !existsFactory {
+		return nil, nil, fmt.Errorf("connector factory not available for: %q", id)
+	}
+	return cfg, f, nil
+}
+
+// logStabilityLevel logs the stability level of a component. The log level is set to info for
+// undefined, unmaintained, deprecated and development. The log level is set to debug
+// for alpha, beta and stable.
+func logStabilityLevel(logger *zap.Logger, sl component.StabilityLevel) {
+	if sl >= component.StabilityLevelAlpha {
+		logger.Debug(sl.LogMessage())
+	} else {
+		logger.Info(sl.LogMessage())
+	}
+}
@@ -16,11 +16,14 @@ package connector // import "go.opentelemetry.io/collector/connector"
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/consumer"
 )
 
@@ -183,6 +186,138 @@ func TestNewFactoryWithAllTypes(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMakeFactoryMap(t *testing.T) {
+	type testCase struct {
+		name string
+		in   []Factory
+		out  map[component.Type]Factory
+	}
+
+	p1 := NewFactory("p1", nil)
+	p2 := NewFactory("p2", nil)
+	testCases := []testCase{
+		{
+			name: "different names",
+			in:   []Factory{p1, p2},
+			out: map[component.Type]Factory{
+				p1.Type(): p1,
+				p2.Type(): p2,
+			},
+		},
+		{
+			name: "same name",
+			in:   []Factory{p1, p2, NewFactory("p1", nil)},
+		},
+	}
+
+	for i := range testCases {
+		tt := testCases[i]
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MakeFactoryMap(tt.in...)
+			if tt.out == nil {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.out, out)
+		})
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	defaultCfg := struct{}{}
+	factories, err := MakeFactoryMap(
+		NewFactory("sametypes", func() component.Config { return &defaultCfg },
+			WithTracesToTraces(createTracesToTraces, component.StabilityLevelDevelopment),
+			WithMetricsToMetrics(createMetricsToMetrics, component.StabilityLevelDevelopment),
+			WithLogsToLogs(createLogsToLogs, component.StabilityLevelDevelopment)),
+		NewFactory("alltypes", func() component.Config { return &defaultCfg },
+			WithTracesToTraces(createTracesToTraces, component.StabilityLevelAlpha),
+			WithTracesToMetrics(createTracesToMetrics, component.StabilityLevelAlpha),
+			WithTracesToLogs(createTracesToLogs, component.StabilityLevelAlpha),
+			WithMetricsToTraces(createMetricsToTraces, component.StabilityLevelAlpha),
+			WithMetricsToMetrics(createMetricsToMetrics, component.StabilityLevelAlpha),
+			WithMetricsToLogs(createMetricsToLogs, component.StabilityLevelAlpha),
+			WithLogsToTraces(createLogsToTraces, component.StabilityLevelAlpha),
+			WithLogsToMetrics(createLogsToMetrics, component.StabilityLevelAlpha),
+			WithLogsToLogs(createLogsToLogs, component.StabilityLevelAlpha)),
+	)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		id        component.ID
+		err       string
+		translate string
+	}{
+		{
+			name: "unknown",
+			id:   component.NewID("unknown"),
+			err:  "connector factory not available for: \"unknown\"",
+		},
+		{
+			name: "sametypes",
+			id:   component.NewID("sametypes"),
+			// Only the connections between the same data types are supported.
+			translate: "connection from %s to %s is not supported",
+		},
+		{
+			name: "alltypes",
+			id:   component.NewID("alltypes"),
+		},
+		{
+			name: "not configured",
+			id:   component.NewIDWithName("alltypes", "unconfigured"),
+			err:  "connector \"alltypes/unconfigured\" is not configured",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgs := map[component.ID]component.Config{
+				component.NewID("unknown"):   &defaultCfg,
+				component.NewID("sametypes"): &defaultCfg,
+				component.NewID("alltypes"):  &defaultCfg,
+			}
+			b := NewBuilder(cfgs, factories)
+			assert.Equal(t, tt.name != "not configured", b.IsConfigured(tt.id))
+
+			set := CreateSettings{ID: tt.id, TelemetrySettings: componenttest.NewNopTelemetrySettings()}
+			checkErr := func(err error, from, to component.DataType) {
+				switch {
+				case tt.err != "":
+					assert.EqualError(t, err, tt.err)
+				case tt.translate != "" && from != to:
+					assert.EqualError(t, err, fmt.Sprintf(tt.translate, from, to))
+				default:
+					assert.NoError(t, err)
+				}
+			}
+
+			_, err = b.CreateTracesToTraces(context.Background(), set, nil)
+			checkErr(err, component.DataTypeTraces, component.DataTypeTraces)
+			_, err = b.CreateTracesToMetrics(context.Background(), set, nil)
+			checkErr(err, component.DataTypeTraces, component.DataTypeMetrics)
+			_, err = b.CreateTracesToLogs(context.Background(), set, nil)
+			checkErr(err, component.DataTypeTraces, component.DataTypeLogs)
+
+			_, err = b.CreateMetricsToTraces(context.Background(), set, nil)
+			checkErr(err, component.DataTypeMetrics, component.DataTypeTraces)
+			_, err = b.CreateMetricsToMetrics(context.Background(), set, nil)
+			checkErr(err, component.DataTypeMetrics, component.DataTypeMetrics)
+			_, err = b.CreateMetricsToLogs(context.Background(), set, nil)
+			checkErr(err, component.DataTypeMetrics, component.DataTypeLogs)
+
+			_, err = b.CreateLogsToTraces(context.Background(), set, nil)
+			checkErr(err, component.DataTypeLogs, component.DataTypeTraces)
+			_, err = b.CreateLogsToMetrics(context.Background(), set, nil)
+			checkErr(err, component.DataTypeLogs, component.DataTypeMetrics)
+			_, err = b.CreateLogsToLogs(context.Background(), set, nil)
+			checkErr(err, component.DataTypeLogs, component.DataTypeLogs)
+		})
+	}
+}
+
 func createTracesToTraces(context.Context, CreateSettings, component.Config, consumer.Traces) (Traces, error) {
 	return nil, nil
 }
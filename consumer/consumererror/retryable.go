@@ -0,0 +1,60 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumererror // import "go.opentelemetry.io/collector/consumer/consumererror"
+
+import (
+	"errors"
+	"time"
+)
+
+// retryable is an error indicating that the data was not accepted because the consumer is temporarily overloaded,
+// and that the same data can be sent again later.
+type retryable struct {
+	err        error
+	retryAfter time.Duration
+}
+
+// NewRetryable wraps an error to indicate that the data was not accepted because the consumer is temporarily
+// overloaded, e.g. its queue is full, so that receivers can ask their clients to send the data again later instead of
+// dropping it. retryAfter is a hint of how long the clients should wait before retrying, 0 if unknown.
+func NewRetryable(err error, retryAfter time.Duration) error {
+	return retryable{err: err, retryAfter: retryAfter}
+}
+
+func (r retryable) Error() string {
+	return r.err.Error()
+}
+
+// Unwrap returns the wrapped error for functions Is and As in standard package errors.
+func (r retryable) Unwrap() error {
+	return r.err
+}
+
+// IsRetryable checks if an error was wrapped with the NewRetryable function.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.As(err, &retryable{})
+}
+
+// RetryAfter returns the retry hint of the outermost error wrapped with the NewRetryable function, 0 if there is none.
+func RetryAfter(err error) time.Duration {
+	var r retryable
+	if errors.As(err, &r) {
+		return r.retryAfter
+	}
+	return 0
+}
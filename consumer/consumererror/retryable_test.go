@@ -0,0 +1,54 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumererror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	var err error
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, time.Duration(0), RetryAfter(err))
+
+	err = errors.New("testError")
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, time.Duration(0), RetryAfter(err))
+
+	err = NewRetryable(err, 5*time.Second)
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, 5*time.Second, RetryAfter(err))
+	assert.EqualError(t, err, "testError")
+	assert.False(t, IsPermanent(err))
+
+	err = fmt.Errorf("%w", err)
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, 5*time.Second, RetryAfter(err))
+}
+
+func TestRetryable_Unwrap(t *testing.T) {
+	var err error = testErrorType{"testError"}
+	retryableErr := NewRetryable(err, time.Second)
+
+	target := testErrorType{}
+	assert.True(t, errors.As(retryableErr, &target))
+	assert.Equal(t, err, target)
+	assert.True(t, errors.Is(NewRetryable(err, 0), err))
+}
@@ -41,6 +41,15 @@ type CreateSettings = component.ExporterCreateSettings //nolint:staticcheck
 // use the NewFactory to implement it.
 type Factory = component.ExporterFactory //nolint:staticcheck
 
+// NestedExportersConfig is implemented by the configurations of the exporters creating other exporters from
+// configurations nested in their own. Once the configuration of the collector is unmarshaled, ResolveExporters
+// is called with the exporter factories of the collector, so that the nested configurations are decoded, and then
+// validated, along with the rest of the configuration.
+type NestedExportersConfig interface {
+	component.Config
+	ResolveExporters(factories map[component.Type]Factory) error
+}
+
 // FactoryOption apply changes to Factory.
 type FactoryOption interface {
 	// applyExporterFactoryOption applies the option.
@@ -0,0 +1,60 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exportererror // import "go.opentelemetry.io/collector/exporter/exportererror"
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PartialExportError is an error to represent
+// that a subset of the exported items were rejected by the destination,
+// while the rest of the items were accepted.
+// The exporter helper does not retry these errors and reports the rejected items as failed.
+type PartialExportError struct {
+	error
+	Rejected int
+}
+
+// NewPartialExportError creates PartialExportError for rejected items.
+// Use this error type only when the destination accepted a subset of the exported data.
+func NewPartialExportError(err error, rejected int) PartialExportError {
+	return PartialExportError{
+		error:    err,
+		Rejected: rejected,
+	}
+}
+
+// IsPartialExportError checks if an error was wrapped with PartialExportError.
+func IsPartialExportError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var partialExportErr PartialExportError
+	return errors.As(err, &partialExportErr)
+}
+
+// NewOTLPPartialSuccessError returns a PartialExportError if an OTLP server rejected some of the items in its
+// partial success response, or nil if the response is a full success. A response with an error message but no
+// rejected items is only a warning, returned as a PartialExportError without rejected items.
+// See https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/otlp.md#partial-success
+func NewOTLPPartialSuccessError(rejected int64, errorMessage string, itemsName string) error {
+	if rejected == 0 && errorMessage == "" {
+		return nil
+	}
+	return NewPartialExportError(
+		fmt.Errorf("OTLP partial success, %d %s rejected: %s", rejected, itemsName, errorMessage), int(rejected))
+}
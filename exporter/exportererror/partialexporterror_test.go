@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exportererror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialExportError(t *testing.T) {
+	rejected := 2
+	err := errors.New("some error")
+	partialErr := NewPartialExportError(err, rejected)
+	assert.Equal(t, err.Error(), partialErr.Error())
+	assert.Equal(t, rejected, partialErr.Rejected)
+}
+
+func TestIsPartialExportError(t *testing.T) {
+	err := errors.New("testError")
+	require.False(t, IsPartialExportError(err))
+	require.False(t, IsPartialExportError(nil))
+
+	err = NewPartialExportError(err, 2)
+	require.True(t, IsPartialExportError(err))
+	require.True(t, IsPartialExportError(fmt.Errorf("wrapped: %w", err)))
+}
+
+func TestNewOTLPPartialSuccessError(t *testing.T) {
+	assert.NoError(t, NewOTLPPartialSuccessError(0, "", "spans"))
+
+	err := NewOTLPPartialSuccessError(3, "too old", "log records")
+	var partialErr PartialExportError
+	require.ErrorAs(t, err, &partialErr)
+	assert.Equal(t, 3, partialErr.Rejected)
+	assert.EqualError(t, err, "OTLP partial success, 3 log records rejected: too old")
+
+	// A message without rejected items is only a warning.
+	err = NewOTLPPartialSuccessError(0, "deprecated attribute", "data points")
+	require.ErrorAs(t, err, &partialErr)
+	assert.Equal(t, 0, partialErr.Rejected)
+}
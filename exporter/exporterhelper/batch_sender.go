@@ -0,0 +1,438 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/multierr"
+
+	"go.opentelemetry.io/collector/exporter/exportererror"
+	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+)
+
+// BatcherSettings defines configuration for batching requests in the exporter right before they are sent, below the
+// retries, so that each retry of a request is batched again.
+// A batch is sent once it reaches one of the minimum sizes or once FlushTimeout has elapsed since it was started.
+type BatcherSettings struct {
+	// Enabled indicates whether to merge requests into batches before sending them.
+	Enabled bool `mapstructure:"enabled"`
+	// FlushTimeout is the maximum time a batch is kept open before it is sent regardless of its size.
+	FlushTimeout time.Duration `mapstructure:"flush_timeout"`
+	// MinSizeItems is the number of spans, metric points or log records after which a batch is sent.
+	// Zero means no item based trigger.
+	MinSizeItems int `mapstructure:"min_size_items"`
+	// MinSizeBytes is the size of the OTLP encoded data after which a batch is sent.
+	// Zero means no size based trigger.
+	MinSizeBytes int `mapstructure:"min_size_bytes"`
+	// MaxSizeItems is the upper limit of items in a batch, larger requests are split. Zero means no limit.
+	MaxSizeItems int `mapstructure:"max_size_items"`
+	// MaxSizeBytes is the upper limit of the OTLP encoded size of a batch, larger requests are split.
+	// The split is based on the average item size of the request, so it is approximate. Zero means no limit.
+	MaxSizeBytes int `mapstructure:"max_size_bytes"`
+}
+
+// NewDefaultBatcherSettings returns the default settings for BatcherSettings.
+func NewDefaultBatcherSettings() BatcherSettings {
+	return BatcherSettings{
+		Enabled:      true,
+		FlushTimeout: 200 * time.Millisecond,
+		MinSizeItems: 8192,
+	}
+}
+
+// Validate checks if the BatcherSettings configuration is valid
+func (bCfg *BatcherSettings) Validate() error {
+	if !bCfg.Enabled {
+		return nil
+	}
+
+	if bCfg.FlushTimeout <= 0 {
+		return errors.New("flush timeout must be positive")
+	}
+	if bCfg.MinSizeItems < 0 || bCfg.MinSizeBytes < 0 || bCfg.MaxSizeItems < 0 || bCfg.MaxSizeBytes < 0 {
+		return errors.New("batch sizes must not be negative")
+	}
+	if bCfg.MaxSizeItems > 0 && bCfg.MinSizeItems > bCfg.MaxSizeItems {
+		return errors.New("max_size_items must be greater or equal to min_size_items")
+	}
+	if bCfg.MaxSizeBytes > 0 && bCfg.MinSizeBytes > bCfg.MaxSizeBytes {
+		return errors.New("max_size_bytes must be greater or equal to min_size_bytes")
+	}
+
+	return nil
+}
+
+// batchableRequest is implemented by the requests that the batchSender is able to merge and split.
+type batchableRequest interface {
+	internal.Request
+	// clone returns a request holding a copy of the data, which can be modified without affecting other consumers.
+	clone() batchableRequest
+	// bytesSize returns the size of the data encoded as OTLP protobuf.
+	bytesSize() int
+	// splitOff removes the given number of items, which must be less than Count, and returns them as a new request.
+	splitOff(size int) batchableRequest
+	// moveTo moves all the data to the end of dest, which must be of the same type.
+	moveTo(dest batchableRequest)
+}
+
+// batchPart is the data a request contributes to a batch: either the whole request, or a part split off a copy of
+// it, which the batch owns.
+type batchPart struct {
+	req   batchableRequest
+	owned bool
+	items int
+}
+
+// batch is a set of requests that is sent once merged and whose result is shared by all its contributors.
+// The requests are only merged when the batch is sent, so that a contributor can withdraw its part until then.
+// It is sent with the context of the first contributor, without its cancellation, and with the earliest
+// deadline of all the contributors.
+type batch struct {
+	parts   []*batchPart
+	created time.Time
+	items   int
+	bytes   int
+	// sealed is set, with the batchSender lock held, once the batch is being sent: its parts can no longer change.
+	sealed bool
+	done   chan struct{}
+	// errs holds the error of each part once done is closed.
+	errs []error
+}
+
+// batchSender is a requestSender that merges incoming requests into batches and splits the ones that are too large.
+// It sits below the retry sender, so that each contributor retries its own data if the batch fails. The send call
+// blocks until all the batches containing data of the request were sent, and returns their errors.
+type batchSender struct {
+	cfg        BatcherSettings
+	nextSender requestSender
+
+	mu          sync.Mutex
+	activeBatch *batch
+
+	stopped    *atomic.Bool
+	stopCh     chan struct{}
+	shutdownWG sync.WaitGroup
+}
+
+func newBatchSender(cfg BatcherSettings, nextSender requestSender) *batchSender {
+	return &batchSender{
+		cfg:        cfg,
+		nextSender: nextSender,
+		stopped:    atomic.NewBool(false),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// start starts the goroutine that sends the batches that reached the flush timeout.
+func (bs *batchSender) start() {
+	bs.shutdownWG.Add(1)
+	go func() {
+		defer bs.shutdownWG.Done()
+		timer := time.NewTimer(bs.cfg.FlushTimeout)
+		for {
+			select {
+			case <-bs.stopCh:
+				timer.Stop()
+				bs.mu.Lock()
+				b := bs.takeActiveBatch()
+				bs.mu.Unlock()
+				if b != nil {
+					bs.export(b)
+				}
+				return
+			case <-timer.C:
+				timer.Reset(bs.flushExpired())
+			}
+		}
+	}()
+}
+
+// shutdown stops batching and sends the pending batch in the background, requests received afterwards
+// are sent as they are. It does not block, so the queue consumers waiting for the pending batch are released
+// while the queue is being drained. Call awaitShutdown to wait until the batches in flight are sent.
+func (bs *batchSender) shutdown() {
+	if bs.stopped.Swap(true) {
+		return
+	}
+	close(bs.stopCh)
+}
+
+// awaitShutdown blocks until all the batches in flight are sent.
+func (bs *batchSender) awaitShutdown() {
+	bs.shutdownWG.Wait()
+}
+
+// takeActiveBatch seals the active batch and returns it, it must be called with mu held.
+func (bs *batchSender) takeActiveBatch() *batch {
+	b := bs.activeBatch
+	if b != nil {
+		b.sealed = true
+		bs.activeBatch = nil
+	}
+	return b
+}
+
+// flushExpired sends the active batch if it is older than the flush timeout,
+// and returns the duration after which the timeout has to be checked again.
+func (bs *batchSender) flushExpired() time.Duration {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.activeBatch == nil {
+		return bs.cfg.FlushTimeout
+	}
+	if age := time.Since(bs.activeBatch.created); age < bs.cfg.FlushTimeout {
+		return bs.cfg.FlushTimeout - age
+	}
+
+	b := bs.takeActiveBatch()
+	bs.shutdownWG.Add(1)
+	go func() {
+		defer bs.shutdownWG.Done()
+		bs.export(b)
+	}()
+	return bs.cfg.FlushTimeout
+}
+
+// pendingPart is a part of a request waiting for its batch to be sent.
+type pendingPart struct {
+	b     *batch
+	index int
+}
+
+// send implements the requestSender interface
+func (bs *batchSender) send(req internal.Request) error {
+	br, ok := req.(batchableRequest)
+	if !ok {
+		return bs.nextSender.send(req)
+	}
+
+	bs.mu.Lock()
+	if bs.stopped.Load() {
+		// The pending batch was already flushed during shutdown, nothing would flush a new one.
+		bs.mu.Unlock()
+		return bs.nextSender.send(req)
+	}
+
+	// The request is only copied if it has to be split, since the data may be shared with other consumers.
+	owned := false
+	var pending []pendingPart
+	var rest batchableRequest
+	for br != nil && br.Count() > 0 {
+		if bs.stopped.Load() {
+			// The lock was released to send a batch and the shutdown flushed the pending batch meanwhile,
+			// nothing would flush a new one.
+			rest = br
+			break
+		}
+		if bs.activeBatch == nil {
+			bs.activeBatch = &batch{created: time.Now(), done: make(chan struct{})}
+		}
+		b := bs.activeBatch
+
+		room := bs.roomInBatch(b, br)
+		if room == 0 {
+			// Nothing more fits in the current batch, send it and start a new one.
+			bs.takeActiveBatch()
+			bs.mu.Unlock()
+			bs.export(b)
+			bs.mu.Lock()
+			continue
+		}
+		part := &batchPart{req: br, owned: owned}
+		if room < br.Count() {
+			if !owned {
+				br, owned = br.clone(), true
+			}
+			part = &batchPart{req: br.splitOff(room), owned: true}
+		} else {
+			br = nil
+		}
+		pending = append(pending, pendingPart{b: b, index: bs.add(b, part)})
+
+		if bs.reachedMinSize(b) {
+			bs.takeActiveBatch()
+			bs.mu.Unlock()
+			bs.export(b)
+			bs.mu.Lock()
+		}
+	}
+	bs.mu.Unlock()
+
+	var errs error
+	if rest != nil {
+		errs = bs.nextSender.send(rest)
+	}
+	ctx := req.Context()
+	for _, p := range pending {
+		select {
+		case <-p.b.done:
+			errs = multierr.Append(errs, p.b.errs[p.index])
+			continue
+		case <-ctx.Done():
+		}
+		// The caller gives up: its part is withdrawn from the batch if it is not being sent yet, otherwise the
+		// caller waits for the result so that the request is not reported as done before it was sent.
+		if bs.withdraw(p) {
+			errs = multierr.Append(errs, ctx.Err())
+			continue
+		}
+		<-p.b.done
+		errs = multierr.Append(errs, p.b.errs[p.index])
+	}
+	return errs
+}
+
+// withdraw removes the part from its batch and returns true if the batch is not being sent yet.
+func (bs *batchSender) withdraw(p pendingPart) bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if p.b.sealed {
+		return false
+	}
+	part := p.b.parts[p.index]
+	p.b.parts[p.index] = nil
+	p.b.items -= part.items
+	if bs.cfg.MaxSizeBytes > 0 || bs.cfg.MinSizeBytes > 0 {
+		p.b.bytes -= part.req.bytesSize()
+	}
+	return true
+}
+
+// roomInBatch returns how many items of the request can be added to the batch without exceeding the maximum sizes.
+// An empty batch always accepts at least one item.
+func (bs *batchSender) roomInBatch(b *batch, req batchableRequest) int {
+	room := req.Count()
+	if bs.cfg.MaxSizeItems > 0 && bs.cfg.MaxSizeItems-b.items < room {
+		room = bs.cfg.MaxSizeItems - b.items
+	}
+	if bs.cfg.MaxSizeBytes > 0 {
+		if size := req.bytesSize(); b.bytes+size > bs.cfg.MaxSizeBytes {
+			// Estimate the number of items that fit using the average item size.
+			if fit := (bs.cfg.MaxSizeBytes - b.bytes) * req.Count() / size; fit < room {
+				room = fit
+			}
+		}
+	}
+	if room <= 0 {
+		if b.items == 0 {
+			return 1
+		}
+		return 0
+	}
+	return room
+}
+
+// add adds the part to the batch and returns its index in the batch.
+func (bs *batchSender) add(b *batch, part *batchPart) int {
+	part.items = part.req.Count()
+	b.items += part.items
+	if bs.cfg.MaxSizeBytes > 0 || bs.cfg.MinSizeBytes > 0 {
+		b.bytes += part.req.bytesSize()
+	}
+	b.parts = append(b.parts, part)
+	return len(b.parts) - 1
+}
+
+func (bs *batchSender) reachedMinSize(b *batch) bool {
+	return (bs.cfg.MinSizeItems > 0 && b.items >= bs.cfg.MinSizeItems) ||
+		(bs.cfg.MinSizeBytes > 0 && b.bytes >= bs.cfg.MinSizeBytes) ||
+		(bs.cfg.MaxSizeItems > 0 && b.items >= bs.cfg.MaxSizeItems) ||
+		(bs.cfg.MaxSizeBytes > 0 && b.bytes >= bs.cfg.MaxSizeBytes)
+}
+
+// export sends the batch and unblocks all the requests waiting on it. A batch with a single part is sent as it is,
+// the requests are copied only to be merged.
+func (bs *batchSender) export(b *batch) {
+	defer close(b.done)
+	b.errs = make([]error, len(b.parts))
+
+	var merged batchableRequest
+	var mergedOwned bool
+	var deadline time.Time
+	var items []int
+	for _, part := range b.parts {
+		if part == nil {
+			continue
+		}
+		if d, ok := part.req.Context().Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+			deadline = d
+		}
+		items = append(items, part.items)
+		if merged == nil {
+			merged, mergedOwned = part.req, part.owned
+			continue
+		}
+		if !mergedOwned {
+			// The first request is only copied once another one has to be merged into it.
+			merged, mergedOwned = merged.clone(), true
+		}
+		src := part.req
+		if !part.owned {
+			src = src.clone()
+		}
+		src.moveTo(merged)
+	}
+	if merged == nil {
+		// All the contributors withdrew their parts.
+		return
+	}
+
+	if len(items) > 1 {
+		// The cancellation of the first contributor must not abort the data of the others.
+		ctx := context.Context(noCancellationContext{Context: merged.Context()})
+		if !deadline.IsZero() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+		merged.SetContext(ctx)
+	}
+	errs := shareBatchError(bs.nextSender.send(merged), items)
+	i := 0
+	for index, part := range b.parts {
+		if part != nil {
+			b.errs[index] = errs[i]
+			i++
+		}
+	}
+}
+
+// shareBatchError returns the error of each part of a batch given their number of items. The items rejected by a
+// partial success are attributed to the parts in order, so that they are counted once in total.
+func shareBatchError(err error, items []int) []error {
+	errs := make([]error, len(items))
+	var partialErr exportererror.PartialExportError
+	if !errors.As(err, &partialErr) {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	rejected := partialErr.Rejected
+	for i, n := range items {
+		if n > rejected {
+			n = rejected
+		}
+		rejected -= n
+		errs[i] = exportererror.NewPartialExportError(err, n)
+	}
+	return errs
+}
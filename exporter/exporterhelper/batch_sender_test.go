@@ -0,0 +1,380 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter/exportererror"
+	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type tracesSink struct {
+	mu      sync.Mutex
+	batches []ptrace.Traces
+	err     error
+}
+
+func (s *tracesSink) push(_ context.Context, td ptrace.Traces) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, td)
+	return s.err
+}
+
+func (s *tracesSink) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := make([]int, len(s.batches))
+	for i, td := range s.batches {
+		sizes[i] = td.SpanCount()
+	}
+	return sizes
+}
+
+func TestBatcherSettings_Validate(t *testing.T) {
+	bCfg := NewDefaultBatcherSettings()
+	assert.NoError(t, bCfg.Validate())
+
+	bCfg.FlushTimeout = 0
+	assert.EqualError(t, bCfg.Validate(), "flush timeout must be positive")
+
+	bCfg = NewDefaultBatcherSettings()
+	bCfg.MaxSizeItems = 10
+	assert.EqualError(t, bCfg.Validate(), "max_size_items must be greater or equal to min_size_items")
+
+	bCfg = NewDefaultBatcherSettings()
+	bCfg.MinSizeBytes = -1
+	assert.EqualError(t, bCfg.Validate(), "batch sizes must not be negative")
+
+	bCfg.Enabled = false
+	assert.NoError(t, bCfg.Validate())
+}
+
+func TestBatchSender_MergeOnMinSize(t *testing.T) {
+	sink := &tracesSink{}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.MinSizeItems = 10
+	bCfg.FlushTimeout = time.Hour
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []int{10}, sink.batchSizes())
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestBatchSender_FlushTimeout(t *testing.T) {
+	sink := &tracesSink{}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.FlushTimeout = 50 * time.Millisecond
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(3)))
+	assert.Equal(t, []int{3}, sink.batchSizes())
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestBatchSender_SplitOnMaxSizeItems(t *testing.T) {
+	sink := &tracesSink{}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.MinSizeItems = 4
+	bCfg.MaxSizeItems = 4
+	bCfg.FlushTimeout = 50 * time.Millisecond
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	td := testdata.GenerateTraces(10)
+	require.NoError(t, te.ConsumeTraces(context.Background(), td))
+	assert.Equal(t, []int{4, 4, 2}, sink.batchSizes())
+	// The original data must not be modified, it can be shared with other exporters.
+	assert.Equal(t, 10, td.SpanCount())
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestBatchSender_SplitOnMaxSizeBytes(t *testing.T) {
+	sink := &tracesSink{}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.MinSizeItems = 0
+	bCfg.MaxSizeBytes = tracesMarshaler.TracesSize(testdata.GenerateTraces(5))
+	bCfg.FlushTimeout = 50 * time.Millisecond
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(20)))
+	total := 0
+	for _, size := range sink.batchSizes() {
+		assert.LessOrEqual(t, size, 5)
+		total += size
+	}
+	assert.Equal(t, 20, total)
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestBatchSender_ErrorReturnedToAllContributors(t *testing.T) {
+	sink := &tracesSink{err: errors.New("backend error")}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.MinSizeItems = 4
+	bCfg.FlushTimeout = time.Hour
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.EqualError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)), "backend error")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []int{4}, sink.batchSizes())
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestBatchSender_FlushOnShutdown(t *testing.T) {
+	sink := &tracesSink{}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.FlushTimeout = time.Hour
+	qCfg := NewDefaultQueueSettings()
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithBatcher(bCfg), WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(3)))
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Equal(t, []int{3}, sink.batchSizes())
+}
+
+func TestBatchSender_EarliestDeadline(t *testing.T) {
+	var deadlines []time.Time
+	var mu sync.Mutex
+	push := func(ctx context.Context, td ptrace.Traces) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		deadlines = append(deadlines, deadline)
+		return nil
+	}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.MinSizeItems = 4
+	bCfg.FlushTimeout = time.Hour
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push, WithBatcher(bCfg), WithTimeout(TimeoutSettings{}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	early := time.Now().Add(time.Minute)
+	earlyCtx, cancelEarly := context.WithDeadline(context.Background(), early)
+	defer cancelEarly()
+	lateCtx, cancelLate := context.WithDeadline(context.Background(), early.Add(time.Hour))
+	defer cancelLate()
+
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{lateCtx, earlyCtx} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			assert.NoError(t, te.ConsumeTraces(ctx, testdata.GenerateTraces(2)))
+		}(ctx)
+	}
+	wg.Wait()
+	require.NoError(t, te.Shutdown(context.Background()))
+	require.Len(t, deadlines, 1)
+	assert.True(t, early.Equal(deadlines[0]))
+}
+
+func TestBatchSender_CallerCancellation(t *testing.T) {
+	sink := &tracesSink{}
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.MinSizeItems = 4
+	bCfg.FlushTimeout = time.Hour
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// The batch is not full, the caller withdraws its data from the batch once its context is done.
+	assert.ErrorIs(t, te.ConsumeTraces(ctx, testdata.GenerateTraces(2)), context.DeadlineExceeded)
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Empty(t, sink.batchSizes())
+}
+
+func TestBatchSender_CallerCancellationWhileSending(t *testing.T) {
+	next := &blockingSender{sending: make(chan struct{}), release: make(chan struct{})}
+	bs := newBatchSender(BatcherSettings{Enabled: true, FlushTimeout: time.Hour, MinSizeItems: 2}, next)
+	bs.start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- bs.send(newTracesRequest(ctx, testdata.GenerateTraces(2), next.sink.push))
+	}()
+
+	// The batch is already being sent, the caller must wait for its result even if its context is done.
+	<-next.sending
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("send returned before the batch was sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(next.release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, []int{2}, next.sink.batchSizes())
+
+	bs.shutdown()
+	bs.awaitShutdown()
+}
+
+// recordingSender records the requests it receives.
+type recordingSender struct {
+	mu   sync.Mutex
+	reqs []internal.Request
+}
+
+func (rs *recordingSender) send(req internal.Request) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.reqs = append(rs.reqs, req)
+	return nil
+}
+
+func TestBatchSender_SingleRequestNotCopied(t *testing.T) {
+	next := &recordingSender{}
+	bs := newBatchSender(BatcherSettings{Enabled: true, FlushTimeout: time.Hour, MinSizeItems: 2}, next)
+	bs.start()
+
+	req := newTracesRequest(context.Background(), testdata.GenerateTraces(2), nil)
+	require.NoError(t, bs.send(req))
+	bs.shutdown()
+	bs.awaitShutdown()
+
+	require.Len(t, next.reqs, 1)
+	assert.Same(t, req, next.reqs[0])
+}
+
+func TestShareBatchError(t *testing.T) {
+	backendErr := errors.New("backend error")
+	assert.Equal(t, []error{backendErr, backendErr}, shareBatchError(backendErr, []int{2, 3}))
+	assert.Equal(t, []error{nil, nil}, shareBatchError(nil, []int{2, 3}))
+
+	// The rejected items are attributed to the parts in order.
+	errs := shareBatchError(exportererror.NewPartialExportError(backendErr, 3), []int{2, 2, 4})
+	require.Len(t, errs, 3)
+	for i, rejected := range []int{2, 1, 0} {
+		var partialErr exportererror.PartialExportError
+		require.ErrorAs(t, errs[i], &partialErr)
+		assert.Equal(t, rejected, partialErr.Rejected)
+	}
+}
+
+// blockingSender blocks the first request until released.
+type blockingSender struct {
+	sending chan struct{}
+	release chan struct{}
+	once    sync.Once
+	sink    tracesSink
+}
+
+func (bs *blockingSender) send(req internal.Request) error {
+	bs.once.Do(func() {
+		close(bs.sending)
+		<-bs.release
+	})
+	return req.Export(context.Background())
+}
+
+func TestBatchSender_ShutdownWhileSending(t *testing.T) {
+	next := &blockingSender{sending: make(chan struct{}), release: make(chan struct{})}
+	bs := newBatchSender(BatcherSettings{Enabled: true, FlushTimeout: time.Hour, MinSizeItems: 3, MaxSizeItems: 3}, next)
+	bs.start()
+
+	done := make(chan error)
+	go func() {
+		done <- bs.send(newTracesRequest(context.Background(), testdata.GenerateTraces(4), next.sink.push))
+	}()
+
+	// Shutdown while the first batch is sent, the remaining item must not be left in a batch that is never flushed.
+	<-next.sending
+	bs.shutdown()
+	bs.awaitShutdown()
+	close(next.release)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("send did not return")
+	}
+	assert.Equal(t, []int{3, 1}, next.sink.batchSizes())
+}
+
+func TestBatchSender_MetricsAndLogs(t *testing.T) {
+	bCfg := NewDefaultBatcherSettings()
+	bCfg.MinSizeItems = 4
+	bCfg.MaxSizeItems = 4
+	bCfg.FlushTimeout = 50 * time.Millisecond
+
+	var metricsSizes []int
+	me, err := NewMetricsExporter(context.Background(), defaultSettings, &fakeMetricsExporterConfig, func(_ context.Context, md pmetric.Metrics) error {
+		metricsSizes = append(metricsSizes, md.DataPointCount())
+		return nil
+	}, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, me.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, me.ConsumeMetrics(context.Background(), testdata.GenerateMetrics(5)))
+	require.NoError(t, me.Shutdown(context.Background()))
+	assert.Equal(t, []int{4, 4, 2}, metricsSizes)
+
+	var logsSizes []int
+	le, err := NewLogsExporter(context.Background(), defaultSettings, &fakeLogsExporterConfig, func(_ context.Context, ld plog.Logs) error {
+		logsSizes = append(logsSizes, ld.LogRecordCount())
+		return nil
+	}, WithBatcher(bCfg))
+	require.NoError(t, err)
+	require.NoError(t, le.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, le.ConsumeLogs(context.Background(), testdata.GenerateLogs(6)))
+	require.NoError(t, le.Shutdown(context.Background()))
+	assert.Equal(t, []int{4, 2}, logsSizes)
+}
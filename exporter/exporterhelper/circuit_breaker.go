@@ -0,0 +1,331 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter/exportererror"
+)
+
+// CircuitBreakerSettings defines configuration for the circuit breaker that stops sending requests while the
+// backend keeps failing. While the circuit is open the queue consumers wait, so the requests stay in the queue.
+type CircuitBreakerSettings struct {
+	// Enabled indicates whether to stop sending requests after consecutive failures or a high failure ratio.
+	Enabled bool `mapstructure:"enabled"`
+	// FailureThreshold is the number of consecutive failed attempts after which the circuit opens.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// FailureRatio is the ratio of failed attempts within FailureRatioWindow above which the circuit opens,
+	// it catches backends failing most but not all of the attempts. Zero disables the trigger.
+	FailureRatio float64 `mapstructure:"failure_ratio"`
+	// FailureRatioWindow is the sliding window over which the failure ratio is computed.
+	FailureRatioWindow time.Duration `mapstructure:"failure_ratio_window"`
+	// FailureRatioMinAttempts is the number of attempts within the window below which the ratio is not checked.
+	FailureRatioMinAttempts int `mapstructure:"failure_ratio_min_attempts"`
+	// OpenDuration is the time the circuit stays open before a single probe request is let through.
+	OpenDuration time.Duration `mapstructure:"open_duration"`
+}
+
+// NewDefaultCircuitBreakerSettings returns the default settings for CircuitBreakerSettings.
+func NewDefaultCircuitBreakerSettings() CircuitBreakerSettings {
+	return CircuitBreakerSettings{
+		Enabled:                 true,
+		FailureThreshold:        5,
+		FailureRatio:            0.5,
+		FailureRatioWindow:      time.Minute,
+		FailureRatioMinAttempts: 20,
+		OpenDuration:            30 * time.Second,
+	}
+}
+
+// Validate checks if the CircuitBreakerSettings configuration is valid
+func (cbCfg *CircuitBreakerSettings) Validate() error {
+	if !cbCfg.Enabled {
+		return nil
+	}
+
+	if cbCfg.FailureThreshold <= 0 {
+		return errors.New("failure threshold must be positive")
+	}
+	if cbCfg.OpenDuration <= 0 {
+		return errors.New("open duration must be positive")
+	}
+	if cbCfg.FailureRatio < 0 || cbCfg.FailureRatio > 1 {
+		return errors.New("failure ratio must be between 0 and 1")
+	}
+	if cbCfg.FailureRatio > 0 && cbCfg.FailureRatioWindow <= 0 {
+		return errors.New("failure ratio window must be positive")
+	}
+	if cbCfg.FailureRatio > 0 && cbCfg.FailureRatioMinAttempts <= 0 {
+		return errors.New("failure ratio minimum attempts must be positive")
+	}
+
+	return nil
+}
+
+type circuitState int
+
+const (
+	// circuitClosed lets all the requests through.
+	circuitClosed circuitState = iota
+	// circuitOpen holds back all the requests until the open duration expires.
+	circuitOpen
+	// circuitHalfOpen lets a single probe request through, its result closes or reopens the circuit.
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half_open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker tracks the failures of the export attempts and decides when attempts are allowed.
+type circuitBreaker struct {
+	cfg    CircuitBreakerSettings
+	logger *zap.Logger
+
+	mu                  sync.Mutex
+	state               circuitState
+	consecutiveFailures int
+	window              *failureWindow
+	openedAt            time.Time
+	probeInFlight       bool
+	// stateChanged is closed and replaced on every transition to wake up the waiting requests.
+	stateChanged chan struct{}
+}
+
+func newCircuitBreaker(cfg CircuitBreakerSettings, logger *zap.Logger) *circuitBreaker {
+	cb := &circuitBreaker{
+		cfg:          cfg,
+		logger:       logger,
+		stateChanged: make(chan struct{}),
+	}
+	if cfg.FailureRatio > 0 {
+		cb.window = newFailureWindow(cfg.FailureRatioWindow)
+	}
+	return cb
+}
+
+// acquire blocks until an attempt is allowed and returns true if the attempt is the probe of the half-open circuit.
+// It returns immediately once stopCh is closed, so the queue can be drained during shutdown, and an error if the
+// context is done.
+func (cb *circuitBreaker) acquire(ctx context.Context, stopCh <-chan struct{}) (bool, error) {
+	for {
+		cb.mu.Lock()
+		var wait <-chan time.Time
+		if cb.state == circuitOpen {
+			openFor := time.Since(cb.openedAt)
+			if openFor < cb.cfg.OpenDuration {
+				wait = time.After(cb.cfg.OpenDuration - openFor)
+			} else {
+				cb.setState(circuitHalfOpen)
+			}
+		}
+		switch {
+		case cb.state == circuitClosed:
+			cb.mu.Unlock()
+			return false, nil
+		case cb.state == circuitHalfOpen && !cb.probeInFlight:
+			cb.probeInFlight = true
+			cb.mu.Unlock()
+			return true, nil
+		}
+		stateChanged := cb.stateChanged
+		cb.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("request is cancelled or timed out while the circuit breaker is open: %w", ctx.Err())
+		case <-stopCh:
+			return false, nil
+		case <-stateChanged:
+		case <-wait:
+		}
+	}
+}
+
+// record updates the circuit with the result of an attempt allowed by acquire, probe tells whether it was the probe.
+// Only the probe closes or reopens a half-open circuit: the attempts allowed before the circuit opened are ignored
+// once it is not closed anymore. Permanent errors and partial successes mean that the backend is reachable, so they
+// do not count as failures.
+func (cb *circuitBreaker) record(probe bool, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	failed := err != nil && !consumererror.IsPermanent(err) && !exportererror.IsPartialExportError(err)
+	if probe {
+		cb.probeInFlight = false
+		if !failed {
+			cb.logger.Info("Exporting succeeded, closing the circuit breaker.")
+			cb.consecutiveFailures = 0
+			if cb.window != nil {
+				cb.window.reset()
+			}
+			cb.setState(circuitClosed)
+			return
+		}
+		cb.consecutiveFailures++
+		cb.open(err)
+		return
+	}
+	if cb.state != circuitClosed {
+		return
+	}
+
+	if cb.window != nil {
+		cb.window.add(time.Now(), failed)
+	}
+	if !failed {
+		cb.consecutiveFailures = 0
+		return
+	}
+	cb.consecutiveFailures++
+	if cb.consecutiveFailures >= cb.cfg.FailureThreshold || cb.failureRatioExceeded() {
+		cb.open(err)
+	}
+}
+
+// failureRatioExceeded must be called with the lock held.
+func (cb *circuitBreaker) failureRatioExceeded() bool {
+	if cb.window == nil {
+		return false
+	}
+	attempts, failures := cb.window.count(time.Now())
+	return attempts >= cb.cfg.FailureRatioMinAttempts && float64(failures) >= cb.cfg.FailureRatio*float64(attempts)
+}
+
+// open must be called with the lock held.
+func (cb *circuitBreaker) open(err error) {
+	fields := []zap.Field{
+		zap.Error(err),
+		zap.Int("consecutive_failures", cb.consecutiveFailures),
+		zap.Duration("open_duration", cb.cfg.OpenDuration),
+	}
+	if cb.window != nil {
+		attempts, failures := cb.window.count(time.Now())
+		fields = append(fields, zap.Int("window_attempts", attempts), zap.Int("window_failures", failures))
+	}
+	cb.logger.Warn("Exporting keeps failing, opening the circuit breaker.", fields...)
+	cb.openedAt = time.Now()
+	cb.setState(circuitOpen)
+}
+
+// isOpen returns true if new attempts would be held back.
+func (cb *circuitBreaker) isOpen() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state != circuitClosed
+}
+
+func (cb *circuitBreaker) currentState() circuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// zPagesProperties returns the current state of the circuit breaker for the zPages.
+func (cb *circuitBreaker) zPagesProperties() [][2]string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	props := [][2]string{
+		{"Circuit breaker state", cb.state.String()},
+		{"Consecutive failures", strconv.Itoa(cb.consecutiveFailures)},
+	}
+	if cb.window != nil {
+		attempts, failures := cb.window.count(time.Now())
+		props = append(props, [2]string{"Failed attempts in window", fmt.Sprintf("%d/%d", failures, attempts)})
+	}
+	if cb.state != circuitClosed {
+		props = append(props, [2]string{"Circuit breaker opened at", cb.openedAt.Format(time.RFC3339)})
+	}
+	return props
+}
+
+// setState must be called with the lock held.
+func (cb *circuitBreaker) setState(state circuitState) {
+	if cb.state == state {
+		return
+	}
+	cb.state = state
+	close(cb.stateChanged)
+	cb.stateChanged = make(chan struct{})
+}
+
+// failureWindowBuckets is the number of buckets the failure window is divided into, the window slides by bucket.
+const failureWindowBuckets = 10
+
+// failureWindow counts the attempts and failures over a sliding window of time.
+type failureWindow struct {
+	bucketSize time.Duration
+	buckets    [failureWindowBuckets]windowBucket
+}
+
+type windowBucket struct {
+	start    time.Time
+	attempts int
+	failures int
+}
+
+func newFailureWindow(window time.Duration) *failureWindow {
+	bucketSize := window / failureWindowBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	return &failureWindow{bucketSize: bucketSize}
+}
+
+// add counts an attempt made at the given time.
+func (fw *failureWindow) add(now time.Time, failed bool) {
+	start := now.Truncate(fw.bucketSize)
+	b := &fw.buckets[int(start.UnixNano()/int64(fw.bucketSize))%failureWindowBuckets]
+	if !b.start.Equal(start) {
+		*b = windowBucket{start: start}
+	}
+	b.attempts++
+	if failed {
+		b.failures++
+	}
+}
+
+// count returns the number of attempts and failures within the window ending at the given time.
+func (fw *failureWindow) count(now time.Time) (attempts int, failures int) {
+	oldest := now.Truncate(fw.bucketSize).Add(-fw.bucketSize * (failureWindowBuckets - 1))
+	for _, b := range fw.buckets {
+		if !b.start.Before(oldest) {
+			attempts += b.attempts
+			failures += b.failures
+		}
+	}
+	return attempts, failures
+}
+
+func (fw *failureWindow) reset() {
+	fw.buckets = [failureWindowBuckets]windowBucket{}
+}
@@ -0,0 +1,217 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+func TestCircuitBreakerSettings_Validate(t *testing.T) {
+	cbCfg := NewDefaultCircuitBreakerSettings()
+	assert.NoError(t, cbCfg.Validate())
+
+	cbCfg.FailureThreshold = 0
+	assert.EqualError(t, cbCfg.Validate(), "failure threshold must be positive")
+
+	cbCfg = NewDefaultCircuitBreakerSettings()
+	cbCfg.OpenDuration = 0
+	assert.EqualError(t, cbCfg.Validate(), "open duration must be positive")
+
+	cbCfg = NewDefaultCircuitBreakerSettings()
+	cbCfg.FailureRatio = 1.5
+	assert.EqualError(t, cbCfg.Validate(), "failure ratio must be between 0 and 1")
+
+	cbCfg = NewDefaultCircuitBreakerSettings()
+	cbCfg.FailureRatioWindow = 0
+	assert.EqualError(t, cbCfg.Validate(), "failure ratio window must be positive")
+
+	cbCfg = NewDefaultCircuitBreakerSettings()
+	cbCfg.FailureRatioMinAttempts = 0
+	assert.EqualError(t, cbCfg.Validate(), "failure ratio minimum attempts must be positive")
+
+	cbCfg.FailureRatio = 0
+	assert.NoError(t, cbCfg.Validate())
+
+	cbCfg.OpenDuration = 0
+	cbCfg.Enabled = false
+	assert.NoError(t, cbCfg.Validate())
+}
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	cb := newCircuitBreaker(CircuitBreakerSettings{Enabled: true, FailureThreshold: 2, OpenDuration: 50 * time.Millisecond}, zap.NewNop())
+	stopCh := make(chan struct{})
+	transientErr := errors.New("transient error")
+
+	probe, err := cb.acquire(context.Background(), stopCh)
+	require.NoError(t, err)
+	assert.False(t, probe)
+	cb.record(probe, transientErr)
+	assert.Equal(t, circuitClosed, cb.currentState())
+
+	// Permanent errors do not count as failures.
+	cb.record(acquire(t, cb, stopCh), consumererror.NewPermanent(transientErr))
+	assert.Equal(t, circuitClosed, cb.currentState())
+
+	// An attempt admitted before the circuit opens does not change its state once it is open.
+	late := acquire(t, cb, stopCh)
+	for i := 0; i < 2; i++ {
+		cb.record(acquire(t, cb, stopCh), transientErr)
+	}
+	assert.Equal(t, circuitOpen, cb.currentState())
+	cb.record(late, nil)
+	assert.Equal(t, circuitOpen, cb.currentState())
+
+	// While open the attempts wait, the context deadline is respected.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = cb.acquire(ctx, stopCh)
+	assert.Error(t, err)
+
+	// After the open duration a single probe is let through.
+	start := time.Now()
+	probe, err = cb.acquire(context.Background(), stopCh)
+	require.NoError(t, err)
+	assert.True(t, probe)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.Equal(t, circuitHalfOpen, cb.currentState())
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = cb.acquire(ctx, stopCh)
+	assert.Error(t, err)
+
+	// Only the probe closes or reopens the half-open circuit.
+	cb.record(false, nil)
+	assert.Equal(t, circuitHalfOpen, cb.currentState())
+	cb.record(probe, transientErr)
+	assert.Equal(t, circuitOpen, cb.currentState())
+	probe = acquire(t, cb, stopCh)
+	require.True(t, probe)
+	cb.record(probe, nil)
+	assert.Equal(t, circuitClosed, cb.currentState())
+	assert.Contains(t, cb.zPagesProperties(), [2]string{"Circuit breaker state", "closed"})
+}
+
+func TestCircuitBreaker_FailureRatio(t *testing.T) {
+	cb := newCircuitBreaker(CircuitBreakerSettings{
+		Enabled:                 true,
+		FailureThreshold:        100,
+		FailureRatio:            0.5,
+		FailureRatioWindow:      time.Hour,
+		FailureRatioMinAttempts: 6,
+		OpenDuration:            time.Hour,
+	}, zap.NewNop())
+	stopCh := make(chan struct{})
+	transientErr := errors.New("transient error")
+
+	// Alternating failures never reach the consecutive threshold, the ratio is only checked after enough attempts.
+	for i := 0; i < 2; i++ {
+		cb.record(acquire(t, cb, stopCh), transientErr)
+		cb.record(acquire(t, cb, stopCh), nil)
+	}
+	cb.record(acquire(t, cb, stopCh), nil)
+	assert.Equal(t, circuitClosed, cb.currentState())
+	assert.Contains(t, cb.zPagesProperties(), [2]string{"Failed attempts in window", "2/5"})
+
+	cb.record(acquire(t, cb, stopCh), transientErr)
+	assert.Equal(t, circuitOpen, cb.currentState())
+}
+
+func TestFailureWindow(t *testing.T) {
+	fw := newFailureWindow(10 * time.Second)
+	now := time.Unix(1000, 0)
+	fw.add(now, true)
+	fw.add(now.Add(5*time.Second), false)
+	fw.add(now.Add(9*time.Second), true)
+
+	attempts, failures := fw.count(now.Add(9 * time.Second))
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, failures)
+
+	// The oldest attempts slide out of the window.
+	attempts, failures = fw.count(now.Add(12 * time.Second))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 1, failures)
+
+	// A bucket reused after a full cycle starts from zero.
+	fw.add(now.Add(20*time.Second), false)
+	attempts, failures = fw.count(now.Add(20 * time.Second))
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 0, failures)
+
+	fw.reset()
+	attempts, _ = fw.count(now.Add(20 * time.Second))
+	assert.Equal(t, 0, attempts)
+}
+
+func TestCircuitBreaker_ReleasedOnShutdown(t *testing.T) {
+	cb := newCircuitBreaker(CircuitBreakerSettings{Enabled: true, FailureThreshold: 1, OpenDuration: time.Hour}, zap.NewNop())
+	stopCh := make(chan struct{})
+	cb.record(acquire(t, cb, stopCh), errors.New("transient error"))
+
+	close(stopCh)
+	probe, err := cb.acquire(context.Background(), stopCh)
+	assert.NoError(t, err)
+	assert.False(t, probe)
+}
+
+// acquire acquires an attempt from the circuit breaker and returns whether it is the probe.
+func acquire(t *testing.T, cb *circuitBreaker, stopCh <-chan struct{}) bool {
+	probe, err := cb.acquire(context.Background(), stopCh)
+	require.NoError(t, err)
+	return probe
+}
+
+func TestQueuedRetry_CircuitBreakerHoldsRequestsInQueue(t *testing.T) {
+	qCfg := NewDefaultQueueSettings()
+	qCfg.NumConsumers = 1
+	rCfg := NewDefaultRetrySettings()
+	rCfg.InitialInterval = time.Millisecond
+	cbCfg := CircuitBreakerSettings{Enabled: true, FailureThreshold: 1, OpenDuration: time.Hour}
+	be, err := newBaseExporter(defaultSettings, fromOptions(WithRetry(rCfg), WithQueue(qCfg), WithCircuitBreaker(cbCfg)), "", nopRequestUnmarshaler())
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+
+	failing := newMockRequest(context.Background(), 2, errors.New("transient error"))
+	require.NoError(t, be.sender.send(failing))
+	failing.checkNumRequests(t, 1)
+	assert.Eventually(t, func() bool {
+		return be.qrSender.breaker.currentState() == circuitOpen
+	}, time.Second, time.Millisecond)
+	checkValueForGlobalManager(t, defaultExporterTags, int64(circuitOpen), "exporter/circuit_breaker_state")
+
+	// The next request is not attempted while the circuit is open.
+	waiting := newMockRequest(context.Background(), 2, nil)
+	require.NoError(t, be.sender.send(waiting))
+	assert.Never(t, func() bool {
+		return waiting.requestCount.Load() > 0 || failing.requestCount.Load() > 1
+	}, 50*time.Millisecond, time.Millisecond)
+	assert.Equal(t, 1, be.qrSender.queue.Size())
+	assert.Contains(t, be.ZPagesProperties(), [2]string{"Circuit breaker state", "open"})
+
+	// On shutdown the waiting requests are released and attempted once.
+	require.NoError(t, be.Shutdown(context.Background()))
+	failing.checkNumRequests(t, 2)
+	waiting.checkNumRequests(t, 1)
+}
@@ -16,11 +16,15 @@ package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporte
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportererror"
 	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
 	"go.opentelemetry.io/collector/obsreport"
 )
@@ -75,6 +79,10 @@ type baseSettings struct {
 	TimeoutSettings
 	QueueSettings
 	RetrySettings
+	BatcherSettings
+	CircuitBreakerSettings
+	DeadLetterSettings
+	RateLimiterSettings
 }
 
 // fromOptions returns the internal options starting from the default and applying all configured options.
@@ -86,6 +94,12 @@ func fromOptions(options ...Option) *baseSettings {
 		QueueSettings: QueueSettings{Enabled: false},
 		// TODO: Enable retry by default (call DefaultRetrySettings)
 		RetrySettings: RetrySettings{Enabled: false},
+		// Batching is opt-in, exporters that prefer a specific batch shape enable it.
+		BatcherSettings: BatcherSettings{Enabled: false},
+		// TODO: Enable the circuit breaker by default (call NewDefaultCircuitBreakerSettings)
+		CircuitBreakerSettings: CircuitBreakerSettings{Enabled: false},
+		DeadLetterSettings:     DeadLetterSettings{Enabled: false},
+		RateLimiterSettings:    RateLimiterSettings{Enabled: false},
 	}
 
 	for _, op := range options {
@@ -138,6 +152,38 @@ func WithQueue(queueSettings QueueSettings) Option {
 	}
 }
 
+// WithCircuitBreaker overrides the default CircuitBreakerSettings for an exporter.
+// The default CircuitBreakerSettings is to disable the circuit breaker.
+func WithCircuitBreaker(circuitBreakerSettings CircuitBreakerSettings) Option {
+	return func(o *baseSettings) {
+		o.CircuitBreakerSettings = circuitBreakerSettings
+	}
+}
+
+// WithDeadLetter overrides the default DeadLetterSettings for an exporter.
+// The default DeadLetterSettings is to drop the requests rejected with a permanent error.
+func WithDeadLetter(deadLetterSettings DeadLetterSettings) Option {
+	return func(o *baseSettings) {
+		o.DeadLetterSettings = deadLetterSettings
+	}
+}
+
+// WithRateLimiter overrides the default RateLimiterSettings for an exporter.
+// The default RateLimiterSettings is to not limit the rate of the requests.
+func WithRateLimiter(rateLimiterSettings RateLimiterSettings) Option {
+	return func(o *baseSettings) {
+		o.RateLimiterSettings = rateLimiterSettings
+	}
+}
+
+// WithBatcher overrides the default BatcherSettings for an exporter.
+// The default BatcherSettings is to disable batching.
+func WithBatcher(batcherSettings BatcherSettings) Option {
+	return func(o *baseSettings) {
+		o.BatcherSettings = batcherSettings
+	}
+}
+
 // WithCapabilities overrides the default Capabilities() function for a Consumer.
 // The default is non-mutable data.
 // TODO: Verify if we can change the default to be mutable as we do for processors.
@@ -151,9 +197,11 @@ func WithCapabilities(capabilities consumer.Capabilities) Option {
 type baseExporter struct {
 	component.StartFunc
 	component.ShutdownFunc
-	obsrep   *obsExporter
-	sender   requestSender
-	qrSender *queuedRetrySender
+	obsrep      *obsExporter
+	sender      requestSender
+	qrSender    *queuedRetrySender
+	batchSender *batchSender
+	rateLimiter *rateLimiter
 }
 
 func newBaseExporter(set exporter.CreateSettings, bs *baseSettings, signal component.DataType, reqUnmarshaler internal.RequestUnmarshaler) (*baseExporter, error) {
@@ -165,7 +213,23 @@ func newBaseExporter(set exporter.CreateSettings, bs *baseSettings, signal compo
 		return nil, err
 	}
 
-	be.qrSender = newQueuedRetrySender(set.ID, signal, bs.QueueSettings, bs.RetrySettings, reqUnmarshaler, &timeoutSender{cfg: bs.TimeoutSettings}, set.Logger)
+	ts := &timeoutSender{cfg: bs.TimeoutSettings}
+	if bs.RateLimiterSettings.Enabled {
+		// Limit every attempt, including retries, since they all count against the backend quota.
+		// The limiter waits within the timeout of the attempt, so that the queued requests do not wait forever.
+		be.rateLimiter = newRateLimiter(bs.RateLimiterSettings, be.obsrep)
+		ts.limiter = be.rateLimiter
+	}
+	var consumer requestSender = ts
+	if bs.BatcherSettings.Enabled {
+		// Batch right before sending, so that every attempt of a request is batched and retried on its own.
+		be.batchSender = newBatchSender(bs.BatcherSettings, ts)
+		consumer = be.batchSender
+	}
+	be.qrSender, err = newQueuedRetrySender(set.ID, signal, bs.QueueSettings, bs.RetrySettings, bs.CircuitBreakerSettings, bs.DeadLetterSettings, reqUnmarshaler, consumer, set.Logger)
+	if err != nil {
+		return nil, err
+	}
 	be.sender = be.qrSender
 	be.StartFunc = func(ctx context.Context, host component.Host) error {
 		// First start the wrapped exporter.
@@ -173,27 +237,149 @@ func newBaseExporter(set exporter.CreateSettings, bs *baseSettings, signal compo
 			return err
 		}
 
+		if be.batchSender != nil {
+			be.batchSender.start()
+		}
+
 		// If no error then start the queuedRetrySender.
-		return be.qrSender.start(ctx, host)
+		if err := be.qrSender.start(ctx, host); err != nil {
+			return err
+		}
+
+		if bs.DeadLetterSettings.RedriveOnStart && be.qrSender.deadLetter != nil {
+			be.qrSender.deadLetter.redriveInBackground(be.qrSender.redriveSender)
+		}
+		return nil
 	}
 	be.ShutdownFunc = func(ctx context.Context) error {
-		// First shutdown the queued retry sender
+		// First stop batching, so the queue consumers waiting for a batch to fill up are released.
+		if be.batchSender != nil {
+			be.batchSender.shutdown()
+		}
+		// Release the requests waiting for the rate limiter, so the queue can be drained.
+		if be.rateLimiter != nil {
+			be.rateLimiter.shutdown()
+		}
+		// Then shutdown the queued retry sender
 		be.qrSender.shutdown()
+		if be.batchSender != nil {
+			be.batchSender.awaitShutdown()
+		}
 		// Last shutdown the wrapped exporter itself.
 		return bs.ShutdownFunc.Shutdown(ctx)
 	}
 	return be, nil
 }
 
+// ZPagesProperties returns the state of the exporter helper stages to be displayed on the pipelines zPage.
+func (be *baseExporter) ZPagesProperties() [][2]string {
+	var props [][2]string
+	if be.qrSender.breaker != nil {
+		props = append(props, be.qrSender.breaker.zPagesProperties()...)
+	}
+	props = append(props, be.qrSender.persistentQueueZPagesProperties()...)
+	if be.qrSender.deadLetter != nil {
+		props = append(props, be.qrSender.deadLetter.zPagesProperties()...)
+	}
+	return props
+}
+
+// ZPagesActions returns the actions that can be triggered from the pipelines zPage, if enabled.
+func (be *baseExporter) ZPagesActions() []string {
+	var actions []string
+	if be.qrSender.cfg.ZPagesActions && len(be.qrSender.persistentQueues) > 0 {
+		actions = append(actions, zPagesActionPurgeQueue, zPagesActionSkipQueueItem)
+	}
+	if be.qrSender.deadLetter != nil && be.qrSender.deadLetter.zPagesActionsEnabled() {
+		actions = append(actions, zPagesActionRedriveDeadLetters)
+	}
+	return actions
+}
+
+// HandleZPagesAction runs an action triggered from the pipelines zPage and returns its outcome.
+func (be *baseExporter) HandleZPagesAction(ctx context.Context, action string, value string) (string, error) {
+	switch action {
+	case zPagesActionPurgeQueue, zPagesActionSkipQueueItem:
+		if !be.qrSender.cfg.ZPagesActions {
+			return "", errZPagesActionsNotEnabled
+		}
+	case zPagesActionRedriveDeadLetters:
+		if be.qrSender.deadLetter == nil || !be.qrSender.deadLetter.zPagesActionsEnabled() {
+			return "", errZPagesActionsNotEnabled
+		}
+	default:
+		return "", fmt.Errorf("%w %q", errUnknownZPagesAction, action)
+	}
+
+	switch action {
+	case zPagesActionPurgeQueue:
+		n, err := be.PurgeQueue(ctx)
+		return fmt.Sprintf("Purged %d items", n), err
+	case zPagesActionSkipQueueItem:
+		lane, rawIndex := "", value
+		if i := strings.LastIndex(value, ":"); i >= 0 {
+			lane, rawIndex = value[:i], value[i+1:]
+		}
+		index, err := strconv.ParseUint(strings.TrimSpace(rawIndex), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid item index %q: %w", rawIndex, err)
+		}
+		if err = be.SkipQueueItem(ctx, lane, index); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Skipped item %d", index), nil
+	default: // zPagesActionRedriveDeadLetters
+		n, err := be.RedriveDeadLetters(ctx)
+		return fmt.Sprintf("Redrove %d items", n), err
+	}
+}
+
+// PurgeQueue deletes the requests of the persistent queue that were not picked by consumers yet, in every lane,
+// and returns how many were deleted.
+func (be *baseExporter) PurgeQueue(ctx context.Context) (int, error) {
+	return be.qrSender.purgePersistentQueues(ctx)
+}
+
+// SkipQueueItem deletes the request at the given index of the persistent queue of the lane, the default lane if
+// empty. The request must be either the next one to be picked by consumers or a request being sent, in which case
+// it is not sent again after a restart. This allows getting rid of a request that can never be sent.
+func (be *baseExporter) SkipQueueItem(ctx context.Context, lane string, index uint64) error {
+	return be.qrSender.skipPersistentQueueItem(ctx, lane, index)
+}
+
+// RedriveDeadLetters sends again the requests kept in the dead letter storage, oldest first, and returns how many
+// were sent. The requests that are rejected again are kept at the end of the dead letter storage.
+func (be *baseExporter) RedriveDeadLetters(ctx context.Context) (int, error) {
+	if be.qrSender.deadLetter == nil {
+		return 0, errDeadLetterNotEnabled
+	}
+	return be.qrSender.deadLetter.redrive(ctx, be.qrSender.redriveSender)
+}
+
 // wrapConsumerSender wraps the consumer sender (the sender that uses retries and timeout) with the given wrapper.
 // This can be used to wrap with observability (create spans, record metrics) the consumer sender.
+// The sender redriving the dead letters is wrapped as well.
 func (be *baseExporter) wrapConsumerSender(f func(consumer requestSender) requestSender) {
+	if be.qrSender.redriveSender != nil {
+		be.qrSender.redriveSender = f(be.qrSender.redriveSender)
+	}
 	be.qrSender.consumerSender = f(be.qrSender.consumerSender)
 }
 
+// dropPartialExportError hides the partial export errors from the callers once they have been reported:
+// the accepted items must not be sent again and the rejected ones are already counted as failed.
+func dropPartialExportError(err error) error {
+	if exportererror.IsPartialExportError(err) {
+		return nil
+	}
+	return err
+}
+
 // timeoutSender is a requestSender that adds a `timeout` to every request that passes this sender.
+// If a rate limiter is set, the request waits for it within the timeout.
 type timeoutSender struct {
-	cfg TimeoutSettings
+	cfg     TimeoutSettings
+	limiter *rateLimiter
 }
 
 func (ts *timeoutSender) send(req internal.Request) error {
@@ -205,5 +391,10 @@ func (ts *timeoutSender) send(req internal.Request) error {
 		ctx, cancelFunc = context.WithTimeout(req.Context(), ts.cfg.Timeout)
 		defer cancelFunc()
 	}
+	if ts.limiter != nil {
+		if err := ts.limiter.wait(ctx, req); err != nil {
+			return err
+		}
+	}
 	return req.Export(ctx)
 }
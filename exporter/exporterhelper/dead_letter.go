@@ -0,0 +1,219 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const (
+	// deadLetterErrorAttribute is the resource attribute holding the rejection error of forwarded dead letters.
+	deadLetterErrorAttribute = "otelcol.dead_letter.error"
+	// deadLetterTimestampAttribute is the resource attribute holding the rejection time of forwarded dead letters.
+	deadLetterTimestampAttribute = "otelcol.dead_letter.timestamp"
+)
+
+// zPagesActionRedriveDeadLetters redrives the requests kept in the dead letter storage.
+const zPagesActionRedriveDeadLetters = "redrive_dead_letters"
+
+var (
+	errNoDeadLetterStorage  = errors.New("dead letter storage is not configured")
+	errDeadLetterNotEnabled = errors.New("dead_letter is not enabled")
+)
+
+// DeadLetterSettings defines where the requests rejected with a permanent error are kept instead of being dropped.
+type DeadLetterSettings struct {
+	// Enabled indicates whether to keep the permanently rejected requests.
+	Enabled bool `mapstructure:"enabled"`
+	// StorageID if not empty, stores the rejected requests using the component specified as a storage extension.
+	StorageID *component.ID `mapstructure:"storage"`
+	// ExporterID if not empty, forwards the rejected requests to the specified exporter of the same signal.
+	// The rejection error and time are added as resource attributes.
+	ExporterID *component.ID `mapstructure:"exporter"`
+	// RedriveOnStart sends again the requests stored by a previous run when the exporter starts.
+	RedriveOnStart bool `mapstructure:"redrive_on_start"`
+	// ZPagesActions indicates whether the stored requests can be redriven from the pipelines zPage.
+	ZPagesActions bool `mapstructure:"zpages_actions"`
+}
+
+// Validate checks if the DeadLetterSettings configuration is valid
+func (dlCfg *DeadLetterSettings) Validate() error {
+	if !dlCfg.Enabled {
+		return nil
+	}
+
+	if (dlCfg.StorageID == nil) == (dlCfg.ExporterID == nil) {
+		return errors.New("exactly one of storage or exporter must be set")
+	}
+	if dlCfg.RedriveOnStart && dlCfg.StorageID == nil {
+		return errors.New("redrive_on_start requires storage to be set")
+	}
+	if dlCfg.ZPagesActions && dlCfg.StorageID == nil {
+		return errors.New("zpages_actions requires storage to be set")
+	}
+
+	return nil
+}
+
+// deadLetterRequest is implemented by the requests that can be forwarded to another exporter.
+type deadLetterRequest interface {
+	internal.Request
+	// forwardTo sends a copy of the data to the exporter, with the rejection error and time set as resource attributes.
+	forwardTo(ctx context.Context, exp component.Component, errMsg string, ts time.Time) error
+}
+
+// deadLetterHandler keeps the permanently rejected requests in a storage extension or forwards them to an exporter.
+type deadLetterHandler struct {
+	cfg    DeadLetterSettings
+	id     component.ID
+	signal component.DataType
+	logger *zap.Logger
+
+	storage  *internal.DeadLetterStorage
+	exporter component.Component
+
+	redriveWG sync.WaitGroup
+}
+
+func newDeadLetterHandler(cfg DeadLetterSettings, id component.ID, signal component.DataType, logger *zap.Logger) *deadLetterHandler {
+	return &deadLetterHandler{
+		cfg:    cfg,
+		id:     id,
+		signal: signal,
+		logger: logger,
+	}
+}
+
+// start resolves the storage extension or the exporter, which are only available from the component.Host.
+// The stored requests are encoded with the codec, which may be nil.
+func (dlh *deadLetterHandler) start(ctx context.Context, host component.Host, unmarshaler internal.RequestUnmarshaler, codec *internal.RequestCodec) error {
+	if dlh.cfg.ExporterID != nil {
+		exp, ok := host.GetExporters()[dlh.signal][*dlh.cfg.ExporterID]
+		if !ok {
+			return fmt.Errorf("dead letter exporter %q not found for %s", dlh.cfg.ExporterID, dlh.signal)
+		}
+		dlh.exporter = exp
+		return nil
+	}
+
+	storageClient, err := toStorageClient(ctx, *dlh.cfg.StorageID, host, dlh.id, component.DataType("dead_letter-"+string(dlh.signal)))
+	if err != nil {
+		return err
+	}
+	if dlh.storage, err = internal.NewDeadLetterStorage(ctx, dlh.logger, storageClient, unmarshaler, codec); err != nil {
+		return err
+	}
+	dls := dlh.storage
+	err = globalInstruments.deadLetterQuarantinedItems.UpsertEntry(func() int64 {
+		return int64(dls.QuarantinedItems())
+	}, metricdata.NewLabelValue(dlh.id.String()), metricdata.NewLabelValue(string(dlh.signal)))
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter quarantined items metric: %w", err)
+	}
+	return nil
+}
+
+// zPagesActionsEnabled returns whether the stored requests can be redriven from the pipelines zPage.
+func (dlh *deadLetterHandler) zPagesActionsEnabled() bool {
+	return dlh.cfg.ZPagesActions && dlh.cfg.StorageID != nil
+}
+
+// zPagesProperties returns the state of the dead letter storage for the zPages.
+func (dlh *deadLetterHandler) zPagesProperties() [][2]string {
+	if dlh.storage == nil {
+		return nil
+	}
+	return [][2]string{
+		{"Dead letters stored", strconv.Itoa(dlh.storage.Size())},
+		{"Dead letters quarantined", strconv.FormatUint(dlh.storage.QuarantinedItems(), 10)},
+	}
+}
+
+// handle keeps the request rejected with the given error.
+func (dlh *deadLetterHandler) handle(req internal.Request, rejectErr error) error {
+	ts := time.Now()
+	if dlh.storage != nil {
+		return dlh.storage.Put(context.Background(), req, rejectErr, ts)
+	}
+
+	dlReq, ok := req.(deadLetterRequest)
+	if !ok {
+		return fmt.Errorf("request of type %T cannot be forwarded", req)
+	}
+	return dlReq.forwardTo(req.Context(), dlh.exporter, rejectErr.Error(), ts)
+}
+
+// redrive sends the stored requests using the given sender, oldest first, and returns how many were accepted.
+// The sender must not queue the requests, since the entries are deleted once it returns.
+// The requests rejected again with a permanent error were already stored again at the end by the sender,
+// so they do not stop the redrive.
+func (dlh *deadLetterHandler) redrive(ctx context.Context, sender requestSender) (int, error) {
+	if dlh.storage == nil {
+		return 0, errNoDeadLetterStorage
+	}
+	rejected := 0
+	n, err := dlh.storage.Redrive(ctx, func(entry internal.DeadLetterEntry) error {
+		entry.Request.SetContext(ctx)
+		err := sender.send(entry.Request)
+		if consumererror.IsPermanent(err) {
+			rejected++
+			return nil
+		}
+		return err
+	})
+	return n - rejected, err
+}
+
+// redriveInBackground redrives the stored requests without blocking the start of the exporter.
+func (dlh *deadLetterHandler) redriveInBackground(sender requestSender) {
+	dlh.redriveWG.Add(1)
+	go func() {
+		defer dlh.redriveWG.Done()
+		n, err := dlh.redrive(context.Background(), sender)
+		if err != nil {
+			dlh.logger.Error("Failed to redrive dead letters", zap.Int("redriven_items", n), zap.Error(err))
+			return
+		}
+		if n > 0 {
+			dlh.logger.Info("Redrove dead letters", zap.Int("redriven_items", n))
+		}
+	}()
+}
+
+func (dlh *deadLetterHandler) shutdown(ctx context.Context) error {
+	dlh.redriveWG.Wait()
+	if dlh.storage != nil {
+		return dlh.storage.Close(ctx)
+	}
+	return nil
+}
+
+func setDeadLetterAttributes(attrs pcommon.Map, errMsg string, ts time.Time) {
+	attrs.PutStr(deadLetterErrorAttribute, errMsg)
+	attrs.PutStr(deadLetterTimestampAttribute, ts.UTC().Format(time.RFC3339Nano))
+}
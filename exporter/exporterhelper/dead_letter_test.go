@@ -0,0 +1,372 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/tag"
+	"go.uber.org/atomic"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestDeadLetterSettings_Validate(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	exporterID := component.NewID("otlp")
+
+	dlCfg := DeadLetterSettings{Enabled: true}
+	assert.EqualError(t, dlCfg.Validate(), "exactly one of storage or exporter must be set")
+
+	dlCfg = DeadLetterSettings{Enabled: true, StorageID: &storageID, ExporterID: &exporterID}
+	assert.EqualError(t, dlCfg.Validate(), "exactly one of storage or exporter must be set")
+
+	dlCfg = DeadLetterSettings{Enabled: true, ExporterID: &exporterID, RedriveOnStart: true}
+	assert.EqualError(t, dlCfg.Validate(), "redrive_on_start requires storage to be set")
+
+	dlCfg = DeadLetterSettings{Enabled: true, ExporterID: &exporterID, ZPagesActions: true}
+	assert.EqualError(t, dlCfg.Validate(), "zpages_actions requires storage to be set")
+
+	dlCfg = DeadLetterSettings{Enabled: true, StorageID: &storageID, RedriveOnStart: true, ZPagesActions: true}
+	assert.NoError(t, dlCfg.Validate())
+
+	dlCfg = DeadLetterSettings{}
+	assert.NoError(t, dlCfg.Validate())
+}
+
+func TestDeadLetter_StorageAndRedrive(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{
+		storageID: newMapStorageExtension(),
+	}}
+
+	var rejecting atomic.Bool
+	rejecting.Store(true)
+	var exported atomic.Int64
+	push := func(_ context.Context, td ptrace.Traces) error {
+		if rejecting.Load() {
+			return consumererror.NewPermanent(errors.New("bad data"))
+		}
+		exported.Add(int64(td.SpanCount()))
+		return nil
+	}
+	dlCfg := DeadLetterSettings{Enabled: true, StorageID: &storageID}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push, WithDeadLetter(dlCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+
+	assert.Error(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	assert.Error(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(3)))
+	require.NoError(t, te.Shutdown(context.Background()))
+
+	// A new instance with redrive_on_start sends the stored requests once the backend accepts them.
+	rejecting.Store(false)
+	dlCfg.RedriveOnStart = true
+	te, err = NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push, WithDeadLetter(dlCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Equal(t, int64(5), exported.Load())
+}
+
+func TestDeadLetter_RedriveDeadLetters(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{
+		storageID: newMapStorageExtension(),
+	}}
+
+	var rejecting atomic.Bool
+	rejecting.Store(true)
+	push := func(_ context.Context, td ptrace.Traces) error {
+		if rejecting.Load() {
+			return consumererror.NewPermanent(errors.New("bad data"))
+		}
+		return nil
+	}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push,
+		WithDeadLetter(DeadLetterSettings{Enabled: true, StorageID: &storageID}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	redriver := te.(interface {
+		RedriveDeadLetters(context.Context) (int, error)
+	})
+
+	assert.Error(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+
+	// Rejected again, the request is kept in the dead letter storage.
+	n, err := redriver.RedriveDeadLetters(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	rejecting.Store(false)
+	n, err = redriver.RedriveDeadLetters(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = redriver.RedriveDeadLetters(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestDeadLetter_RedriveBelowQueue(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{
+		storageID: newMapStorageExtension(),
+	}}
+
+	var mu sync.Mutex
+	pushErr := consumererror.NewPermanent(errors.New("bad data"))
+	setPushErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		pushErr = err
+	}
+	var exported atomic.Int64
+	push := func(_ context.Context, td ptrace.Traces) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if pushErr != nil {
+			return pushErr
+		}
+		exported.Add(int64(td.SpanCount()))
+		return nil
+	}
+	rCfg := NewDefaultRetrySettings()
+	rCfg.Enabled = false
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push,
+		WithQueue(NewDefaultQueueSettings()), WithRetry(rCfg), WithDeadLetter(DeadLetterSettings{Enabled: true, StorageID: &storageID}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	be := te.(*traceExporter).baseExporter
+
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	require.Eventually(t, func() bool { return be.qrSender.deadLetter.storage.Size() == 1 }, time.Second, 10*time.Millisecond)
+
+	// A temporary failure keeps the request in the dead letter storage, it is not handed over to the queue.
+	setPushErr(errors.New("unavailable"))
+	n, err := be.RedriveDeadLetters(context.Background())
+	assert.EqualError(t, err, "unavailable")
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 1, be.qrSender.deadLetter.storage.Size())
+
+	setPushErr(nil)
+	n, err = be.RedriveDeadLetters(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, int64(2), exported.Load())
+	assert.Equal(t, 0, be.qrSender.deadLetter.storage.Size())
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestDeadLetter_RedriveNotEnabled(t *testing.T) {
+	be, err := newBaseExporter(defaultSettings, fromOptions(), "", nopRequestUnmarshaler())
+	require.NoError(t, err)
+	_, err = be.RedriveDeadLetters(context.Background())
+	assert.ErrorIs(t, err, errDeadLetterNotEnabled)
+}
+
+func TestDeadLetter_ZPagesActions(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{
+		storageID: newMapStorageExtension(),
+	}}
+
+	var rejecting atomic.Bool
+	rejecting.Store(true)
+	push := func(_ context.Context, td ptrace.Traces) error {
+		if rejecting.Load() {
+			return consumererror.NewPermanent(errors.New("bad data"))
+		}
+		return nil
+	}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push,
+		WithDeadLetter(DeadLetterSettings{Enabled: true, StorageID: &storageID, ZPagesActions: true}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	assert.Error(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+
+	admin, ok := te.(zPagesActionHandler)
+	require.True(t, ok)
+	assert.Equal(t, []string{zPagesActionRedriveDeadLetters}, admin.ZPagesActions())
+	assert.Contains(t, admin.ZPagesProperties(), [2]string{"Dead letters stored", "1"})
+	assert.Contains(t, admin.ZPagesProperties(), [2]string{"Dead letters quarantined", "0"})
+	dataTypeTag, _ := tag.NewKey(dataTypeKey)
+	checkExporterHelperMetric(t, "exporter/dead_letter_quarantined_items",
+		[]tag.Tag{{Key: exporterTag, Value: defaultID.String()}, {Key: dataTypeTag, Value: string(component.DataTypeTraces)}}, 0)
+
+	// The queue actions are not enabled.
+	_, err = admin.HandleZPagesAction(context.Background(), zPagesActionPurgeQueue, "")
+	assert.ErrorIs(t, err, errZPagesActionsNotEnabled)
+
+	rejecting.Store(false)
+	msg, err := admin.HandleZPagesAction(context.Background(), zPagesActionRedriveDeadLetters, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Redrove 1 items", msg)
+	assert.Contains(t, admin.ZPagesProperties(), [2]string{"Dead letters stored", "0"})
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestDeadLetter_ZPagesActionsNotEnabled(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{
+		storageID: newMapStorageExtension(),
+	}}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, newTraceDataPusher(nil),
+		WithDeadLetter(DeadLetterSettings{Enabled: true, StorageID: &storageID}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+
+	admin, ok := te.(zPagesActionHandler)
+	require.True(t, ok)
+	assert.Nil(t, admin.ZPagesActions())
+	_, err = admin.HandleZPagesAction(context.Background(), zPagesActionRedriveDeadLetters, "")
+	assert.ErrorIs(t, err, errZPagesActionsNotEnabled)
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestDeadLetter_ForwardToExporter(t *testing.T) {
+	exporterID := component.NewIDWithName("otlp", "dead_letter")
+	sink := &tracesSinkExporter{}
+	host := &exportersHost{exporters: map[component.DataType]map[component.ID]component.Component{
+		component.DataTypeTraces: {exporterID: sink},
+	}}
+
+	push := func(context.Context, ptrace.Traces) error {
+		return consumererror.NewPermanent(errors.New("bad data"))
+	}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push,
+		WithDeadLetter(DeadLetterSettings{Enabled: true, ExporterID: &exporterID}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+
+	td := testdata.GenerateTraces(2)
+	assert.Error(t, te.ConsumeTraces(context.Background(), td))
+	require.NoError(t, te.Shutdown(context.Background()))
+
+	require.Len(t, sink.AllTraces(), 1)
+	forwarded := sink.AllTraces()[0]
+	assert.Equal(t, 2, forwarded.SpanCount())
+	attrs := forwarded.ResourceSpans().At(0).Resource().Attributes()
+	errMsg, ok := attrs.Get(deadLetterErrorAttribute)
+	require.True(t, ok)
+	assert.Contains(t, errMsg.Str(), "bad data")
+	_, ok = attrs.Get(deadLetterTimestampAttribute)
+	assert.True(t, ok)
+
+	// The original data is not modified.
+	_, ok = td.ResourceSpans().At(0).Resource().Attributes().Get(deadLetterErrorAttribute)
+	assert.False(t, ok)
+}
+
+func TestDeadLetter_ExporterNotFound(t *testing.T) {
+	exporterID := component.NewID("otlp")
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, newTraceDataPusher(nil),
+		WithDeadLetter(DeadLetterSettings{Enabled: true, ExporterID: &exporterID}))
+	require.NoError(t, err)
+	assert.Error(t, te.Start(context.Background(), componenttest.NewNopHost()))
+}
+
+type tracesSinkExporter struct {
+	component.StartFunc
+	component.ShutdownFunc
+	consumertest.TracesSink
+}
+
+type exportersHost struct {
+	component.Host
+	exporters map[component.DataType]map[component.ID]component.Component
+}
+
+func (h *exportersHost) GetExporters() map[component.DataType]map[component.ID]component.Component {
+	return h.exporters
+}
+
+// mapStorageExtension keeps a mapStorageClient for each name.
+type mapStorageExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+	mu      sync.Mutex
+	clients map[string]*mapStorageClient
+}
+
+func newMapStorageExtension() *mapStorageExtension {
+	return &mapStorageExtension{clients: map[string]*mapStorageClient{}}
+}
+
+func (mse *mapStorageExtension) GetClient(_ context.Context, _ component.Kind, _ component.ID, name string) (storage.Client, error) {
+	mse.mu.Lock()
+	defer mse.mu.Unlock()
+	if _, ok := mse.clients[name]; !ok {
+		mse.clients[name] = newMapStorageClient()
+	}
+	return mse.clients[name], nil
+}
+
+// mapStorageClient is a storage.Client kept in memory, it survives Close so it can be reused by another exporter.
+type mapStorageClient struct {
+	mu sync.Mutex
+	st map[string][]byte
+}
+
+func newMapStorageClient() *mapStorageClient {
+	return &mapStorageClient{st: map[string][]byte{}}
+}
+
+func (m *mapStorageClient) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	err := m.Batch(ctx, op)
+	return op.Value, err
+}
+
+func (m *mapStorageClient) Set(ctx context.Context, key string, value []byte) error {
+	return m.Batch(ctx, storage.SetOperation(key, value))
+}
+
+func (m *mapStorageClient) Delete(ctx context.Context, key string) error {
+	return m.Batch(ctx, storage.DeleteOperation(key))
+}
+
+func (m *mapStorageClient) Close(context.Context) error {
+	return nil
+}
+
+func (m *mapStorageClient) Batch(_ context.Context, ops ...storage.Operation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value = m.st[op.Key]
+		case storage.Set:
+			m.st[op.Key] = op.Value
+		case storage.Delete:
+			delete(m.st, op.Key)
+		}
+	}
+	return nil
+}
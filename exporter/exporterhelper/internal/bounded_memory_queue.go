@@ -65,6 +65,21 @@ func (q *boundedMemoryQueue) StartConsumers(numWorkers int, callback func(item R
 	startWG.Wait()
 }
 
+// laneItems implements laneQueue.
+func (q *boundedMemoryQueue) laneItems() <-chan Request {
+	return q.items
+}
+
+// onLaneDequeue implements laneQueue.
+func (q *boundedMemoryQueue) onLaneDequeue(Request) {
+	q.size.Sub(1)
+}
+
+// drainsOnStop returns true, like the consumers started by StartConsumers the lane consumes the remaining items.
+func (q *boundedMemoryQueue) drainsOnStop() bool {
+	return true
+}
+
 // Produce is used by the producer to submit new item to the queue. Returns false in case of queue overflow.
 func (q *boundedMemoryQueue) Produce(item Request) bool {
 	if q.stopped.Load() {
@@ -0,0 +1,252 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+const deadLetterEntryVersion = byte(1)
+
+// quarantineKeyPrefix is the prefix of the keys the entries that cannot be decoded are moved to.
+const quarantineKeyPrefix = "quarantine/"
+
+var errInvalidDeadLetterEntry = errors.New("invalid dead letter entry")
+
+// DeadLetterEntry is a request that was rejected with a permanent error, along with the error and the rejection time.
+type DeadLetterEntry struct {
+	Request   Request
+	Error     string
+	Timestamp time.Time
+}
+
+// DeadLetterStorage keeps the permanently rejected requests in a storage extension client, in rejection order.
+// Like the persistent queue, the entries are stored under contiguous indexes and the read and write indexes are
+// stored under separate keys.
+type DeadLetterStorage struct {
+	logger      *zap.Logger
+	client      storage.Client
+	unmarshaler RequestUnmarshaler
+	codec       *RequestCodec
+
+	// redriveMu makes sure only one Redrive runs at a time, mu protects the indexes and the counters.
+	redriveMu        sync.Mutex
+	mu               sync.Mutex
+	readIndex        itemIndex
+	writeIndex       itemIndex
+	quarantinedItems uint64
+}
+
+// NewDeadLetterStorage creates a DeadLetterStorage using the given client, restoring the entries stored by a previous run.
+// The requests are encoded with the codec, which may be nil to store them as is.
+func NewDeadLetterStorage(ctx context.Context, logger *zap.Logger, client storage.Client, unmarshaler RequestUnmarshaler, codec *RequestCodec) (*DeadLetterStorage, error) {
+	dls := &DeadLetterStorage{
+		logger:      logger,
+		client:      client,
+		unmarshaler: unmarshaler,
+		codec:       codec,
+	}
+
+	ri, err := dls.getIndex(ctx, readIndexKey)
+	if err != nil {
+		return nil, err
+	}
+	wi, err := dls.getIndex(ctx, writeIndexKey)
+	if err != nil {
+		return nil, err
+	}
+	dls.readIndex, dls.writeIndex = ri, wi
+	if wi > ri {
+		logger.Info("Found requests in the dead letter storage", zap.Uint64(zapNumberOfItems, uint64(wi-ri)))
+	}
+	return dls, nil
+}
+
+// Put stores the request with the error that rejected it.
+func (dls *DeadLetterStorage) Put(ctx context.Context, req Request, rejectErr error, ts time.Time) error {
+	reqBytes, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	if reqBytes, err = dls.codec.encode(reqBytes); err != nil {
+		return err
+	}
+	errMsg := ""
+	if rejectErr != nil {
+		errMsg = rejectErr.Error()
+	}
+
+	dls.mu.Lock()
+	defer dls.mu.Unlock()
+	wi, err := itemIndexToBytes(dls.writeIndex + 1)
+	if err != nil {
+		return err
+	}
+	if err = dls.client.Batch(ctx,
+		storage.SetOperation(itemKey(dls.writeIndex), encodeDeadLetterEntry(reqBytes, errMsg, ts)),
+		storage.SetOperation(writeIndexKey, wi)); err != nil {
+		return err
+	}
+	dls.writeIndex++
+	return nil
+}
+
+// Redrive calls fn for every stored entry, oldest first, and deletes the entries for which fn succeeds.
+// It stops at the first error and returns the number of entries that were redriven.
+// Entries that cannot be decoded, e.g. encrypted with another key, would fail on every attempt: they are moved to a
+// key starting with the quarantine prefix, so that they no longer block the redrive but can still be recovered.
+// The entries rejected again while redriving are stored at the end, so fn may lead to Put being called.
+func (dls *DeadLetterStorage) Redrive(ctx context.Context, fn func(DeadLetterEntry) error) (int, error) {
+	dls.redriveMu.Lock()
+	defer dls.redriveMu.Unlock()
+
+	dls.mu.Lock()
+	end := dls.writeIndex
+	dls.mu.Unlock()
+
+	redriven := 0
+	for {
+		dls.mu.Lock()
+		index := dls.readIndex
+		dls.mu.Unlock()
+		if index >= end {
+			return redriven, nil
+		}
+
+		key := itemKey(index)
+		value, err := dls.client.Get(ctx, key)
+		if err != nil {
+			return redriven, err
+		}
+
+		var ops []storage.Operation
+		entry, decodeErr := dls.decodeDeadLetterEntry(value)
+		if decodeErr != nil {
+			dls.logger.Warn("Moving dead letter entry that cannot be decoded to quarantine",
+				zap.String(zapKey, key), zap.String("quarantineKey", quarantineKeyPrefix+key), zap.Error(decodeErr))
+			ops = append(ops, storage.SetOperation(quarantineKeyPrefix+key, value))
+		} else {
+			if err = fn(entry); err != nil {
+				return redriven, err
+			}
+			redriven++
+		}
+
+		ri, err := itemIndexToBytes(index + 1)
+		if err != nil {
+			return redriven, err
+		}
+		ops = append(ops, storage.DeleteOperation(key), storage.SetOperation(readIndexKey, ri))
+		dls.mu.Lock()
+		err = dls.client.Batch(ctx, ops...)
+		if err == nil {
+			dls.readIndex++
+			if decodeErr != nil {
+				dls.quarantinedItems++
+			}
+		}
+		dls.mu.Unlock()
+		if err != nil {
+			return redriven, err
+		}
+	}
+}
+
+// Size returns the number of stored entries.
+func (dls *DeadLetterStorage) Size() int {
+	dls.mu.Lock()
+	defer dls.mu.Unlock()
+	return int(dls.writeIndex - dls.readIndex)
+}
+
+// QuarantinedItems returns the number of entries moved to quarantine since the storage was created.
+func (dls *DeadLetterStorage) QuarantinedItems() uint64 {
+	dls.mu.Lock()
+	defer dls.mu.Unlock()
+	return dls.quarantinedItems
+}
+
+// Close releases the storage client.
+func (dls *DeadLetterStorage) Close(ctx context.Context) error {
+	return dls.client.Close(ctx)
+}
+
+func (dls *DeadLetterStorage) getIndex(ctx context.Context, key string) (itemIndex, error) {
+	value, err := dls.client.Get(ctx, key)
+	if err != nil || value == nil {
+		return 0, err
+	}
+	idx, err := bytesToItemIndex(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read dead letter index %q: %w", key, err)
+	}
+	return idx.(itemIndex), nil
+}
+
+// encodeDeadLetterEntry encodes the entry as the version, the timestamp in nanoseconds,
+// the length of the error message, the error message and the marshaled request.
+func encodeDeadLetterEntry(reqBytes []byte, errMsg string, ts time.Time) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(deadLetterEntryVersion)
+	_ = binary.Write(&buf, binary.LittleEndian, ts.UnixNano())
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(errMsg)))
+	buf.WriteString(errMsg)
+	buf.Write(reqBytes)
+	return buf.Bytes()
+}
+
+func (dls *DeadLetterStorage) decodeDeadLetterEntry(b []byte) (DeadLetterEntry, error) {
+	reader := bytes.NewReader(b)
+	version, err := reader.ReadByte()
+	if err != nil || version != deadLetterEntryVersion {
+		return DeadLetterEntry{}, errInvalidDeadLetterEntry
+	}
+	var ts int64
+	var errLen uint32
+	if err = binary.Read(reader, binary.LittleEndian, &ts); err != nil {
+		return DeadLetterEntry{}, errInvalidDeadLetterEntry
+	}
+	if err = binary.Read(reader, binary.LittleEndian, &errLen); err != nil || int(errLen) > reader.Len() {
+		return DeadLetterEntry{}, errInvalidDeadLetterEntry
+	}
+	errMsg := make([]byte, errLen)
+	if _, err = reader.Read(errMsg); err != nil && errLen > 0 {
+		return DeadLetterEntry{}, errInvalidDeadLetterEntry
+	}
+	reqBytes, err := dls.codec.decode(b[len(b)-reader.Len():])
+	if err != nil {
+		return DeadLetterEntry{}, err
+	}
+	req, err := dls.unmarshaler(reqBytes)
+	if err != nil {
+		return DeadLetterEntry{}, err
+	}
+	return DeadLetterEntry{Request: req, Error: string(errMsg), Timestamp: time.Unix(0, ts)}, nil
+}
+
+func itemKey(index itemIndex) string {
+	return strconv.FormatUint(uint64(index), 10)
+}
@@ -0,0 +1,146 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDeadLetterStorage_PutRedrive(t *testing.T) {
+	client := newMockStorageClient()
+	dls, err := NewDeadLetterStorage(context.Background(), zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	require.NoError(t, err)
+
+	ts := time.Unix(1000, 5)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, dls.Put(context.Background(), newFakeTracesRequest(newTraces(1, i)), errors.New("rejected"), ts))
+	}
+	assert.Equal(t, 3, dls.Size())
+
+	// Reopening the storage restores the entries.
+	dls, err = NewDeadLetterStorage(context.Background(), zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, dls.Size())
+
+	// A failure stops the redrive and keeps the entry.
+	var spans []int
+	n, err := dls.Redrive(context.Background(), func(entry DeadLetterEntry) error {
+		assert.Equal(t, "rejected", entry.Error)
+		assert.True(t, ts.Equal(entry.Timestamp))
+		count := entry.Request.(*fakeTracesRequest).td.SpanCount()
+		if count == 2 {
+			return errors.New("still failing")
+		}
+		spans = append(spans, count)
+		return nil
+	})
+	assert.EqualError(t, err, "still failing")
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 2, dls.Size())
+
+	n, err = dls.Redrive(context.Background(), func(entry DeadLetterEntry) error {
+		spans = append(spans, entry.Request.(*fakeTracesRequest).td.SpanCount())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{1, 2, 3}, spans)
+	assert.Equal(t, 0, dls.Size())
+}
+
+func TestDeadLetterStorage_PutWhileRedriving(t *testing.T) {
+	dls, err := NewDeadLetterStorage(context.Background(), zap.NewNop(), newMockStorageClient(), newFakeTracesRequestUnmarshalerFunc(), nil)
+	require.NoError(t, err)
+	require.NoError(t, dls.Put(context.Background(), newFakeTracesRequest(newTraces(1, 1)), errors.New("rejected"), time.Now()))
+
+	// The entries rejected again are stored at the end and are not redriven in the same run.
+	n, err := dls.Redrive(context.Background(), func(entry DeadLetterEntry) error {
+		return dls.Put(context.Background(), entry.Request, errors.New("rejected again"), time.Now())
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, dls.Size())
+}
+
+func TestDeadLetterStorage_Encoded(t *testing.T) {
+	client := newMockStorageClient()
+	codec, err := NewRequestCodec(true, testKey)
+	require.NoError(t, err)
+	dls, err := NewDeadLetterStorage(context.Background(), zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), codec)
+	require.NoError(t, err)
+	req := newFakeTracesRequest(newTraces(1, 3))
+	require.NoError(t, dls.Put(context.Background(), req, errors.New("rejected"), time.Now()))
+
+	// The request is not stored as is.
+	reqBytes, err := req.Marshal()
+	require.NoError(t, err)
+	stored, err := client.Get(context.Background(), itemKey(0))
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(stored, reqBytes))
+
+	// The entries cannot be read without the key.
+	dls, err = NewDeadLetterStorage(context.Background(), zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	require.NoError(t, err)
+	n, err := dls.Redrive(context.Background(), func(entry DeadLetterEntry) error {
+		return errors.New("must not be called")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, uint64(1), dls.QuarantinedItems())
+
+	require.NoError(t, dls.Put(context.Background(), req, errors.New("rejected"), time.Now()))
+	dls, err = NewDeadLetterStorage(context.Background(), zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), codec)
+	require.NoError(t, err)
+	n, err = dls.Redrive(context.Background(), func(entry DeadLetterEntry) error {
+		assert.Equal(t, 3, entry.Request.(*fakeTracesRequest).td.SpanCount())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestDeadLetterStorage_CorruptedEntry(t *testing.T) {
+	client := newMockStorageClient()
+	dls, err := NewDeadLetterStorage(context.Background(), zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	require.NoError(t, err)
+	require.NoError(t, dls.Put(context.Background(), newFakeTracesRequest(newTraces(1, 1)), errors.New("rejected"), time.Now()))
+	require.NoError(t, dls.Put(context.Background(), newFakeTracesRequest(newTraces(1, 2)), errors.New("rejected"), time.Now()))
+	require.NoError(t, client.Set(context.Background(), itemKey(0), []byte{0xFF}))
+
+	n, err := dls.Redrive(context.Background(), func(entry DeadLetterEntry) error {
+		assert.Equal(t, 2, entry.Request.(*fakeTracesRequest).td.SpanCount())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 0, dls.Size())
+
+	// The corrupted entry is kept under the quarantine prefix.
+	assert.Equal(t, uint64(1), dls.QuarantinedItems())
+	quarantined, err := client.Get(context.Background(), quarantineKeyPrefix+itemKey(0))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xFF}, quarantined)
+	stored, err := client.Get(context.Background(), itemKey(0))
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -42,6 +43,31 @@ type persistentQueue struct {
 	storage    *persistentContiguousStorage
 }
 
+// PersistentQueueStats describes the state of a persistent queue.
+type PersistentQueueStats struct {
+	// ReadIndex is the index of the next item to be picked by consumers.
+	ReadIndex uint64
+	// WriteIndex is the index at which the next item is going to be stored.
+	WriteIndex uint64
+	// DispatchedItems is the number of items picked by consumers and not acknowledged yet.
+	DispatchedItems int
+	// OldestItemAge is the time since the oldest item still stored was added, 0 if the queue is empty.
+	// The items left by a previous run are considered added when the queue was created.
+	OldestItemAge time.Duration
+	// CorruptedItems is the number of items dropped because they could not be unmarshaled.
+	CorruptedItems uint64
+}
+
+// PersistentQueueAdmin is implemented by the persistent queue to inspect and repair its storage.
+type PersistentQueueAdmin interface {
+	// Stats returns the current state of the queue.
+	Stats() PersistentQueueStats
+	// Purge deletes the items not picked by consumers yet and returns how many were deleted.
+	Purge(ctx context.Context) (int, error)
+	// Skip deletes the item at the given index, which must be either the next one to read or a dispatched one.
+	Skip(ctx context.Context, index uint64) error
+}
+
 // buildPersistentStorageName returns a name that is constructed out of queue name and signal type. This is done
 // to avoid conflicts between different signals, which require unique persistent storage name
 func buildPersistentStorageName(name string, signal component.DataType) string {
@@ -49,11 +75,11 @@ func buildPersistentStorageName(name string, signal component.DataType) string {
 }
 
 // NewPersistentQueue creates a new queue backed by file storage; name and signal must be a unique combination that identifies the queue storage
-func NewPersistentQueue(ctx context.Context, name string, signal component.DataType, capacity int, logger *zap.Logger, client storage.Client, unmarshaler RequestUnmarshaler) ProducerConsumerQueue {
+func NewPersistentQueue(ctx context.Context, name string, signal component.DataType, capacity int, logger *zap.Logger, client storage.Client, unmarshaler RequestUnmarshaler, codec *RequestCodec) ProducerConsumerQueue {
 	return &persistentQueue{
 		logger:   logger,
 		stopChan: make(chan struct{}),
-		storage:  newPersistentContiguousStorage(ctx, buildPersistentStorageName(name, signal), uint64(capacity), logger, client, unmarshaler),
+		storage:  newPersistentContiguousStorage(ctx, buildPersistentStorageName(name, signal), uint64(capacity), logger, client, unmarshaler, codec),
 	}
 }
 
@@ -78,6 +104,19 @@ func (pq *persistentQueue) StartConsumers(num int, callback func(item Request))
 	}
 }
 
+// laneItems implements laneQueue.
+func (pq *persistentQueue) laneItems() <-chan Request {
+	return pq.storage.get()
+}
+
+// onLaneDequeue implements laneQueue, the items are already marked as dispatched by the storage.
+func (pq *persistentQueue) onLaneDequeue(Request) {}
+
+// drainsOnStop returns false, the remaining items are kept in the storage.
+func (pq *persistentQueue) drainsOnStop() bool {
+	return false
+}
+
 // Produce adds an item to the queue and returns true if it was accepted
 func (pq *persistentQueue) Produce(item Request) bool {
 	err := pq.storage.put(item)
@@ -98,3 +137,25 @@ func (pq *persistentQueue) Stop() {
 func (pq *persistentQueue) Size() int {
 	return int(pq.storage.size())
 }
+
+// Stats returns the current state of the queue.
+func (pq *persistentQueue) Stats() PersistentQueueStats {
+	st := pq.storage.stats()
+	return PersistentQueueStats{
+		ReadIndex:       uint64(st.readIndex),
+		WriteIndex:      uint64(st.writeIndex),
+		DispatchedItems: st.dispatchedItems,
+		OldestItemAge:   st.oldestItemAge,
+		CorruptedItems:  st.corruptedItems,
+	}
+}
+
+// Purge deletes the items not picked by consumers yet and returns how many were deleted.
+func (pq *persistentQueue) Purge(ctx context.Context) (int, error) {
+	return pq.storage.purge(ctx)
+}
+
+// Skip deletes the item at the given index, which must be either the next one to read or a dispatched one.
+func (pq *persistentQueue) Skip(ctx context.Context, index uint64) error {
+	return pq.storage.skip(ctx, itemIndex(index))
+}
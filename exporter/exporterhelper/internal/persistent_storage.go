@@ -17,8 +17,9 @@ package internal // import "go.opentelemetry.io/collector/exporter/exporterhelpe
 import (
 	"context"
 	"errors"
-	"strconv"
+	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -53,6 +54,7 @@ type persistentContiguousStorage struct {
 	queueName   string
 	client      storage.Client
 	unmarshaler RequestUnmarshaler
+	codec       *RequestCodec
 
 	putChan  chan struct{}
 	stopChan chan struct{}
@@ -65,10 +67,24 @@ type persistentContiguousStorage struct {
 	readIndex                itemIndex
 	writeIndex               itemIndex
 	currentlyDispatchedItems []itemIndex
+	// enqueueTimes holds the time the items put since the start were added to the queue,
+	// the items left by a previous run are considered added at startTime.
+	enqueueTimes   map[itemIndex]time.Time
+	startTime      time.Time
+	corruptedItems uint64
 
 	itemsCount *atomic.Uint64
 }
 
+// persistentStorageStats is a snapshot of the state of the persistent queue.
+type persistentStorageStats struct {
+	readIndex       itemIndex
+	writeIndex      itemIndex
+	dispatchedItems int
+	oldestItemAge   time.Duration
+	corruptedItems  uint64
+}
+
 type itemIndex uint64
 
 const (
@@ -86,22 +102,26 @@ var (
 	errMaxCapacityReached   = errors.New("max capacity reached")
 	errValueNotSet          = errors.New("value not set")
 	errKeyNotPresentInBatch = errors.New("key was not present in get batchStruct")
+	errItemNotInQueue       = errors.New("item is neither the next one to read nor currently dispatched")
 )
 
 // newPersistentContiguousStorage creates a new file-storage extension backed queue;
 // queueName parameter must be a unique value that identifies the queue.
 // The queue needs to be initialized separately using initPersistentContiguousStorage.
-func newPersistentContiguousStorage(ctx context.Context, queueName string, capacity uint64, logger *zap.Logger, client storage.Client, unmarshaler RequestUnmarshaler) *persistentContiguousStorage {
+func newPersistentContiguousStorage(ctx context.Context, queueName string, capacity uint64, logger *zap.Logger, client storage.Client, unmarshaler RequestUnmarshaler, codec *RequestCodec) *persistentContiguousStorage {
 	pcs := &persistentContiguousStorage{
-		logger:      logger,
-		client:      client,
-		queueName:   queueName,
-		unmarshaler: unmarshaler,
-		capacity:    capacity,
-		putChan:     make(chan struct{}, capacity),
-		reqChan:     make(chan Request),
-		stopChan:    make(chan struct{}),
-		itemsCount:  atomic.NewUint64(0),
+		logger:       logger,
+		client:       client,
+		queueName:    queueName,
+		unmarshaler:  unmarshaler,
+		codec:        codec,
+		capacity:     capacity,
+		putChan:      make(chan struct{}, capacity),
+		reqChan:      make(chan Request),
+		stopChan:     make(chan struct{}),
+		itemsCount:   atomic.NewUint64(0),
+		enqueueTimes: make(map[itemIndex]time.Time),
+		startTime:    time.Now(),
 	}
 
 	initPersistentContiguousStorage(ctx, pcs)
@@ -226,6 +246,7 @@ func (pcs *persistentContiguousStorage) put(req Request) error {
 	}
 
 	itemKey := pcs.itemKey(pcs.writeIndex)
+	pcs.enqueueTimes[pcs.writeIndex] = time.Now()
 	pcs.writeIndex++
 	pcs.itemsCount.Store(uint64(pcs.writeIndex - pcs.readIndex))
 
@@ -256,6 +277,11 @@ func (pcs *persistentContiguousStorage) getNextItem(ctx context.Context) (Reques
 		batch, err := newBatch(pcs).get(pcs.itemKey(index)).execute(ctx)
 		if err == nil {
 			req, err = batch.getRequestResult(pcs.itemKey(index))
+			if err != nil && !errors.Is(err, errValueNotSet) {
+				pcs.corruptedItems++
+				pcs.logger.Warn("Failed unmarshalling item, dropping it",
+					zap.String(zapQueueNameKey, pcs.queueName), zap.String(zapKey, pcs.itemKey(index)), zap.Error(err))
+			}
 		}
 
 		if err != nil || req == nil {
@@ -314,6 +340,10 @@ func (pcs *persistentContiguousStorage) retrieveNotDispatchedReqs(ctx context.Co
 		cleanupBatch.delete(keys[i])
 	}
 
+	// The items are put back to the queue under new keys, so the list of currently dispatched items is emptied too:
+	// otherwise the items that cannot be retrieved would be looked up again on every restart.
+	cleanupBatch.setItemIndexArray(currentlyDispatchedItemsKey, nil)
+
 	_, retrieveErr := retrieveBatch.execute(ctx)
 	_, cleanupErr := cleanupBatch.execute(ctx)
 
@@ -333,6 +363,7 @@ func (pcs *persistentContiguousStorage) retrieveNotDispatchedReqs(ctx context.Co
 		req, err := retrieveBatch.getRequestResult(key)
 		// If error happened or item is nil, it will be efficiently ignored
 		if err != nil {
+			pcs.corruptedItems++
 			pcs.logger.Warn("Failed unmarshalling item",
 				zap.String(zapQueueNameKey, pcs.queueName), zap.String(zapKey, key), zap.Error(err))
 		} else {
@@ -369,6 +400,7 @@ func (pcs *persistentContiguousStorage) itemDispatchingFinish(ctx context.Contex
 		}
 	}
 	pcs.currentlyDispatchedItems = updatedDispatchedItems
+	delete(pcs.enqueueTimes, index)
 
 	_, err := newBatch(pcs).
 		setItemIndexArray(currentlyDispatchedItemsKey, pcs.currentlyDispatchedItems).
@@ -391,6 +423,110 @@ func (pcs *persistentContiguousStorage) updateReadIndex(ctx context.Context) {
 	}
 }
 
+// stats returns the current state of the queue.
+func (pcs *persistentContiguousStorage) stats() persistentStorageStats {
+	pcs.mu.Lock()
+	defer pcs.mu.Unlock()
+
+	st := persistentStorageStats{
+		readIndex:       pcs.readIndex,
+		writeIndex:      pcs.writeIndex,
+		dispatchedItems: len(pcs.currentlyDispatchedItems),
+		corruptedItems:  pcs.corruptedItems,
+	}
+
+	// The items are added in the order of their index, so the oldest one has the lowest index
+	// out of the items currently dispatched and the next one to read.
+	oldest, found := pcs.readIndex, pcs.readIndex != pcs.writeIndex
+	for _, it := range pcs.currentlyDispatchedItems {
+		if !found || it < oldest {
+			oldest, found = it, true
+		}
+	}
+	if found {
+		enqueueTime, ok := pcs.enqueueTimes[oldest]
+		if !ok {
+			enqueueTime = pcs.startTime
+		}
+		st.oldestItemAge = time.Since(enqueueTime)
+	}
+	return st
+}
+
+// purge deletes all the items that were not picked by consumers yet and returns how many were deleted.
+// The currently dispatched items are not affected.
+func (pcs *persistentContiguousStorage) purge(ctx context.Context) (int, error) {
+	pcs.mu.Lock()
+	defer pcs.mu.Unlock()
+
+	if pcs.readIndex == pcs.writeIndex {
+		return 0, nil
+	}
+
+	batch := newBatch(pcs).setItemIndex(readIndexKey, pcs.writeIndex)
+	for index := pcs.readIndex; index < pcs.writeIndex; index++ {
+		batch.delete(pcs.itemKey(index))
+	}
+	if _, err := batch.execute(ctx); err != nil {
+		return 0, err
+	}
+
+	purged := int(pcs.writeIndex - pcs.readIndex)
+	for index := pcs.readIndex; index < pcs.writeIndex; index++ {
+		delete(pcs.enqueueTimes, index)
+	}
+	pcs.readIndex = pcs.writeIndex
+	pcs.itemsCount.Store(0)
+	pcs.drainPutChan(purged)
+	pcs.logger.Info("Purged persistent queue",
+		zap.String(zapQueueNameKey, pcs.queueName), zap.Int(zapNumberOfItems, purged))
+	return purged, nil
+}
+
+// skip deletes the item at the given index, which must be either the next one to read or a currently dispatched one.
+// This allows getting rid of an item which cannot be processed, without losing the rest of the queue.
+func (pcs *persistentContiguousStorage) skip(ctx context.Context, index itemIndex) error {
+	pcs.mu.Lock()
+	defer pcs.mu.Unlock()
+
+	for _, it := range pcs.currentlyDispatchedItems {
+		if it == index {
+			pcs.itemDispatchingFinish(ctx, index)
+			pcs.logger.Info("Skipped currently dispatched item",
+				zap.String(zapQueueNameKey, pcs.queueName), zap.String(zapKey, pcs.itemKey(index)))
+			return nil
+		}
+	}
+
+	if index != pcs.readIndex || pcs.readIndex == pcs.writeIndex {
+		return fmt.Errorf("cannot skip item %d: %w", index, errItemNotInQueue)
+	}
+
+	if _, err := newBatch(pcs).setItemIndex(readIndexKey, pcs.readIndex+1).delete(pcs.itemKey(index)).execute(ctx); err != nil {
+		return err
+	}
+	delete(pcs.enqueueTimes, index)
+	pcs.readIndex++
+	pcs.itemsCount.Store(uint64(pcs.writeIndex - pcs.readIndex))
+	pcs.drainPutChan(1)
+	pcs.logger.Info("Skipped item",
+		zap.String(zapQueueNameKey, pcs.queueName), zap.String(zapKey, pcs.itemKey(index)))
+	return nil
+}
+
+// drainPutChan removes the tokens of n items removed from the queue, so that put does not block on a channel full of
+// tokens of items that are gone. A token may already be taken by the loop, which then finds no item to read.
+// It must be called with mu held.
+func (pcs *persistentContiguousStorage) drainPutChan(n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-pcs.putChan:
+		default:
+			return
+		}
+	}
+}
+
 func (pcs *persistentContiguousStorage) itemKey(index itemIndex) string {
-	return strconv.FormatUint(uint64(index), 10)
+	return itemKey(index)
 }
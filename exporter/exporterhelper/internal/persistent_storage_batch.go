@@ -29,17 +29,26 @@ var errItemIndexArrInvalidDataType = errors.New("invalid data type, expected []i
 
 // batchStruct provides convenience capabilities for creating and processing storage extension batches
 type batchStruct struct {
-	logger *zap.Logger
-	pcs    *persistentContiguousStorage
+	logger      *zap.Logger
+	client      storage.Client
+	unmarshaler RequestUnmarshaler
+	codec       *RequestCodec
 
 	operations    []storage.Operation
 	getOperations map[string]storage.Operation
 }
 
 func newBatch(pcs *persistentContiguousStorage) *batchStruct {
+	return newStorageBatch(pcs.logger, pcs.client, pcs.unmarshaler, pcs.codec)
+}
+
+// newStorageBatch creates a batch running over the given client, the unmarshaler and the codec are used for the requests
+func newStorageBatch(logger *zap.Logger, client storage.Client, unmarshaler RequestUnmarshaler, codec *RequestCodec) *batchStruct {
 	return &batchStruct{
-		logger:        pcs.logger,
-		pcs:           pcs,
+		logger:        logger,
+		client:        client,
+		unmarshaler:   unmarshaler,
+		codec:         codec,
 		operations:    []storage.Operation{},
 		getOperations: map[string]storage.Operation{},
 	}
@@ -47,7 +56,7 @@ func newBatch(pcs *persistentContiguousStorage) *batchStruct {
 
 // execute runs the provided operations in order
 func (bof *batchStruct) execute(ctx context.Context) (*batchStruct, error) {
-	err := bof.pcs.client.Batch(ctx, bof.operations...)
+	err := bof.client.Batch(ctx, bof.operations...)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +157,7 @@ func (bof *batchStruct) getItemIndexArrayResult(key string) ([]itemIndex, error)
 
 // setRequest adds Set operation over a given request to the batch
 func (bof *batchStruct) setRequest(key string, value Request) *batchStruct {
-	return bof.set(key, value, requestToBytes)
+	return bof.set(key, value, bof.requestToBytes)
 }
 
 // setItemIndex adds Set operation over a given itemIndex to the batch
@@ -217,10 +226,18 @@ func bytesToItemIndexArray(b []byte) (interface{}, error) {
 	return val, err
 }
 
-func requestToBytes(req interface{}) ([]byte, error) {
-	return req.(Request).Marshal()
+func (bof *batchStruct) requestToBytes(req interface{}) ([]byte, error) {
+	b, err := req.(Request).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return bof.codec.encode(b)
 }
 
 func (bof *batchStruct) bytesToRequest(b []byte) (interface{}, error) {
-	return bof.pcs.unmarshaler(b)
+	b, err := bof.codec.decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return bof.unmarshaler(b)
 }
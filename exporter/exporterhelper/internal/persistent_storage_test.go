@@ -28,6 +28,7 @@ import (
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.opentelemetry.io/collector/extension/experimental/storage/storagetest"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
@@ -45,7 +46,7 @@ func createTestClient(extension storage.Extension) storage.Client {
 }
 
 func createTestPersistentStorageWithLoggingAndCapacity(client storage.Client, logger *zap.Logger, capacity uint64) *persistentContiguousStorage {
-	return newPersistentContiguousStorage(context.Background(), "foo", capacity, logger, client, newFakeTracesRequestUnmarshalerFunc())
+	return newPersistentContiguousStorage(context.Background(), "foo", capacity, logger, client, newFakeTracesRequestUnmarshalerFunc(), nil)
 }
 
 func createTestPersistentStorage(client storage.Client) *persistentContiguousStorage {
@@ -338,6 +339,157 @@ func TestPersistentStorage_EmptyRequest(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPersistentStorage_Stats(t *testing.T) {
+	client := createTestClient(createStorageExtension(t.TempDir()))
+	ps := createTestPersistentStorage(client)
+	req := newFakeTracesRequest(newTraces(5, 10))
+
+	st := ps.stats()
+	require.Equal(t, persistentStorageStats{}, st)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, ps.put(req))
+	}
+	// Item index 0 is currently in unbuffered channel
+	requireCurrentlyDispatchedItemsEqual(t, ps, []itemIndex{0})
+	time.Sleep(10 * time.Millisecond)
+	st = ps.stats()
+	require.Equal(t, itemIndex(1), st.readIndex)
+	require.Equal(t, itemIndex(3), st.writeIndex)
+	require.Equal(t, 1, st.dispatchedItems)
+	require.GreaterOrEqual(t, st.oldestItemAge, 10*time.Millisecond)
+	require.Equal(t, uint64(0), st.corruptedItems)
+
+	// Corrupt the dispatched item and the next one, and reload: both are dropped and counted.
+	ps.stop()
+	require.NoError(t, client.Set(context.Background(), "0", []byte{0, 1, 2}))
+	require.NoError(t, client.Set(context.Background(), "1", []byte{0, 1, 2}))
+	newPs := createTestPersistentStorage(client)
+	require.Eventually(t, func() bool {
+		return newPs.stats().corruptedItems == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	requireCurrentlyDispatchedItemsEqual(t, newPs, []itemIndex{2})
+	require.Equal(t, uint64(0), newPs.size())
+
+	// The dropped items are not looked up again on the next restart.
+	newPs.stop()
+	newPs = createTestPersistentStorage(client)
+	requireCurrentlyDispatchedItemsEqual(t, newPs, []itemIndex{3})
+	require.Equal(t, uint64(0), newPs.stats().corruptedItems)
+}
+
+func TestPersistentStorage_Purge(t *testing.T) {
+	client := createTestClient(createStorageExtension(t.TempDir()))
+	ps := createTestPersistentStorage(client)
+	req := newFakeTracesRequest(newTraces(5, 10))
+
+	n, err := ps.purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ps.put(req))
+	}
+	requireCurrentlyDispatchedItemsEqual(t, ps, []itemIndex{0})
+	require.Eventually(t, func() bool {
+		return ps.size() == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	n, err = ps.purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, uint64(0), ps.size())
+	st := ps.stats()
+	require.Equal(t, itemIndex(5), st.readIndex)
+	require.Equal(t, itemIndex(5), st.writeIndex)
+
+	// The dispatched item is kept, the purged ones are deleted from the storage.
+	for i := 0; i < 5; i++ {
+		bb, err := client.Get(context.Background(), ps.itemKey(itemIndex(i)))
+		require.NoError(t, err)
+		require.Equal(t, i == 0, bb != nil)
+	}
+
+	// The queue keeps working after the purge.
+	getItemFromChannel(t, ps).OnProcessingFinished()
+	require.NoError(t, ps.put(req))
+	requireCurrentlyDispatchedItemsEqual(t, ps, []itemIndex{5})
+}
+
+func TestPersistentStorage_PutToCapacityAfterPurgeAndSkip(t *testing.T) {
+	client := createTestClient(createStorageExtension(t.TempDir()))
+	ps := createTestPersistentStorageWithLoggingAndCapacity(client, zap.NewNop(), 4)
+	req := newFakeTracesRequest(newTraces(5, 10))
+
+	// With no consumer, item 0 waits in the unbuffered channel and the others stay in the queue.
+	putToCapacity := func() {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for ps.size() < ps.capacity {
+				require.NoError(t, ps.put(req))
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "put blocked")
+		}
+	}
+	putToCapacity()
+	requireCurrentlyDispatchedItemsEqual(t, ps, []itemIndex{0})
+
+	n, err := ps.purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	putToCapacity()
+
+	require.NoError(t, ps.skip(context.Background(), ps.stats().readIndex))
+	putToCapacity()
+
+	st := ps.stats()
+	require.Equal(t, itemIndex(9), st.writeIndex)
+	require.Equal(t, uint64(4), ps.size())
+	ps.stop()
+}
+
+func TestPersistentStorage_Skip(t *testing.T) {
+	client := createTestClient(createStorageExtension(t.TempDir()))
+	ps := createTestPersistentStorage(client)
+	req := newFakeTracesRequest(newTraces(5, 10))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, ps.put(req))
+	}
+	requireCurrentlyDispatchedItemsEqual(t, ps, []itemIndex{0})
+	require.Eventually(t, func() bool {
+		return ps.size() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Only the next item to read or a dispatched one can be skipped.
+	require.ErrorIs(t, ps.skip(context.Background(), 2), errItemNotInQueue)
+	require.ErrorIs(t, ps.skip(context.Background(), 3), errItemNotInQueue)
+
+	require.NoError(t, ps.skip(context.Background(), 1))
+	require.Equal(t, uint64(1), ps.size())
+	require.Equal(t, itemIndex(2), ps.stats().readIndex)
+
+	require.NoError(t, ps.skip(context.Background(), 0))
+	requireCurrentlyDispatchedItemsEqual(t, ps, nil)
+
+	for i := 0; i < 2; i++ {
+		bb, err := client.Get(context.Background(), ps.itemKey(itemIndex(i)))
+		require.NoError(t, err)
+		require.Nil(t, bb)
+	}
+
+	// After a restart only the remaining item is sent.
+	ps.stop()
+	newPs := createTestPersistentStorage(client)
+	requireCurrentlyDispatchedItemsEqual(t, newPs, []itemIndex{2})
+	require.Equal(t, uint64(0), newPs.size())
+}
+
 func BenchmarkPersistentStorage_TraceSpans(b *testing.B) {
 	cases := []struct {
 		numTraces        int
@@ -431,6 +583,39 @@ func TestPersistentStorage_StopShouldCloseClient(t *testing.T) {
 	require.Equal(t, uint64(1), castedClient.getCloseCount())
 }
 
+func TestPersistentStorage_StopShouldCloseInMemoryClient(t *testing.T) {
+	client := createTestClient(storagetest.NewInMemoryExtension())
+	ps := createTestPersistentStorage(client)
+
+	ps.stop()
+
+	_, err := client.Get(context.Background(), "key")
+	require.Error(t, err, "expected client to be closed")
+}
+
+func TestPersistentStorage_RestartWithInMemoryExtension(t *testing.T) {
+	ext := storagetest.NewInMemoryExtension()
+	ps := createTestPersistentStorage(createTestClient(ext))
+	req := newFakeTracesRequest(newTraces(5, 10))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, ps.put(req))
+	}
+	// Item index 0 is currently in unbuffered channel
+	requireCurrentlyDispatchedItemsEqual(t, ps, []itemIndex{0})
+	ps.stop()
+
+	// The stopped storage closed its client, the restarted one gets a new client of the same storage.
+	// Item 0 was not finished so it is requeued at the end, and item 1 is pulled into the unbuffered channel.
+	newPs := createTestPersistentStorage(createTestClient(ext))
+	requireCurrentlyDispatchedItemsEqual(t, newPs, []itemIndex{1})
+	require.Eventually(t, func() bool {
+		return newPs.size() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, itemIndex(4), newPs.writeIndex)
+	newPs.stop()
+}
+
 func getItemFromChannel(t *testing.T, pcs *persistentContiguousStorage) Request {
 	var readReq Request
 	require.Eventually(t, func() bool {
@@ -0,0 +1,225 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// PriorityLane is one of the queues making up a priority queue.
+type PriorityLane struct {
+	// Queue holds the items of the lane, it can be a memory, a persistent or a spill queue.
+	Queue ProducerConsumerQueue
+	// Weight is the share of the consumers given to the lane while other lanes have items too.
+	Weight int
+}
+
+// laneQueue is implemented by the queues that can be lanes of a priority queue. The lanes do not start their own
+// consumers: the consumers of the priority queue pick the lane to dequeue from, so the items stay in their lane,
+// and count against its capacity, until a consumer is free to process them.
+type laneQueue interface {
+	// laneItems returns the channel the items of the lane are dequeued from.
+	laneItems() <-chan Request
+	// onLaneDequeue must be called with every item received from laneItems, before it is processed.
+	onLaneDequeue(item Request)
+	// drainsOnStop returns true if the items left in the queue are still consumed once it is stopped,
+	// laneItems is then closed once the queue is empty.
+	drainsOnStop() bool
+}
+
+var errNotLaneQueue = errors.New("the queue cannot be a lane of a priority queue")
+
+// priorityQueue dispatches the items to multiple lanes, each one with its own capacity. The consumers are shared by
+// the lanes: a free consumer dequeues from one of the lanes having items, chosen by smooth weighted round-robin so
+// that each lane gets a share of the consumers proportional to its weight.
+type priorityQueue struct {
+	lanes  []PriorityLane
+	queues []laneQueue
+	route  func(item Request) int
+	picker *weightedPicker
+
+	callback func(item Request)
+	stopWG   sync.WaitGroup
+	stopOnce sync.Once
+	stopChan chan struct{}
+}
+
+// NewPriorityQueue creates a queue made of the given lanes, route returns the index of the lane an item is put into.
+func NewPriorityQueue(lanes []PriorityLane, route func(item Request) int) (ProducerConsumerQueue, error) {
+	pq := &priorityQueue{
+		lanes:    lanes,
+		route:    route,
+		stopChan: make(chan struct{}),
+	}
+	weights := make([]int, len(lanes))
+	for i, lane := range lanes {
+		q, ok := lane.Queue.(laneQueue)
+		if !ok {
+			return nil, errNotLaneQueue
+		}
+		pq.queues = append(pq.queues, q)
+		weights[i] = lane.Weight
+	}
+	pq.picker = newWeightedPicker(weights)
+	return pq, nil
+}
+
+// StartConsumers starts the given number of consumers shared by all the lanes.
+func (pq *priorityQueue) StartConsumers(num int, callback func(item Request)) {
+	pq.callback = callback
+	for i := 0; i < num; i++ {
+		pq.stopWG.Add(1)
+		go func() {
+			defer pq.stopWG.Done()
+			for {
+				item, ok := pq.next()
+				if !ok {
+					return
+				}
+				callback(item)
+			}
+		}()
+	}
+}
+
+// next blocks until an item is dequeued from one of the lanes, it returns false once the queue is stopped.
+func (pq *priorityQueue) next() (Request, bool) {
+	select {
+	case <-pq.stopChan:
+		return nil, false
+	default:
+	}
+
+	// Try the lanes in the order of the round-robin first, the ones tried before the chosen lane are empty.
+	order := pq.picker.order()
+	for i, lane := range order {
+		select {
+		case item := <-pq.queues[lane].laneItems():
+			pq.picker.picked(order[i:], order[:i])
+			pq.queues[lane].onLaneDequeue(item)
+			return item, true
+		default:
+		}
+	}
+
+	// All the lanes are empty, take the first item produced in any of them.
+	cases := make([]reflect.SelectCase, 0, len(pq.queues)+1)
+	for _, q := range pq.queues {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(q.laneItems())})
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(pq.stopChan)})
+	chosen, value, ok := reflect.Select(cases)
+	if chosen == len(pq.queues) || !ok {
+		return nil, false
+	}
+	item := value.Interface().(Request)
+	pq.picker.picked([]int{chosen}, nil)
+	pq.queues[chosen].onLaneDequeue(item)
+	return item, true
+}
+
+// Produce adds an item to its lane and returns true if it was accepted
+func (pq *priorityQueue) Produce(item Request) bool {
+	return pq.lanes[pq.route(item)].Queue.Produce(item)
+}
+
+// Size returns the current depth of all the lanes
+func (pq *priorityQueue) Size() int {
+	size := 0
+	for _, lane := range pq.lanes {
+		size += lane.Queue.Size()
+	}
+	return size
+}
+
+// Stop stops the consumers, then all the lanes. The items left in the lanes draining on stop are consumed before
+// it returns.
+func (pq *priorityQueue) Stop() {
+	pq.stopOnce.Do(func() {
+		close(pq.stopChan)
+		pq.stopWG.Wait()
+		for i, lane := range pq.lanes {
+			lane.Queue.Stop()
+			if pq.callback == nil || !pq.queues[i].drainsOnStop() {
+				continue
+			}
+			for item := range pq.queues[i].laneItems() {
+				pq.queues[i].onLaneDequeue(item)
+				pq.callback(item)
+			}
+		}
+	})
+}
+
+// weightedPicker chooses the lane a free consumer dequeues from, by smooth weighted round-robin among the lanes
+// having items.
+type weightedPicker struct {
+	mu    sync.Mutex
+	lanes []*pickerLane
+}
+
+type pickerLane struct {
+	weight  int
+	current int
+}
+
+func newWeightedPicker(weights []int) *weightedPicker {
+	wp := &weightedPicker{}
+	for _, w := range weights {
+		if w <= 0 {
+			w = 1
+		}
+		wp.lanes = append(wp.lanes, &pickerLane{weight: w})
+	}
+	return wp
+}
+
+// order returns the lanes in the order they are tried: the lane the round-robin picks if all of them have items
+// comes first.
+func (wp *weightedPicker) order() []int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	order := make([]int, len(wp.lanes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		li, lj := wp.lanes[order[i]], wp.lanes[order[j]]
+		return li.current+li.weight > lj.current+lj.weight
+	})
+	return order
+}
+
+// picked records that an item was dequeued from the first of the waiting lanes, which may have items, while the
+// empty lanes had none. The empty lanes lose their credit, so they do not take over the consumers once they get
+// items again.
+func (wp *weightedPicker) picked(waiting []int, empty []int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	total := 0
+	for _, i := range waiting {
+		wp.lanes[i].current += wp.lanes[i].weight
+		total += wp.lanes[i].weight
+	}
+	wp.lanes[waiting[0]].current -= total
+	for _, i := range empty {
+		wp.lanes[i].current = 0
+	}
+}
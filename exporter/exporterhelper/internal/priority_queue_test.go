@@ -0,0 +1,152 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	q, err := NewPriorityQueue([]PriorityLane{
+		{Queue: NewBoundedMemoryQueue(1), Weight: 3},
+		{Queue: NewBoundedMemoryQueue(2), Weight: 1},
+	}, func(item Request) int {
+		if strings.HasPrefix(item.(stringRequest).str, "high") {
+			return 0
+		}
+		return 1
+	})
+	require.NoError(t, err)
+
+	// Each lane has its own capacity.
+	assert.True(t, q.Produce(newStringRequest("high-1")))
+	assert.False(t, q.Produce(newStringRequest("high-2")))
+	assert.True(t, q.Produce(newStringRequest("low-1")))
+	assert.True(t, q.Produce(newStringRequest("low-2")))
+	assert.False(t, q.Produce(newStringRequest("low-3")))
+	assert.Equal(t, 3, q.Size())
+
+	var mu sync.Mutex
+	var consumed []string
+	q.StartConsumers(1, func(item Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		consumed = append(consumed, item.(stringRequest).str)
+	})
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(consumed) == 3
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, q.Size())
+	q.Stop()
+	assert.ElementsMatch(t, []string{"high-1", "low-1", "low-2"}, consumed)
+}
+
+func TestPriorityQueue_ItemsStayInLaneUntilConsumed(t *testing.T) {
+	high, low := NewBoundedMemoryQueue(4), NewBoundedMemoryQueue(4)
+	q, err := NewPriorityQueue([]PriorityLane{{Queue: high, Weight: 3}, {Queue: low, Weight: 1}}, func(item Request) int {
+		if strings.HasPrefix(item.(stringRequest).str, "high") {
+			return 0
+		}
+		return 1
+	})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	consumed := make(chan string, 8)
+	q.StartConsumers(1, func(item Request) {
+		consumed <- item.(stringRequest).str
+		<-release
+	})
+	for i := 0; i < 4; i++ {
+		assert.True(t, q.Produce(newStringRequest(fmt.Sprintf("low-%d", i))))
+	}
+	assert.Equal(t, "low-0", <-consumed)
+
+	// While the only consumer is busy, the items are not dequeued and count against the capacity of their lane.
+	assert.Equal(t, 3, low.Size())
+	assert.True(t, q.Produce(newStringRequest("low-4")))
+	assert.False(t, q.Produce(newStringRequest("low-5")))
+	for i := 0; i < 4; i++ {
+		assert.True(t, q.Produce(newStringRequest(fmt.Sprintf("high-%d", i))))
+	}
+	assert.Equal(t, 8, q.Size())
+
+	// The free consumer then picks the lanes by weight.
+	var order []string
+	for i := 0; i < 8; i++ {
+		release <- struct{}{}
+		order = append(order, <-consumed)
+	}
+	assert.Equal(t, []string{"high-0", "high-1", "low-1", "high-2", "high-3", "low-2", "low-3", "low-4"}, order)
+	close(release)
+	q.Stop()
+}
+
+func TestPriorityQueue_DrainMemoryLanesOnStop(t *testing.T) {
+	q, err := NewPriorityQueue([]PriorityLane{{Queue: NewBoundedMemoryQueue(2), Weight: 1}}, func(Request) int { return 0 })
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var consumed []string
+	q.StartConsumers(1, func(item Request) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		consumed = append(consumed, item.(stringRequest).str)
+	})
+	assert.True(t, q.Produce(newStringRequest("a")))
+	assert.Eventually(t, func() bool { return q.Size() == 0 }, time.Second, time.Millisecond)
+	assert.True(t, q.Produce(newStringRequest("b")))
+	assert.True(t, q.Produce(newStringRequest("c")))
+
+	// The items left in the memory lane are consumed before Stop returns.
+	close(release)
+	q.Stop()
+	assert.False(t, q.Produce(newStringRequest("d")))
+	assert.Equal(t, []string{"a", "b", "c"}, consumed)
+}
+
+func TestPriorityQueue_NotLaneQueue(t *testing.T) {
+	_, err := NewPriorityQueue([]PriorityLane{{Queue: &priorityQueue{}, Weight: 1}}, func(Request) int { return 0 })
+	assert.ErrorIs(t, err, errNotLaneQueue)
+}
+
+func TestWeightedPicker(t *testing.T) {
+	wp := newWeightedPicker([]int{3, 1, 0})
+
+	// While all the lanes have items, they are picked proportionally to their weight, a weight of 0 counts as 1.
+	var order []int
+	for i := 0; i < 10; i++ {
+		lanes := wp.order()
+		wp.picked(lanes, nil)
+		order = append(order, lanes[0])
+	}
+	assert.Equal(t, []int{0, 1, 0, 2, 0, 0, 1, 0, 2, 0}, order)
+
+	// An empty lane loses its credit.
+	wp.picked([]int{1}, []int{0, 2})
+	assert.Equal(t, 0, wp.lanes[0].current)
+	assert.Equal(t, 0, wp.lanes[2].current)
+}
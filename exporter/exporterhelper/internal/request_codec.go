@@ -0,0 +1,144 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// The requests are stored either as the raw marshaled request, as written before the format was versioned, or with a
+// header made of a marker, the format version and flags describing how the rest of the value is encoded.
+// The marker is a zero byte, which cannot start a marshaled request since protobuf field numbers start at 1.
+const (
+	requestFormatMarker  = byte(0)
+	requestFormatVersion = byte(1)
+	requestHeaderSize    = 3
+
+	flagCompressed = byte(1 << 0)
+	flagEncrypted  = byte(1 << 1)
+)
+
+var (
+	errRequestEncrypted       = errors.New("stored request is encrypted but no encryption key is configured")
+	errInvalidEncodedRequest  = errors.New("invalid encoded request")
+	errUnsupportedFormatFlags = errors.New("unsupported stored request flags")
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+// RequestCodec compresses and encrypts the requests written to the storage.
+// A nil RequestCodec writes the raw marshaled requests, it can still read the compressed ones.
+type RequestCodec struct {
+	compress bool
+	aead     cipher.AEAD
+}
+
+// NewRequestCodec creates a RequestCodec compressing the requests with zstd if compress is true, and encrypting them
+// with AES-GCM if key is not empty. The key must be 16, 24 or 32 bytes long.
+func NewRequestCodec(compress bool, key []byte) (*RequestCodec, error) {
+	c := &RequestCodec{compress: compress}
+	if len(key) > 0 {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+		if c.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		// The options are valid, creating the encoder and the decoder cannot fail.
+		zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	})
+}
+
+// encode encodes the marshaled request.
+func (c *RequestCodec) encode(b []byte) ([]byte, error) {
+	if c == nil {
+		return b, nil
+	}
+
+	header := []byte{requestFormatMarker, requestFormatVersion, 0}
+	if c.compress {
+		initZstd()
+		b = zstdEncoder.EncodeAll(b, nil)
+		header[2] |= flagCompressed
+	}
+	if c.aead == nil {
+		return append(header, b...), nil
+	}
+
+	header[2] |= flagEncrypted
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(header)+len(nonce)+len(b)+c.aead.Overhead())
+	out = append(append(out, header...), nonce...)
+	// The header is authenticated so that the flags cannot be changed.
+	return c.aead.Seal(out, nonce, b, header), nil
+}
+
+// decode returns the marshaled request, whatever the encoding it was written with.
+func (c *RequestCodec) decode(b []byte) ([]byte, error) {
+	if len(b) == 0 || b[0] != requestFormatMarker {
+		return b, nil
+	}
+	if len(b) < requestHeaderSize {
+		return nil, errInvalidEncodedRequest
+	}
+	if b[1] != requestFormatVersion {
+		return nil, fmt.Errorf("unsupported stored request format version %d", b[1])
+	}
+	header, flags, b := b[:requestHeaderSize], b[2], b[requestHeaderSize:]
+	if flags&^(flagCompressed|flagEncrypted) != 0 {
+		return nil, errUnsupportedFormatFlags
+	}
+
+	if flags&flagEncrypted != 0 {
+		if c == nil || c.aead == nil {
+			return nil, errRequestEncrypted
+		}
+		nonceSize := c.aead.NonceSize()
+		if len(b) < nonceSize {
+			return nil, errInvalidEncodedRequest
+		}
+		var err error
+		if b, err = c.aead.Open(nil, b[:nonceSize], b[nonceSize:], header); err != nil {
+			return nil, fmt.Errorf("failed to decrypt stored request: %w", err)
+		}
+	}
+	if flags&flagCompressed != 0 {
+		initZstd()
+		return zstdDecoder.DecodeAll(b, nil)
+	}
+	return b, nil
+}
@@ -0,0 +1,135 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestRequestCodec(t *testing.T) {
+	data, err := newFakeTracesRequest(newTraces(10, 10)).Marshal()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		compress bool
+		key      []byte
+		flags    byte
+	}{
+		{name: "compressed", compress: true, flags: flagCompressed},
+		{name: "encrypted", key: testKey, flags: flagEncrypted},
+		{name: "compressed and encrypted", compress: true, key: testKey[:16], flags: flagCompressed | flagEncrypted},
+		{name: "versioned only", flags: 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			codec, err := NewRequestCodec(c.compress, c.key)
+			require.NoError(t, err)
+			encoded, err := codec.encode(data)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{requestFormatMarker, requestFormatVersion, c.flags}, encoded[:requestHeaderSize])
+			if c.compress {
+				assert.Less(t, len(encoded), len(data))
+			}
+			if c.key != nil {
+				assert.False(t, bytes.Contains(encoded, []byte("should-not-be-changed")))
+			}
+
+			decoded, err := codec.decode(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, data, decoded)
+		})
+	}
+}
+
+func TestRequestCodec_Decode(t *testing.T) {
+	data, err := newFakeTracesRequest(newTraces(1, 1)).Marshal()
+	require.NoError(t, err)
+	codec, err := NewRequestCodec(true, testKey)
+	require.NoError(t, err)
+	encoded, err := codec.encode(data)
+	require.NoError(t, err)
+
+	// The requests stored before the format was versioned are read as is, with or without a codec.
+	var nilCodec *RequestCodec
+	decoded, err := nilCodec.decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+	decoded, err = codec.decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+	encodedByNil, err := nilCodec.encode(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, encodedByNil)
+
+	_, err = nilCodec.decode(encoded)
+	assert.ErrorIs(t, err, errRequestEncrypted)
+
+	otherCodec, err := NewRequestCodec(false, bytes.Repeat([]byte{8}, 32))
+	require.NoError(t, err)
+	_, err = otherCodec.decode(encoded)
+	assert.ErrorContains(t, err, "failed to decrypt stored request")
+
+	// The header is authenticated.
+	tampered := append([]byte{}, encoded...)
+	tampered[2] = flagEncrypted
+	_, err = codec.decode(tampered)
+	assert.ErrorContains(t, err, "failed to decrypt stored request")
+
+	_, err = codec.decode([]byte{requestFormatMarker, 2, 0})
+	assert.EqualError(t, err, "unsupported stored request format version 2")
+	_, err = codec.decode([]byte{requestFormatMarker, requestFormatVersion, 0x80})
+	assert.ErrorIs(t, err, errUnsupportedFormatFlags)
+	_, err = codec.decode([]byte{requestFormatMarker, requestFormatVersion, flagEncrypted, 1})
+	assert.ErrorIs(t, err, errInvalidEncodedRequest)
+	_, err = codec.decode([]byte{requestFormatMarker})
+	assert.ErrorIs(t, err, errInvalidEncodedRequest)
+
+	_, err = NewRequestCodec(false, []byte("short"))
+	assert.ErrorContains(t, err, "invalid encryption key")
+}
+
+func TestPersistentStorage_UpgradeToEncoded(t *testing.T) {
+	client := newMockStorageClient()
+	ps := newPersistentContiguousStorage(context.Background(), "foo", 100, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, ps.put(newFakeTracesRequest(newTraces(1, i))))
+	}
+	getItemFromChannel(t, ps).OnProcessingFinished()
+	ps.stop()
+
+	codec, err := NewRequestCodec(true, testKey)
+	require.NoError(t, err)
+	ps = newPersistentContiguousStorage(context.Background(), "foo", 100, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), codec)
+	require.NoError(t, ps.put(newFakeTracesRequest(newTraces(1, 3))))
+
+	stored, err := client.Get(context.Background(), ps.itemKey(2))
+	require.NoError(t, err)
+	require.NotEmpty(t, stored)
+	assert.Equal(t, requestFormatMarker, stored[0])
+
+	// Both the request stored before the upgrade and the encoded one are read.
+	assert.Equal(t, 2, getItemFromChannel(t, ps).(*fakeTracesRequest).td.SpanCount())
+	assert.Equal(t, 3, getItemFromChannel(t, ps).(*fakeTracesRequest).td.SpanCount())
+	ps.stop()
+}
@@ -0,0 +1,243 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal // import "go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+// spillQueue keeps the items in memory, up to a high-water mark. Past the mark the new items are written to a
+// storage client, and moved back to memory as the consumers make room, oldest first. On Stop the items still in
+// memory are written to the storage ahead of the spilled ones instead of being drained, and all of them are
+// restored when the queue is created again with the same client.
+//
+// Like the persistent queue, the spilled items are stored under contiguous indexes, with the read and write
+// indexes stored under separate keys. Unlike it, the items are deleted from the storage as soon as they are moved
+// to memory, so only the items spilled when the collector crashes survive it.
+type spillQueue struct {
+	logger        *zap.Logger
+	client        storage.Client
+	unmarshaler   RequestUnmarshaler
+	codec         *RequestCodec
+	capacity      int
+	highWatermark int
+
+	// mu protects the indexes and makes sure the items are moved between memory and the storage in order.
+	mu    sync.Mutex
+	items chan Request
+	// readIndex is the index of the oldest spilled item, and writeIndex the index of the next one. The indexes are
+	// only used through their difference, the number of spilled items, and as keys: they may wrap around, see Stop.
+	readIndex  itemIndex
+	writeIndex itemIndex
+	stopped    bool
+
+	stopWG   sync.WaitGroup
+	stopOnce sync.Once
+	stopChan chan struct{}
+}
+
+// NewSpillQueue creates a memory queue of the given capacity, spilling the items past highWatermark to the client.
+// The items spilled by a previous run are restored.
+func NewSpillQueue(ctx context.Context, capacity int, highWatermark int, logger *zap.Logger, client storage.Client, unmarshaler RequestUnmarshaler, codec *RequestCodec) ProducerConsumerQueue {
+	if highWatermark <= 0 || highWatermark > capacity {
+		highWatermark = capacity
+	}
+	sq := &spillQueue{
+		logger:        logger,
+		client:        client,
+		unmarshaler:   unmarshaler,
+		codec:         codec,
+		capacity:      capacity,
+		highWatermark: highWatermark,
+		items:         make(chan Request, highWatermark),
+		stopChan:      make(chan struct{}),
+	}
+
+	// An index that is not set is 0, the read index is set alone when the queue is only spilled on Stop.
+	batch, err := newStorageBatch(logger, client, unmarshaler, codec).get(readIndexKey, writeIndexKey).execute(ctx)
+	if err == nil {
+		sq.readIndex, err = batch.getItemIndexResult(readIndexKey)
+		if errors.Is(err, errValueNotSet) {
+			err = nil
+		}
+	}
+	if err == nil {
+		sq.writeIndex, err = batch.getItemIndexResult(writeIndexKey)
+		if errors.Is(err, errValueNotSet) {
+			err = nil
+		}
+	}
+	if err != nil {
+		logger.Error("Failed getting the spilled items indexes, starting with an empty queue", zap.Error(err))
+		sq.readIndex, sq.writeIndex = 0, 0
+	} else if spilled := sq.writeIndex - sq.readIndex; spilled > 0 {
+		logger.Info("Restoring spilled items", zap.Uint64(zapNumberOfItems, uint64(spilled)))
+	}
+
+	sq.mu.Lock()
+	sq.refill(ctx)
+	sq.mu.Unlock()
+	return sq
+}
+
+// StartConsumers starts the given number of consumers which will be consuming items
+func (sq *spillQueue) StartConsumers(num int, callback func(item Request)) {
+	for i := 0; i < num; i++ {
+		sq.stopWG.Add(1)
+		go func() {
+			defer sq.stopWG.Done()
+			for {
+				select {
+				case item := <-sq.items:
+					sq.onLaneDequeue(item)
+					callback(item)
+				case <-sq.stopChan:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// laneItems implements laneQueue.
+func (sq *spillQueue) laneItems() <-chan Request {
+	return sq.items
+}
+
+// onLaneDequeue moves the oldest spilled items to memory as the items are dequeued.
+func (sq *spillQueue) onLaneDequeue(Request) {
+	sq.mu.Lock()
+	sq.refill(context.Background())
+	sq.mu.Unlock()
+}
+
+// drainsOnStop returns false, the remaining items are spilled to the storage.
+func (sq *spillQueue) drainsOnStop() bool {
+	return false
+}
+
+// Produce adds an item to the queue and returns true if it was accepted
+func (sq *spillQueue) Produce(item Request) bool {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	if sq.stopped || len(sq.items)+sq.spilled() >= sq.capacity {
+		return false
+	}
+
+	// Once some items are spilled, the new ones go after them.
+	if sq.spilled() == 0 && len(sq.items) < sq.highWatermark {
+		sq.items <- item
+		return true
+	}
+
+	_, err := newStorageBatch(sq.logger, sq.client, sq.unmarshaler, sq.codec).
+		setItemIndex(writeIndexKey, sq.writeIndex+1).
+		setRequest(itemKey(sq.writeIndex), item).
+		execute(context.Background())
+	if err != nil {
+		sq.logger.Error("Failed spilling item to storage", zap.Error(err))
+		return false
+	}
+	sq.writeIndex++
+	return true
+}
+
+// Stop stops the consumers, then writes the items left in memory to the storage and closes the client
+func (sq *spillQueue) Stop() {
+	sq.stopOnce.Do(func() {
+		close(sq.stopChan)
+		// The items given back by the consumers while stopping are accepted until they are all stopped.
+		sq.stopWG.Wait()
+
+		sq.mu.Lock()
+		defer sq.mu.Unlock()
+		sq.stopped = true
+
+		var reqs []Request
+		for len(sq.items) > 0 {
+			reqs = append(reqs, <-sq.items)
+		}
+		if len(reqs) > 0 {
+			// The items in memory are older than the spilled ones, they are stored right before them.
+			// When fewer items were read from the storage than are in memory, e.g. when it is only used on Stop,
+			// the read index wraps around below 0 on purpose: the number of spilled items is writeIndex - readIndex
+			// modulo 2^64, which stays below the capacity, and the keys of the items follow each other from
+			// math.MaxUint64 to 0.
+			readIndex := sq.readIndex
+			if n := itemIndex(len(reqs)); readIndex >= n {
+				readIndex -= n
+			} else {
+				readIndex = math.MaxUint64 - (n - readIndex - 1)
+			}
+			batch := newStorageBatch(sq.logger, sq.client, sq.unmarshaler, sq.codec).setItemIndex(readIndexKey, readIndex)
+			for i, req := range reqs {
+				batch.setRequest(itemKey(readIndex+itemIndex(i)), req)
+			}
+			if _, err := batch.execute(context.Background()); err != nil {
+				sq.logger.Error("Failed spilling items to storage on shutdown, dropping them",
+					zap.Int(zapNumberOfItems, len(reqs)), zap.Error(err))
+			} else {
+				sq.readIndex = readIndex
+				sq.logger.Info("Spilled items to storage on shutdown", zap.Int(zapNumberOfItems, len(reqs)))
+			}
+		}
+
+		if err := sq.client.Close(context.Background()); err != nil {
+			sq.logger.Warn("Failed closing the spill storage", zap.Error(err))
+		}
+	})
+}
+
+// Size returns the number of items in memory and in the storage
+func (sq *spillQueue) Size() int {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	return len(sq.items) + sq.spilled()
+}
+
+func (sq *spillQueue) spilled() int {
+	return int(sq.writeIndex - sq.readIndex)
+}
+
+// refill moves the spilled items to memory until the high-water mark, it must be called with mu held.
+func (sq *spillQueue) refill(ctx context.Context) {
+	for sq.spilled() > 0 && len(sq.items) < sq.highWatermark {
+		key := itemKey(sq.readIndex)
+		batch, err := newStorageBatch(sq.logger, sq.client, sq.unmarshaler, sq.codec).
+			get(key).
+			delete(key).
+			setItemIndex(readIndexKey, sq.readIndex+1).
+			execute(ctx)
+		if err != nil {
+			sq.logger.Error("Failed restoring spilled item from storage", zap.String(zapKey, key), zap.Error(err))
+			return
+		}
+		sq.readIndex++
+
+		req, err := batch.getRequestResult(key)
+		if err != nil {
+			sq.logger.Warn("Discarding spilled item that cannot be restored", zap.String(zapKey, key), zap.Error(err))
+			continue
+		}
+		sq.items <- req
+	}
+}
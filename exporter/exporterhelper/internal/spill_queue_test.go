@@ -0,0 +1,142 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func consumeSpanCounts(t *testing.T, q ProducerConsumerQueue, expected int) []int {
+	var mu sync.Mutex
+	var counts []int
+	q.StartConsumers(1, func(item Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts = append(counts, item.(*fakeTracesRequest).td.SpanCount())
+	})
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(counts) == expected
+	}, time.Second, time.Millisecond)
+	return counts
+}
+
+func TestSpillQueue_HighWatermark(t *testing.T) {
+	client := newMockStorageClient()
+	q := NewSpillQueue(context.Background(), 5, 2, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+
+	for i := 1; i <= 5; i++ {
+		require.True(t, q.Produce(newFakeTracesRequest(newTraces(1, i))))
+	}
+	assert.False(t, q.Produce(newFakeTracesRequest(newTraces(1, 6))))
+	assert.Equal(t, 5, q.Size())
+
+	// The three items past the high-water mark are in the storage.
+	for i := 0; i < 3; i++ {
+		value, err := client.Get(context.Background(), itemKey(itemIndex(i)))
+		require.NoError(t, err)
+		assert.NotNil(t, value)
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, consumeSpanCounts(t, q, 5))
+	assert.Equal(t, 0, q.Size())
+	q.Stop()
+	assert.False(t, q.Produce(newFakeTracesRequest(newTraces(1, 1))))
+}
+
+func TestSpillQueue_RestoreAfterStop(t *testing.T) {
+	client := newMockStorageClient()
+	q := NewSpillQueue(context.Background(), 10, 2, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	for i := 1; i <= 4; i++ {
+		require.True(t, q.Produce(newFakeTracesRequest(newTraces(1, i))))
+	}
+	q.Stop()
+	assert.Equal(t, uint64(1), client.(*mockStorageClient).getCloseCount())
+
+	// The items in memory are stored ahead of the spilled ones.
+	q = NewSpillQueue(context.Background(), 10, 2, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	assert.Equal(t, 4, q.Size())
+	assert.Equal(t, []int{1, 2, 3, 4}, consumeSpanCounts(t, q, 4))
+	q.Stop()
+
+	q = NewSpillQueue(context.Background(), 10, 2, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	assert.Equal(t, 0, q.Size())
+	q.Stop()
+}
+
+func TestSpillQueue_ReadIndexWrapsAround(t *testing.T) {
+	client := newMockStorageClient()
+	q := NewSpillQueue(context.Background(), 10, 2, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	for i := 1; i <= 3; i++ {
+		require.True(t, q.Produce(newFakeTracesRequest(newTraces(1, i))))
+	}
+	q.Stop()
+
+	// The two items in memory are stored before the spilled item 0.
+	batch, err := newStorageBatch(zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil).get(readIndexKey).execute(context.Background())
+	require.NoError(t, err)
+	readIndex, err := batch.getItemIndexResult(readIndexKey)
+	require.NoError(t, err)
+	assert.Equal(t, itemIndex(math.MaxUint64-1), readIndex)
+
+	// The restored queue reads them from the storage first, across the wrap around.
+	q = NewSpillQueue(context.Background(), 10, 2, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	assert.Equal(t, itemIndex(0), q.(*spillQueue).readIndex)
+	assert.Equal(t, 3, q.Size())
+
+	// Keep spilling and restoring across the wrap around.
+	for i := 4; i <= 6; i++ {
+		require.True(t, q.Produce(newFakeTracesRequest(newTraces(1, i))))
+	}
+	q.Stop()
+	q = NewSpillQueue(context.Background(), 10, 2, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	assert.Equal(t, 6, q.Size())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, consumeSpanCounts(t, q, 6))
+	q.Stop()
+}
+
+func TestSpillQueue_SpilledOnStopOnly(t *testing.T) {
+	client := newMockStorageClient()
+	q := NewSpillQueue(context.Background(), 10, 0, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	for i := 1; i <= 2; i++ {
+		require.True(t, q.Produce(newFakeTracesRequest(newTraces(1, i))))
+	}
+	q.Stop()
+
+	q = NewSpillQueue(context.Background(), 10, 0, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	assert.Equal(t, []int{1, 2}, consumeSpanCounts(t, q, 2))
+	q.Stop()
+}
+
+func TestSpillQueue_CorruptedItem(t *testing.T) {
+	client := newMockStorageClient()
+	q := NewSpillQueue(context.Background(), 10, 1, zap.NewNop(), client, newFakeTracesRequestUnmarshalerFunc(), nil)
+	for i := 1; i <= 3; i++ {
+		require.True(t, q.Produce(newFakeTracesRequest(newTraces(1, i))))
+	}
+	require.NoError(t, client.Set(context.Background(), itemKey(0), []byte{0xFF}))
+
+	assert.Equal(t, []int{1, 3}, consumeSpanCounts(t, q, 2))
+	q.Stop()
+}
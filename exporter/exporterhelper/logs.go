@@ -17,12 +17,15 @@ package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporte
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 )
 
@@ -73,6 +76,46 @@ func (req *logsRequest) Count() int {
 	return req.ld.LogRecordCount()
 }
 
+func (req *logsRequest) clone() batchableRequest {
+	ld := plog.NewLogs()
+	req.ld.CopyTo(ld)
+	return &logsRequest{baseRequest: baseRequest{ctx: req.ctx}, ld: ld, pusher: req.pusher}
+}
+
+func (req *logsRequest) bytesSize() int {
+	return logsMarshaler.LogsSize(req.ld)
+}
+
+func (req *logsRequest) splitOff(size int) batchableRequest {
+	return &logsRequest{baseRequest: baseRequest{ctx: req.ctx}, ld: splitLogs(size, req.ld), pusher: req.pusher}
+}
+
+func (req *logsRequest) moveTo(dest batchableRequest) {
+	req.ld.ResourceLogs().MoveAndAppendTo(dest.(*logsRequest).ld.ResourceLogs())
+}
+
+func (req *logsRequest) anyResource(fn func(pcommon.Resource) bool) bool {
+	for i := 0; i < req.ld.ResourceLogs().Len(); i++ {
+		if fn(req.ld.ResourceLogs().At(i).Resource()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (req *logsRequest) forwardTo(ctx context.Context, exp component.Component, errMsg string, ts time.Time) error {
+	next, ok := exp.(consumer.Logs)
+	if !ok {
+		return fmt.Errorf("exporter of type %T does not accept logs", exp)
+	}
+	ld := plog.NewLogs()
+	req.ld.CopyTo(ld)
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		setDeadLetterAttributes(ld.ResourceLogs().At(i).Resource().Attributes(), errMsg, ts)
+	}
+	return next.ConsumeLogs(ctx, ld)
+}
+
 type logsExporter struct {
 	*baseExporter
 	consumer.Logs
@@ -134,5 +177,5 @@ func (lewo *logsExporterWithObservability) send(req internal.Request) error {
 	req.SetContext(lewo.obsrep.StartLogsOp(req.Context()))
 	err := lewo.nextSender.send(req)
 	lewo.obsrep.EndLogsOp(req.Context(), req.Count(), err)
-	return err
+	return dropPartialExportError(err)
 }
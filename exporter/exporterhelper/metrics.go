@@ -17,12 +17,15 @@ package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporte
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
@@ -74,6 +77,46 @@ func (req *metricsRequest) Count() int {
 	return req.md.DataPointCount()
 }
 
+func (req *metricsRequest) clone() batchableRequest {
+	md := pmetric.NewMetrics()
+	req.md.CopyTo(md)
+	return &metricsRequest{baseRequest: baseRequest{ctx: req.ctx}, md: md, pusher: req.pusher}
+}
+
+func (req *metricsRequest) bytesSize() int {
+	return metricsMarshaler.MetricsSize(req.md)
+}
+
+func (req *metricsRequest) splitOff(size int) batchableRequest {
+	return &metricsRequest{baseRequest: baseRequest{ctx: req.ctx}, md: splitMetrics(size, req.md), pusher: req.pusher}
+}
+
+func (req *metricsRequest) moveTo(dest batchableRequest) {
+	req.md.ResourceMetrics().MoveAndAppendTo(dest.(*metricsRequest).md.ResourceMetrics())
+}
+
+func (req *metricsRequest) anyResource(fn func(pcommon.Resource) bool) bool {
+	for i := 0; i < req.md.ResourceMetrics().Len(); i++ {
+		if fn(req.md.ResourceMetrics().At(i).Resource()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (req *metricsRequest) forwardTo(ctx context.Context, exp component.Component, errMsg string, ts time.Time) error {
+	next, ok := exp.(consumer.Metrics)
+	if !ok {
+		return fmt.Errorf("exporter of type %T does not accept metrics", exp)
+	}
+	md := pmetric.NewMetrics()
+	req.md.CopyTo(md)
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		setDeadLetterAttributes(md.ResourceMetrics().At(i).Resource().Attributes(), errMsg, ts)
+	}
+	return next.ConsumeMetrics(ctx, md)
+}
+
 type metricsExporter struct {
 	*baseExporter
 	consumer.Metrics
@@ -135,5 +178,5 @@ func (mewo *metricsSenderWithObservability) send(req internal.Request) error {
 	req.SetContext(mewo.obsrep.StartMetricsOp(req.Context()))
 	err := mewo.nextSender.send(req)
 	mewo.obsrep.EndMetricsOp(req.Context(), req.Count(), err)
-	return err
+	return dropPartialExportError(err)
 }
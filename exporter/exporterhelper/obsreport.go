@@ -29,6 +29,12 @@ import (
 //       into existing `obsreport` package once its functionally is not exposed
 //       as public API. For now this part is kept private.
 
+// laneKey is the label identifying the lane of the sending queue, "default" when no lanes are configured.
+const laneKey = "lane"
+
+// dataTypeKey is the label identifying the signal of the dead letter storage.
+const dataTypeKey = "data_type"
+
 var (
 	globalInstruments = newInstruments(metric.NewRegistry())
 )
@@ -41,6 +47,13 @@ type instruments struct {
 	registry                    *metric.Registry
 	queueSize                   *metric.Int64DerivedGauge
 	queueCapacity               *metric.Int64DerivedGauge
+	circuitBreakerState         *metric.Int64DerivedGauge
+	persistentReadIndex         *metric.Int64DerivedGauge
+	persistentWriteIndex        *metric.Int64DerivedGauge
+	persistentDispatchedItems   *metric.Int64DerivedGauge
+	persistentOldestItemAge     *metric.Int64DerivedGauge
+	persistentCorruptedItems    *metric.Int64DerivedCumulative
+	deadLetterQuarantinedItems  *metric.Int64DerivedCumulative
 	failedToEnqueueTraceSpans   *metric.Int64Cumulative
 	failedToEnqueueMetricPoints *metric.Int64Cumulative
 	failedToEnqueueLogRecords   *metric.Int64Cumulative
@@ -62,6 +75,48 @@ func newInstruments(registry *metric.Registry) *instruments {
 		metric.WithLabelKeys(obsmetrics.ExporterKey),
 		metric.WithUnit(metricdata.UnitDimensionless))
 
+	insts.circuitBreakerState, _ = registry.AddInt64DerivedGauge(
+		obsmetrics.ExporterKey+"/circuit_breaker_state",
+		metric.WithDescription("Current state of the circuit breaker (0 closed, 1 open, 2 half-open)"),
+		metric.WithLabelKeys(obsmetrics.ExporterKey),
+		metric.WithUnit(metricdata.UnitDimensionless))
+
+	insts.persistentReadIndex, _ = registry.AddInt64DerivedGauge(
+		obsmetrics.ExporterKey+"/persistent_queue_read_index",
+		metric.WithDescription("Index of the next item to be read from the persistent queue"),
+		metric.WithLabelKeys(obsmetrics.ExporterKey, laneKey),
+		metric.WithUnit(metricdata.UnitDimensionless))
+
+	insts.persistentWriteIndex, _ = registry.AddInt64DerivedGauge(
+		obsmetrics.ExporterKey+"/persistent_queue_write_index",
+		metric.WithDescription("Index of the next item to be written to the persistent queue"),
+		metric.WithLabelKeys(obsmetrics.ExporterKey, laneKey),
+		metric.WithUnit(metricdata.UnitDimensionless))
+
+	insts.persistentDispatchedItems, _ = registry.AddInt64DerivedGauge(
+		obsmetrics.ExporterKey+"/persistent_queue_dispatched_items",
+		metric.WithDescription("Number of items read from the persistent queue and not acknowledged yet"),
+		metric.WithLabelKeys(obsmetrics.ExporterKey, laneKey),
+		metric.WithUnit(metricdata.UnitDimensionless))
+
+	insts.persistentOldestItemAge, _ = registry.AddInt64DerivedGauge(
+		obsmetrics.ExporterKey+"/persistent_queue_oldest_item_age",
+		metric.WithDescription("Age of the oldest item stored in the persistent queue"),
+		metric.WithLabelKeys(obsmetrics.ExporterKey, laneKey),
+		metric.WithUnit(metricdata.UnitMilliseconds))
+
+	insts.persistentCorruptedItems, _ = registry.AddInt64DerivedCumulative(
+		obsmetrics.ExporterKey+"/persistent_queue_corrupted_items",
+		metric.WithDescription("Number of items dropped from the persistent queue because they could not be unmarshaled"),
+		metric.WithLabelKeys(obsmetrics.ExporterKey, laneKey),
+		metric.WithUnit(metricdata.UnitDimensionless))
+
+	insts.deadLetterQuarantinedItems, _ = registry.AddInt64DerivedCumulative(
+		obsmetrics.ExporterKey+"/dead_letter_quarantined_items",
+		metric.WithDescription("Number of items moved to quarantine by the dead letter storage because they could not be unmarshaled"),
+		metric.WithLabelKeys(obsmetrics.ExporterKey, dataTypeKey),
+		metric.WithUnit(metricdata.UnitDimensionless))
+
 	insts.failedToEnqueueTraceSpans, _ = registry.AddInt64Cumulative(
 		obsmetrics.ExporterKey+"/enqueue_failed_spans",
 		metric.WithDescription("Number of spans failed to be added to the sending queue."),
@@ -0,0 +1,176 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// defaultLaneName is the name of the lane for the requests matching none of the configured lanes.
+const defaultLaneName = "default"
+
+// PriorityLaneSettings defines a lane of the sending queue, with its own capacity.
+type PriorityLaneSettings struct {
+	// Name identifies the lane, it must be unique within the queue.
+	Name string `mapstructure:"name"`
+	// QueueSize is the maximum number of batches allowed in the lane at a given time.
+	QueueSize int `mapstructure:"queue_size"`
+	// Weight is the share of the consumers given to the lane while the other lanes are waiting for them.
+	// If 0, defaults to 1.
+	Weight int `mapstructure:"weight"`
+	// Match selects the requests put into the lane.
+	Match PriorityLaneMatch `mapstructure:"match"`
+}
+
+// PriorityLaneMatch selects the requests having one of the Values, either for a resource attribute or for a
+// client metadata key. Exactly one of ResourceAttribute or MetadataKey must be set.
+type PriorityLaneMatch struct {
+	// ResourceAttribute is the resource attribute to look up, a request matches if any of its resources has one of the values.
+	ResourceAttribute string `mapstructure:"resource_attribute"`
+	// MetadataKey is the client metadata key to look up, see client.Info.
+	MetadataKey string `mapstructure:"metadata_key"`
+	// Values are the values to match.
+	Values []string `mapstructure:"values"`
+}
+
+// Validate checks if the PriorityLaneSettings configuration is valid
+func (plCfg *PriorityLaneSettings) Validate() error {
+	if plCfg.Name == "" {
+		return errors.New("lane name must not be empty")
+	}
+	if plCfg.QueueSize <= 0 {
+		return fmt.Errorf("lane %q: queue size must be positive", plCfg.Name)
+	}
+	if plCfg.Weight < 0 {
+		return fmt.Errorf("lane %q: weight must not be negative", plCfg.Name)
+	}
+	if (plCfg.Match.ResourceAttribute == "") == (plCfg.Match.MetadataKey == "") {
+		return fmt.Errorf("lane %q: exactly one of resource_attribute or metadata_key must be set", plCfg.Name)
+	}
+	if len(plCfg.Match.Values) == 0 {
+		return fmt.Errorf("lane %q: values must not be empty", plCfg.Name)
+	}
+	return nil
+}
+
+func validateLanes(lanes []PriorityLaneSettings) error {
+	names := map[string]struct{}{defaultLaneName: {}}
+	for i := range lanes {
+		if err := lanes[i].Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[lanes[i].Name]; ok {
+			return fmt.Errorf("lane %q: name is reserved or used by another lane", lanes[i].Name)
+		}
+		names[lanes[i].Name] = struct{}{}
+	}
+	return nil
+}
+
+// laneRouter returns the index of the first lane matching a request, or the index of the default lane
+// which comes after the configured ones.
+type laneRouter struct {
+	lanes []laneMatcher
+}
+
+type laneMatcher struct {
+	resourceAttribute string
+	metadataKey       string
+	values            map[string]struct{}
+}
+
+func newLaneRouter(lanes []PriorityLaneSettings) *laneRouter {
+	lr := &laneRouter{}
+	for _, lane := range lanes {
+		lm := laneMatcher{
+			resourceAttribute: lane.Match.ResourceAttribute,
+			metadataKey:       lane.Match.MetadataKey,
+			values:            make(map[string]struct{}, len(lane.Match.Values)),
+		}
+		for _, v := range lane.Match.Values {
+			lm.values[v] = struct{}{}
+		}
+		lr.lanes = append(lr.lanes, lm)
+	}
+	return lr
+}
+
+func (lr *laneRouter) route(req internal.Request) int {
+	for i, lm := range lr.lanes {
+		if lm.matches(req) {
+			return i
+		}
+	}
+	return len(lr.lanes)
+}
+
+func (lm laneMatcher) matches(req internal.Request) bool {
+	if lm.metadataKey != "" {
+		for _, v := range client.FromContext(req.Context()).Metadata.Get(lm.metadataKey) {
+			if _, ok := lm.values[v]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	rr, ok := req.(interface {
+		anyResource(fn func(pcommon.Resource) bool) bool
+	})
+	if !ok {
+		return false
+	}
+	return rr.anyResource(func(res pcommon.Resource) bool {
+		v, found := res.Attributes().Get(lm.resourceAttribute)
+		if !found {
+			return false
+		}
+		_, ok := lm.values[v.AsString()]
+		return ok
+	})
+}
+
+// newPriorityQueue creates a queue with a lane for each of the configured lanes plus the default lane,
+// newLane creates the queue backing a lane.
+func newPriorityQueue(qCfg QueueSettings, newLane func(name string, capacity int) (internal.ProducerConsumerQueue, error)) (internal.ProducerConsumerQueue, error) {
+	lanes := make([]internal.PriorityLane, 0, len(qCfg.Lanes)+1)
+	for _, lane := range qCfg.Lanes {
+		q, err := newLane(lane.Name, lane.QueueSize)
+		if err != nil {
+			return nil, err
+		}
+		lanes = append(lanes, internal.PriorityLane{Queue: q, Weight: lane.Weight})
+	}
+	q, err := newLane(defaultLaneName, qCfg.QueueSize)
+	if err != nil {
+		return nil, err
+	}
+	lanes = append(lanes, internal.PriorityLane{Queue: q, Weight: 1})
+	return internal.NewPriorityQueue(lanes, newLaneRouter(qCfg.Lanes).route)
+}
+
+// queueCapacity returns the total capacity of the lanes of the queue.
+func queueCapacity(qCfg QueueSettings) int {
+	capacity := qCfg.QueueSize
+	for _, lane := range qCfg.Lanes {
+		capacity += lane.QueueSize
+	}
+	return capacity
+}
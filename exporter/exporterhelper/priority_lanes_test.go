@@ -0,0 +1,179 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestPriorityLaneSettings_Validate(t *testing.T) {
+	lane := func(name string) PriorityLaneSettings {
+		return PriorityLaneSettings{Name: name, QueueSize: 10, Match: PriorityLaneMatch{ResourceAttribute: "severity", Values: []string{"error"}}}
+	}
+	qCfg := NewDefaultQueueSettings()
+	qCfg.Lanes = []PriorityLaneSettings{lane("errors"), lane("slo")}
+	assert.NoError(t, qCfg.Validate())
+
+	qCfg.Lanes = []PriorityLaneSettings{lane("errors"), lane("errors")}
+	assert.EqualError(t, qCfg.Validate(), `lane "errors": name is reserved or used by another lane`)
+
+	qCfg.Lanes = []PriorityLaneSettings{lane(defaultLaneName)}
+	assert.EqualError(t, qCfg.Validate(), `lane "default": name is reserved or used by another lane`)
+
+	qCfg.Lanes = []PriorityLaneSettings{lane("")}
+	assert.EqualError(t, qCfg.Validate(), "lane name must not be empty")
+
+	invalid := lane("errors")
+	invalid.QueueSize = 0
+	qCfg.Lanes = []PriorityLaneSettings{invalid}
+	assert.EqualError(t, qCfg.Validate(), `lane "errors": queue size must be positive`)
+
+	invalid = lane("errors")
+	invalid.Weight = -1
+	qCfg.Lanes = []PriorityLaneSettings{invalid}
+	assert.EqualError(t, qCfg.Validate(), `lane "errors": weight must not be negative`)
+
+	invalid = lane("errors")
+	invalid.Match.MetadataKey = "tenant"
+	qCfg.Lanes = []PriorityLaneSettings{invalid}
+	assert.EqualError(t, qCfg.Validate(), `lane "errors": exactly one of resource_attribute or metadata_key must be set`)
+
+	invalid = lane("errors")
+	invalid.Match.Values = nil
+	qCfg.Lanes = []PriorityLaneSettings{invalid}
+	assert.EqualError(t, qCfg.Validate(), `lane "errors": values must not be empty`)
+
+	// The lanes are not validated when the queue is disabled.
+	qCfg.Enabled = false
+	assert.NoError(t, qCfg.Validate())
+}
+
+func TestLaneRouter(t *testing.T) {
+	lr := newLaneRouter([]PriorityLaneSettings{
+		{Name: "errors", Match: PriorityLaneMatch{ResourceAttribute: "severity", Values: []string{"error", "fatal"}}},
+		{Name: "tenant", Match: PriorityLaneMatch{MetadataKey: "x-tenant", Values: []string{"acme"}}},
+	})
+
+	td := testdata.GenerateTraces(1)
+	assert.Equal(t, 2, lr.route(newTracesRequest(context.Background(), td, nil)))
+
+	// Any of the resources can match.
+	td.ResourceSpans().AppendEmpty().Resource().Attributes().PutStr("severity", "fatal")
+	assert.Equal(t, 0, lr.route(newTracesRequest(context.Background(), td, nil)))
+
+	ctx := client.NewContext(context.Background(), client.Info{
+		Metadata: client.NewMetadata(map[string][]string{"x-tenant": {"other", "acme"}}),
+	})
+	assert.Equal(t, 1, lr.route(newTracesRequest(ctx, testdata.GenerateTraces(1), nil)))
+
+	md := testdata.GenerateMetrics(1)
+	md.ResourceMetrics().At(0).Resource().Attributes().PutStr("severity", "error")
+	assert.Equal(t, 0, lr.route(newMetricsRequest(context.Background(), md, nil)))
+
+	ld := testdata.GenerateLogs(1)
+	ld.ResourceLogs().At(0).Resource().Attributes().PutStr("severity", "info")
+	assert.Equal(t, 2, lr.route(newLogsRequest(context.Background(), ld, nil)))
+}
+
+func TestPriorityLanes_MemoryQueue(t *testing.T) {
+	qCfg := NewDefaultQueueSettings()
+	qCfg.NumConsumers = 1
+	qCfg.QueueSize = 1
+	qCfg.Lanes = []PriorityLaneSettings{
+		{Name: "errors", QueueSize: 1, Weight: 3, Match: PriorityLaneMatch{ResourceAttribute: "severity", Values: []string{"error"}}},
+	}
+
+	started := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	sink := &tracesSink{}
+	push := func(ctx context.Context, td ptrace.Traces) error {
+		select {
+		case started <- struct{}{}:
+			<-unblock
+		default:
+		}
+		return sink.push(ctx, td)
+	}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	// The only consumer is busy with the first request.
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(1)))
+	<-started
+
+	// The default lane fills up.
+	expected := []int{1}
+	for te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)) == nil {
+		expected = append(expected, 2)
+	}
+
+	// The errors still have room in their lane.
+	td := testdata.GenerateTraces(3)
+	td.ResourceSpans().At(0).Resource().Attributes().PutStr("severity", "error")
+	require.NoError(t, te.ConsumeTraces(context.Background(), td))
+
+	close(unblock)
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.ElementsMatch(t, append(expected, 3), sink.batchSizes())
+}
+
+func TestPriorityLanes_PersistentQueue(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	ext := newMapStorageExtension()
+	host := &mockHost{ext: map[component.ID]component.Component{storageID: ext}}
+
+	qCfg := NewDefaultQueueSettings()
+	qCfg.StorageID = &storageID
+	qCfg.Lanes = []PriorityLaneSettings{
+		{Name: "errors", QueueSize: 10, Match: PriorityLaneMatch{ResourceAttribute: "severity", Values: []string{"error"}}},
+	}
+	sink := &tracesSink{}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+
+	td := testdata.GenerateTraces(3)
+	td.ResourceSpans().At(0).Resource().Attributes().PutStr("severity", "error")
+	require.NoError(t, te.ConsumeTraces(context.Background(), td))
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	assert.Eventually(t, func() bool { return len(sink.batchSizes()) == 2 }, time.Second, time.Millisecond)
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.ElementsMatch(t, []int{2, 3}, sink.batchSizes())
+
+	// The default lane keeps the storage of the queue without lanes.
+	ext.mu.Lock()
+	defer ext.mu.Unlock()
+	assert.Contains(t, ext.clients, "traces")
+	assert.Contains(t, ext.clients, "errors-traces")
+}
+
+func TestQueueCapacity(t *testing.T) {
+	qCfg := NewDefaultQueueSettings()
+	assert.Equal(t, 5000, queueCapacity(qCfg))
+	qCfg.Lanes = []PriorityLaneSettings{{QueueSize: 100}, {QueueSize: 10}}
+	assert.Equal(t, 5110, queueCapacity(qCfg))
+}
@@ -0,0 +1,131 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.uber.org/multierr"
+)
+
+const (
+	// zPagesActionPurgeQueue purges the persistent queue of every lane.
+	zPagesActionPurgeQueue = "purge_queue"
+	// zPagesActionSkipQueueItem skips an item of the persistent queue, the value is "<index>" or "<lane>:<index>".
+	zPagesActionSkipQueueItem = "skip_queue_item"
+)
+
+var (
+	errPersistentQueueNotEnabled = errors.New("persistent queue is not enabled")
+	errZPagesActionsNotEnabled   = errors.New("zpages_actions is not enabled")
+	errUnknownZPagesAction       = errors.New("unknown action")
+)
+
+// persistentLanes returns the names of the lanes backed by a persistent queue, sorted.
+func (qrs *queuedRetrySender) persistentLanes() []string {
+	lanes := make([]string, 0, len(qrs.persistentQueues))
+	for lane := range qrs.persistentQueues {
+		lanes = append(lanes, lane)
+	}
+	sort.Strings(lanes)
+	return lanes
+}
+
+// startPersistentQueueMetrics starts reporting the state of the persistent queue of every lane.
+func (qrs *queuedRetrySender) startPersistentQueueMetrics() error {
+	for _, lane := range qrs.persistentLanes() {
+		pq := qrs.persistentQueues[lane]
+		labels := []metricdata.LabelValue{metricdata.NewLabelValue(qrs.fullName), metricdata.NewLabelValue(lane)}
+		errs := multierr.Combine(
+			globalInstruments.persistentReadIndex.UpsertEntry(func() int64 {
+				return int64(pq.Stats().ReadIndex)
+			}, labels...),
+			globalInstruments.persistentWriteIndex.UpsertEntry(func() int64 {
+				return int64(pq.Stats().WriteIndex)
+			}, labels...),
+			globalInstruments.persistentDispatchedItems.UpsertEntry(func() int64 {
+				return int64(pq.Stats().DispatchedItems)
+			}, labels...),
+			globalInstruments.persistentOldestItemAge.UpsertEntry(func() int64 {
+				return pq.Stats().OldestItemAge.Milliseconds()
+			}, labels...),
+			globalInstruments.persistentCorruptedItems.UpsertEntry(func() int64 {
+				return int64(pq.Stats().CorruptedItems)
+			}, labels...),
+		)
+		if errs != nil {
+			return errs
+		}
+	}
+	return nil
+}
+
+// persistentQueueZPagesProperties returns the state of the persistent queue of every lane for the zPages.
+func (qrs *queuedRetrySender) persistentQueueZPagesProperties() [][2]string {
+	var props [][2]string
+	for _, lane := range qrs.persistentLanes() {
+		prefix := "Persistent queue"
+		if len(qrs.cfg.Lanes) > 0 {
+			prefix += " (" + lane + ")"
+		}
+		st := qrs.persistentQueues[lane].Stats()
+		props = append(props,
+			[2]string{prefix + " read index", strconv.FormatUint(st.ReadIndex, 10)},
+			[2]string{prefix + " write index", strconv.FormatUint(st.WriteIndex, 10)},
+			[2]string{prefix + " dispatched items", strconv.Itoa(st.DispatchedItems)},
+			[2]string{prefix + " oldest item age", st.OldestItemAge.Round(time.Millisecond).String()},
+			[2]string{prefix + " corrupted items", strconv.FormatUint(st.CorruptedItems, 10)},
+		)
+	}
+	return props
+}
+
+// purgePersistentQueues purges the persistent queue of every lane and returns how many items were deleted.
+func (qrs *queuedRetrySender) purgePersistentQueues(ctx context.Context) (int, error) {
+	if len(qrs.persistentQueues) == 0 {
+		return 0, errPersistentQueueNotEnabled
+	}
+	total := 0
+	var errs error
+	for _, lane := range qrs.persistentLanes() {
+		n, err := qrs.persistentQueues[lane].Purge(ctx)
+		total += n
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("lane %q: %w", lane, err))
+		}
+	}
+	return total, errs
+}
+
+// skipPersistentQueueItem skips the item at the given index of the persistent queue of the lane.
+func (qrs *queuedRetrySender) skipPersistentQueueItem(ctx context.Context, lane string, index uint64) error {
+	if len(qrs.persistentQueues) == 0 {
+		return errPersistentQueueNotEnabled
+	}
+	if lane == "" {
+		lane = defaultLaneName
+	}
+	pq, ok := qrs.persistentQueues[lane]
+	if !ok {
+		return fmt.Errorf("unknown lane %q", lane)
+	}
+	return pq.Skip(ctx, index)
+}
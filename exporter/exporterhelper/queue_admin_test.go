@@ -0,0 +1,157 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/tag"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/internal/testdata"
+)
+
+type zPagesActionHandler interface {
+	ZPagesProperties() [][2]string
+	ZPagesActions() []string
+	HandleZPagesAction(ctx context.Context, action string, value string) (string, error)
+}
+
+func TestPersistentQueue_StatsAndAdminActions(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{storageID: newMapStorageExtension()}}
+
+	qCfg := NewDefaultQueueSettings()
+	qCfg.StorageID = &storageID
+	// Without consumers the requests stay in the queue, except the first one which is dispatched right away.
+	qCfg.NumConsumers = 0
+	qCfg.ZPagesActions = true
+	sink := &tracesSink{}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(i)))
+	}
+
+	admin, ok := te.(zPagesActionHandler)
+	require.True(t, ok)
+	assert.Equal(t, []string{zPagesActionPurgeQueue, zPagesActionSkipQueueItem}, admin.ZPagesActions())
+	assert.Eventually(t, func() bool {
+		props := admin.ZPagesProperties()
+		return contains(props, [2]string{"Persistent queue read index", "1"}) &&
+			contains(props, [2]string{"Persistent queue dispatched items", "1"})
+	}, time.Second, time.Millisecond)
+	assert.Contains(t, admin.ZPagesProperties(), [2]string{"Persistent queue write index", "4"})
+	assert.Contains(t, admin.ZPagesProperties(), [2]string{"Persistent queue corrupted items", "0"})
+
+	checkPersistentQueueMetric(t, "exporter/persistent_queue_read_index", 1)
+	checkPersistentQueueMetric(t, "exporter/persistent_queue_write_index", 4)
+	checkPersistentQueueMetric(t, "exporter/persistent_queue_dispatched_items", 1)
+	checkPersistentQueueMetric(t, "exporter/persistent_queue_corrupted_items", 0)
+
+	// Skip the dispatched request and the next one, then purge the others.
+	_, err = admin.HandleZPagesAction(context.Background(), zPagesActionSkipQueueItem, "3")
+	assert.Error(t, err)
+	_, err = admin.HandleZPagesAction(context.Background(), zPagesActionSkipQueueItem, "unknown:1")
+	assert.EqualError(t, err, `unknown lane "unknown"`)
+	_, err = admin.HandleZPagesAction(context.Background(), zPagesActionSkipQueueItem, "first")
+	assert.Error(t, err)
+	msg, err := admin.HandleZPagesAction(context.Background(), zPagesActionSkipQueueItem, "0")
+	require.NoError(t, err)
+	assert.Equal(t, "Skipped item 0", msg)
+	msg, err = admin.HandleZPagesAction(context.Background(), zPagesActionSkipQueueItem, "default:1")
+	require.NoError(t, err)
+	assert.Equal(t, "Skipped item 1", msg)
+	msg, err = admin.HandleZPagesAction(context.Background(), zPagesActionPurgeQueue, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Purged 2 items", msg)
+	_, err = admin.HandleZPagesAction(context.Background(), "unknown", "")
+	assert.ErrorIs(t, err, errUnknownZPagesAction)
+	require.NoError(t, te.Shutdown(context.Background()))
+
+	// Nothing is left for the next start.
+	qCfg.NumConsumers = 1
+	te, err = NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Empty(t, sink.batchSizes())
+}
+
+func TestPersistentQueue_AdminWithoutPersistence(t *testing.T) {
+	be, err := newBaseExporter(defaultSettings, fromOptions(WithQueue(NewDefaultQueueSettings())), "", nopRequestUnmarshaler())
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+	assert.Nil(t, be.ZPagesActions())
+	assert.Empty(t, be.ZPagesProperties())
+	_, err = be.PurgeQueue(context.Background())
+	assert.ErrorIs(t, err, errPersistentQueueNotEnabled)
+	assert.ErrorIs(t, be.SkipQueueItem(context.Background(), "", 0), errPersistentQueueNotEnabled)
+	require.NoError(t, be.Shutdown(context.Background()))
+}
+
+func TestPersistentQueue_ZPagesActionsNotEnabled(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{storageID: newMapStorageExtension()}}
+	qCfg := NewDefaultQueueSettings()
+	qCfg.StorageID = &storageID
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, (&tracesSink{}).push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+
+	admin, ok := te.(zPagesActionHandler)
+	require.True(t, ok)
+	assert.Nil(t, admin.ZPagesActions())
+	_, err = admin.HandleZPagesAction(context.Background(), zPagesActionPurgeQueue, "")
+	assert.ErrorIs(t, err, errZPagesActionsNotEnabled)
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+// checkPersistentQueueMetric checks the value reported for the default lane of the test exporter.
+func checkPersistentQueueMetric(t *testing.T, name string, value int64) {
+	laneTag, _ := tag.NewKey(laneKey)
+	checkExporterHelperMetric(t, name, []tag.Tag{{Key: exporterTag, Value: defaultID.String()}, {Key: laneTag, Value: defaultLaneName}}, value)
+}
+
+// checkExporterHelperMetric checks the last value reported for the metric with the given tags.
+func checkExporterHelperMetric(t *testing.T, name string, tags []tag.Tag, value int64) {
+	for _, metric := range globalInstruments.registry.Read() {
+		if metric.Descriptor.Name != name {
+			continue
+		}
+		for _, ts := range metric.TimeSeries {
+			if tagsMatchLabelKeys(tags, metric.Descriptor.LabelKeys, ts.LabelValues) {
+				assert.Equal(t, value, ts.Points[len(ts.Points)-1].Value.(int64))
+				return
+			}
+		}
+	}
+	assert.Failf(t, "metric not reported", "could not find metric %v with tags %s reported", name, tags)
+}
+
+func contains(props [][2]string, prop [2]string) bool {
+	for _, p := range props {
+		if p == prop {
+			return true
+		}
+	}
+	return false
+}
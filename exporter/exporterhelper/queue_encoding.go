@@ -0,0 +1,100 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/collector/config/configcompression"
+	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+)
+
+// QueueEncryptionSettings defines the AES-GCM encryption of the batches written to the storage.
+// The key is base64 encoded and must decode to 16, 24 or 32 bytes, for AES-128, AES-192 or AES-256.
+// At most one of KeyFile or KeyEnv can be set, the encryption is enabled if one of them is.
+type QueueEncryptionSettings struct {
+	// KeyFile is the path of the file holding the key.
+	KeyFile string `mapstructure:"key_file"`
+	// KeyEnv is the name of the environment variable holding the key.
+	KeyEnv string `mapstructure:"key_env"`
+}
+
+func (qeCfg *QueueEncryptionSettings) enabled() bool {
+	return qeCfg.KeyFile != "" || qeCfg.KeyEnv != ""
+}
+
+// Validate checks if the QueueEncryptionSettings configuration is valid
+func (qeCfg *QueueEncryptionSettings) Validate() error {
+	if qeCfg.KeyFile != "" && qeCfg.KeyEnv != "" {
+		return errors.New("at most one of key_file or key_env can be set")
+	}
+	return nil
+}
+
+// loadKey reads and decodes the encryption key.
+func (qeCfg *QueueEncryptionSettings) loadKey() ([]byte, error) {
+	var encoded string
+	if qeCfg.KeyFile != "" {
+		b, err := os.ReadFile(qeCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the queue encryption key: %w", err)
+		}
+		encoded = string(b)
+	} else {
+		var ok bool
+		if encoded, ok = os.LookupEnv(qeCfg.KeyEnv); !ok {
+			return nil, fmt.Errorf("environment variable %q holding the queue encryption key is not set", qeCfg.KeyEnv)
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the queue encryption key: %w", err)
+	}
+	return key, nil
+}
+
+func validateQueueEncoding(qCfg *QueueSettings) error {
+	compressed := configcompression.IsCompressed(qCfg.Compression)
+	if compressed && qCfg.Compression != configcompression.Zstd {
+		return fmt.Errorf("unsupported queue compression %q, only zstd is supported", qCfg.Compression)
+	}
+	if err := qCfg.Encryption.Validate(); err != nil {
+		return err
+	}
+	if (compressed || qCfg.Encryption.enabled()) && qCfg.StorageID == nil && qCfg.SpillStorageID == nil {
+		return errors.New("compression and encryption require storage or spill_storage to be set")
+	}
+	return nil
+}
+
+// newRequestCodec returns the codec of the batches written to the storage, or nil to write them as is.
+func newRequestCodec(qCfg QueueSettings) (*internal.RequestCodec, error) {
+	compressed := configcompression.IsCompressed(qCfg.Compression)
+	if !compressed && !qCfg.Encryption.enabled() {
+		return nil, nil
+	}
+	var key []byte
+	if qCfg.Encryption.enabled() {
+		var err error
+		if key, err = qCfg.Encryption.loadKey(); err != nil {
+			return nil, err
+		}
+	}
+	return internal.NewRequestCodec(compressed, key)
+}
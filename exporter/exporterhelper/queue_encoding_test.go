@@ -0,0 +1,128 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configcompression"
+	"go.opentelemetry.io/collector/internal/testdata"
+)
+
+var testEncryptionKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+
+func TestQueueSettings_ValidateEncoding(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	qCfg := NewDefaultQueueSettings()
+	qCfg.StorageID = &storageID
+	qCfg.Compression = configcompression.Zstd
+	qCfg.Encryption = QueueEncryptionSettings{KeyEnv: "QUEUE_KEY"}
+	assert.NoError(t, qCfg.Validate())
+
+	qCfg.Compression = configcompression.Gzip
+	assert.EqualError(t, qCfg.Validate(), `unsupported queue compression "gzip", only zstd is supported`)
+
+	qCfg.Compression = "none"
+	qCfg.Encryption.KeyFile = "key"
+	assert.EqualError(t, qCfg.Validate(), "at most one of key_file or key_env can be set")
+
+	qCfg.StorageID = nil
+	qCfg.Encryption = QueueEncryptionSettings{KeyFile: "key"}
+	assert.EqualError(t, qCfg.Validate(), "compression and encryption require storage or spill_storage to be set")
+
+	qCfg.SpillStorageID = &storageID
+	assert.NoError(t, qCfg.Validate())
+}
+
+func TestQueueEncryptionSettings_LoadKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(testEncryptionKey+"\n"), 0600))
+	key, err := (&QueueEncryptionSettings{KeyFile: keyFile}).loadKey()
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	t.Setenv("QUEUE_KEY", testEncryptionKey)
+	key, err = (&QueueEncryptionSettings{KeyEnv: "QUEUE_KEY"}).loadKey()
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	_, err = (&QueueEncryptionSettings{KeyFile: filepath.Join(t.TempDir(), "missing")}).loadKey()
+	assert.ErrorContains(t, err, "failed to read the queue encryption key")
+
+	_, err = (&QueueEncryptionSettings{KeyEnv: "QUEUE_KEY_NOT_SET"}).loadKey()
+	assert.EqualError(t, err, `environment variable "QUEUE_KEY_NOT_SET" holding the queue encryption key is not set`)
+
+	t.Setenv("QUEUE_KEY", "not base64!")
+	_, err = (&QueueEncryptionSettings{KeyEnv: "QUEUE_KEY"}).loadKey()
+	assert.ErrorContains(t, err, "failed to decode the queue encryption key")
+}
+
+func TestQueuedRetrySpillEncoded(t *testing.T) {
+	t.Setenv("QUEUE_KEY", testEncryptionKey)
+	storageID := component.NewID("file_storage")
+	ext := newMapStorageExtension()
+	host := &mockHost{ext: map[component.ID]component.Component{storageID: ext}}
+
+	qCfg := NewDefaultQueueSettings()
+	qCfg.SpillStorageID = &storageID
+	qCfg.Compression = configcompression.Zstd
+	qCfg.Encryption = QueueEncryptionSettings{KeyEnv: "QUEUE_KEY"}
+	// Without consumers the requests are spilled on shutdown.
+	qCfg.NumConsumers = 0
+	sink := &tracesSink{}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	require.NoError(t, te.Shutdown(context.Background()))
+
+	client := ext.clients["spill-traces"]
+	require.Len(t, client.st, 2)
+	for key, stored := range client.st {
+		if key != "ri" {
+			assert.Equal(t, byte(0), stored[0], "the stored request carries the format header")
+		}
+	}
+
+	qCfg.NumConsumers = 1
+	te, err = NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	assert.Eventually(t, func() bool { return len(sink.batchSizes()) == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Equal(t, []int{2}, sink.batchSizes())
+}
+
+func TestQueuedRetryPersistenceEncryptionKeyError(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{storageID: newMapStorageExtension()}}
+
+	qCfg := NewDefaultQueueSettings()
+	qCfg.StorageID = &storageID
+	qCfg.Encryption = QueueEncryptionSettings{KeyEnv: "QUEUE_KEY_NOT_SET"}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, newTraceDataPusher(nil), WithQueue(qCfg))
+	require.NoError(t, err)
+	assert.ErrorContains(t, te.Start(context.Background(), host), "QUEUE_KEY_NOT_SET")
+}
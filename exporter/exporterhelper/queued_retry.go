@@ -28,7 +28,9 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configcompression"
 	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter/exportererror"
 	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
 	"go.opentelemetry.io/collector/extension/experimental/storage"
 	"go.opentelemetry.io/collector/internal/obsreportconfig/obsmetrics"
@@ -51,6 +53,22 @@ type QueueSettings struct {
 	// StorageID if not empty, enables the persistent storage and uses the component specified
 	// as a storage extension for the persistent queue
 	StorageID *component.ID `mapstructure:"storage"`
+	// SpillStorageID if not empty, keeps the queue in memory but writes it to the component specified as a storage
+	// extension past SpillHighWatermark and on shutdown, to restore it on the next start. It cannot be used with StorageID.
+	SpillStorageID *component.ID `mapstructure:"spill_storage"`
+	// SpillHighWatermark is the number of batches kept in memory before the new ones are spilled to the storage.
+	// If 0, defaults to QueueSize so that the queue is only spilled on shutdown.
+	SpillHighWatermark int `mapstructure:"spill_high_watermark"`
+	// Compression is the compression of the batches written to the storage, only zstd is supported.
+	Compression configcompression.CompressionType `mapstructure:"compression"`
+	// Encryption configures the encryption of the batches written to the storage.
+	Encryption QueueEncryptionSettings `mapstructure:"encryption"`
+	// Lanes if not empty, splits the queue in priority lanes. The requests matching none of the lanes go to the
+	// default lane, sized by QueueSize and with a weight of 1.
+	Lanes []PriorityLaneSettings `mapstructure:"lanes"`
+	// ZPagesActions indicates whether the persistent queue can be purged and its batches skipped from the
+	// pipelines zPage. Disabled by default, since the actions delete data.
+	ZPagesActions bool `mapstructure:"zpages_actions"`
 }
 
 // NewDefaultQueueSettings returns the default settings for QueueSettings.
@@ -76,7 +94,19 @@ func (qCfg *QueueSettings) Validate() error {
 		return errors.New("queue size must be positive")
 	}
 
-	return nil
+	if qCfg.StorageID != nil && qCfg.SpillStorageID != nil {
+		return errors.New("storage and spill_storage cannot be both set")
+	}
+
+	if qCfg.SpillHighWatermark < 0 || qCfg.SpillHighWatermark > qCfg.QueueSize {
+		return errors.New("spill high watermark must be between 0 and the queue size")
+	}
+
+	if err := validateQueueEncoding(qCfg); err != nil {
+		return err
+	}
+
+	return validateLanes(qCfg.Lanes)
 }
 
 type queuedRetrySender struct {
@@ -85,15 +115,22 @@ type queuedRetrySender struct {
 	signal             component.DataType
 	cfg                QueueSettings
 	consumerSender     requestSender
+	redriveSender      requestSender
 	queue              internal.ProducerConsumerQueue
 	retryStopCh        chan struct{}
 	traceAttribute     attribute.KeyValue
 	logger             *zap.Logger
 	requeuingEnabled   bool
 	requestUnmarshaler internal.RequestUnmarshaler
+	breaker            *circuitBreaker
+	deadLetter         *deadLetterHandler
+	// persistentQueues holds the persistent queue of each lane, by lane name.
+	persistentQueues map[string]internal.PersistentQueueAdmin
+	// retryAfter is the hint given to the callers to send the data again when the queue is full.
+	retryAfter time.Duration
 }
 
-func newQueuedRetrySender(id component.ID, signal component.DataType, qCfg QueueSettings, rCfg RetrySettings, reqUnmarshaler internal.RequestUnmarshaler, nextSender requestSender, logger *zap.Logger) *queuedRetrySender {
+func newQueuedRetrySender(id component.ID, signal component.DataType, qCfg QueueSettings, rCfg RetrySettings, cbCfg CircuitBreakerSettings, dlCfg DeadLetterSettings, reqUnmarshaler internal.RequestUnmarshaler, nextSender requestSender, logger *zap.Logger) (*queuedRetrySender, error) {
 	retryStopCh := make(chan struct{})
 	sampledLogger := createSampledLogger(logger)
 	traceAttr := attribute.String(obsmetrics.ExporterKey, id.String())
@@ -107,24 +144,53 @@ func newQueuedRetrySender(id component.ID, signal component.DataType, qCfg Queue
 		traceAttribute:     traceAttr,
 		logger:             sampledLogger,
 		requestUnmarshaler: reqUnmarshaler,
+		retryAfter:         rCfg.InitialInterval,
+	}
+	if cbCfg.Enabled {
+		qrs.breaker = newCircuitBreaker(cbCfg, logger)
+	}
+	if dlCfg.Enabled {
+		qrs.deadLetter = newDeadLetterHandler(dlCfg, id, signal, logger)
 	}
 
-	qrs.consumerSender = &retrySender{
+	policies, err := newRetryPolicies(rCfg)
+	if err != nil {
+		return nil, err
+	}
+	rs := &retrySender{
 		traceAttribute: traceAttr,
 		cfg:            rCfg,
+		policies:       policies,
 		nextSender:     nextSender,
 		stopCh:         retryStopCh,
 		logger:         sampledLogger,
+		breaker:        qrs.breaker,
 		// Following three functions actually depend on queuedRetrySender
 		onTemporaryFailure: qrs.onTemporaryFailure,
+		onPermanentFailure: qrs.onPermanentFailure,
+	}
+	qrs.consumerSender = rs
+	if qrs.deadLetter != nil {
+		// The dead letters are redriven below the queue, so that they are deleted only once exported,
+		// and they are not requeued since they are still kept in the dead letter storage.
+		redriveSender := *rs
+		redriveSender.onTemporaryFailure = qrs.onRedriveTemporaryFailure
+		qrs.redriveSender = &redriveSender
 	}
 
-	if qCfg.StorageID == nil {
-		qrs.queue = internal.NewBoundedMemoryQueue(qrs.cfg.QueueSize)
+	if qCfg.StorageID == nil && qCfg.SpillStorageID == nil {
+		if len(qCfg.Lanes) > 0 {
+			// Creating memory lanes never fails.
+			qrs.queue, _ = newPriorityQueue(qCfg, func(_ string, capacity int) (internal.ProducerConsumerQueue, error) {
+				return internal.NewBoundedMemoryQueue(capacity), nil
+			})
+		} else {
+			qrs.queue = internal.NewBoundedMemoryQueue(qrs.cfg.QueueSize)
+		}
 	}
-	// The Persistent Queue is initialized separately as it needs extra information about the component
+	// The Persistent and spill queues are initialized separately as they need extra information about the component
 
-	return qrs
+	return qrs, nil
 }
 
 func getStorageExtension(extensions map[component.ID]component.Component, storageID component.ID) (storage.Extension, error) {
@@ -153,16 +219,48 @@ func toStorageClient(ctx context.Context, storageID component.ID, host component
 
 // initializePersistentQueue uses extra information for initialization available from component.Host
 func (qrs *queuedRetrySender) initializePersistentQueue(ctx context.Context, host component.Host) error {
-	if qrs.cfg.StorageID == nil {
+	if qrs.cfg.StorageID == nil && qrs.cfg.SpillStorageID == nil {
 		return nil
 	}
 
-	storageClient, err := toStorageClient(ctx, *qrs.cfg.StorageID, host, qrs.id, qrs.signal)
+	codec, err := newRequestCodec(qrs.cfg)
 	if err != nil {
 		return err
 	}
 
-	qrs.queue = internal.NewPersistentQueue(ctx, qrs.fullName, qrs.signal, qrs.cfg.QueueSize, qrs.logger, storageClient, qrs.requestUnmarshaler)
+	qrs.persistentQueues = make(map[string]internal.PersistentQueueAdmin)
+	newLane := func(name string, capacity int) (internal.ProducerConsumerQueue, error) {
+		// The default lane keeps the storage of the queue without lanes, the other lanes use their own storage.
+		queueName, signal := qrs.fullName, qrs.signal
+		if name != defaultLaneName {
+			queueName, signal = qrs.fullName+"-"+name, component.DataType(name+"-"+string(qrs.signal))
+		}
+		if qrs.cfg.SpillStorageID != nil {
+			storageClient, err := toStorageClient(ctx, *qrs.cfg.SpillStorageID, host, qrs.id, component.DataType("spill-"+string(signal)))
+			if err != nil {
+				return nil, err
+			}
+			return internal.NewSpillQueue(ctx, capacity, qrs.cfg.SpillHighWatermark, qrs.logger, storageClient, qrs.requestUnmarshaler, codec), nil
+		}
+		storageClient, err := toStorageClient(ctx, *qrs.cfg.StorageID, host, qrs.id, signal)
+		if err != nil {
+			return nil, err
+		}
+		q := internal.NewPersistentQueue(ctx, queueName, qrs.signal, capacity, qrs.logger, storageClient, qrs.requestUnmarshaler, codec)
+		if admin, ok := q.(internal.PersistentQueueAdmin); ok {
+			qrs.persistentQueues[name] = admin
+		}
+		return q, nil
+	}
+
+	if len(qrs.cfg.Lanes) > 0 {
+		qrs.queue, err = newPriorityQueue(qrs.cfg, newLane)
+	} else {
+		qrs.queue, err = newLane(defaultLaneName, qrs.cfg.QueueSize)
+	}
+	if err != nil {
+		return err
+	}
 
 	// TODO: this can be further exposed as a config param rather than relying on a type of queue
 	qrs.requeuingEnabled = true
@@ -195,12 +293,59 @@ func (qrs *queuedRetrySender) onTemporaryFailure(logger *zap.Logger, req interna
 	return err
 }
 
+func (qrs *queuedRetrySender) onRedriveTemporaryFailure(logger *zap.Logger, req internal.Request, err error) error {
+	logger.Error(
+		"Redriving dead letter failed. Keeping it in the dead letter.",
+		zap.Error(err),
+		zap.Int("dead_letter_items", req.Count()),
+	)
+	return err
+}
+
+func (qrs *queuedRetrySender) onPermanentFailure(logger *zap.Logger, req internal.Request, err error) error {
+	if qrs.deadLetter == nil {
+		logger.Error(
+			"Exporting failed. The error is not retryable. Dropping data.",
+			zap.Error(err),
+			zap.Int("dropped_items", req.Count()),
+		)
+		return err
+	}
+
+	if dlErr := qrs.deadLetter.handle(req, err); dlErr != nil {
+		logger.Error(
+			"Exporting failed. The error is not retryable and the dead letter did not accept the data. Dropping data.",
+			zap.Error(err),
+			zap.NamedError("dead_letter_error", dlErr),
+			zap.Int("dropped_items", req.Count()),
+		)
+		return err
+	}
+
+	logger.Warn(
+		"Exporting failed. The error is not retryable. Moved data to the dead letter.",
+		zap.Error(err),
+		zap.Int("dead_letter_items", req.Count()),
+	)
+	return err
+}
+
 // start is invoked during service startup.
 func (qrs *queuedRetrySender) start(ctx context.Context, host component.Host) error {
 	if err := qrs.initializePersistentQueue(ctx, host); err != nil {
 		return err
 	}
 
+	if qrs.deadLetter != nil {
+		codec, err := newRequestCodec(qrs.cfg)
+		if err != nil {
+			return err
+		}
+		if err = qrs.deadLetter.start(ctx, host, qrs.requestUnmarshaler, codec); err != nil {
+			return err
+		}
+	}
+
 	qrs.queue.StartConsumers(qrs.cfg.NumConsumers, func(item internal.Request) {
 		_ = qrs.consumerSender.send(item)
 		item.OnProcessingFinished()
@@ -215,13 +360,28 @@ func (qrs *queuedRetrySender) start(ctx context.Context, host component.Host) er
 			return fmt.Errorf("failed to create retry queue size metric: %w", err)
 		}
 		err = globalInstruments.queueCapacity.UpsertEntry(func() int64 {
-			return int64(qrs.cfg.QueueSize)
+			return int64(queueCapacity(qrs.cfg))
 		}, metricdata.NewLabelValue(qrs.fullName))
 		if err != nil {
 			return fmt.Errorf("failed to create retry queue capacity metric: %w", err)
 		}
 	}
 
+	// Start reporting circuit breaker state metric
+	if qrs.breaker != nil {
+		err := globalInstruments.circuitBreakerState.UpsertEntry(func() int64 {
+			return int64(qrs.breaker.currentState())
+		}, metricdata.NewLabelValue(qrs.fullName))
+		if err != nil {
+			return fmt.Errorf("failed to create circuit breaker state metric: %w", err)
+		}
+	}
+
+	// Start reporting persistent queue metrics
+	if err := qrs.startPersistentQueueMetrics(); err != nil {
+		return fmt.Errorf("failed to create persistent queue metrics: %w", err)
+	}
+
 	return nil
 }
 
@@ -242,6 +402,13 @@ func (qrs *queuedRetrySender) shutdown() {
 	if qrs.queue != nil {
 		qrs.queue.Stop()
 	}
+
+	// Last release the dead letter storage, the drained requests may have been rejected.
+	if qrs.deadLetter != nil {
+		if err := qrs.deadLetter.shutdown(context.Background()); err != nil {
+			qrs.logger.Warn("Failed to close the dead letter storage", zap.Error(err))
+		}
+	}
 }
 
 // RetrySettings defines configuration for retrying batches in case of export failure.
@@ -257,6 +424,8 @@ type RetrySettings struct {
 	// MaxElapsedTime is the maximum amount of time (including retries) spent trying to send a request/batch.
 	// Once this value is reached, the data is discarded.
 	MaxElapsedTime time.Duration `mapstructure:"max_elapsed_time"`
+	// Policies override whether and how the failures with given gRPC codes or HTTP statuses are retried.
+	Policies []RetryPolicy `mapstructure:"policies"`
 }
 
 // NewDefaultRetrySettings returns the default settings for RetrySettings.
@@ -312,7 +481,8 @@ func (qrs *queuedRetrySender) send(req internal.Request) error {
 			zap.Int("dropped_items", req.Count()),
 		)
 		span.AddEvent("Dropped item, sending_queue is full.", trace.WithAttributes(qrs.traceAttribute))
-		return errSendingQueueIsFull
+		// The callers can send the data again once the queue is drained, e.g. receivers can ask their clients to.
+		return consumererror.NewRetryable(errSendingQueueIsFull, qrs.retryAfter)
 	}
 
 	span.AddEvent("Enqueued item.", trace.WithAttributes(qrs.traceAttribute))
@@ -346,16 +516,25 @@ type onRequestHandlingFinishedFunc func(*zap.Logger, internal.Request, error) er
 type retrySender struct {
 	traceAttribute     attribute.KeyValue
 	cfg                RetrySettings
+	policies           retryPolicies
 	nextSender         requestSender
 	stopCh             chan struct{}
 	logger             *zap.Logger
+	breaker            *circuitBreaker
 	onTemporaryFailure onRequestHandlingFinishedFunc
+	onPermanentFailure onRequestHandlingFinishedFunc
 }
 
 // send implements the requestSender interface
 func (rs *retrySender) send(req internal.Request) error {
 	if !rs.cfg.Enabled {
-		err := rs.nextSender.send(req)
+		err := rs.sendAttempt(req)
+		if exportererror.IsPartialExportError(err) {
+			return rs.onPartialSuccess(err)
+		}
+		if consumererror.IsPermanent(err) {
+			return rs.onPermanentFailure(rs.logger, req, err)
+		}
 		if err != nil {
 			rs.logger.Error(
 				"Exporting failed. Try enabling retry_on_failure config option to retry on retryable errors",
@@ -365,18 +544,9 @@ func (rs *retrySender) send(req internal.Request) error {
 		return err
 	}
 
-	// Do not use NewExponentialBackOff since it calls Reset and the code here must
-	// call Reset after changing the InitialInterval (this saves an unnecessary call to Now).
-	expBackoff := backoff.ExponentialBackOff{
-		InitialInterval:     rs.cfg.InitialInterval,
-		RandomizationFactor: backoff.DefaultRandomizationFactor,
-		Multiplier:          backoff.DefaultMultiplier,
-		MaxInterval:         rs.cfg.MaxInterval,
-		MaxElapsedTime:      rs.cfg.MaxElapsedTime,
-		Stop:                backoff.Stop,
-		Clock:               backoff.SystemClock,
-	}
-	expBackoff.Reset()
+	// The backoffs are created on the first failure of each policy, failures matching no policy use the default.
+	start := time.Now()
+	backoffs := map[*retryPolicy]*retryBackoff{}
 	span := trace.SpanFromContext(req.Context())
 	retryNum := int64(0)
 	for {
@@ -384,32 +554,57 @@ func (rs *retrySender) send(req internal.Request) error {
 			"Sending request.",
 			trace.WithAttributes(rs.traceAttribute, attribute.Int64("retry_num", retryNum)))
 
-		err := rs.nextSender.send(req)
+		err := rs.sendAttempt(req)
 		if err == nil {
 			return nil
 		}
 
-		// Immediately drop data on permanent errors.
-		if consumererror.IsPermanent(err) {
-			rs.logger.Error(
-				"Exporting failed. The error is not retryable. Dropping data.",
-				zap.Error(err),
-				zap.Int("dropped_items", req.Count()),
-			)
-			return err
+		// Never retry partial successes, the accepted items would be sent again.
+		if exportererror.IsPartialExportError(err) {
+			return rs.onPartialSuccess(err)
+		}
+
+		// Immediately drop data on permanent errors, or hand it over to the dead letter.
+		// A retry policy can override whether the exporter reported the error as permanent.
+		policy := rs.policies.match(err)
+		retryable, forced := policy.forcedRetryable()
+		if forced && !retryable && !consumererror.IsPermanent(err) {
+			err = consumererror.NewPermanent(err)
+		}
+		if consumererror.IsPermanent(err) && !(forced && retryable) {
+			return rs.onPermanentFailure(rs.logger, req, err)
 		}
 
 		// Give the request a chance to extract signal data to retry if only some data
 		// failed to process.
 		req = req.OnError(err)
 
-		backoffDelay := expBackoff.NextBackOff()
+		rb, ok := backoffs[policy]
+		if !ok {
+			rb = newRetryBackoff(rs.cfg, policy)
+			backoffs[policy] = rb
+		}
+		backoffDelay := rb.nextBackOff(start)
 		if backoffDelay == backoff.Stop {
 			// throw away the batch
 			err = fmt.Errorf("max elapsed time expired %w", err)
 			return rs.onTemporaryFailure(rs.logger, req, err)
 		}
 
+		// While the circuit is open the next attempt waits for the circuit breaker instead of the backoff.
+		if rs.breaker != nil && rs.breaker.isOpen() {
+			select {
+			case <-rs.stopCh:
+				return fmt.Errorf("interrupted due to shutdown %w", err)
+			default:
+			}
+			span.AddEvent(
+				"Exporting failed. Will retry the request once the circuit breaker lets it through.",
+				trace.WithAttributes(rs.traceAttribute, attribute.String("error", err.Error())))
+			retryNum++
+			continue
+		}
+
 		throttleErr := throttleRetry{}
 		isThrottle := errors.As(err, &throttleErr)
 		if isThrottle {
@@ -441,6 +636,33 @@ func (rs *retrySender) send(req internal.Request) error {
 	}
 }
 
+// onPartialSuccess logs the items rejected by the destination. The error is returned so that the rejected items
+// are reported as failed by the observability sender.
+func (rs *retrySender) onPartialSuccess(err error) error {
+	var partialErr exportererror.PartialExportError
+	_ = errors.As(err, &partialErr)
+	rs.logger.Warn(
+		"Exporting partially succeeded. Some items were rejected by the destination and will not be retried.",
+		zap.Error(err),
+		zap.Int("rejected_items", partialErr.Rejected),
+	)
+	return err
+}
+
+// sendAttempt sends the request once, waiting first for the circuit breaker if enabled.
+func (rs *retrySender) sendAttempt(req internal.Request) error {
+	if rs.breaker == nil {
+		return rs.nextSender.send(req)
+	}
+	probe, err := rs.breaker.acquire(req.Context(), rs.stopCh)
+	if err != nil {
+		return err
+	}
+	err = rs.nextSender.send(req)
+	rs.breaker.record(probe, err)
+	return err
+}
+
 // max returns the larger of x or y.
 func max(x, y time.Duration) time.Duration {
 	if x < y {
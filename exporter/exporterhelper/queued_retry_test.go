@@ -308,7 +308,11 @@ func TestQueuedRetry_DropOnFull(t *testing.T) {
 		assert.NoError(t, be.Shutdown(context.Background()))
 	})
 	ocs.run(func() {
-		require.Error(t, be.sender.send(newMockRequest(context.Background(), 2, errors.New("transient error"))))
+		err := be.sender.send(newMockRequest(context.Background(), 2, errors.New("transient error")))
+		require.ErrorIs(t, err, errSendingQueueIsFull)
+		// The callers are told to send the data again later.
+		assert.True(t, consumererror.IsRetryable(err))
+		assert.Equal(t, rCfg.InitialInterval, consumererror.RetryAfter(err))
 	})
 }
 
@@ -389,6 +393,15 @@ func TestQueueSettings_Validate(t *testing.T) {
 	qCfg := NewDefaultQueueSettings()
 	assert.NoError(t, qCfg.Validate())
 
+	storageID := component.NewID("file_storage")
+	qCfg.StorageID = &storageID
+	qCfg.SpillStorageID = &storageID
+	assert.EqualError(t, qCfg.Validate(), "storage and spill_storage cannot be both set")
+
+	qCfg.StorageID = nil
+	qCfg.SpillHighWatermark = qCfg.QueueSize + 1
+	assert.EqualError(t, qCfg.Validate(), "spill high watermark must be between 0 and the queue size")
+
 	qCfg.QueueSize = 0
 	assert.EqualError(t, qCfg.Validate(), "queue size must be positive")
 
@@ -559,6 +572,36 @@ func TestQueuedRetryPersistenceEnabled(t *testing.T) {
 	require.NoError(t, be.Shutdown(context.Background()))
 }
 
+func TestQueuedRetrySpillEnabled(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	host := &mockHost{ext: map[component.ID]component.Component{storageID: newMapStorageExtension()}}
+
+	qCfg := NewDefaultQueueSettings()
+	qCfg.QueueSize = 10
+	qCfg.SpillStorageID = &storageID
+	qCfg.SpillHighWatermark = 2
+	// Without consumers the requests stay in the queue until shutdown.
+	qCfg.NumConsumers = 0
+	sink := &tracesSink{}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(i)))
+	}
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Empty(t, sink.batchSizes())
+
+	// The requests are restored in order on the next start.
+	qCfg.NumConsumers = 1
+	te, err = NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), host))
+	assert.Eventually(t, func() bool { return len(sink.batchSizes()) == 4 }, time.Second, time.Millisecond)
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Equal(t, []int{1, 2, 3, 4}, sink.batchSizes())
+}
+
 func TestQueuedRetryPersistenceEnabledStorageError(t *testing.T) {
 	storageError := errors.New("could not get storage client")
 	tt, err := obsreporttest.SetupTelemetry(defaultID)
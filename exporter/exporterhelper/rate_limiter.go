@@ -0,0 +1,183 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+)
+
+// RateLimiterSettings defines configuration for limiting the rate at which requests are sent to the backend.
+// The limits use token buckets: the tokens are refilled at the configured rate, up to the burst size, and every
+// attempt to send a request takes as many tokens as its number of items and its size in bytes.
+type RateLimiterSettings struct {
+	// Enabled indicates whether to limit the rate at which requests are sent.
+	Enabled bool `mapstructure:"enabled"`
+	// ItemsPerSecond is the number of spans, metric points or log records that can be sent per second, 0 means no limit.
+	ItemsPerSecond float64 `mapstructure:"items_per_second"`
+	// BytesPerSecond is the size of the OTLP encoded requests that can be sent per second, 0 means no limit.
+	BytesPerSecond float64 `mapstructure:"bytes_per_second"`
+	// BurstItems is the number of items that can be sent at once after an idle period.
+	// If 0, defaults to ItemsPerSecond.
+	BurstItems int `mapstructure:"burst_items"`
+	// BurstBytes is the size of the requests that can be sent at once after an idle period.
+	// If 0, defaults to BytesPerSecond.
+	BurstBytes int `mapstructure:"burst_bytes"`
+}
+
+// Validate checks if the RateLimiterSettings configuration is valid
+func (rlCfg *RateLimiterSettings) Validate() error {
+	if !rlCfg.Enabled {
+		return nil
+	}
+
+	if rlCfg.ItemsPerSecond < 0 || rlCfg.BytesPerSecond < 0 || rlCfg.BurstItems < 0 || rlCfg.BurstBytes < 0 {
+		return errors.New("rate limits must not be negative")
+	}
+	if rlCfg.ItemsPerSecond == 0 && rlCfg.BytesPerSecond == 0 {
+		return errors.New("at least one of items_per_second or bytes_per_second must be set")
+	}
+
+	return nil
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate tokens per second.
+// The tokens can go negative to let through requests larger than the burst, the next requests wait for the debt.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	b := float64(burst)
+	if b <= 0 {
+		b = rate
+	}
+	return &tokenBucket{rate: rate, burst: b, tokens: b, last: now}
+}
+
+// delay returns the time to wait until n tokens can be taken.
+func (tb *tokenBucket) delay(now time.Time, n float64) time.Duration {
+	if tb == nil {
+		return 0
+	}
+	tb.refill(now)
+	if missing := n - tb.tokens; missing > 0 {
+		return time.Duration(missing / tb.rate * float64(time.Second))
+	}
+	return 0
+}
+
+func (tb *tokenBucket) take(n float64) {
+	if tb != nil {
+		tb.tokens -= n
+	}
+}
+
+func (tb *tokenBucket) refill(now time.Time) {
+	if now.After(tb.last) {
+		tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+		tb.last = now
+	}
+}
+
+// rateLimiter delays every attempt to send a request until the rate limits allow it, or until the context of the
+// attempt is done.
+type rateLimiter struct {
+	obsrep *obsExporter
+
+	mu    sync.Mutex
+	items *tokenBucket
+	bytes *tokenBucket
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func newRateLimiter(cfg RateLimiterSettings, obsrep *obsExporter) *rateLimiter {
+	now := time.Now()
+	return &rateLimiter{
+		obsrep: obsrep,
+		items:  newTokenBucket(cfg.ItemsPerSecond, cfg.BurstItems, now),
+		bytes:  newTokenBucket(cfg.BytesPerSecond, cfg.BurstBytes, now),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// wait waits until the request can be sent, the context is the one of the attempt, holding its timeout.
+func (rls *rateLimiter) wait(ctx context.Context, req internal.Request) error {
+	items := float64(req.Count())
+	var bytes float64
+	if rls.bytes != nil {
+		if br, ok := req.(interface{ bytesSize() int }); ok {
+			bytes = float64(br.bytesSize())
+		}
+	}
+
+	rls.mu.Lock()
+	now := time.Now()
+	wait := rls.items.delay(now, items)
+	if bytesWait := rls.bytes.delay(now, bytes); bytesWait > wait {
+		wait = bytesWait
+	}
+	// Take the tokens right away, so the concurrent requests wait for their turn after this one.
+	rls.items.take(items)
+	rls.bytes.take(bytes)
+	rls.mu.Unlock()
+
+	if wait > 0 {
+		rls.obsrep.RecordThrottle(ctx, wait)
+		span := trace.SpanFromContext(ctx)
+		span.AddEvent("Waiting for the rate limiter.", trace.WithAttributes(
+			attribute.String("wait", wait.String())))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			rls.mu.Lock()
+			rls.items.take(-items)
+			rls.bytes.take(-bytes)
+			rls.mu.Unlock()
+			return fmt.Errorf("request is cancelled or timed out while waiting for the rate limiter: %w", ctx.Err())
+		case <-rls.stopCh:
+			// Do not hold back the requests drained during shutdown.
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// shutdown releases the waiting requests.
+func (rls *rateLimiter) shutdown() {
+	rls.stopOnce.Do(func() { close(rls.stopCh) })
+}
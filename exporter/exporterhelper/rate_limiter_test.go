@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/obsreport/obsreporttest"
+)
+
+func TestRateLimiterSettings_Validate(t *testing.T) {
+	rlCfg := RateLimiterSettings{Enabled: true}
+	assert.EqualError(t, rlCfg.Validate(), "at least one of items_per_second or bytes_per_second must be set")
+
+	rlCfg = RateLimiterSettings{Enabled: true, ItemsPerSecond: 10, BurstBytes: -1}
+	assert.EqualError(t, rlCfg.Validate(), "rate limits must not be negative")
+
+	rlCfg = RateLimiterSettings{Enabled: true, BytesPerSecond: 1024}
+	assert.NoError(t, rlCfg.Validate())
+
+	rlCfg = RateLimiterSettings{}
+	assert.NoError(t, rlCfg.Validate())
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	assert.Nil(t, newTokenBucket(0, 10, now))
+
+	tb := newTokenBucket(10, 20, now)
+	assert.Equal(t, time.Duration(0), tb.delay(now, 20))
+	tb.take(20)
+	assert.Equal(t, 500*time.Millisecond, tb.delay(now, 5))
+
+	// Refilled at the rate, up to the burst.
+	assert.Equal(t, time.Duration(0), tb.delay(now.Add(500*time.Millisecond), 5))
+	assert.Equal(t, time.Duration(0), tb.delay(now.Add(time.Hour), 20))
+	assert.Equal(t, 100*time.Millisecond, tb.delay(now.Add(time.Hour), 21))
+
+	// Requests larger than the burst are let through, the next ones wait for the debt.
+	tb.take(50)
+	assert.Equal(t, 3*time.Second+100*time.Millisecond, tb.delay(now.Add(time.Hour), 1))
+
+	// The burst defaults to the rate.
+	tb = newTokenBucket(10, 0, now)
+	assert.Equal(t, 100*time.Millisecond, tb.delay(now, 11))
+}
+
+func TestRateLimiter_WaitsForTokens(t *testing.T) {
+	sink := &tracesSink{}
+	rlCfg := RateLimiterSettings{Enabled: true, ItemsPerSecond: 100, BurstItems: 10}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithRateLimiter(rlCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	start := time.Now()
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(10)))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(10)))
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, []int{10, 10}, sink.batchSizes())
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestRateLimiter_LimitsBytes(t *testing.T) {
+	sink := &tracesSink{}
+	td := testdata.GenerateTraces(5)
+	size := tracesMarshaler.TracesSize(td)
+	rlCfg := RateLimiterSettings{Enabled: true, BytesPerSecond: float64(size) * 10, BurstBytes: size}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithRateLimiter(rlCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	start := time.Now()
+	require.NoError(t, te.ConsumeTraces(context.Background(), td))
+	require.NoError(t, te.ConsumeTraces(context.Background(), td))
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestRateLimiter_WaitsUntilDeadline(t *testing.T) {
+	tt, err := obsreporttest.SetupTelemetry(fakeTracesExporterName)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+	sink := &tracesSink{}
+	rlCfg := RateLimiterSettings{Enabled: true, ItemsPerSecond: 1}
+	te, err := NewTracesExporter(context.Background(), tt.ToExporterCreateSettings(), &fakeTracesExporterConfig, sink.push,
+		WithRateLimiter(rlCfg), WithRetry(RetrySettings{}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, te.ConsumeTraces(ctx, testdata.GenerateTraces(10)), context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Empty(t, sink.batchSizes())
+	require.NoError(t, te.Shutdown(context.Background()))
+
+	// The burst defaults to a single item, so the request would need to wait for 9 more.
+	require.NoError(t, tt.CheckExporterThrottle(1, 9000))
+}
+
+func TestRateLimiter_WaitsWithinTimeout(t *testing.T) {
+	sink := &tracesSink{}
+	rlCfg := RateLimiterSettings{Enabled: true, ItemsPerSecond: 1}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push,
+		WithRateLimiter(rlCfg), WithTimeout(TimeoutSettings{Timeout: 50 * time.Millisecond}), WithRetry(RetrySettings{}))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	// The request context has no deadline, the wait is bounded by the timeout of the attempt.
+	start := time.Now()
+	assert.ErrorIs(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(10)), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Empty(t, sink.batchSizes())
+	require.NoError(t, te.Shutdown(context.Background()))
+}
+
+func TestRateLimiter_ReleasedOnShutdown(t *testing.T) {
+	sink := &tracesSink{}
+	qCfg := NewDefaultQueueSettings()
+	qCfg.NumConsumers = 1
+	rlCfg := RateLimiterSettings{Enabled: true, ItemsPerSecond: 1, BurstItems: 2}
+	te, err := NewTracesExporter(context.Background(), defaultSettings, &fakeTracesExporterConfig, sink.push, WithRateLimiter(rlCfg), WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, te.Start(context.Background(), componenttest.NewNopHost()))
+
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	require.NoError(t, te.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	assert.Eventually(t, func() bool { return len(sink.batchSizes()) == 1 }, time.Second, time.Millisecond)
+
+	// The second request waits for the rate limiter, it is sent right away on shutdown.
+	start := time.Now()
+	require.NoError(t, te.Shutdown(context.Background()))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []int{2, 2}, sink.batchSizes())
+}
@@ -0,0 +1,241 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy overrides the retry behavior for the failures with the given gRPC codes or HTTP statuses.
+// The exporters report the code of a failure by wrapping the error with NewGRPCStatusError or NewHTTPStatusError.
+type RetryPolicy struct {
+	// GRPCCodes are the gRPC status codes the policy applies to, by name, e.g. `RESOURCE_EXHAUSTED`.
+	GRPCCodes []string `mapstructure:"grpc_codes"`
+	// HTTPStatuses are the HTTP status codes the policy applies to, e.g. `429`, or status classes like `5xx`.
+	HTTPStatuses []string `mapstructure:"http_statuses"`
+	// Retryable forces whether the matching failures are retried. When not set the exporter decides.
+	Retryable *bool `mapstructure:"retryable"`
+	// InitialInterval overrides RetrySettings.InitialInterval for the matching failures when not 0.
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	// MaxInterval overrides RetrySettings.MaxInterval for the matching failures when not 0.
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+	// MaxElapsedTime overrides RetrySettings.MaxElapsedTime for the matching failures when not 0.
+	// It is measured from the first attempt to send the request.
+	MaxElapsedTime time.Duration `mapstructure:"max_elapsed_time"`
+}
+
+// Validate checks if the RetryPolicy configuration is valid
+func (p *RetryPolicy) Validate() error {
+	_, err := p.compile()
+	return err
+}
+
+func (p *RetryPolicy) compile() (*retryPolicy, error) {
+	if len(p.GRPCCodes) == 0 && len(p.HTTPStatuses) == 0 {
+		return nil, errors.New("retry policy must set at least one of grpc_codes or http_statuses")
+	}
+	if p.InitialInterval < 0 || p.MaxInterval < 0 || p.MaxElapsedTime < 0 {
+		return nil, errors.New("retry policy intervals must not be negative")
+	}
+
+	rp := &retryPolicy{
+		grpcCodes: make(map[codes.Code]bool, len(p.GRPCCodes)),
+		retryable: p.Retryable,
+	}
+	for _, name := range p.GRPCCodes {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err != nil {
+			return nil, fmt.Errorf("invalid gRPC code %q in retry policy", name)
+		}
+		rp.grpcCodes[code] = true
+	}
+	for _, s := range p.HTTPStatuses {
+		m, err := parseHTTPStatusMatch(s)
+		if err != nil {
+			return nil, err
+		}
+		rp.httpStatuses = append(rp.httpStatuses, m)
+	}
+	rp.initialInterval = p.InitialInterval
+	rp.maxInterval = p.MaxInterval
+	rp.maxElapsedTime = p.MaxElapsedTime
+	return rp, nil
+}
+
+// httpStatusMatch matches a single status when class is false, or the statuses from code to code+99 otherwise.
+type httpStatusMatch struct {
+	code  int
+	class bool
+}
+
+func parseHTTPStatusMatch(s string) (httpStatusMatch, error) {
+	s = strings.ToLower(s)
+	if len(s) == 3 && s[1:] == "xx" && s[0] >= '1' && s[0] <= '5' {
+		return httpStatusMatch{code: int(s[0]-'0') * 100, class: true}, nil
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return httpStatusMatch{}, fmt.Errorf("invalid HTTP status %q in retry policy", s)
+	}
+	return httpStatusMatch{code: code}, nil
+}
+
+func (m httpStatusMatch) matches(status int) bool {
+	if m.class {
+		return status >= m.code && status < m.code+100
+	}
+	return status == m.code
+}
+
+type retryPolicy struct {
+	grpcCodes       map[codes.Code]bool
+	httpStatuses    []httpStatusMatch
+	retryable       *bool
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	maxElapsedTime  time.Duration
+}
+
+func (rp *retryPolicy) matches(se statusError) bool {
+	if se.grpc {
+		return rp.grpcCodes[se.grpcCode]
+	}
+	for _, m := range rp.httpStatuses {
+		if m.matches(se.httpStatus) {
+			return true
+		}
+	}
+	return false
+}
+
+// forcedRetryable returns whether the policy forces the matching failures to be retried or not, ok is false if the
+// exporter decides. The policy is nil for the failures no policy matches.
+func (rp *retryPolicy) forcedRetryable() (retryable bool, ok bool) {
+	if rp == nil || rp.retryable == nil {
+		return false, false
+	}
+	return *rp.retryable, true
+}
+
+// retryBackoff is the backoff of a retry policy for a single request, created on the first failure it matches.
+type retryBackoff struct {
+	backoff        *backoff.ExponentialBackOff
+	maxElapsedTime time.Duration
+}
+
+// newRetryBackoff creates the backoff for the policy, with the RetrySettings values where the policy sets none.
+// The policy is nil for the failures no policy matches.
+func newRetryBackoff(cfg RetrySettings, rp *retryPolicy) *retryBackoff {
+	rb := &retryBackoff{maxElapsedTime: cfg.MaxElapsedTime}
+	// Do not use NewExponentialBackOff since it calls Reset and the code here must
+	// call Reset after changing the InitialInterval (this saves an unnecessary call to Now).
+	expBackoff := &backoff.ExponentialBackOff{
+		InitialInterval:     cfg.InitialInterval,
+		RandomizationFactor: backoff.DefaultRandomizationFactor,
+		Multiplier:          backoff.DefaultMultiplier,
+		MaxInterval:         cfg.MaxInterval,
+		// The elapsed time is checked against the start of the request instead, see nextBackOff.
+		MaxElapsedTime: 0,
+		Stop:           backoff.Stop,
+		Clock:          backoff.SystemClock,
+	}
+	if rp != nil {
+		if rp.initialInterval != 0 {
+			expBackoff.InitialInterval = rp.initialInterval
+		}
+		if rp.maxInterval != 0 {
+			expBackoff.MaxInterval = rp.maxInterval
+		}
+		if rp.maxElapsedTime != 0 {
+			rb.maxElapsedTime = rp.maxElapsedTime
+		}
+	}
+	expBackoff.Reset()
+	rb.backoff = expBackoff
+	return rb
+}
+
+// nextBackOff returns the delay before the next attempt, or backoff.Stop if the attempt would start after the
+// maximum elapsed time since the request was first sent.
+func (rb *retryBackoff) nextBackOff(start time.Time) time.Duration {
+	delay := rb.backoff.NextBackOff()
+	if rb.maxElapsedTime != 0 && time.Since(start)+delay > rb.maxElapsedTime {
+		return backoff.Stop
+	}
+	return delay
+}
+
+// statusError carries the gRPC code or HTTP status an exporter received, to select the retry policy.
+type statusError struct {
+	err        error
+	grpc       bool
+	grpcCode   codes.Code
+	httpStatus int
+}
+
+func (s statusError) Error() string {
+	return s.err.Error()
+}
+
+func (s statusError) Unwrap() error {
+	return s.err
+}
+
+// NewGRPCStatusError wraps an export error with the gRPC status code returned by the backend,
+// so that the retry policies configured for the code apply to it.
+func NewGRPCStatusError(err error, code codes.Code) error {
+	return statusError{err: err, grpc: true, grpcCode: code}
+}
+
+// NewHTTPStatusError wraps an export error with the HTTP status code returned by the backend,
+// so that the retry policies configured for the status apply to it.
+func NewHTTPStatusError(err error, status int) error {
+	return statusError{err: err, httpStatus: status}
+}
+
+// retryPolicies selects the policy of a failure, the first configured policy matching it wins.
+type retryPolicies []*retryPolicy
+
+func newRetryPolicies(cfg RetrySettings) (retryPolicies, error) {
+	var rps retryPolicies
+	for i := range cfg.Policies {
+		rp, err := cfg.Policies[i].compile()
+		if err != nil {
+			return nil, err
+		}
+		rps = append(rps, rp)
+	}
+	return rps, nil
+}
+
+func (rps retryPolicies) match(err error) *retryPolicy {
+	se := statusError{}
+	if len(rps) == 0 || !errors.As(err, &se) {
+		return nil
+	}
+	for _, rp := range rps {
+		if rp.matches(se) {
+			return rp
+		}
+	}
+	return nil
+}
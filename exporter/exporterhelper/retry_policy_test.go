@@ -0,0 +1,165 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporterhelper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy RetryPolicy
+		err    string
+	}{
+		{name: "grpc codes", policy: RetryPolicy{GRPCCodes: []string{"RESOURCE_EXHAUSTED", "data_loss"}}},
+		{name: "http statuses", policy: RetryPolicy{HTTPStatuses: []string{"429", "5xx"}}},
+		{name: "no codes", policy: RetryPolicy{}, err: "retry policy must set at least one of grpc_codes or http_statuses"},
+		{name: "unknown grpc code", policy: RetryPolicy{GRPCCodes: []string{"NOT_A_CODE"}}, err: `invalid gRPC code "NOT_A_CODE" in retry policy`},
+		{name: "invalid http status", policy: RetryPolicy{HTTPStatuses: []string{"600"}}, err: `invalid HTTP status "600" in retry policy`},
+		{name: "invalid http class", policy: RetryPolicy{HTTPStatuses: []string{"6xx"}}, err: `invalid HTTP status "6xx" in retry policy`},
+		{name: "negative interval", policy: RetryPolicy{HTTPStatuses: []string{"503"}, MaxInterval: -time.Second}, err: "retry policy intervals must not be negative"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.policy.Validate()
+			if c.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicies_Match(t *testing.T) {
+	rCfg := NewDefaultRetrySettings()
+	rCfg.Policies = []RetryPolicy{
+		{GRPCCodes: []string{"UNAVAILABLE"}, HTTPStatuses: []string{"503"}},
+		{HTTPStatuses: []string{"5xx"}},
+	}
+	rps, err := newRetryPolicies(rCfg)
+	require.NoError(t, err)
+
+	err = errors.New("export failed")
+	assert.Same(t, rps[0], rps.match(NewGRPCStatusError(err, codes.Unavailable)))
+	assert.Same(t, rps[0], rps.match(NewThrottleRetry(NewHTTPStatusError(err, 503), time.Second)))
+	assert.Same(t, rps[1], rps.match(consumererror.NewPermanent(NewHTTPStatusError(err, 500))))
+	assert.Nil(t, rps.match(NewGRPCStatusError(err, codes.Internal)))
+	assert.Nil(t, rps.match(NewHTTPStatusError(err, 400)))
+	assert.Nil(t, rps.match(err))
+	assert.Equal(t, "export failed", NewGRPCStatusError(err, codes.Unavailable).Error())
+}
+
+func newRetryPolicyExporter(t *testing.T, rCfg RetrySettings) (*baseExporter, *observabilityConsumerSender) {
+	qCfg := NewDefaultQueueSettings()
+	qCfg.NumConsumers = 1
+	be, err := newBaseExporter(defaultSettings, fromOptions(WithRetry(rCfg), WithQueue(qCfg)), "", nopRequestUnmarshaler())
+	require.NoError(t, err)
+	ocs := newObservabilityConsumerSender(be.qrSender.consumerSender)
+	be.qrSender.consumerSender = ocs
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, be.Shutdown(context.Background()))
+	})
+	return be, ocs
+}
+
+func TestQueuedRetry_InvalidRetryPolicy(t *testing.T) {
+	rCfg := NewDefaultRetrySettings()
+	rCfg.Policies = []RetryPolicy{{GRPCCodes: []string{"NOT_A_CODE"}}}
+	_, err := newBaseExporter(defaultSettings, fromOptions(WithRetry(rCfg)), "", nopRequestUnmarshaler())
+	assert.EqualError(t, err, `invalid gRPC code "NOT_A_CODE" in retry policy`)
+}
+
+func TestQueuedRetry_RetryPolicyNotRetryable(t *testing.T) {
+	retryable := false
+	rCfg := NewDefaultRetrySettings()
+	rCfg.InitialInterval = 0
+	rCfg.Policies = []RetryPolicy{{GRPCCodes: []string{"DATA_LOSS"}, Retryable: &retryable}}
+	be, ocs := newRetryPolicyExporter(t, rCfg)
+
+	mockR := newMockRequest(context.Background(), 2, NewGRPCStatusError(errors.New("data loss"), codes.DataLoss))
+	ocs.run(func() {
+		// This is asynchronous so it should just enqueue, no errors expected.
+		require.NoError(t, be.sender.send(mockR))
+	})
+	ocs.awaitAsyncProcessing()
+
+	mockR.checkNumRequests(t, 1)
+	ocs.checkSendItemsCount(t, 0)
+	ocs.checkDroppedItemsCount(t, 2)
+}
+
+func TestQueuedRetry_RetryPolicyRetryable(t *testing.T) {
+	retryable := true
+	rCfg := NewDefaultRetrySettings()
+	rCfg.InitialInterval = 0
+	rCfg.Policies = []RetryPolicy{{HTTPStatuses: []string{"4xx"}, Retryable: &retryable}}
+	be, ocs := newRetryPolicyExporter(t, rCfg)
+
+	// The exporter reports the failure as permanent, the policy retries it anyway.
+	mockR := newMockRequest(context.Background(), 2, consumererror.NewPermanent(NewHTTPStatusError(errors.New("conflict"), 409)))
+	ocs.run(func() {
+		require.NoError(t, be.sender.send(mockR))
+	})
+	ocs.awaitAsyncProcessing()
+
+	mockR.checkNumRequests(t, 2)
+	ocs.checkSendItemsCount(t, 2)
+	ocs.checkDroppedItemsCount(t, 0)
+}
+
+func TestQueuedRetry_RetryPolicyBackoff(t *testing.T) {
+	rCfg := NewDefaultRetrySettings()
+	rCfg.InitialInterval = time.Millisecond
+	rCfg.Policies = []RetryPolicy{
+		{GRPCCodes: []string{"RESOURCE_EXHAUSTED"}, InitialInterval: 200 * time.Millisecond},
+		{GRPCCodes: []string{"UNAVAILABLE"}, MaxElapsedTime: time.Nanosecond},
+	}
+	be, ocs := newRetryPolicyExporter(t, rCfg)
+
+	mockR := newMockRequest(context.Background(), 2, NewGRPCStatusError(errors.New("slow down"), codes.ResourceExhausted))
+	start := time.Now()
+	ocs.run(func() {
+		require.NoError(t, be.sender.send(mockR))
+	})
+	ocs.awaitAsyncProcessing()
+
+	// The backoff of the policy is randomized by up to 50%.
+	assert.Less(t, 100*time.Millisecond, time.Since(start))
+	mockR.checkNumRequests(t, 2)
+	ocs.checkSendItemsCount(t, 2)
+
+	// The maximum elapsed time of the policy expires before the first retry.
+	mockR = newMockRequest(context.Background(), 2, NewGRPCStatusError(errors.New("unavailable"), codes.Unavailable))
+	ocs.run(func() {
+		require.NoError(t, be.sender.send(mockR))
+	})
+	ocs.awaitAsyncProcessing()
+
+	mockR.checkNumRequests(t, 1)
+	ocs.checkDroppedItemsCount(t, 2)
+}
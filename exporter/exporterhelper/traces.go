@@ -17,12 +17,15 @@ package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporte
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
@@ -74,6 +77,46 @@ func (req *tracesRequest) Count() int {
 	return req.td.SpanCount()
 }
 
+func (req *tracesRequest) clone() batchableRequest {
+	td := ptrace.NewTraces()
+	req.td.CopyTo(td)
+	return &tracesRequest{baseRequest: baseRequest{ctx: req.ctx}, td: td, pusher: req.pusher}
+}
+
+func (req *tracesRequest) bytesSize() int {
+	return tracesMarshaler.TracesSize(req.td)
+}
+
+func (req *tracesRequest) splitOff(size int) batchableRequest {
+	return &tracesRequest{baseRequest: baseRequest{ctx: req.ctx}, td: splitTraces(size, req.td), pusher: req.pusher}
+}
+
+func (req *tracesRequest) moveTo(dest batchableRequest) {
+	req.td.ResourceSpans().MoveAndAppendTo(dest.(*tracesRequest).td.ResourceSpans())
+}
+
+func (req *tracesRequest) anyResource(fn func(pcommon.Resource) bool) bool {
+	for i := 0; i < req.td.ResourceSpans().Len(); i++ {
+		if fn(req.td.ResourceSpans().At(i).Resource()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (req *tracesRequest) forwardTo(ctx context.Context, exp component.Component, errMsg string, ts time.Time) error {
+	next, ok := exp.(consumer.Traces)
+	if !ok {
+		return fmt.Errorf("exporter of type %T does not accept traces", exp)
+	}
+	td := ptrace.NewTraces()
+	req.td.CopyTo(td)
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		setDeadLetterAttributes(td.ResourceSpans().At(i).Resource().Attributes(), errMsg, ts)
+	}
+	return next.ConsumeTraces(ctx, td)
+}
+
 type traceExporter struct {
 	*baseExporter
 	consumer.Traces
@@ -136,5 +179,5 @@ func (tewo *tracesExporterWithObservability) send(req internal.Request) error {
 	// Forward the data to the next consumer (this pusher is the next).
 	err := tewo.nextSender.send(req)
 	tewo.obsrep.EndTracesOp(req.Context(), req.Count(), err)
-	return err
+	return dropPartialExportError(err)
 }
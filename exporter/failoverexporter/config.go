@@ -0,0 +1,147 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverexporter // import "go.opentelemetry.io/collector/exporter/failoverexporter"
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+// backendDisabledSettings are the exporter helper settings always disabled on the backends, since the failover
+// exporter could not see their failures in time.
+var backendDisabledSettings = []string{"sending_queue", "retry_on_failure"}
+
+// Config defines configuration for the failover exporter.
+type Config struct {
+	exporterhelper.TimeoutSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct.
+	exporterhelper.QueueSettings   `mapstructure:"sending_queue"`
+	exporterhelper.RetrySettings   `mapstructure:"retry_on_failure"`
+
+	// Exporters are the backends, in order of preference. The data is sent to a single one of them.
+	Exporters []ExporterSettings `mapstructure:"exporters"`
+
+	// FailureThreshold is the number of consecutive failures of the backend in use after which the next one is used.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+
+	// RetryInterval is how often a request is sent to the primary backend while another one is used,
+	// the primary backend is used again as soon as one of these requests succeeds.
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+}
+
+// ExporterSettings defines a backend of the failover exporter.
+type ExporterSettings struct {
+	// ID is the component ID of the backend, its type selects the exporter factory used to create it.
+	ID component.ID `mapstructure:"id"`
+
+	// Config is the configuration of the backend, the factory defaults apply to the settings it does not set.
+	// The sending queue and the retries of the backend are always disabled.
+	Config map[string]interface{} `mapstructure:"config"`
+
+	// factory and cfg are the factory of the backend and its decoded configuration, once resolved.
+	factory exporter.Factory
+	cfg     component.Config
+}
+
+var _ exporter.NestedExportersConfig = (*Config)(nil)
+
+// ResolveExporters decodes the configurations of the backends with their factories, it is called by the collector
+// once its configuration is unmarshaled so that the backend configurations are validated with the failover exporter.
+func (cfg *Config) ResolveExporters(factories map[component.Type]exporter.Factory) error {
+	for i := range cfg.Exporters {
+		exp := &cfg.Exporters[i]
+		factory, ok := factories[exp.ID.Type()]
+		if !ok {
+			return fmt.Errorf("exporter factory not available for %q", exp.ID)
+		}
+		if err := exp.resolve(factory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve decodes the configuration of the backend on top of the factory defaults, with the sending queue and the
+// retries disabled if the exporter has them: they are enabled by default by most exporters, but a backend with a
+// queue accepts every request while the queue is not full, and one retrying its requests only reports a failure
+// once it gives up, so the failover would not happen in time.
+func (exp *ExporterSettings) resolve(factory exporter.Factory) error {
+	cfg := factory.CreateDefaultConfig()
+	defaults := confmap.New()
+	if err := defaults.Marshal(cfg); err != nil {
+		return fmt.Errorf("error reading default configuration for exporter %q: %w", exp.ID, err)
+	}
+	conf := confmap.NewFromStringMap(exp.Config)
+	for _, key := range backendDisabledSettings {
+		enabledKey := key + confmap.KeyDelimiter + "enabled"
+		if defaults.IsSet(enabledKey) && !conf.IsSet(enabledKey) {
+			disabled := confmap.NewFromStringMap(map[string]interface{}{key: map[string]interface{}{"enabled": false}})
+			if err := conf.Merge(disabled); err != nil {
+				return err
+			}
+		}
+	}
+	if err := component.UnmarshalConfig(conf, cfg); err != nil {
+		return fmt.Errorf("error reading configuration for exporter %q: %w", exp.ID, err)
+	}
+	exp.factory, exp.cfg = factory, cfg
+	return nil
+}
+
+// Validate checks if the exporter configuration is valid
+func (cfg *Config) Validate() error {
+	if err := cfg.QueueSettings.Validate(); err != nil {
+		return fmt.Errorf("queue settings has invalid configuration: %w", err)
+	}
+	if len(cfg.Exporters) < 2 {
+		return errors.New("at least two exporters must be specified")
+	}
+	ids := make(map[component.ID]bool, len(cfg.Exporters))
+	for _, exp := range cfg.Exporters {
+		if exp.ID.Type() == "" {
+			return errors.New("exporter id must be specified")
+		}
+		if exp.ID.Type() == typeStr {
+			return fmt.Errorf("exporter %q cannot be a failover exporter", exp.ID)
+		}
+		if ids[exp.ID] {
+			return fmt.Errorf("duplicate exporter %q", exp.ID)
+		}
+		ids[exp.ID] = true
+		conf := confmap.NewFromStringMap(exp.Config)
+		for _, key := range backendDisabledSettings {
+			if enabled, _ := conf.Get(key + confmap.KeyDelimiter + "enabled").(bool); enabled {
+				return fmt.Errorf("exporter %q cannot enable %s, the requests are queued and retried by the failover exporter", exp.ID, key)
+			}
+		}
+		if exp.cfg != nil {
+			if err := component.ValidateConfig(exp.cfg); err != nil {
+				return fmt.Errorf("invalid configuration for exporter %q: %w", exp.ID, err)
+			}
+		}
+	}
+	if cfg.FailureThreshold <= 0 {
+		return errors.New("failure threshold must be positive")
+	}
+	if cfg.RetryInterval <= 0 {
+		return errors.New("retry interval must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverexporter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+func TestUnmarshalDefaultConfig(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	assert.NoError(t, component.UnmarshalConfig(confmap.New(), cfg))
+	assert.Equal(t, factory.CreateDefaultConfig(), cfg)
+	// Default/Empty config is invalid.
+	assert.Error(t, component.ValidateConfig(cfg))
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, err)
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	assert.NoError(t, component.UnmarshalConfig(cm, cfg))
+	assert.Equal(t,
+		&Config{
+			TimeoutSettings: exporterhelper.TimeoutSettings{
+				Timeout: 10 * time.Second,
+			},
+			RetrySettings: exporterhelper.RetrySettings{
+				Enabled:         true,
+				InitialInterval: 10 * time.Second,
+				MaxInterval:     1 * time.Minute,
+				MaxElapsedTime:  10 * time.Minute,
+			},
+			QueueSettings: exporterhelper.QueueSettings{
+				Enabled:      true,
+				NumConsumers: 2,
+				QueueSize:    10,
+			},
+			FailureThreshold: 5,
+			RetryInterval:    30 * time.Second,
+			Exporters: []ExporterSettings{
+				{
+					ID:     component.NewIDWithName("otlp", "primary"),
+					Config: map[string]interface{}{"endpoint": "primary.example.com:4317"},
+				},
+				{
+					ID: component.NewIDWithName("otlp", "standby"),
+					Config: map[string]interface{}{
+						"endpoint":      "standby.example.com:4317",
+						"sending_queue": map[string]interface{}{"enabled": false},
+					},
+				},
+			},
+		}, cfg)
+	assert.NoError(t, component.ValidateConfig(cfg))
+}
+
+func TestValidateConfig(t *testing.T) {
+	primary := ExporterSettings{ID: component.NewIDWithName("otlp", "primary")}
+	standby := ExporterSettings{ID: component.NewIDWithName("otlp", "standby")}
+	cases := []struct {
+		name   string
+		modify func(cfg *Config)
+		err    string
+	}{
+		{
+			name:   "single exporter",
+			modify: func(cfg *Config) { cfg.Exporters = cfg.Exporters[:1] },
+			err:    "at least two exporters must be specified",
+		},
+		{
+			name:   "missing id",
+			modify: func(cfg *Config) { cfg.Exporters[1] = ExporterSettings{} },
+			err:    "exporter id must be specified",
+		},
+		{
+			name:   "duplicate exporter",
+			modify: func(cfg *Config) { cfg.Exporters[1] = primary },
+			err:    `duplicate exporter "otlp/primary"`,
+		},
+		{
+			name:   "nested failover",
+			modify: func(cfg *Config) { cfg.Exporters[1] = ExporterSettings{ID: component.NewID(typeStr)} },
+			err:    `exporter "failover" cannot be a failover exporter`,
+		},
+		{
+			name: "backend queue enabled",
+			modify: func(cfg *Config) {
+				cfg.Exporters[1].Config = map[string]interface{}{"sending_queue": map[string]interface{}{"enabled": true}}
+			},
+			err: `exporter "otlp/standby" cannot enable sending_queue, the requests are queued and retried by the failover exporter`,
+		},
+		{
+			name: "backend retries enabled",
+			modify: func(cfg *Config) {
+				cfg.Exporters[0].Config = map[string]interface{}{"retry_on_failure": map[string]interface{}{"enabled": true}}
+			},
+			err: `exporter "otlp/primary" cannot enable retry_on_failure, the requests are queued and retried by the failover exporter`,
+		},
+		{
+			name:   "failure threshold",
+			modify: func(cfg *Config) { cfg.FailureThreshold = 0 },
+			err:    "failure threshold must be positive",
+		},
+		{
+			name:   "retry interval",
+			modify: func(cfg *Config) { cfg.RetryInterval = 0 },
+			err:    "retry interval must be positive",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Exporters = []ExporterSettings{primary, standby}
+			require.NoError(t, cfg.Validate())
+			c.modify(cfg)
+			assert.EqualError(t, cfg.Validate(), c.err)
+		})
+	}
+}
+
+func TestResolveExporters(t *testing.T) {
+	factories := map[component.Type]exporter.Factory{testBackendType: newTestBackendFactory(nil)}
+	cfg := newTestConfig()
+	require.NoError(t, cfg.ResolveExporters(factories))
+	require.NoError(t, cfg.Validate())
+
+	// The backends are decoded with their factory, their sending queue and retries are disabled.
+	primaryCfg := cfg.Exporters[0].cfg.(*testBackendConfig)
+	assert.Equal(t, "primary:4317", primaryCfg.Endpoint)
+	assert.False(t, primaryCfg.QueueSettings.Enabled)
+	assert.False(t, primaryCfg.RetrySettings.Enabled)
+	assert.Equal(t, exporterhelper.NewDefaultQueueSettings().QueueSize, primaryCfg.QueueSize)
+
+	cfg = newTestConfig()
+	cfg.Exporters[1].ID = component.NewID("unknown")
+	assert.EqualError(t, cfg.ResolveExporters(factories), `exporter factory not available for "unknown"`)
+
+	cfg = newTestConfig()
+	cfg.Exporters[1].Config = map[string]interface{}{"unknown_setting": true}
+	assert.ErrorContains(t, cfg.ResolveExporters(factories), `error reading configuration for exporter "test/standby"`)
+
+	// The backend configurations are validated with the failover exporter.
+	cfg = newTestConfig()
+	cfg.Exporters[1].Config = map[string]interface{}{"endpoint": "invalid"}
+	require.NoError(t, cfg.ResolveExporters(factories))
+	assert.EqualError(t, cfg.Validate(), `invalid configuration for exporter "test/standby": invalid endpoint`)
+}
@@ -0,0 +1,105 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverexporter // import "go.opentelemetry.io/collector/exporter/failoverexporter"
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+const (
+	// The value of "type" key in configuration.
+	typeStr = "failover"
+
+	defaultFailureThreshold = 3
+	defaultRetryInterval    = time.Minute
+)
+
+// NewFactory creates a factory for the failover exporter.
+func NewFactory() exporter.Factory {
+	return exporter.NewFactory(
+		typeStr,
+		createDefaultConfig,
+		exporter.WithTraces(createTracesExporter, component.StabilityLevelDevelopment),
+		exporter.WithMetrics(createMetricsExporter, component.StabilityLevelDevelopment),
+		exporter.WithLogs(createLogsExporter, component.StabilityLevelDevelopment),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		TimeoutSettings:  exporterhelper.NewDefaultTimeoutSettings(),
+		RetrySettings:    exporterhelper.NewDefaultRetrySettings(),
+		QueueSettings:    exporterhelper.NewDefaultQueueSettings(),
+		FailureThreshold: defaultFailureThreshold,
+		RetryInterval:    defaultRetryInterval,
+	}
+}
+
+func createTracesExporter(
+	ctx context.Context,
+	set exporter.CreateSettings,
+	cfg component.Config,
+) (exporter.Traces, error) {
+	fCfg := cfg.(*Config)
+	fe := newFailoverExporter(fCfg, set, component.DataTypeTraces)
+	return exporterhelper.NewTracesExporter(ctx, set, cfg,
+		fe.pushTraces,
+		exporterhelper.WithStart(fe.start),
+		exporterhelper.WithShutdown(fe.shutdown),
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+		exporterhelper.WithTimeout(fCfg.TimeoutSettings),
+		exporterhelper.WithRetry(fCfg.RetrySettings),
+		exporterhelper.WithQueue(fCfg.QueueSettings))
+}
+
+func createMetricsExporter(
+	ctx context.Context,
+	set exporter.CreateSettings,
+	cfg component.Config,
+) (exporter.Metrics, error) {
+	fCfg := cfg.(*Config)
+	fe := newFailoverExporter(fCfg, set, component.DataTypeMetrics)
+	return exporterhelper.NewMetricsExporter(ctx, set, cfg,
+		fe.pushMetrics,
+		exporterhelper.WithStart(fe.start),
+		exporterhelper.WithShutdown(fe.shutdown),
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+		exporterhelper.WithTimeout(fCfg.TimeoutSettings),
+		exporterhelper.WithRetry(fCfg.RetrySettings),
+		exporterhelper.WithQueue(fCfg.QueueSettings))
+}
+
+func createLogsExporter(
+	ctx context.Context,
+	set exporter.CreateSettings,
+	cfg component.Config,
+) (exporter.Logs, error) {
+	fCfg := cfg.(*Config)
+	fe := newFailoverExporter(fCfg, set, component.DataTypeLogs)
+	return exporterhelper.NewLogsExporter(ctx, set, cfg,
+		fe.pushLogs,
+		exporterhelper.WithStart(fe.start),
+		exporterhelper.WithShutdown(fe.shutdown),
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+		exporterhelper.WithTimeout(fCfg.TimeoutSettings),
+		exporterhelper.WithRetry(fCfg.RetrySettings),
+		exporterhelper.WithQueue(fCfg.QueueSettings))
+}
@@ -0,0 +1,240 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverexporter // import "go.opentelemetry.io/collector/exporter/failoverexporter"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type backend struct {
+	id  component.ID
+	exp component.Component
+}
+
+// failoverExporter sends each request to the backend in use, starting with the primary one. After FailureThreshold
+// consecutive failures it moves on to the next backend, back to the primary one after the last. While the primary
+// backend is not in use a request is sent to it every RetryInterval, and it is used again if the request succeeds.
+type failoverExporter struct {
+	cfg      *Config
+	set      exporter.CreateSettings
+	dataType component.DataType
+	logger   *zap.Logger
+
+	backends []backend
+
+	mu sync.Mutex
+	// active is the index of the backend in use.
+	active int
+	// failures is the number of consecutive failures of the backend in use.
+	failures int
+	// lastProbe is the time the primary backend was last tried, or the time it stopped being used.
+	lastProbe time.Time
+}
+
+func newFailoverExporter(cfg *Config, set exporter.CreateSettings, dataType component.DataType) *failoverExporter {
+	return &failoverExporter{
+		cfg:      cfg,
+		set:      set,
+		dataType: dataType,
+		logger:   set.Logger,
+	}
+}
+
+// start creates the backends and starts them. The backend configurations not resolved with the configuration of the
+// collector, e.g. when the exporter is created directly by its factory, are resolved with the factories of the host.
+func (fe *failoverExporter) start(ctx context.Context, host component.Host) error {
+	cfgs := make(map[component.ID]component.Config, len(fe.cfg.Exporters))
+	factories := make(map[component.Type]exporter.Factory)
+	for i := range fe.cfg.Exporters {
+		expCfg := &fe.cfg.Exporters[i]
+		if expCfg.cfg == nil {
+			factory, ok := host.GetFactory(component.KindExporter, expCfg.ID.Type()).(exporter.Factory)
+			if !ok {
+				return fmt.Errorf("exporter factory not available for %q", expCfg.ID)
+			}
+			if err := expCfg.resolve(factory); err != nil {
+				return err
+			}
+			if err := component.ValidateConfig(expCfg.cfg); err != nil {
+				return fmt.Errorf("invalid configuration for exporter %q: %w", expCfg.ID, err)
+			}
+		}
+		cfgs[expCfg.ID] = expCfg.cfg
+		factories[expCfg.ID.Type()] = expCfg.factory
+	}
+
+	builder := exporter.NewBuilder(cfgs, factories)
+	for _, expCfg := range fe.cfg.Exporters {
+		set := exporter.CreateSettings{
+			ID:                expCfg.ID,
+			TelemetrySettings: fe.set.TelemetrySettings,
+			BuildInfo:         fe.set.BuildInfo,
+		}
+		set.TelemetrySettings.Logger = fe.logger.With(zap.String("failover_exporter", expCfg.ID.String()))
+
+		var exp component.Component
+		var err error
+		switch fe.dataType {
+		case component.DataTypeTraces:
+			exp, err = builder.CreateTraces(ctx, set)
+		case component.DataTypeMetrics:
+			exp, err = builder.CreateMetrics(ctx, set)
+		case component.DataTypeLogs:
+			exp, err = builder.CreateLogs(ctx, set)
+		default:
+			err = fmt.Errorf("data type %q is not supported", fe.dataType)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create exporter %q: %w", expCfg.ID, err)
+		}
+		// The backend is added before starting it, so that it is shut down even if it fails to start.
+		fe.backends = append(fe.backends, backend{id: expCfg.ID, exp: exp})
+		if err = exp.Start(ctx, host); err != nil {
+			return fmt.Errorf("failed to start exporter %q: %w", expCfg.ID, err)
+		}
+	}
+	return nil
+}
+
+// shutdown shuts the backends down.
+func (fe *failoverExporter) shutdown(ctx context.Context) error {
+	var errs error
+	for _, b := range fe.backends {
+		errs = multierr.Append(errs, b.exp.Shutdown(ctx))
+	}
+	return errs
+}
+
+func (fe *failoverExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	return fe.send(func(exp component.Component) error {
+		return exp.(consumer.Traces).ConsumeTraces(ctx, td)
+	})
+}
+
+func (fe *failoverExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
+	return fe.send(func(exp component.Component) error {
+		return exp.(consumer.Metrics).ConsumeMetrics(ctx, md)
+	})
+}
+
+func (fe *failoverExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
+	return fe.send(func(exp component.Component) error {
+		return exp.(consumer.Logs).ConsumeLogs(ctx, ld)
+	})
+}
+
+// send sends the request to the backend in use. When it is time to try the primary backend again the request is
+// sent to it first, and to the backend in use only if the primary one fails.
+func (fe *failoverExporter) send(consume func(exp component.Component) error) error {
+	if fe.shouldProbe() {
+		err := consume(fe.backends[0].exp)
+		if !isFailure(err) {
+			fe.failBack()
+			return err
+		}
+		fe.logger.Debug("Primary exporter is still failing", zap.String("exporter", fe.backends[0].id.String()), zap.Error(err))
+	}
+
+	fe.mu.Lock()
+	active := fe.active
+	fe.mu.Unlock()
+	err := consume(fe.backends[active].exp)
+	fe.record(active, err)
+	return err
+}
+
+// isFailure returns whether the error counts as a failure of the backend.
+// The permanent errors are caused by the data, the backend that returns them is healthy.
+func isFailure(err error) bool {
+	return err != nil && !consumererror.IsPermanent(err)
+}
+
+// shouldProbe returns whether the primary backend is not in use and the retry interval elapsed since it was last
+// tried. Only one request is sent to the primary backend per interval.
+func (fe *failoverExporter) shouldProbe() bool {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	if fe.active == 0 || time.Since(fe.lastProbe) < fe.cfg.RetryInterval {
+		return false
+	}
+	fe.lastProbe = time.Now()
+	return true
+}
+
+// failBack uses the primary backend again.
+func (fe *failoverExporter) failBack() {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	if fe.active == 0 {
+		return
+	}
+	fe.logger.Info("Primary exporter recovered, failing back",
+		zap.String("exporter", fe.backends[0].id.String()),
+		zap.String("previous_exporter", fe.backends[fe.active].id.String()))
+	fe.active = 0
+	fe.failures = 0
+}
+
+// record counts the consecutive failures of the backend at the given index, and moves on to the next backend once
+// they reach the threshold.
+func (fe *failoverExporter) record(index int, err error) {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	// The backend in use changed while the request was sent.
+	if index != fe.active {
+		return
+	}
+	if !isFailure(err) {
+		fe.failures = 0
+		return
+	}
+	fe.failures++
+	if fe.failures < fe.cfg.FailureThreshold {
+		return
+	}
+
+	next := (fe.active + 1) % len(fe.backends)
+	fe.logger.Warn("Exporter keeps failing, failing over to the next one",
+		zap.String("exporter", fe.backends[fe.active].id.String()),
+		zap.String("next_exporter", fe.backends[next].id.String()),
+		zap.Int("failures", fe.failures),
+		zap.Error(err))
+	if fe.active == 0 {
+		fe.lastProbe = time.Now()
+	}
+	fe.active = next
+	fe.failures = 0
+}
+
+// activeExporter returns the ID of the backend in use.
+func (fe *failoverExporter) activeExporter() component.ID {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	return fe.backends[fe.active].id
+}
@@ -0,0 +1,292 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failoverexporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const testBackendType = "test"
+
+var (
+	primaryID = component.NewIDWithName(testBackendType, "primary")
+	standbyID = component.NewIDWithName(testBackendType, "standby")
+	errExport = errors.New("export failed")
+)
+
+type testBackendConfig struct {
+	exporterhelper.QueueSettings `mapstructure:"sending_queue"`
+	exporterhelper.RetrySettings `mapstructure:"retry_on_failure"`
+
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+func (cfg *testBackendConfig) Validate() error {
+	if cfg.Endpoint == "invalid" {
+		return errors.New("invalid endpoint")
+	}
+	return nil
+}
+
+// testBackend counts the requests it receives and fails them while err is set.
+type testBackend struct {
+	cfg      *testBackendConfig
+	mu       sync.Mutex
+	err      error
+	received int
+	started  bool
+	shutdown bool
+}
+
+func (b *testBackend) Start(context.Context, component.Host) error {
+	b.started = true
+	return nil
+}
+
+func (b *testBackend) Shutdown(context.Context) error {
+	b.shutdown = true
+	return nil
+}
+
+func (b *testBackend) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (b *testBackend) consume() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.received++
+	return b.err
+}
+
+func (b *testBackend) ConsumeTraces(context.Context, ptrace.Traces) error {
+	return b.consume()
+}
+
+func (b *testBackend) ConsumeMetrics(context.Context, pmetric.Metrics) error {
+	return b.consume()
+}
+
+func (b *testBackend) ConsumeLogs(context.Context, plog.Logs) error {
+	return b.consume()
+}
+
+func (b *testBackend) setError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+func (b *testBackend) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.received
+}
+
+// newTestBackendFactory returns a factory adding the backends it creates to the given map.
+func newTestBackendFactory(backends map[component.ID]*testBackend) exporter.Factory {
+	var mu sync.Mutex
+	create := func(set exporter.CreateSettings, cfg component.Config) *testBackend {
+		mu.Lock()
+		defer mu.Unlock()
+		b := &testBackend{cfg: cfg.(*testBackendConfig)}
+		backends[set.ID] = b
+		return b
+	}
+	return exporter.NewFactory(
+		testBackendType,
+		func() component.Config {
+			return &testBackendConfig{
+				QueueSettings: exporterhelper.NewDefaultQueueSettings(),
+				RetrySettings: exporterhelper.NewDefaultRetrySettings(),
+			}
+		},
+		exporter.WithTraces(func(_ context.Context, set exporter.CreateSettings, cfg component.Config) (exporter.Traces, error) {
+			return create(set, cfg), nil
+		}, component.StabilityLevelDevelopment),
+		exporter.WithMetrics(func(_ context.Context, set exporter.CreateSettings, cfg component.Config) (exporter.Metrics, error) {
+			return create(set, cfg), nil
+		}, component.StabilityLevelDevelopment),
+		exporter.WithLogs(func(_ context.Context, set exporter.CreateSettings, cfg component.Config) (exporter.Logs, error) {
+			return create(set, cfg), nil
+		}, component.StabilityLevelDevelopment),
+	)
+}
+
+type testHost struct {
+	component.Host
+	factories map[component.Type]component.Factory
+}
+
+func (h *testHost) GetFactory(kind component.Kind, componentType component.Type) component.Factory {
+	if kind != component.KindExporter {
+		return nil
+	}
+	return h.factories[componentType]
+}
+
+func newTestHost(backends map[component.ID]*testBackend) component.Host {
+	return &testHost{
+		Host:      componenttest.NewNopHost(),
+		factories: map[component.Type]component.Factory{testBackendType: newTestBackendFactory(backends)},
+	}
+}
+
+func newTestConfig() *Config {
+	cfg := createDefaultConfig().(*Config)
+	cfg.FailureThreshold = 2
+	cfg.RetryInterval = 50 * time.Millisecond
+	cfg.Exporters = []ExporterSettings{
+		{ID: primaryID, Config: map[string]interface{}{"endpoint": "primary:4317"}},
+		{ID: standbyID},
+	}
+	return cfg
+}
+
+func startTestFailover(t *testing.T, cfg *Config) (*failoverExporter, map[component.ID]*testBackend) {
+	backends := make(map[component.ID]*testBackend)
+	fe := newFailoverExporter(cfg, exportertest.NewNopCreateSettings(), component.DataTypeTraces)
+	require.NoError(t, fe.start(context.Background(), newTestHost(backends)))
+	t.Cleanup(func() {
+		assert.NoError(t, fe.shutdown(context.Background()))
+	})
+	require.Len(t, backends, 2)
+	return fe, backends
+}
+
+func TestFailover_SwitchOverAndFailBack(t *testing.T) {
+	fe, backends := startTestFailover(t, newTestConfig())
+	primary, standby := backends[primaryID], backends[standbyID]
+	td := testdata.GenerateTraces(1)
+
+	require.NoError(t, fe.pushTraces(context.Background(), td))
+	assert.Equal(t, primaryID, fe.activeExporter())
+
+	// The requests fail until the threshold is reached, then the standby is used.
+	primary.setError(errExport)
+	assert.ErrorIs(t, fe.pushTraces(context.Background(), td), errExport)
+	assert.Equal(t, primaryID, fe.activeExporter())
+	assert.ErrorIs(t, fe.pushTraces(context.Background(), td), errExport)
+	assert.Equal(t, standbyID, fe.activeExporter())
+	require.NoError(t, fe.pushTraces(context.Background(), td))
+	assert.Equal(t, 3, primary.count())
+	assert.Equal(t, 1, standby.count())
+
+	// Once the retry interval elapsed a request is sent to the primary first, it falls back to the standby.
+	time.Sleep(fe.cfg.RetryInterval)
+	require.NoError(t, fe.pushTraces(context.Background(), td))
+	assert.Equal(t, 4, primary.count())
+	assert.Equal(t, 2, standby.count())
+	require.NoError(t, fe.pushTraces(context.Background(), td))
+	assert.Equal(t, 4, primary.count(), "the primary is tried once per retry interval")
+	assert.Equal(t, 3, standby.count())
+
+	// The primary recovered, it is used again after the next retry interval.
+	primary.setError(nil)
+	time.Sleep(fe.cfg.RetryInterval)
+	require.NoError(t, fe.pushTraces(context.Background(), td))
+	assert.Equal(t, primaryID, fe.activeExporter())
+	require.NoError(t, fe.pushTraces(context.Background(), td))
+	assert.Equal(t, 6, primary.count())
+	assert.Equal(t, 3, standby.count())
+}
+
+func TestFailover_CyclesThroughBackends(t *testing.T) {
+	fe, backends := startTestFailover(t, newTestConfig())
+	backends[primaryID].setError(errExport)
+	backends[standbyID].setError(errExport)
+	td := testdata.GenerateTraces(1)
+
+	for i := 0; i < 2; i++ {
+		assert.Error(t, fe.pushTraces(context.Background(), td))
+	}
+	assert.Equal(t, standbyID, fe.activeExporter())
+	for i := 0; i < 2; i++ {
+		assert.Error(t, fe.pushTraces(context.Background(), td))
+	}
+	assert.Equal(t, primaryID, fe.activeExporter())
+}
+
+func TestFailover_PermanentErrorsDoNotSwitch(t *testing.T) {
+	fe, backends := startTestFailover(t, newTestConfig())
+	backends[primaryID].setError(consumererror.NewPermanent(errExport))
+	td := testdata.GenerateTraces(1)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, consumererror.IsPermanent(fe.pushTraces(context.Background(), td)))
+	}
+	assert.Equal(t, primaryID, fe.activeExporter())
+	assert.Equal(t, 0, backends[standbyID].count())
+}
+
+func TestFailover_BackendQueueAndRetryDisabled(t *testing.T) {
+	_, backends := startTestFailover(t, newTestConfig())
+	for id, b := range backends {
+		assert.False(t, b.cfg.QueueSettings.Enabled, id.String())
+		assert.False(t, b.cfg.RetrySettings.Enabled, id.String())
+	}
+	assert.Equal(t, "primary:4317", backends[primaryID].cfg.Endpoint)
+}
+
+func TestFailover_StartErrors(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Exporters[1].ID = component.NewID("unknown")
+	fe := newFailoverExporter(cfg, exportertest.NewNopCreateSettings(), component.DataTypeTraces)
+	assert.EqualError(t, fe.start(context.Background(), newTestHost(map[component.ID]*testBackend{})),
+		`exporter factory not available for "unknown"`)
+
+	cfg = newTestConfig()
+	cfg.Exporters[1].Config = map[string]interface{}{"unknown_setting": true}
+	fe = newFailoverExporter(cfg, exportertest.NewNopCreateSettings(), component.DataTypeTraces)
+	assert.ErrorContains(t, fe.start(context.Background(), newTestHost(map[component.ID]*testBackend{})),
+		`error reading configuration for exporter "test/standby"`)
+}
+
+func TestFailover_Lifecycle(t *testing.T) {
+	backends := make(map[component.ID]*testBackend)
+	cfg := newTestConfig()
+	cfg.QueueSettings.Enabled = false
+	exp, err := NewFactory().CreateTracesExporter(context.Background(), exportertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), newTestHost(backends)))
+	assert.True(t, backends[primaryID].started)
+	assert.True(t, backends[standbyID].started)
+
+	require.NoError(t, exp.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	assert.Equal(t, 1, backends[primaryID].count())
+
+	require.NoError(t, exp.Shutdown(context.Background()))
+	assert.True(t, backends[primaryID].shutdown)
+	assert.True(t, backends[standbyID].shutdown)
+}
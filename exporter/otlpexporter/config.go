@@ -27,10 +27,14 @@ import (
 // Config defines configuration for OpenCensus exporter.
 type Config struct {
 	// Deprecated: [v0.68.0] will be removed soon.
-	config.ExporterSettings        `mapstructure:",squash"`
-	exporterhelper.TimeoutSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct.
-	exporterhelper.QueueSettings   `mapstructure:"sending_queue"`
-	exporterhelper.RetrySettings   `mapstructure:"retry_on_failure"`
+	config.ExporterSettings               `mapstructure:",squash"`
+	exporterhelper.TimeoutSettings        `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct.
+	exporterhelper.QueueSettings          `mapstructure:"sending_queue"`
+	exporterhelper.RetrySettings          `mapstructure:"retry_on_failure"`
+	exporterhelper.BatcherSettings        `mapstructure:"batcher"`
+	exporterhelper.CircuitBreakerSettings `mapstructure:"circuit_breaker"`
+	exporterhelper.DeadLetterSettings     `mapstructure:"dead_letter"`
+	exporterhelper.RateLimiterSettings    `mapstructure:"rate_limiter"`
 
 	configgrpc.GRPCClientSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct.
 
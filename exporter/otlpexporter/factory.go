@@ -43,10 +43,18 @@ func NewFactory() exporter.Factory {
 }
 
 func createDefaultConfig() component.Config {
+	// Batching and the circuit breaker are opt-in, enabling them uses the default batch sizes and thresholds.
+	batcherSettings := exporterhelper.NewDefaultBatcherSettings()
+	batcherSettings.Enabled = false
+	circuitBreakerSettings := exporterhelper.NewDefaultCircuitBreakerSettings()
+	circuitBreakerSettings.Enabled = false
+
 	return &Config{
-		TimeoutSettings: exporterhelper.NewDefaultTimeoutSettings(),
-		RetrySettings:   exporterhelper.NewDefaultRetrySettings(),
-		QueueSettings:   exporterhelper.NewDefaultQueueSettings(),
+		TimeoutSettings:        exporterhelper.NewDefaultTimeoutSettings(),
+		RetrySettings:          exporterhelper.NewDefaultRetrySettings(),
+		QueueSettings:          exporterhelper.NewDefaultQueueSettings(),
+		BatcherSettings:        batcherSettings,
+		CircuitBreakerSettings: circuitBreakerSettings,
 		GRPCClientSettings: configgrpc.GRPCClientSettings{
 			Headers: map[string]string{},
 			// Default to gzip compression
@@ -64,6 +72,10 @@ func (oce *baseExporter) helperOptions() []exporterhelper.Option {
 		exporterhelper.WithTimeout(oce.config.TimeoutSettings),
 		exporterhelper.WithRetry(oce.config.RetrySettings),
 		exporterhelper.WithQueue(oce.config.QueueSettings),
+		exporterhelper.WithBatcher(oce.config.BatcherSettings),
+		exporterhelper.WithCircuitBreaker(oce.config.CircuitBreakerSettings),
+		exporterhelper.WithDeadLetter(oce.config.DeadLetterSettings),
+		exporterhelper.WithRateLimiter(oce.config.RateLimiterSettings),
 		exporterhelper.WithStart(oce.start),
 		exporterhelper.WithShutdown(oce.shutdown),
 	}
@@ -34,6 +34,7 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportererror"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
 	"go.opentelemetry.io/collector/exporter/otlpexporter/internal/arrow"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -141,8 +142,12 @@ func (e *baseExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
 		return nil
 	}
 	req := ptraceotlp.NewExportRequestFromTraces(td)
-	_, err := e.traceExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
-	return processGRPCError(err)
+	resp, respErr := e.traceExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
+	if respErr != nil {
+		return processGRPCError(respErr)
+	}
+	partialSuccess := resp.PartialSuccess()
+	return exportererror.NewOTLPPartialSuccessError(partialSuccess.RejectedSpans(), partialSuccess.ErrorMessage(), "spans")
 }
 
 func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
@@ -152,8 +157,12 @@ func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) erro
 		return nil
 	}
 	req := pmetricotlp.NewExportRequestFromMetrics(md)
-	_, err := e.metricExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
-	return processGRPCError(err)
+	resp, respErr := e.metricExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
+	if respErr != nil {
+		return processGRPCError(respErr)
+	}
+	partialSuccess := resp.PartialSuccess()
+	return exportererror.NewOTLPPartialSuccessError(partialSuccess.RejectedDataPoints(), partialSuccess.ErrorMessage(), "data points")
 }
 
 func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
@@ -163,8 +172,12 @@ func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
 		return nil
 	}
 	req := plogotlp.NewExportRequestFromLogs(ld)
-	_, err := e.logExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
-	return processGRPCError(err)
+	resp, respErr := e.logExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
+	if respErr != nil {
+		return processGRPCError(respErr)
+	}
+	partialSuccess := resp.PartialSuccess()
+	return exportererror.NewOTLPPartialSuccessError(partialSuccess.RejectedLogRecords(), partialSuccess.ErrorMessage(), "log records")
 }
 
 func (e *baseExporter) enhanceContext(ctx context.Context) context.Context {
@@ -189,6 +202,8 @@ func processGRPCError(err error) error {
 	}
 
 	// Now, this is this a real error.
+	// Report its code so that the retry policies configured for it apply.
+	err = exporterhelper.NewGRPCStatusError(err, st.Code())
 
 	retryInfo := getRetryInfo(st)
 
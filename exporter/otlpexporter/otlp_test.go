@@ -39,6 +39,7 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
 
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/configgrpc"
 	"go.opentelemetry.io/collector/config/configtls"
@@ -46,6 +47,7 @@ import (
 	"go.opentelemetry.io/collector/exporter/exportertest"
 	"go.opentelemetry.io/collector/exporter/otlpexporter/internal/arrow"
 	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/obsreport/obsreporttest"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -78,7 +80,8 @@ func (r *mockReceiver) setExportError(err error) {
 
 type mockTracesReceiver struct {
 	mockReceiver
-	lastRequest ptrace.Traces
+	lastRequest    ptrace.Traces
+	exportResponse func() ptraceotlp.ExportResponse
 }
 
 func (r *mockTracesReceiver) Export(ctx context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
@@ -89,6 +92,9 @@ func (r *mockTracesReceiver) Export(ctx context.Context, req ptraceotlp.ExportRe
 	defer r.mux.Unlock()
 	r.lastRequest = td
 	r.metadata, _ = metadata.FromIncomingContext(ctx)
+	if r.exportResponse != nil {
+		return r.exportResponse(), r.exportError
+	}
 	return ptraceotlp.NewExportResponse(), r.exportError
 }
 
@@ -288,6 +294,45 @@ func TestSendTraces(t *testing.T) {
 	require.Contains(t, md.Get("User-Agent")[0], "Collector/1.2.3test")
 }
 
+func TestSendTracesPartialSuccess(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:")
+	require.NoError(t, err, "Failed to find an available address to run the gRPC server: %v", err)
+	rcv, _ := otlpTracesReceiverOnGRPCServer(ln, false)
+	rcv.exportResponse = func() ptraceotlp.ExportResponse {
+		response := ptraceotlp.NewExportResponse()
+		response.PartialSuccess().SetRejectedSpans(1)
+		response.PartialSuccess().SetErrorMessage("span too large")
+		return response
+	}
+	rcv.start()
+	defer rcv.srv.GracefulStop()
+
+	tt, err := obsreporttest.SetupTelemetry(component.NewID(typeStr))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.QueueSettings.Enabled = false
+	cfg.GRPCClientSettings = configgrpc.GRPCClientSettings{
+		Endpoint: ln.Addr().String(),
+		TLSSetting: configtls.TLSClientSetting{
+			Insecure: true,
+		},
+	}
+	exp, err := factory.CreateTracesExporter(context.Background(), tt.ToExporterCreateSettings(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() {
+		assert.NoError(t, exp.Shutdown(context.Background()))
+	}()
+
+	// The partial success is not an error and is not retried, the rejected span is reported as failed.
+	assert.NoError(t, exp.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	assert.EqualValues(t, 1, rcv.requestCount.Load())
+	require.NoError(t, tt.CheckExporterTraces(1, 1))
+}
+
 func TestSendTracesWhenEndpointHasHttpScheme(t *testing.T) {
 	tests := []struct {
 		name               string
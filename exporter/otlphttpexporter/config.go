@@ -26,10 +26,14 @@ import (
 // Config defines configuration for OTLP/HTTP exporter.
 type Config struct {
 	// Deprecated: [v0.68.0] will be removed soon.
-	config.ExporterSettings       `mapstructure:",squash"`
-	confighttp.HTTPClientSettings `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct.
-	exporterhelper.QueueSettings  `mapstructure:"sending_queue"`
-	exporterhelper.RetrySettings  `mapstructure:"retry_on_failure"`
+	config.ExporterSettings               `mapstructure:",squash"`
+	confighttp.HTTPClientSettings         `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct.
+	exporterhelper.QueueSettings          `mapstructure:"sending_queue"`
+	exporterhelper.RetrySettings          `mapstructure:"retry_on_failure"`
+	exporterhelper.BatcherSettings        `mapstructure:"batcher"`
+	exporterhelper.CircuitBreakerSettings `mapstructure:"circuit_breaker"`
+	exporterhelper.DeadLetterSettings     `mapstructure:"dead_letter"`
+	exporterhelper.RateLimiterSettings    `mapstructure:"rate_limiter"`
 
 	// The URL to send traces to. If omitted the Endpoint + "/v1/traces" will be used.
 	TracesEndpoint string `mapstructure:"traces_endpoint"`
@@ -46,6 +46,7 @@ func TestUnmarshalConfig(t *testing.T) {
 	factory := NewFactory()
 	cfg := factory.CreateDefaultConfig()
 	assert.NoError(t, component.UnmarshalConfig(cm, cfg))
+	deadLetterStorageID := component.NewIDWithName("file_storage", "dead_letter")
 	assert.Equal(t,
 		&Config{
 			RetrySettings: exporterhelper.RetrySettings{
@@ -59,6 +60,28 @@ func TestUnmarshalConfig(t *testing.T) {
 				NumConsumers: 2,
 				QueueSize:    10,
 			},
+			BatcherSettings: exporterhelper.BatcherSettings{
+				Enabled:      true,
+				FlushTimeout: time.Second,
+				MinSizeItems: 1000,
+				MaxSizeItems: 2000,
+			},
+			CircuitBreakerSettings: exporterhelper.CircuitBreakerSettings{
+				Enabled:                 true,
+				FailureThreshold:        10,
+				FailureRatioWindow:      time.Minute,
+				FailureRatioMinAttempts: 20,
+				OpenDuration:            time.Minute,
+			},
+			DeadLetterSettings: exporterhelper.DeadLetterSettings{
+				Enabled:   true,
+				StorageID: &deadLetterStorageID,
+			},
+			RateLimiterSettings: exporterhelper.RateLimiterSettings{
+				Enabled:        true,
+				ItemsPerSecond: 5000,
+				BurstItems:     10000,
+			},
 			HTTPClientSettings: confighttp.HTTPClientSettings{
 				Headers: map[string]configopaque.String{
 					"can you have a . here?": "F0000000-0000-0000-0000-000000000000",
@@ -46,9 +46,17 @@ func NewFactory() exporter.Factory {
 }
 
 func createDefaultConfig() component.Config {
+	// Batching and the circuit breaker are opt-in, enabling them uses the default batch sizes and thresholds.
+	batcherSettings := exporterhelper.NewDefaultBatcherSettings()
+	batcherSettings.Enabled = false
+	circuitBreakerSettings := exporterhelper.NewDefaultCircuitBreakerSettings()
+	circuitBreakerSettings.Enabled = false
+
 	return &Config{
-		RetrySettings: exporterhelper.NewDefaultRetrySettings(),
-		QueueSettings: exporterhelper.NewDefaultQueueSettings(),
+		RetrySettings:          exporterhelper.NewDefaultRetrySettings(),
+		QueueSettings:          exporterhelper.NewDefaultQueueSettings(),
+		BatcherSettings:        batcherSettings,
+		CircuitBreakerSettings: circuitBreakerSettings,
 		HTTPClientSettings: confighttp.HTTPClientSettings{
 			Endpoint: "",
 			Timeout:  30 * time.Second,
@@ -99,7 +107,11 @@ func createTracesExporter(
 		// explicitly disable since we rely on http.Client timeout logic.
 		exporterhelper.WithTimeout(exporterhelper.TimeoutSettings{Timeout: 0}),
 		exporterhelper.WithRetry(oCfg.RetrySettings),
-		exporterhelper.WithQueue(oCfg.QueueSettings))
+		exporterhelper.WithQueue(oCfg.QueueSettings),
+		exporterhelper.WithBatcher(oCfg.BatcherSettings),
+		exporterhelper.WithCircuitBreaker(oCfg.CircuitBreakerSettings),
+		exporterhelper.WithDeadLetter(oCfg.DeadLetterSettings),
+		exporterhelper.WithRateLimiter(oCfg.RateLimiterSettings))
 }
 
 func createMetricsExporter(
@@ -125,7 +137,11 @@ func createMetricsExporter(
 		// explicitly disable since we rely on http.Client timeout logic.
 		exporterhelper.WithTimeout(exporterhelper.TimeoutSettings{Timeout: 0}),
 		exporterhelper.WithRetry(oCfg.RetrySettings),
-		exporterhelper.WithQueue(oCfg.QueueSettings))
+		exporterhelper.WithQueue(oCfg.QueueSettings),
+		exporterhelper.WithBatcher(oCfg.BatcherSettings),
+		exporterhelper.WithCircuitBreaker(oCfg.CircuitBreakerSettings),
+		exporterhelper.WithDeadLetter(oCfg.DeadLetterSettings),
+		exporterhelper.WithRateLimiter(oCfg.RateLimiterSettings))
 }
 
 func createLogsExporter(
@@ -151,5 +167,9 @@ func createLogsExporter(
 		// explicitly disable since we rely on http.Client timeout logic.
 		exporterhelper.WithTimeout(exporterhelper.TimeoutSettings{Timeout: 0}),
 		exporterhelper.WithRetry(oCfg.RetrySettings),
-		exporterhelper.WithQueue(oCfg.QueueSettings))
+		exporterhelper.WithQueue(oCfg.QueueSettings),
+		exporterhelper.WithBatcher(oCfg.BatcherSettings),
+		exporterhelper.WithCircuitBreaker(oCfg.CircuitBreakerSettings),
+		exporterhelper.WithDeadLetter(oCfg.DeadLetterSettings),
+		exporterhelper.WithRateLimiter(oCfg.RateLimiterSettings))
 }
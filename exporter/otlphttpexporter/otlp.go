@@ -24,6 +24,7 @@ import (
 	"net/url"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -33,6 +34,7 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportererror"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
@@ -101,7 +103,7 @@ func (e *baseExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
 		return consumererror.NewPermanent(err)
 	}
 
-	return e.export(ctx, e.tracesURL, request)
+	return e.export(ctx, e.tracesURL, request, e.tracesPartialSuccessHandler)
 }
 
 func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
@@ -110,7 +112,7 @@ func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) erro
 	if err != nil {
 		return consumererror.NewPermanent(err)
 	}
-	return e.export(ctx, e.metricsURL, request)
+	return e.export(ctx, e.metricsURL, request, e.metricsPartialSuccessHandler)
 }
 
 func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
@@ -120,10 +122,10 @@ func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
 		return consumererror.NewPermanent(err)
 	}
 
-	return e.export(ctx, e.logsURL, request)
+	return e.export(ctx, e.logsURL, request, e.logsPartialSuccessHandler)
 }
 
-func (e *baseExporter) export(ctx context.Context, url string, request []byte) error {
+func (e *baseExporter) export(ctx context.Context, url string, request []byte, partialSuccessHandler partialSuccessHandler) error {
 	e.logger.Debug("Preparing to make HTTP request", zap.String("url", url))
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(request))
 	if err != nil {
@@ -144,8 +146,8 @@ func (e *baseExporter) export(ctx context.Context, url string, request []byte) e
 	}()
 
 	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-		// Request is successful.
-		return nil
+		// Request is successful, check if some of the items were rejected.
+		return handlePartialSuccessResponse(resp, partialSuccessHandler)
 	}
 
 	respStatus := readResponse(resp)
@@ -161,6 +163,8 @@ func (e *baseExporter) export(ctx context.Context, url string, request []byte) e
 			"error exporting items, request to %s responded with HTTP Status Code %d",
 			url, resp.StatusCode)
 	}
+	// Report the status so that the retry policies configured for it apply.
+	formattedErr = exporterhelper.NewHTTPStatusError(formattedErr, resp.StatusCode)
 
 	// Check if the server is overwhelmed.
 	// See spec https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/otlp.md#throttling-1
@@ -186,6 +190,65 @@ func (e *baseExporter) export(ctx context.Context, url string, request []byte) e
 	return formattedErr
 }
 
+// partialSuccessHandler decodes the export response and returns a PartialExportError if some items were rejected.
+type partialSuccessHandler func(respBytes []byte, contentType string) error
+
+func handlePartialSuccessResponse(resp *http.Response, partialSuccessHandler partialSuccessHandler) error {
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseReadBytes))
+	if err != nil || len(bodyBytes) == 0 {
+		// The response is optional, the request is successful anyway.
+		return nil
+	}
+	return partialSuccessHandler(bodyBytes, resp.Header.Get("Content-Type"))
+}
+
+// exportResponse is implemented by the export responses of all the signals.
+type exportResponse interface {
+	UnmarshalProto(data []byte) error
+	UnmarshalJSON(data []byte) error
+}
+
+func (e *baseExporter) unmarshalResponse(resp exportResponse, respBytes []byte, contentType string) bool {
+	var err error
+	if strings.HasPrefix(contentType, "application/json") {
+		err = resp.UnmarshalJSON(respBytes)
+	} else {
+		err = resp.UnmarshalProto(respBytes)
+	}
+	if err != nil {
+		e.logger.Debug("Cannot decode the export response, ignoring it", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (e *baseExporter) tracesPartialSuccessHandler(respBytes []byte, contentType string) error {
+	resp := ptraceotlp.NewExportResponse()
+	if !e.unmarshalResponse(resp, respBytes, contentType) {
+		return nil
+	}
+	partialSuccess := resp.PartialSuccess()
+	return exportererror.NewOTLPPartialSuccessError(partialSuccess.RejectedSpans(), partialSuccess.ErrorMessage(), "spans")
+}
+
+func (e *baseExporter) metricsPartialSuccessHandler(respBytes []byte, contentType string) error {
+	resp := pmetricotlp.NewExportResponse()
+	if !e.unmarshalResponse(resp, respBytes, contentType) {
+		return nil
+	}
+	partialSuccess := resp.PartialSuccess()
+	return exportererror.NewOTLPPartialSuccessError(partialSuccess.RejectedDataPoints(), partialSuccess.ErrorMessage(), "data points")
+}
+
+func (e *baseExporter) logsPartialSuccessHandler(respBytes []byte, contentType string) error {
+	resp := plogotlp.NewExportResponse()
+	if !e.unmarshalResponse(resp, respBytes, contentType) {
+		return nil
+	}
+	partialSuccess := resp.PartialSuccess()
+	return exportererror.NewOTLPPartialSuccessError(partialSuccess.RejectedLogRecords(), partialSuccess.ErrorMessage(), "log records")
+}
+
 // Does the 'code' indicate a permanent error
 func isPermanentClientFailure(code int) bool {
 	switch code {
@@ -43,12 +43,16 @@ import (
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportererror"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
 	"go.opentelemetry.io/collector/exporter/exportertest"
 	"go.opentelemetry.io/collector/internal/testdata"
 	"go.opentelemetry.io/collector/internal/testutil"
+	"go.opentelemetry.io/collector/obsreport/obsreporttest"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver"
@@ -381,6 +385,57 @@ func startAndCleanup(t *testing.T, cmp component.Component) {
 	})
 }
 
+func TestPartialSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := ptraceotlp.NewExportResponse()
+		response.PartialSuccess().SetRejectedSpans(1)
+		response.PartialSuccess().SetErrorMessage("span too large")
+		respBytes, err := response.MarshalProto()
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, err = w.Write(respBytes)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	tt, err := obsreporttest.SetupTelemetry(component.NewID(typeStr))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+	factory := NewFactory()
+	cfg := createExporterConfig(srv.URL, factory.CreateDefaultConfig())
+	exp, err := factory.CreateTracesExporter(context.Background(), tt.ToExporterCreateSettings(), cfg)
+	require.NoError(t, err)
+	startAndCleanup(t, exp)
+
+	// The partial success is not an error, the rejected span is reported as failed.
+	assert.NoError(t, exp.ConsumeTraces(context.Background(), testdata.GenerateTraces(2)))
+	require.NoError(t, tt.CheckExporterTraces(1, 1))
+}
+
+func TestPartialSuccessHandlers(t *testing.T) {
+	exp, err := newExporter(createDefaultConfig(), exportertest.NewNopCreateSettings())
+	require.NoError(t, err)
+
+	metricsResponse := pmetricotlp.NewExportResponse()
+	metricsResponse.PartialSuccess().SetRejectedDataPoints(3)
+	metricsResponse.PartialSuccess().SetErrorMessage("too old")
+	jsonBytes, err := metricsResponse.MarshalJSON()
+	require.NoError(t, err)
+	err = exp.metricsPartialSuccessHandler(jsonBytes, "application/json")
+	var partialErr exportererror.PartialExportError
+	require.ErrorAs(t, err, &partialErr)
+	assert.Equal(t, 3, partialErr.Rejected)
+	assert.EqualError(t, err, "OTLP partial success, 3 data points rejected: too old")
+
+	protoBytes, err := plogotlp.NewExportResponse().MarshalProto()
+	require.NoError(t, err)
+	assert.NoError(t, exp.logsPartialSuccessHandler(protoBytes, "application/x-protobuf"))
+
+	// Responses that cannot be decoded are ignored.
+	assert.NoError(t, exp.tracesPartialSuccessHandler([]byte{0xFF}, "application/x-protobuf"))
+}
+
 func TestErrorResponses(t *testing.T) {
 	addr := testutil.GetAvailableLocalAddress(t)
 	errMsgPrefix := fmt.Sprintf("error exporting items, request to http://%s/v1/traces responded with HTTP Status Code ", addr)
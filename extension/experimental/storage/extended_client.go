@@ -0,0 +1,388 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage // import "go.opentelemetry.io/collector/extension/experimental/storage"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExtendedClient is a Client that can also list its keys and make them expire.
+// The components needing it check whether the Client of the storage implements it, or use NewExtendedClient.
+type ExtendedClient interface {
+	Client
+
+	// List returns, in lexicographic order, the keys starting with prefix that come after cursor, at most limit of
+	// them if limit is positive. The returned cursor is passed to the next call to get the following keys, it is
+	// empty once all the keys were listed. The expired keys are not listed.
+	List(ctx context.Context, prefix string, cursor string, limit int) (keys []string, next string, err error)
+
+	// SetTTL makes the key expire once ttl elapsed: Get no longer returns it and List no longer lists it.
+	// A ttl that is not positive removes the expiration, and so does setting the key again.
+	// SetTTL doesn't error if the key is not found - it just no-ops.
+	SetTTL(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// indexKey is the key of the root of the index kept by the adapter of NewExtendedClient, its pages are stored under
+// indexKey followed by a slash and their id. All the keys starting with indexKey are reserved.
+const indexKey = "__extended_client_index"
+
+// maxPageKeys is the number of keys above which a page of the index is split.
+const maxPageKeys = 512
+
+var errReservedKey = errors.New("keys starting with " + indexKey + " are reserved")
+
+// NewExtendedClient returns the client if it implements ExtendedClient, otherwise it wraps it in an ExtendedClient
+// keeping an index of the keys and their expirations in the client itself. The index is split in pages of bounded
+// size, updated in the same Batch as the keys they index, so a change only rewrites the page of the key.
+//
+// The adapter must be the only user of the client, and only lists the keys set through it: a client must be wrapped
+// before its first key is set.
+func NewExtendedClient(client Client) ExtendedClient {
+	if ec, ok := client.(ExtendedClient); ok {
+		return ec
+	}
+	return &extendedClient{client: client}
+}
+
+// indexRoot lists the pages of the index in the order of their keys.
+type indexRoot struct {
+	NextID int         `json:"next_id"`
+	Pages  []indexPage `json:"pages"`
+}
+
+// indexPage holds the keys greater or equal to its Low key and lower than the Low key of the next page.
+type indexPage struct {
+	Low string `json:"low"`
+	ID  int    `json:"id"`
+}
+
+// pageKeys maps the keys of a page to their expiration time in Unix nanoseconds, 0 if they don't expire.
+type pageKeys map[string]int64
+
+func (pk pageKeys) isExpired(key string, now int64) bool {
+	expiration := pk[key]
+	return expiration != 0 && expiration <= now
+}
+
+type extendedClient struct {
+	client Client
+
+	mu   sync.Mutex
+	root *indexRoot
+}
+
+func pageKey(id int) string {
+	return indexKey + "/" + strconv.Itoa(id)
+}
+
+// loadRoot reads the root of the index from the client the first time it is needed.
+// It must be called with the lock held.
+func (c *extendedClient) loadRoot(ctx context.Context) error {
+	if c.root != nil {
+		return nil
+	}
+	buf, err := c.client.Get(ctx, indexKey)
+	if err != nil {
+		return err
+	}
+	root := &indexRoot{NextID: 1, Pages: []indexPage{{Low: "", ID: 0}}}
+	if buf != nil {
+		if err = json.Unmarshal(buf, root); err != nil {
+			return err
+		}
+	}
+	c.root = root
+	return nil
+}
+
+// pageOf returns the position in the root of the page holding the key.
+func (r *indexRoot) pageOf(key string) int {
+	return sort.Search(len(r.Pages), func(i int) bool { return r.Pages[i].Low > key }) - 1
+}
+
+// loadPages reads the pages at the given positions of the root in a single batch.
+func (c *extendedClient) loadPages(ctx context.Context, positions []int) (map[int]pageKeys, error) {
+	ops := make([]Operation, len(positions))
+	for i, pos := range positions {
+		ops[i] = GetOperation(pageKey(c.root.Pages[pos].ID))
+	}
+	if err := c.client.Batch(ctx, ops...); err != nil {
+		return nil, err
+	}
+	pages := make(map[int]pageKeys, len(positions))
+	for i, pos := range positions {
+		page := pageKeys{}
+		if ops[i].Value != nil {
+			if err := json.Unmarshal(ops[i].Value, &page); err != nil {
+				return nil, err
+			}
+		}
+		pages[pos] = page
+	}
+	return pages, nil
+}
+
+// Get will retrieve data from storage that corresponds to the specified key
+func (c *extendedClient) Get(ctx context.Context, key string) ([]byte, error) {
+	op := GetOperation(key)
+	if err := c.Batch(ctx, op); err != nil {
+		return nil, err
+	}
+	return op.Value, nil
+}
+
+// Set will store data. The data can be retrieved using the same key
+func (c *extendedClient) Set(ctx context.Context, key string, value []byte) error {
+	return c.Batch(ctx, SetOperation(key, value))
+}
+
+// Delete will delete data associated with the specified key
+func (c *extendedClient) Delete(ctx context.Context, key string) error {
+	return c.Batch(ctx, DeleteOperation(key))
+}
+
+// Batch executes the operations along with the update of the pages of their keys, in a single batch of the client.
+// The expired keys used by the operations are deleted first.
+func (c *extendedClient) Batch(ctx context.Context, ops ...Operation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadRoot(ctx); err != nil {
+		return err
+	}
+
+	var positions []int
+	seen := map[int]bool{}
+	for _, op := range ops {
+		if strings.HasPrefix(op.Key, indexKey) {
+			return errReservedKey
+		}
+		if pos := c.root.pageOf(op.Key); !seen[pos] {
+			seen[pos] = true
+			positions = append(positions, pos)
+		}
+	}
+	pages, err := c.loadPages(ctx, positions)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixNano()
+	changed := map[int]bool{}
+	var expired []Operation
+	for _, op := range ops {
+		pos := c.root.pageOf(op.Key)
+		if page := pages[pos]; page.isExpired(op.Key, now) {
+			expired = append(expired, DeleteOperation(op.Key))
+			delete(page, op.Key)
+			changed[pos] = true
+		}
+	}
+	for _, op := range ops {
+		pos := c.root.pageOf(op.Key)
+		page := pages[pos]
+		expiration, found := page[op.Key]
+		switch {
+		case op.Type == Set && (!found || expiration != 0):
+			page[op.Key] = 0
+			changed[pos] = true
+		case op.Type == Delete && found:
+			delete(page, op.Key)
+			changed[pos] = true
+		}
+	}
+	return c.commit(ctx, pages, changed, append(expired, ops...))
+}
+
+// commit executes the operations along with the writes of the changed pages, and of the root if pages were split or
+// removed, and keeps the new root once they succeeded. It must be called with the lock held.
+func (c *extendedClient) commit(ctx context.Context, pages map[int]pageKeys, changed map[int]bool, ops []Operation) error {
+	root := &indexRoot{NextID: c.root.NextID}
+	rootChanged := false
+	for pos, ref := range c.root.Pages {
+		page := pages[pos]
+		switch {
+		case !changed[pos]:
+			root.Pages = append(root.Pages, ref)
+		case len(page) == 0 && pos > 0:
+			// The keys of an empty page now go to the previous page, the first one is kept to hold the lowest keys.
+			ops = append(ops, DeleteOperation(pageKey(ref.ID)))
+			rootChanged = true
+		case len(page) > maxPageKeys:
+			keys := make([]string, 0, len(page))
+			for key := range page {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for start := 0; start < len(keys); start += maxPageKeys / 2 {
+				end := start + maxPageKeys/2
+				if end > len(keys) {
+					end = len(keys)
+				}
+				part := indexPage{Low: ref.Low, ID: ref.ID}
+				if start > 0 {
+					part = indexPage{Low: keys[start], ID: root.NextID}
+					root.NextID++
+				}
+				split := make(pageKeys, end-start)
+				for _, key := range keys[start:end] {
+					split[key] = page[key]
+				}
+				op, err := setPageOperation(part.ID, split)
+				if err != nil {
+					return err
+				}
+				ops = append(ops, op)
+				root.Pages = append(root.Pages, part)
+			}
+			rootChanged = true
+		default:
+			op, err := setPageOperation(ref.ID, page)
+			if err != nil {
+				return err
+			}
+			ops = append(ops, op)
+			root.Pages = append(root.Pages, ref)
+		}
+	}
+	if rootChanged {
+		buf, err := json.Marshal(root)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, SetOperation(indexKey, buf))
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	if err := c.client.Batch(ctx, ops...); err != nil {
+		return err
+	}
+	if rootChanged {
+		c.root = root
+	}
+	return nil
+}
+
+func setPageOperation(id int, page pageKeys) (Operation, error) {
+	buf, err := json.Marshal(page)
+	if err != nil {
+		return nil, err
+	}
+	return SetOperation(pageKey(id), buf), nil
+}
+
+// List reads the pages holding the keys after the cursor with the prefix, and removes the expired keys it meets.
+func (c *extendedClient) List(ctx context.Context, prefix string, cursor string, limit int) ([]string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadRoot(ctx); err != nil {
+		return nil, "", err
+	}
+
+	start := prefix
+	if cursor > start {
+		start = cursor
+	}
+	now := time.Now().UnixNano()
+	pages := map[int]pageKeys{}
+	changed := map[int]bool{}
+	var expired []Operation
+	var keys []string
+	for pos := c.root.pageOf(start); pos < len(c.root.Pages); pos++ {
+		if low := c.root.Pages[pos].Low; low > prefix && !strings.HasPrefix(low, prefix) {
+			break
+		}
+		if limit > 0 && len(keys) > limit {
+			break
+		}
+		loaded, err := c.loadPages(ctx, []int{pos})
+		if err != nil {
+			return nil, "", err
+		}
+		page := loaded[pos]
+		pages[pos] = page
+		matching := make([]string, 0, len(page))
+		for key := range page {
+			if strings.HasPrefix(key, prefix) && key > cursor {
+				matching = append(matching, key)
+			}
+		}
+		sort.Strings(matching)
+		for _, key := range matching {
+			if page.isExpired(key, now) {
+				expired = append(expired, DeleteOperation(key))
+				delete(page, key)
+				changed[pos] = true
+				continue
+			}
+			keys = append(keys, key)
+		}
+	}
+	if err := c.commit(ctx, pages, changed, expired); err != nil {
+		return nil, "", err
+	}
+
+	if limit > 0 && len(keys) > limit {
+		return keys[:limit], keys[limit-1], nil
+	}
+	return keys, "", nil
+}
+
+// SetTTL records the expiration of the key in its page of the index.
+func (c *extendedClient) SetTTL(ctx context.Context, key string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if strings.HasPrefix(key, indexKey) {
+		return errReservedKey
+	}
+	if err := c.loadRoot(ctx); err != nil {
+		return err
+	}
+
+	pos := c.root.pageOf(key)
+	pages, err := c.loadPages(ctx, []int{pos})
+	if err != nil {
+		return err
+	}
+	page := pages[pos]
+	now := time.Now()
+	if page.isExpired(key, now.UnixNano()) {
+		return nil
+	}
+	if _, ok := page[key]; !ok {
+		// The key may have been set before the client was wrapped.
+		value, err := c.client.Get(ctx, key)
+		if err != nil || value == nil {
+			return err
+		}
+	}
+	page[key] = 0
+	if ttl > 0 {
+		page[key] = now.Add(ttl).UnixNano()
+	}
+	return c.commit(ctx, pages, map[int]bool{pos: true}, nil)
+}
+
+// Close will close the wrapped client
+func (c *extendedClient) Close(ctx context.Context) error {
+	return c.client.Close(ctx)
+}
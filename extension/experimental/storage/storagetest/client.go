@@ -0,0 +1,179 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest // import "go.opentelemetry.io/collector/extension/experimental/storage/storagetest"
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+var errClientClosed = errors.New("client is closed")
+
+// inMemoryStorage is the data shared by the clients of a component and storage name.
+type inMemoryStorage struct {
+	mu          sync.Mutex
+	data        map[string][]byte
+	expirations map[string]time.Time
+}
+
+func newInMemoryStorage() *inMemoryStorage {
+	return &inMemoryStorage{
+		data:        map[string][]byte{},
+		expirations: map[string]time.Time{},
+	}
+}
+
+// removeIfExpired removes the key if it expired, and returns whether it did. It must be called with the lock held.
+func (st *inMemoryStorage) removeIfExpired(key string, now time.Time) bool {
+	expiration, ok := st.expirations[key]
+	if !ok || now.Before(expiration) {
+		return false
+	}
+	delete(st.data, key)
+	delete(st.expirations, key)
+	return true
+}
+
+type inMemoryClient struct {
+	storage *inMemoryStorage
+	// closed is protected by the mutex of the storage.
+	closed bool
+}
+
+// Ensure this storage client implements the appropriate interface
+var _ storage.ExtendedClient = (*inMemoryClient)(nil)
+
+// NewInMemoryClient returns a storage.ExtendedClient keeping the data in memory, which is lost when the client is
+// closed.
+func NewInMemoryClient() storage.ExtendedClient {
+	return &inMemoryClient{storage: newInMemoryStorage()}
+}
+
+// Get will retrieve data from storage that corresponds to the specified key
+func (c *inMemoryClient) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	if err := c.Batch(ctx, op); err != nil {
+		return nil, err
+	}
+	return op.Value, nil
+}
+
+// Set will store data. The data can be retrieved using the same key
+func (c *inMemoryClient) Set(ctx context.Context, key string, value []byte) error {
+	return c.Batch(ctx, storage.SetOperation(key, value))
+}
+
+// Delete will delete data associated with the specified key
+func (c *inMemoryClient) Delete(ctx context.Context, key string) error {
+	return c.Batch(ctx, storage.DeleteOperation(key))
+}
+
+// Batch executes the specified operations in order, all at once. Get operation results are put in-place
+func (c *inMemoryClient) Batch(_ context.Context, ops ...storage.Operation) error {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+	if c.closed {
+		return errClientClosed
+	}
+
+	// The operations are checked first, so that the batch is applied entirely or not at all.
+	for _, op := range ops {
+		if op.Type != storage.Get && op.Type != storage.Set && op.Type != storage.Delete {
+			return errors.New("wrong operation type")
+		}
+	}
+	now := time.Now()
+	for _, op := range ops {
+		c.storage.removeIfExpired(op.Key, now)
+		switch op.Type {
+		case storage.Get:
+			op.Value = copyValue(c.storage.data[op.Key])
+		case storage.Set:
+			c.storage.data[op.Key] = copyValue(op.Value)
+			delete(c.storage.expirations, op.Key)
+		case storage.Delete:
+			delete(c.storage.data, op.Key)
+			delete(c.storage.expirations, op.Key)
+		}
+	}
+	return nil
+}
+
+// List returns the keys starting with prefix that come after cursor, in lexicographic order
+func (c *inMemoryClient) List(_ context.Context, prefix string, cursor string, limit int) ([]string, string, error) {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+	if c.closed {
+		return nil, "", errClientClosed
+	}
+
+	now := time.Now()
+	var keys []string
+	for key := range c.storage.data {
+		if !c.storage.removeIfExpired(key, now) && strings.HasPrefix(key, prefix) && key > cursor {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		return keys[:limit], keys[limit-1], nil
+	}
+	return keys, "", nil
+}
+
+// SetTTL makes the key expire once ttl elapsed, or removes its expiration if ttl is not positive
+func (c *inMemoryClient) SetTTL(_ context.Context, key string, ttl time.Duration) error {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+	if c.closed {
+		return errClientClosed
+	}
+
+	now := time.Now()
+	if c.storage.removeIfExpired(key, now) {
+		return nil
+	}
+	if _, ok := c.storage.data[key]; !ok {
+		return nil
+	}
+	if ttl > 0 {
+		c.storage.expirations[key] = now.Add(ttl)
+	} else {
+		delete(c.storage.expirations, key)
+	}
+	return nil
+}
+
+// Close closes the client, the data is kept for the clients requested later from the extension.
+func (c *inMemoryClient) Close(context.Context) error {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// copyValue copies the value, so that neither the caller nor the storage see the changes made by the other one.
+func copyValue(value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(value)), value...)
+}
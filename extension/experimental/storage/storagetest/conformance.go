@@ -0,0 +1,351 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest // import "go.opentelemetry.io/collector/extension/experimental/storage/storagetest"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+// ClientOpener opens clients of a storage. The clients it opens share the same data: what is written with a client
+// is read by the clients opened after it is closed.
+type ClientOpener func(t *testing.T) storage.Client
+
+// CheckClient checks that the clients of a storage.Client implementation behave as the storage.Client interface
+// requires. newStorage is called for each check, and must return the opener of a new, empty storage.
+func CheckClient(t *testing.T, newStorage func(t *testing.T) ClientOpener) {
+	checks := []struct {
+		name  string
+		check func(t *testing.T, open ClientOpener)
+	}{
+		{name: "GetMissingKey", check: checkGetMissingKey},
+		{name: "SetGetDelete", check: checkSetGetDelete},
+		{name: "Batch", check: checkBatch},
+		{name: "Close", check: checkClose},
+		{name: "ConcurrentAccess", check: checkConcurrentAccess},
+		{name: "Reopen", check: checkReopen},
+	}
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			c.check(t, newStorage(t))
+		})
+	}
+}
+
+// openClient opens a client, closed at the end of the test unless the check closes it itself.
+func openClient(t *testing.T, open ClientOpener) storage.Client {
+	client := open(t)
+	require.NotNil(t, client)
+	t.Cleanup(func() {
+		// The check may already have closed the client, the result of closing it again is not checked.
+		_ = client.Close(context.Background())
+	})
+	return client
+}
+
+func checkGetMissingKey(t *testing.T, open ClientOpener) {
+	client := openClient(t, open)
+	value, err := client.Get(context.Background(), "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func checkSetGetDelete(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openClient(t, open)
+
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+	value, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	require.NoError(t, client.Set(ctx, "key", []byte("overwritten")), "Set must overwrite an existing key")
+	value, err = client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("overwritten"), value)
+
+	require.NoError(t, client.Delete(ctx, "key"))
+	value, err = client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	assert.NoError(t, client.Delete(ctx, "key"), "Delete of a missing key must not fail")
+}
+
+func checkBatch(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openClient(t, open)
+	require.NoError(t, client.Set(ctx, "existing", []byte("existing")))
+	require.NoError(t, client.Set(ctx, "deleted", []byte("deleted")))
+
+	ops := []storage.Operation{
+		storage.GetOperation("existing"),
+		storage.GetOperation("missing"),
+		storage.SetOperation("new", []byte("new")),
+		storage.GetOperation("new"),
+		storage.DeleteOperation("deleted"),
+		storage.GetOperation("deleted"),
+		storage.SetOperation("existing", []byte("overwritten")),
+	}
+	require.NoError(t, client.Batch(ctx, ops...))
+	assert.Equal(t, []byte("existing"), ops[0].Value, "Get results must be put in place")
+	assert.Nil(t, ops[1].Value, "Get of a missing key must give nil")
+	assert.Equal(t, []byte("new"), ops[3].Value, "operations must see the previous ones of the batch")
+	assert.Nil(t, ops[5].Value, "operations must see the previous ones of the batch")
+
+	values := map[string][]byte{"existing": []byte("overwritten"), "new": []byte("new"), "deleted": nil}
+	for key, expected := range values {
+		value, err := client.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, expected, value, key)
+	}
+
+	assert.NoError(t, client.Batch(ctx), "an empty batch must not fail")
+}
+
+func checkClose(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openClient(t, open)
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+	require.NoError(t, client.Close(ctx))
+
+	// A closed client must not lose the operations silently.
+	_, err := client.Get(ctx, "key")
+	assert.Error(t, err, "Get must fail once the client is closed")
+	assert.Error(t, client.Set(ctx, "key", []byte("value")), "Set must fail once the client is closed")
+	assert.Error(t, client.Delete(ctx, "key"), "Delete must fail once the client is closed")
+	assert.Error(t, client.Batch(ctx, storage.GetOperation("key")), "Batch must fail once the client is closed")
+}
+
+func checkConcurrentAccess(t *testing.T, open ClientOpener) {
+	const (
+		goroutines = 10
+		keys       = 50
+	)
+	ctx := context.Background()
+	client := openClient(t, open)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := 0; k < keys; k++ {
+				key := fmt.Sprintf("key_%d_%d", g, k)
+				assert.NoError(t, client.Set(ctx, key, []byte(key)))
+				value, err := client.Get(ctx, key)
+				assert.NoError(t, err)
+				assert.Equal(t, []byte(key), value)
+				op := storage.GetOperation(key)
+				assert.NoError(t, client.Batch(ctx, storage.DeleteOperation(key), storage.SetOperation(key, []byte(key)), op))
+				assert.Equal(t, []byte(key), op.Value)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for g := 0; g < goroutines; g++ {
+		for k := 0; k < keys; k++ {
+			key := fmt.Sprintf("key_%d_%d", g, k)
+			value, err := client.Get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, []byte(key), value)
+		}
+	}
+}
+
+func checkReopen(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openClient(t, open)
+	require.NoError(t, client.Batch(ctx,
+		storage.SetOperation("kept", []byte("kept")),
+		storage.SetOperation("deleted", []byte("deleted"))))
+	require.NoError(t, client.Close(ctx))
+
+	client = openClient(t, open)
+	value, err := client.Get(ctx, "kept")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("kept"), value, "data must survive reopening the storage")
+	require.NoError(t, client.Delete(ctx, "deleted"))
+	require.NoError(t, client.Close(ctx))
+
+	client = openClient(t, open)
+	value, err = client.Get(ctx, "deleted")
+	require.NoError(t, err)
+	assert.Nil(t, value, "deletions must survive reopening the storage")
+}
+
+// CheckExtendedClient checks that the storage.ExtendedClient returned by storage.NewExtendedClient for the clients of
+// a storage.Client implementation behaves as the storage.ExtendedClient interface requires: the checks apply to the
+// implementations of storage.ExtendedClient, and to the adapter of storage.NewExtendedClient for the other ones.
+// newStorage is called for each check, and must return the opener of a new, empty storage.
+func CheckExtendedClient(t *testing.T, newStorage func(t *testing.T) ClientOpener) {
+	checks := []struct {
+		name  string
+		check func(t *testing.T, open ClientOpener)
+	}{
+		{name: "ListPrefix", check: checkListPrefix},
+		{name: "ListCursor", check: checkListCursor},
+		{name: "TTL", check: checkTTL},
+		{name: "TTLRemoved", check: checkTTLRemoved},
+		{name: "ReopenExtended", check: checkReopenExtended},
+	}
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			c.check(t, newStorage(t))
+		})
+	}
+	// The extended client must still behave as a client.
+	CheckClient(t, func(t *testing.T) ClientOpener {
+		open := newStorage(t)
+		return func(t *testing.T) storage.Client {
+			return storage.NewExtendedClient(open(t))
+		}
+	})
+}
+
+// openExtendedClient opens a client, closed at the end of the test unless the check closes it itself.
+func openExtendedClient(t *testing.T, open ClientOpener) storage.ExtendedClient {
+	return storage.NewExtendedClient(openClient(t, open))
+}
+
+// setKeys sets the keys, with the key as value.
+func setKeys(t *testing.T, client storage.Client, keys ...string) {
+	ops := make([]storage.Operation, 0, len(keys))
+	for _, key := range keys {
+		ops = append(ops, storage.SetOperation(key, []byte(key)))
+	}
+	require.NoError(t, client.Batch(context.Background(), ops...))
+}
+
+func checkListPrefix(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openExtendedClient(t, open)
+	setKeys(t, client, "a/2", "b/1", "a/1", "a", "a/10", "deleted")
+	require.NoError(t, client.Delete(ctx, "deleted"))
+
+	keys, next, err := client.List(ctx, "a/", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/1", "a/10", "a/2"}, keys, "keys must be listed in lexicographic order")
+	assert.Empty(t, next)
+
+	keys, next, err = client.List(ctx, "", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "a/1", "a/10", "a/2", "b/1"}, keys, "deleted keys must not be listed")
+	assert.Empty(t, next)
+
+	keys, next, err = client.List(ctx, "c", "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.Empty(t, next)
+}
+
+func checkListCursor(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openExtendedClient(t, open)
+	setKeys(t, client, "k0", "k1", "k2", "k3", "k4", "other")
+
+	var pages [][]string
+	cursor := ""
+	for {
+		keys, next, err := client.List(ctx, "k", cursor, 2)
+		require.NoError(t, err)
+		pages = append(pages, keys)
+		if next == "" {
+			break
+		}
+		require.Less(t, len(pages), 5, "the cursor must move forward")
+		cursor = next
+	}
+	assert.Equal(t, [][]string{{"k0", "k1"}, {"k2", "k3"}, {"k4"}}, pages)
+
+	keys, next, err := client.List(ctx, "k", "k2", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k3", "k4"}, keys)
+	assert.Empty(t, next, "the cursor must be empty once all the keys were listed")
+}
+
+// isGone returns whether the key can neither be read nor listed.
+func isGone(t *testing.T, client storage.ExtendedClient, key string) bool {
+	ctx := context.Background()
+	value, err := client.Get(ctx, key)
+	require.NoError(t, err)
+	keys, _, err := client.List(ctx, key, "", 0)
+	require.NoError(t, err)
+	return value == nil && len(keys) == 0
+}
+
+func checkTTL(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openExtendedClient(t, open)
+	setKeys(t, client, "expiring", "kept")
+
+	require.NoError(t, client.SetTTL(ctx, "expiring", 50*time.Millisecond))
+	assert.Eventually(t, func() bool {
+		return isGone(t, client, "expiring")
+	}, 5*time.Second, 10*time.Millisecond, "the key must expire")
+	value, err := client.Get(ctx, "kept")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("kept"), value)
+
+	assert.NoError(t, client.SetTTL(ctx, "missing", time.Minute), "SetTTL of a missing key must not fail")
+	assert.True(t, isGone(t, client, "missing"), "SetTTL must not create the key")
+
+	// An expired key can be set again.
+	setKeys(t, client, "expiring")
+	value, err = client.Get(ctx, "expiring")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("expiring"), value)
+}
+
+func checkTTLRemoved(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openExtendedClient(t, open)
+	setKeys(t, client, "persisted", "overwritten")
+
+	require.NoError(t, client.SetTTL(ctx, "persisted", 50*time.Millisecond))
+	require.NoError(t, client.SetTTL(ctx, "persisted", 0))
+	require.NoError(t, client.SetTTL(ctx, "overwritten", 50*time.Millisecond))
+	setKeys(t, client, "overwritten")
+	time.Sleep(100 * time.Millisecond)
+
+	keys, _, err := client.List(ctx, "", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"overwritten", "persisted"}, keys, "a ttl that is not positive, or setting the key again, must remove the expiration")
+}
+
+func checkReopenExtended(t *testing.T, open ClientOpener) {
+	ctx := context.Background()
+	client := openExtendedClient(t, open)
+	setKeys(t, client, "expiring", "kept")
+	require.NoError(t, client.SetTTL(ctx, "expiring", 50*time.Millisecond))
+	require.NoError(t, client.Close(ctx))
+
+	client = openExtendedClient(t, open)
+	assert.Eventually(t, func() bool {
+		return isGone(t, client, "expiring")
+	}, 5*time.Second, 10*time.Millisecond, "the expiration must survive reopening the storage")
+	keys, _, err := client.List(ctx, "", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kept"}, keys, "the keys must be listed after reopening the storage")
+}
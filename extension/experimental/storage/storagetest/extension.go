@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest // import "go.opentelemetry.io/collector/extension/experimental/storage/storagetest"
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+const typeStr = "memory_storage"
+
+// InMemoryConfig is the configuration of the in-memory storage extension, which has no settings.
+type InMemoryConfig struct{}
+
+// NewInMemoryFactory returns an extension.Factory that creates in-memory storage extensions.
+func NewInMemoryFactory() extension.Factory {
+	return extension.NewFactory(
+		typeStr,
+		func() component.Config {
+			return &InMemoryConfig{}
+		},
+		func(context.Context, extension.CreateSettings, component.Config) (extension.Extension, error) {
+			return NewInMemoryExtension(), nil
+		},
+		component.StabilityLevelDevelopment)
+}
+
+// inMemoryExtension keeps a storage for each component and storage name, for as long as the extension lives.
+type inMemoryExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+
+	mu       sync.Mutex
+	storages map[string]*inMemoryStorage
+}
+
+// NewInMemoryExtension returns a storage.Extension keeping the data in memory, for tests and for setups where the
+// data does not need to survive a restart of the collector. The data written with a client is read by the clients
+// requested later for the same component and storage name, even after the first one is closed.
+func NewInMemoryExtension() storage.Extension {
+	return &inMemoryExtension{storages: map[string]*inMemoryStorage{}}
+}
+
+// GetClient returns a client for the storage of the component and storage name.
+func (ext *inMemoryExtension) GetClient(_ context.Context, kind component.Kind, id component.ID, storageName string) (storage.Client, error) {
+	key := fmt.Sprintf("%d/%s/%s", kind, id, storageName)
+
+	ext.mu.Lock()
+	defer ext.mu.Unlock()
+	st, ok := ext.storages[key]
+	if !ok {
+		st = newInMemoryStorage()
+		ext.storages[key] = st
+	}
+	return &inMemoryClient{storage: st}, nil
+}
@@ -0,0 +1,219 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+)
+
+func newTestStorage(t *testing.T) ClientOpener {
+	ext := NewInMemoryExtension()
+	require.NoError(t, ext.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, ext.Shutdown(context.Background()))
+	})
+	return func(t *testing.T) storage.Client {
+		client, err := ext.GetClient(context.Background(), component.KindExporter, component.NewID("test"), "")
+		require.NoError(t, err)
+		return client
+	}
+}
+
+func TestInMemoryClient(t *testing.T) {
+	CheckClient(t, newTestStorage)
+	CheckExtendedClient(t, newTestStorage)
+}
+
+// basicClient hides the storage.ExtendedClient methods of the client it wraps.
+type basicClient struct {
+	storage.Client
+}
+
+func TestExtendedClientAdapter(t *testing.T) {
+	CheckExtendedClient(t, func(t *testing.T) ClientOpener {
+		open := newTestStorage(t)
+		return func(t *testing.T) storage.Client {
+			return basicClient{Client: open(t)}
+		}
+	})
+}
+
+func TestInMemoryExtension_StoragesAreIsolated(t *testing.T) {
+	ctx := context.Background()
+	ext := NewInMemoryExtension()
+	id := component.NewID("test")
+
+	var clients []storage.Client
+	for _, name := range []string{"", "other"} {
+		for _, kind := range []component.Kind{component.KindReceiver, component.KindExporter} {
+			client, err := ext.GetClient(ctx, kind, id, name)
+			require.NoError(t, err)
+			clients = append(clients, client)
+		}
+	}
+	client, err := ext.GetClient(ctx, component.KindExporter, component.NewIDWithName("test", "other"), "")
+	require.NoError(t, err)
+	clients = append(clients, client)
+
+	for i, client := range clients {
+		require.NoError(t, client.Set(ctx, "key", []byte{byte(i)}))
+	}
+	for i, client := range clients {
+		value, err := client.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, []byte{byte(i)}, value)
+		assert.NoError(t, client.Close(ctx))
+	}
+}
+
+func TestInMemoryClient_ValuesAreCopied(t *testing.T) {
+	ctx := context.Background()
+	client := NewInMemoryClient()
+	value := []byte("value")
+	require.NoError(t, client.Set(ctx, "key", value))
+	value[0] = 'V'
+
+	got, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+	got[0] = 'V'
+
+	got, err = client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), got)
+	assert.NoError(t, client.Close(ctx))
+}
+
+func TestInMemoryFactory(t *testing.T) {
+	factory := NewInMemoryFactory()
+	cfg := factory.CreateDefaultConfig()
+	assert.NoError(t, componenttest.CheckConfigStruct(cfg))
+
+	ext, err := factory.CreateExtension(context.Background(), extensiontest.NewNopCreateSettings(), cfg)
+	require.NoError(t, err)
+	_, ok := ext.(storage.Extension)
+	assert.True(t, ok)
+}
+
+func TestExtendedClientAdapter_ReservedKey(t *testing.T) {
+	ctx := context.Background()
+	client := storage.NewExtendedClient(basicClient{Client: NewInMemoryClient()})
+	assert.Error(t, client.Set(ctx, "__extended_client_index", []byte("value")))
+	assert.Error(t, client.SetTTL(ctx, "__extended_client_index", time.Second))
+	assert.NoError(t, client.Close(ctx))
+}
+
+func TestExtendedClientAdapter_KeysSetBeforeWrapping(t *testing.T) {
+	ctx := context.Background()
+	inner := basicClient{Client: NewInMemoryClient()}
+	require.NoError(t, inner.Set(ctx, "before", []byte("value")))
+
+	client := storage.NewExtendedClient(inner)
+	require.NoError(t, client.Set(ctx, "after", []byte("value")))
+	keys, _, err := client.List(ctx, "", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"after"}, keys, "only the keys set through the adapter are listed")
+
+	require.NoError(t, client.SetTTL(ctx, "before", time.Millisecond))
+	assert.Eventually(t, func() bool {
+		value, err := client.Get(ctx, "before")
+		require.NoError(t, err)
+		return value == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, client.Close(ctx))
+}
+
+// recordingClient records the keys written by each batch of the client it wraps.
+type recordingClient struct {
+	basicClient
+	written [][]string
+}
+
+func (c *recordingClient) Batch(ctx context.Context, ops ...storage.Operation) error {
+	var keys []string
+	for _, op := range ops {
+		if op.Type != storage.Get {
+			keys = append(keys, op.Key)
+		}
+	}
+	if len(keys) > 0 {
+		c.written = append(c.written, keys)
+	}
+	return c.basicClient.Batch(ctx, ops...)
+}
+
+func TestExtendedClientAdapter_Pages(t *testing.T) {
+	ctx := context.Background()
+	inner := &recordingClient{basicClient: basicClient{Client: NewInMemoryClient()}}
+	client := storage.NewExtendedClient(inner)
+
+	// Enough keys to split the index in several pages.
+	var expected []string
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("key-%04d", i)
+		expected = append(expected, key)
+		require.NoError(t, client.Set(ctx, key, []byte(key)))
+	}
+	value, err := inner.Get(ctx, "__extended_client_index/1")
+	require.NoError(t, err)
+	assert.NotNil(t, value, "the index must be split in pages")
+
+	// A change only rewrites the page of the key, and setting a key already indexed only writes the key.
+	inner.written = nil
+	require.NoError(t, client.Set(ctx, "key-1000", []byte("other")))
+	require.NoError(t, client.Delete(ctx, "key-1000"))
+	assert.Equal(t, [][]string{{"key-1000"}}, inner.written[:1])
+	require.Len(t, inner.written, 2)
+	assert.Len(t, inner.written[1], 2)
+	require.NoError(t, client.Set(ctx, "key-1000", []byte("key-1000")))
+
+	var keys []string
+	cursor := ""
+	for {
+		var page []string
+		page, cursor, err = client.List(ctx, "key-", cursor, 300)
+		require.NoError(t, err)
+		keys = append(keys, page...)
+		if cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, expected, keys)
+
+	// Emptied pages are removed from the index.
+	for _, key := range expected[:1990] {
+		require.NoError(t, client.Delete(ctx, key))
+	}
+	keys, _, err = client.List(ctx, "", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, expected[1990:], keys)
+
+	// The index is read back from the client.
+	keys, _, err = storage.NewExtendedClient(inner).List(ctx, "key-19", "key-1995", 0)
+	require.NoError(t, err)
+	assert.Equal(t, expected[1996:], keys)
+	assert.NoError(t, client.Close(ctx))
+}
@@ -0,0 +1,442 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestorageextension // import "go.opentelemetry.io/collector/extension/filestorageextension"
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+const (
+	// compactionSuffix is appended to the name of a storage file to get the name of its compacted copy.
+	compactionSuffix = ".compacting"
+
+	oneMiB = 1048576
+)
+
+var (
+	defaultBucket = []byte(`default`)
+	// expirationBucket holds the expiration time of the keys with a TTL, in Unix nanoseconds.
+	expirationBucket = []byte(`expiration`)
+
+	errClientClosed = errors.New("storage client is closed")
+)
+
+// fileStorageClient keeps the data of a single component in a bbolt file. Every operation is a transaction of
+// the file, which is never left partially written: after a crash it holds the data of the last committed Batch.
+type fileStorageClient struct {
+	logger        *zap.Logger
+	compactionCfg CompactionConfig
+	options       *bbolt.Options
+
+	// mu prevents the operations from using the file while it is replaced by its compacted copy.
+	mu sync.RWMutex
+	db *bbolt.DB
+	// isCompactionNeeded is set once the file grew above the rebound needed threshold.
+	isCompactionNeeded bool
+
+	stopReboundChecks chan struct{}
+	reboundChecksDone chan struct{}
+}
+
+// Ensure this storage client implements the appropriate interface
+var _ storage.ExtendedClient = (*fileStorageClient)(nil)
+
+func newClient(logger *zap.Logger, path string, timeout time.Duration, compactionCfg CompactionConfig, fsync bool) (*fileStorageClient, error) {
+	// A compacted copy left behind is from a compaction that was interrupted before replacing the storage file,
+	// which is still complete.
+	if err := os.Remove(compactionPath(compactionCfg.Directory, path)); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove interrupted compaction of %s: %w", path, err)
+	}
+
+	options := &bbolt.Options{
+		Timeout:        timeout,
+		NoSync:         !fsync,
+		NoFreelistSync: true,
+	}
+	db, err := openDB(path, options)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &fileStorageClient{
+		logger:        logger,
+		compactionCfg: compactionCfg,
+		options:       options,
+		db:            db,
+	}
+	if compactionCfg.OnRebound {
+		c.startReboundChecks()
+	}
+	return c, nil
+}
+
+func openDB(path string, options *bbolt.Options) (*bbolt.DB, error) {
+	db, err := bbolt.Open(path, 0600, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage file %s: %w", path, err)
+	}
+	if err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err = tx.CreateBucketIfNotExists(defaultBucket); err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists(expirationBucket)
+		return err
+	}); err != nil {
+		return nil, multierr.Append(fmt.Errorf("failed to initialize storage file %s: %w", path, err), db.Close())
+	}
+	return db, nil
+}
+
+func compactionPath(directory string, path string) string {
+	return filepath.Join(directory, filepath.Base(path)+compactionSuffix)
+}
+
+// Get will retrieve data from storage that corresponds to the specified key
+func (c *fileStorageClient) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	if err := c.Batch(ctx, op); err != nil {
+		return nil, err
+	}
+	return op.Value, nil
+}
+
+// Set will store data. The data can be retrieved using the same key
+func (c *fileStorageClient) Set(ctx context.Context, key string, value []byte) error {
+	return c.Batch(ctx, storage.SetOperation(key, value))
+}
+
+// Delete will delete data associated with the specified key
+func (c *fileStorageClient) Delete(ctx context.Context, key string) error {
+	return c.Batch(ctx, storage.DeleteOperation(key))
+}
+
+// Batch executes the specified operations in order, in a single transaction: either all of them are applied to the
+// file or none of them is. Get operation results are put in-place. A batch of Get operations only runs in a read-only
+// transaction, which does not wait for the writes.
+func (c *fileStorageClient) Batch(_ context.Context, ops ...storage.Operation) error {
+	if onlyGets(ops) {
+		return c.view(func(bucket, expirations *bbolt.Bucket) error {
+			now := time.Now()
+			for _, op := range ops {
+				key := []byte(op.Key)
+				// The expired keys are left for the next write transaction to remove.
+				if isExpired(expirations, key, now) {
+					op.Value = nil
+					continue
+				}
+				op.Value = getValue(bucket, key)
+			}
+			return nil
+		})
+	}
+
+	return c.update(func(bucket, expirations *bbolt.Bucket) error {
+		now := time.Now()
+		var err error
+		for _, op := range ops {
+			key := []byte(op.Key)
+			if _, err = removeIfExpired(bucket, expirations, key, now); err != nil {
+				return err
+			}
+
+			switch op.Type {
+			case storage.Get:
+				op.Value = getValue(bucket, key)
+			case storage.Set:
+				if err = bucket.Put(key, op.Value); err == nil {
+					err = expirations.Delete(key)
+				}
+			case storage.Delete:
+				if err = bucket.Delete(key); err == nil {
+					err = expirations.Delete(key)
+				}
+			default:
+				return errors.New("wrong operation type")
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func onlyGets(ops []storage.Operation) bool {
+	for _, op := range ops {
+		if op.Type != storage.Get {
+			return false
+		}
+	}
+	return true
+}
+
+// getValue returns a copy of the value of the key, since the value is only valid during the transaction.
+func getValue(bucket *bbolt.Bucket, key []byte) []byte {
+	value := bucket.Get(key)
+	if value == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(value)), value...)
+}
+
+// List returns the keys starting with prefix that come after cursor, in lexicographic order. The expired keys met
+// are removed.
+func (c *fileStorageClient) List(_ context.Context, prefix string, cursor string, limit int) ([]string, string, error) {
+	var keys []string
+	var next string
+	err := c.update(func(bucket, expirations *bbolt.Bucket) error {
+		keys, next = nil, ""
+		now := time.Now()
+		var expired [][]byte
+		start := []byte(prefix)
+		if cursor > prefix {
+			start = []byte(cursor)
+		}
+
+		cur := bucket.Cursor()
+		for k, _ := cur.Seek(start); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = cur.Next() {
+			if string(k) <= cursor {
+				continue
+			}
+			if isExpired(expirations, k, now) {
+				// The bucket must not be changed while iterating over it.
+				expired = append(expired, append([]byte(nil), k...))
+				continue
+			}
+			if limit > 0 && len(keys) == limit {
+				next = keys[limit-1]
+				break
+			}
+			keys = append(keys, string(k))
+		}
+
+		for _, k := range expired {
+			if _, err := removeIfExpired(bucket, expirations, k, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return keys, next, nil
+}
+
+// SetTTL makes the key expire once ttl elapsed, or removes its expiration if ttl is not positive
+func (c *fileStorageClient) SetTTL(_ context.Context, key string, ttl time.Duration) error {
+	return c.update(func(bucket, expirations *bbolt.Bucket) error {
+		now := time.Now()
+		k := []byte(key)
+		removed, err := removeIfExpired(bucket, expirations, k, now)
+		if err != nil || removed || bucket.Get(k) == nil {
+			return err
+		}
+		if ttl <= 0 {
+			return expirations.Delete(k)
+		}
+		expiration := make([]byte, 8)
+		binary.BigEndian.PutUint64(expiration, uint64(now.Add(ttl).UnixNano()))
+		return expirations.Put(k, expiration)
+	})
+}
+
+// update runs fn in a write transaction of the storage file.
+func (c *fileStorageClient) update(fn func(bucket, expirations *bbolt.Bucket) error) error {
+	return c.transaction(true, fn)
+}
+
+// view runs fn in a read-only transaction of the storage file.
+func (c *fileStorageClient) view(fn func(bucket, expirations *bbolt.Bucket) error) error {
+	return c.transaction(false, fn)
+}
+
+func (c *fileStorageClient) transaction(writable bool, fn func(bucket, expirations *bbolt.Bucket) error) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.db == nil {
+		return errClientClosed
+	}
+
+	txFn := func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(defaultBucket)
+		expirations := tx.Bucket(expirationBucket)
+		if bucket == nil || expirations == nil {
+			return errors.New("storage not initialized")
+		}
+		return fn(bucket, expirations)
+	}
+	if writable {
+		return c.db.Update(txFn)
+	}
+	return c.db.View(txFn)
+}
+
+func isExpired(expirations *bbolt.Bucket, key []byte, now time.Time) bool {
+	expiration := expirations.Get(key)
+	return len(expiration) == 8 && int64(binary.BigEndian.Uint64(expiration)) <= now.UnixNano()
+}
+
+// removeIfExpired removes the key if it expired, and returns whether it did.
+func removeIfExpired(bucket, expirations *bbolt.Bucket, key []byte, now time.Time) (bool, error) {
+	if !isExpired(expirations, key, now) {
+		return false, nil
+	}
+	if err := bucket.Delete(key); err != nil {
+		return false, err
+	}
+	return true, expirations.Delete(key)
+}
+
+// Close will close the storage file
+func (c *fileStorageClient) Close(context.Context) error {
+	if c.stopReboundChecks != nil {
+		close(c.stopReboundChecks)
+		<-c.reboundChecksDone
+		c.stopReboundChecks = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db == nil {
+		return nil
+	}
+	err := c.db.Close()
+	c.db = nil
+	return err
+}
+
+// compact replaces the storage file with a compacted copy. The copy is written and synced to disk before being
+// renamed over the storage file, so a crash leaves either the original file or the compacted one.
+func (c *fileStorageClient) compact() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db == nil {
+		return errClientClosed
+	}
+
+	path := c.db.Path()
+	tempPath := compactionPath(c.compactionCfg.Directory, path)
+	started := time.Now()
+	c.logger.Debug("Starting compaction of storage file", zap.String("compaction_path", tempPath))
+
+	// The copy is always synced, it must be complete before it replaces the storage file.
+	compactedDB, err := bbolt.Open(tempPath, 0600, &bbolt.Options{Timeout: c.options.Timeout, NoFreelistSync: true})
+	if err != nil {
+		return fmt.Errorf("failed to create compacted copy of storage file: %w", err)
+	}
+	if err = bbolt.Compact(compactedDB, c.db, c.compactionCfg.MaxTransactionSize); err != nil {
+		return multierr.Combine(
+			fmt.Errorf("failed to compact storage file: %w", err),
+			compactedDB.Close(),
+			os.Remove(tempPath))
+	}
+	if err = compactedDB.Close(); err != nil {
+		return multierr.Append(fmt.Errorf("failed to close compacted copy of storage file: %w", err), os.Remove(tempPath))
+	}
+
+	// The storage file must be closed to be replaced, it is reopened whether the replacement succeeds or not.
+	if err = c.db.Close(); err != nil {
+		return multierr.Append(fmt.Errorf("failed to close storage file for compaction: %w", err), os.Remove(tempPath))
+	}
+	renameErr := os.Rename(tempPath, path)
+	if renameErr != nil {
+		renameErr = multierr.Append(fmt.Errorf("failed to replace storage file with its compacted copy: %w", renameErr), os.Remove(tempPath))
+	}
+	db, err := openDB(path, c.options)
+	if err != nil {
+		c.db = nil
+		return multierr.Append(renameErr, err)
+	}
+	c.db = db
+	if renameErr != nil {
+		return renameErr
+	}
+
+	c.isCompactionNeeded = false
+	c.logger.Debug("Finished compaction of storage file", zap.Duration("duration", time.Since(started)))
+	return nil
+}
+
+// startReboundChecks checks the size of the storage file every check interval, and compacts it on rebound.
+func (c *fileStorageClient) startReboundChecks() {
+	c.stopReboundChecks = make(chan struct{})
+	c.reboundChecksDone = make(chan struct{})
+	go func() {
+		defer close(c.reboundChecksDone)
+		ticker := time.NewTicker(c.compactionCfg.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stopReboundChecks:
+				return
+			case <-ticker.C:
+				if !c.shouldCompact() {
+					continue
+				}
+				if err := c.compact(); err != nil {
+					c.logger.Error("Failed to compact storage file on rebound", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// shouldCompact returns whether the storage file grew above the rebound needed threshold since it was last
+// compacted, and its data shrank back below the rebound trigger threshold.
+func (c *fileStorageClient) shouldCompact() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db == nil {
+		return false
+	}
+
+	totalSize, dataSize, err := c.getDBSize()
+	if err != nil {
+		c.logger.Error("Failed to get size of storage file", zap.Error(err))
+		return false
+	}
+	if totalSize > c.compactionCfg.ReboundNeededThresholdMiB*oneMiB {
+		c.isCompactionNeeded = true
+	}
+	return c.isCompactionNeeded && dataSize < c.compactionCfg.ReboundTriggerThresholdMiB*oneMiB
+}
+
+// getDBSize returns the size of the storage file, and the size of the data it holds, which excludes the free pages.
+func (c *fileStorageClient) getDBSize() (totalSize int64, dataSize int64, err error) {
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		totalSize = tx.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return totalSize, totalSize - int64(c.db.Stats().FreeAlloc), nil
+}
@@ -0,0 +1,214 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestorageextension
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.opentelemetry.io/collector/extension/experimental/storage/storagetest"
+)
+
+func newTestFileClient(t *testing.T, compactionCfg CompactionConfig) (*fileStorageClient, string) {
+	dir := t.TempDir()
+	compactionCfg.Directory = dir
+	path := filepath.Join(dir, "test")
+	client, err := newClient(zap.NewNop(), path, time.Second, compactionCfg, false)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, client.Close(context.Background()))
+	})
+	return client, path
+}
+
+func newConformanceStorage(t *testing.T) storagetest.ClientOpener {
+	path := filepath.Join(t.TempDir(), "test")
+	return func(t *testing.T) storage.Client {
+		client, err := newClient(zap.NewNop(), path, time.Second, CompactionConfig{Directory: filepath.Dir(path)}, false)
+		require.NoError(t, err)
+		return client
+	}
+}
+
+func TestClient_Conformance(t *testing.T) {
+	storagetest.CheckClient(t, newConformanceStorage)
+	storagetest.CheckExtendedClient(t, newConformanceStorage)
+}
+
+func TestClient_Operations(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestFileClient(t, CompactionConfig{})
+
+	value, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+	value, err = client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	require.NoError(t, client.Set(ctx, "key", []byte("other")))
+	value, err = client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("other"), value)
+
+	require.NoError(t, client.Delete(ctx, "key"))
+	require.NoError(t, client.Delete(ctx, "key"))
+	value, err = client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestClient_Batch(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestFileClient(t, CompactionConfig{})
+	require.NoError(t, client.Set(ctx, "deleted", []byte("value")))
+
+	ops := []storage.Operation{
+		storage.SetOperation("first", []byte("1")),
+		storage.GetOperation("first"),
+		storage.DeleteOperation("deleted"),
+		storage.GetOperation("deleted"),
+		storage.SetOperation("second", []byte("2")),
+	}
+	require.NoError(t, client.Batch(ctx, ops...))
+	assert.Equal(t, []byte("1"), ops[1].Value)
+	assert.Nil(t, ops[3].Value)
+
+	// A failed operation rolls back the whole batch.
+	err := client.Batch(ctx,
+		storage.SetOperation("third", []byte("3")),
+		storage.DeleteOperation("first"),
+		storage.SetOperation("", []byte("invalid")))
+	require.Error(t, err)
+
+	get := []storage.Operation{
+		storage.GetOperation("first"),
+		storage.GetOperation("second"),
+		storage.GetOperation("third"),
+	}
+	require.NoError(t, client.Batch(ctx, get...))
+	assert.Equal(t, []byte("1"), get[0].Value)
+	assert.Equal(t, []byte("2"), get[1].Value)
+	assert.Nil(t, get[2].Value)
+}
+
+func TestClient_GetReadOnly(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestFileClient(t, CompactionConfig{})
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+	require.NoError(t, client.Set(ctx, "expired", []byte("value")))
+	require.NoError(t, client.SetTTL(ctx, "expired", time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	// The Get operations do not wait for the write transaction in progress.
+	tx, err := client.db.Begin(true)
+	require.NoError(t, err)
+	get := []storage.Operation{
+		storage.GetOperation("key"),
+		storage.GetOperation("expired"),
+		storage.GetOperation("missing"),
+	}
+	done := make(chan error)
+	go func() {
+		done <- client.Batch(ctx, get...)
+	}()
+	select {
+	case err = <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Get waited for the write transaction")
+	}
+	require.NoError(t, tx.Rollback())
+
+	assert.Equal(t, []byte("value"), get[0].Value)
+	assert.Nil(t, get[1].Value)
+	assert.Nil(t, get[2].Value)
+}
+
+func TestClient_Closed(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestFileClient(t, CompactionConfig{})
+	require.NoError(t, client.Close(ctx))
+
+	_, err := client.Get(ctx, "key")
+	assert.ErrorIs(t, err, errClientClosed)
+	assert.ErrorIs(t, client.Set(ctx, "key", []byte("value")), errClientClosed)
+	assert.ErrorIs(t, client.Delete(ctx, "key"), errClientClosed)
+	assert.ErrorIs(t, client.compact(), errClientClosed)
+}
+
+// fillAndEmpty writes about 2MiB of data to the client, then deletes all but the last item.
+func fillAndEmpty(t *testing.T, client storage.Client) {
+	ctx := context.Background()
+	value := make([]byte, 1024)
+	for i := 0; i < 2048; i++ {
+		require.NoError(t, client.Set(ctx, fmt.Sprintf("key_%d", i), value))
+	}
+	for i := 0; i < 2047; i++ {
+		require.NoError(t, client.Delete(ctx, fmt.Sprintf("key_%d", i)))
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func TestClient_Compact(t *testing.T) {
+	ctx := context.Background()
+	client, path := newTestFileClient(t, CompactionConfig{MaxTransactionSize: 100})
+	fillAndEmpty(t, client)
+	sizeBefore := fileSize(t, path)
+
+	require.NoError(t, client.compact())
+	assert.Less(t, fileSize(t, path), sizeBefore)
+	assert.NoFileExists(t, path+compactionSuffix)
+
+	value, err := client.Get(ctx, "key_2047")
+	require.NoError(t, err)
+	assert.Len(t, value, 1024)
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+}
+
+func TestClient_CompactOnRebound(t *testing.T) {
+	client, path := newTestFileClient(t, CompactionConfig{
+		OnRebound:                  true,
+		ReboundNeededThresholdMiB:  2,
+		ReboundTriggerThresholdMiB: 1,
+		CheckInterval:              10 * time.Millisecond,
+	})
+	fillAndEmpty(t, client)
+
+	// The file grew to about 4MiB, it may already be compacted while the data is deleted.
+	assert.Eventually(t, func() bool {
+		return fileSize(t, path) < 2*oneMiB
+	}, 5*time.Second, 10*time.Millisecond)
+
+	value, err := client.Get(context.Background(), "key_2047")
+	require.NoError(t, err)
+	assert.Len(t, value, 1024)
+}
@@ -0,0 +1,109 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestorageextension // import "go.opentelemetry.io/collector/extension/filestorageextension"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines configuration for the file storage extension.
+type Config struct {
+	// Directory is the directory where the storage files are kept, one for each component and storage name.
+	// It must exist and be writable by the collector.
+	Directory string `mapstructure:"directory"`
+
+	// Timeout is how long to wait for the lock of a storage file, held by another process, before giving up.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Compaction defines when the storage files are compacted to give the space of deleted data back to the filesystem.
+	Compaction CompactionConfig `mapstructure:"compaction"`
+
+	// FSync flushes every write to the disk before returning. Without it the writes survive a crash of the
+	// collector but not a crash of the operating system or a power loss.
+	FSync bool `mapstructure:"fsync"`
+}
+
+// CompactionConfig defines the compaction of the storage files.
+type CompactionConfig struct {
+	// OnStart compacts a storage file when a client is created for it.
+	OnStart bool `mapstructure:"on_start"`
+
+	// OnRebound compacts a storage file while the collector runs, once it grew above ReboundNeededThresholdMiB
+	// and its data shrank back below ReboundTriggerThresholdMiB, e.g. after a backend outage filled a queue.
+	OnRebound bool `mapstructure:"on_rebound"`
+
+	// Directory is where the compacted file is written before it replaces the storage file. It must be on the same
+	// filesystem as the storage directory, and defaults to it.
+	Directory string `mapstructure:"directory"`
+
+	// MaxTransactionSize is the maximum number of items copied in a single transaction while compacting,
+	// 0 copies the whole file in a single transaction.
+	MaxTransactionSize int64 `mapstructure:"max_transaction_size"`
+
+	// ReboundNeededThresholdMiB is the size of a storage file above which it is compacted on rebound.
+	ReboundNeededThresholdMiB int64 `mapstructure:"rebound_needed_threshold_mib"`
+
+	// ReboundTriggerThresholdMiB is the size of the data below which a storage file that needs it is compacted.
+	ReboundTriggerThresholdMiB int64 `mapstructure:"rebound_trigger_threshold_mib"`
+
+	// CheckInterval is how often the size of the storage files is checked for the compaction on rebound.
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks if the extension configuration is valid
+func (cfg *Config) Validate() error {
+	dirs := []string{cfg.Directory}
+	if (cfg.Compaction.OnStart || cfg.Compaction.OnRebound) && cfg.Compaction.Directory != "" {
+		dirs = append(dirs, cfg.Compaction.Directory)
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("directory must exist: %w", err)
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+
+	if cfg.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	if cfg.Compaction.MaxTransactionSize < 0 {
+		return errors.New("max transaction size for compaction must not be negative")
+	}
+	if cfg.Compaction.OnRebound {
+		if cfg.Compaction.ReboundNeededThresholdMiB <= 0 || cfg.Compaction.ReboundTriggerThresholdMiB <= 0 {
+			return errors.New("rebound thresholds for compaction must be positive")
+		}
+		if cfg.Compaction.ReboundTriggerThresholdMiB > cfg.Compaction.ReboundNeededThresholdMiB {
+			return errors.New("rebound trigger threshold for compaction must not exceed the needed threshold")
+		}
+		if cfg.Compaction.CheckInterval <= 0 {
+			return errors.New("check interval for compaction must be positive")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestorageextension
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+)
+
+func TestUnmarshalDefaultConfig(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	assert.NoError(t, component.UnmarshalConfig(confmap.New(), cfg))
+	assert.Equal(t, factory.CreateDefaultConfig(), cfg)
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, err)
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	assert.NoError(t, component.UnmarshalConfig(cm, cfg))
+	assert.Equal(t,
+		&Config{
+			Directory: ".",
+			Timeout:   2 * time.Second,
+			FSync:     true,
+			Compaction: CompactionConfig{
+				OnStart:                    true,
+				OnRebound:                  true,
+				Directory:                  ".",
+				MaxTransactionSize:         2048,
+				ReboundNeededThresholdMiB:  50,
+				ReboundTriggerThresholdMiB: 5,
+				CheckInterval:              10 * time.Second,
+			},
+		}, cfg)
+	assert.NoError(t, component.ValidateConfig(cfg))
+}
+
+func TestValidateConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0600))
+	cases := []struct {
+		name   string
+		modify func(cfg *Config)
+		err    string
+	}{
+		{
+			name:   "missing directory",
+			modify: func(cfg *Config) { cfg.Directory = filepath.Join(t.TempDir(), "missing") },
+			err:    "directory must exist",
+		},
+		{
+			name:   "not a directory",
+			modify: func(cfg *Config) { cfg.Directory = file },
+			err:    "is not a directory",
+		},
+		{
+			name: "missing compaction directory",
+			modify: func(cfg *Config) {
+				cfg.Compaction.OnStart = true
+				cfg.Compaction.Directory = filepath.Join(t.TempDir(), "missing")
+			},
+			err: "directory must exist",
+		},
+		{
+			name:   "negative timeout",
+			modify: func(cfg *Config) { cfg.Timeout = -time.Second },
+			err:    "timeout must not be negative",
+		},
+		{
+			name:   "negative max transaction size",
+			modify: func(cfg *Config) { cfg.Compaction.MaxTransactionSize = -1 },
+			err:    "max transaction size for compaction must not be negative",
+		},
+		{
+			name: "zero rebound threshold",
+			modify: func(cfg *Config) {
+				cfg.Compaction.OnRebound = true
+				cfg.Compaction.ReboundTriggerThresholdMiB = 0
+			},
+			err: "rebound thresholds for compaction must be positive",
+		},
+		{
+			name: "trigger above needed threshold",
+			modify: func(cfg *Config) {
+				cfg.Compaction.OnRebound = true
+				cfg.Compaction.ReboundTriggerThresholdMiB = cfg.Compaction.ReboundNeededThresholdMiB + 1
+			},
+			err: "rebound trigger threshold for compaction must not exceed the needed threshold",
+		},
+		{
+			name: "zero check interval",
+			modify: func(cfg *Config) {
+				cfg.Compaction.OnRebound = true
+				cfg.Compaction.CheckInterval = 0
+			},
+			err: "check interval for compaction must be positive",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Directory = t.TempDir()
+			require.NoError(t, cfg.Validate())
+			c.modify(cfg)
+			assert.ErrorContains(t, cfg.Validate(), c.err)
+		})
+	}
+}
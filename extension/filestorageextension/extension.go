@@ -0,0 +1,112 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestorageextension // import "go.opentelemetry.io/collector/extension/filestorageextension"
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+type localFileStorage struct {
+	cfg    *Config
+	logger *zap.Logger
+}
+
+// Ensure this storage extension implements the appropriate interface
+var _ storage.Extension = (*localFileStorage)(nil)
+
+func newLocalFileStorage(cfg *Config, logger *zap.Logger) *localFileStorage {
+	return &localFileStorage{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Start does nothing, the storage files are opened by GetClient.
+func (lfs *localFileStorage) Start(context.Context, component.Host) error {
+	return nil
+}
+
+// Shutdown does nothing, the clients are closed by the components using them.
+func (lfs *localFileStorage) Shutdown(context.Context) error {
+	return nil
+}
+
+// GetClient returns a storage client for an individual component, kept in its own file.
+func (lfs *localFileStorage) GetClient(_ context.Context, kind component.Kind, id component.ID, storageName string) (storage.Client, error) {
+	var rawName string
+	if storageName == "" {
+		rawName = fmt.Sprintf("%s_%s_%s", kindString(kind), id.Type(), id.Name())
+	} else {
+		rawName = fmt.Sprintf("%s_%s_%s_%s", kindString(kind), id.Type(), id.Name(), storageName)
+	}
+	path := filepath.Join(lfs.cfg.Directory, sanitize(rawName))
+
+	compactionCfg := lfs.cfg.Compaction
+	if compactionCfg.Directory == "" {
+		compactionCfg.Directory = lfs.cfg.Directory
+	}
+	client, err := newClient(lfs.logger.With(zap.String("path", path)), path, lfs.cfg.Timeout, compactionCfg, lfs.cfg.FSync)
+	if err != nil {
+		return nil, err
+	}
+
+	if compactionCfg.OnStart {
+		if err = client.compact(); err != nil {
+			return nil, multierr.Combine(err, client.Close(context.Background()))
+		}
+	}
+	return client, nil
+}
+
+func kindString(k component.Kind) string {
+	switch k {
+	case component.KindReceiver:
+		return "receiver"
+	case component.KindProcessor:
+		return "processor"
+	case component.KindExporter:
+		return "exporter"
+	case component.KindExtension:
+		return "extension"
+	case component.KindConnector:
+		return "connector"
+	default:
+		return "other"
+	}
+}
+
+// sanitize escapes the bytes that may not be used in a file name on any OS, as well as '~' which is the escape
+// character, so that different names are never mapped to the same file.
+func sanitize(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "~%02X", c)
+	}
+	return b.String()
+}
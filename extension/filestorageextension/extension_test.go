@@ -0,0 +1,142 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestorageextension
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+func newTestExtension(t *testing.T) *localFileStorage {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Directory = t.TempDir()
+	cfg.Timeout = 100 * time.Millisecond
+	lfs := newLocalFileStorage(cfg, zap.NewNop())
+	require.NoError(t, lfs.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, lfs.Shutdown(context.Background()))
+	})
+	return lfs
+}
+
+func newTestClient(t *testing.T, lfs *localFileStorage, id component.ID, storageName string) storage.Client {
+	client, err := lfs.GetClient(context.Background(), component.KindExporter, id, storageName)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, client.Close(context.Background()))
+	})
+	return client
+}
+
+func TestExtension_ClientsAreIsolated(t *testing.T) {
+	ctx := context.Background()
+	lfs := newTestExtension(t)
+	clients := []storage.Client{
+		newTestClient(t, lfs, component.NewID("otlp"), ""),
+		newTestClient(t, lfs, component.NewIDWithName("otlp", "backup"), ""),
+		newTestClient(t, lfs, component.NewIDWithName("otlp", "backup"), "traces"),
+	}
+	receiverClient, err := lfs.GetClient(ctx, component.KindReceiver, component.NewID("otlp"), "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, receiverClient.Close(ctx)) }()
+	clients = append(clients, receiverClient)
+
+	for i, client := range clients {
+		require.NoError(t, client.Set(ctx, "key", []byte{byte(i)}))
+	}
+	for i, client := range clients {
+		value, err := client.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, []byte{byte(i)}, value)
+	}
+
+	entries, err := os.ReadDir(lfs.cfg.Directory)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{
+		"exporter_otlp_",
+		"exporter_otlp_backup",
+		"exporter_otlp_backup_traces",
+		"receiver_otlp_",
+	}, names)
+}
+
+func TestExtension_DataSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	lfs := newTestExtension(t)
+	id := component.NewID("otlp")
+
+	client, err := lfs.GetClient(ctx, component.KindExporter, id, "")
+	require.NoError(t, err)
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+	require.NoError(t, client.Close(ctx))
+
+	client = newTestClient(t, lfs, id, "")
+	value, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestExtension_FileLockedByOtherClient(t *testing.T) {
+	lfs := newTestExtension(t)
+	id := component.NewID("otlp")
+	newTestClient(t, lfs, id, "")
+
+	_, err := lfs.GetClient(context.Background(), component.KindExporter, id, "")
+	assert.ErrorContains(t, err, "failed to open storage file")
+}
+
+func TestExtension_InterruptedCompaction(t *testing.T) {
+	ctx := context.Background()
+	lfs := newTestExtension(t)
+	lfs.cfg.Compaction.OnStart = true
+	id := component.NewID("otlp")
+
+	client, err := lfs.GetClient(ctx, component.KindExporter, id, "")
+	require.NoError(t, err)
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+	require.NoError(t, client.Close(ctx))
+
+	// A crash during the compaction leaves a partial copy next to the complete storage file.
+	partialCopy := filepath.Join(lfs.cfg.Directory, "exporter_otlp_"+compactionSuffix)
+	require.NoError(t, os.WriteFile(partialCopy, []byte("partial"), 0600))
+
+	client = newTestClient(t, lfs, id, "")
+	value, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+	assert.NoFileExists(t, partialCopy)
+}
+
+func TestSanitize(t *testing.T) {
+	assert.Equal(t, "exporter_otlp_backup-1.traces", sanitize("exporter_otlp_backup-1.traces"))
+	assert.Equal(t, "exporter_otlp_a~2Fb~5Cc~3A~7E", sanitize(`exporter_otlp_a/b\c:~`))
+	assert.Equal(t, "receiver_otlp_~C3~A9", sanitize("receiver_otlp_é"))
+	assert.NotEqual(t, sanitize("a/b"), sanitize("a~2Fb"))
+}
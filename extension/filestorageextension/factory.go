@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestorageextension // import "go.opentelemetry.io/collector/extension/filestorageextension"
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+)
+
+const (
+	// The value of extension "type" in configuration.
+	typeStr = "file_storage"
+
+	defaultTimeout                    = time.Second
+	defaultMaxTransactionSize         = 65536
+	defaultReboundNeededThresholdMiB  = 100
+	defaultReboundTriggerThresholdMiB = 10
+	defaultCheckInterval              = 5 * time.Second
+)
+
+// NewFactory creates a factory for the file storage extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(typeStr, createDefaultConfig, createExtension, component.StabilityLevelDevelopment)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Directory: getDefaultDirectory(),
+		Timeout:   defaultTimeout,
+		Compaction: CompactionConfig{
+			MaxTransactionSize:         defaultMaxTransactionSize,
+			ReboundNeededThresholdMiB:  defaultReboundNeededThresholdMiB,
+			ReboundTriggerThresholdMiB: defaultReboundTriggerThresholdMiB,
+			CheckInterval:              defaultCheckInterval,
+		},
+	}
+}
+
+func createExtension(_ context.Context, set extension.CreateSettings, cfg component.Config) (extension.Extension, error) {
+	return newLocalFileStorage(cfg.(*Config), set.TelemetrySettings.Logger), nil
+}
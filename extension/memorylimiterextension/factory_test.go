@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiterextension
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+)
+
+func TestFactory_CreateDefaultConfig(t *testing.T) {
+	cfg := createDefaultConfig()
+	assert.Equal(t, &Config{}, cfg)
+	assert.NoError(t, componenttest.CheckConfigStruct(cfg))
+
+	// This extension can't be created with the default config.
+	ext, err := createExtension(context.Background(), extensiontest.NewNopCreateSettings(), cfg)
+	assert.Error(t, err)
+	assert.Nil(t, ext)
+}
+
+func TestFactory_CreateExtension(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.CheckInterval = 100 * time.Millisecond
+	cfg.MemoryLimitMiB = 5722
+	cfg.MemorySpikeLimitMiB = 1907
+	ext, err := createExtension(context.Background(), extensiontest.NewNopCreateSettings(), cfg)
+	require.NoError(t, err)
+	require.NotNil(t, ext)
+
+	assert.NoError(t, ext.Start(context.Background(), componenttest.NewNopHost()))
+	assert.NoError(t, ext.Shutdown(context.Background()))
+}
+
+func TestFactory_CreateExtensionUnknownMode(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.CheckInterval = 100 * time.Millisecond
+	cfg.MemoryLimitMiB = 5722
+	cfg.Mode = "unknown"
+	ext, err := createExtension(context.Background(), extensiontest.NewNopCreateSettings(), cfg)
+	assert.Error(t, err)
+	assert.Nil(t, ext)
+}
@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiterextension // import "go.opentelemetry.io/collector/extension/memorylimiterextension"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/internal/memorylimiter"
+)
+
+type memoryLimiterExtension struct {
+	memLimiter *memorylimiter.MemoryLimiter
+}
+
+func newMemoryLimiter(cfg *Config, set extension.CreateSettings) (*memoryLimiterExtension, error) {
+	memLimiter, err := memorylimiter.NewMemoryLimiter(&memorylimiter.Config{
+		CheckInterval:         cfg.CheckInterval,
+		MemoryLimitMiB:        cfg.MemoryLimitMiB,
+		MemorySpikeLimitMiB:   cfg.MemorySpikeLimitMiB,
+		MemoryLimitPercentage: cfg.MemoryLimitPercentage,
+		MemorySpikePercentage: cfg.MemorySpikePercentage,
+		Mode:                  cfg.Mode,
+		GCPercent:             cfg.GCPercent,
+	}, memorylimiter.Settings{
+		TelemetrySettings: set.TelemetrySettings,
+		ID:                set.ID,
+		Kind:              component.KindExtension,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &memoryLimiterExtension{memLimiter: memLimiter}, nil
+}
+
+func (ml *memoryLimiterExtension) Start(ctx context.Context, host component.Host) error {
+	return ml.memLimiter.Start(ctx, host)
+}
+
+func (ml *memoryLimiterExtension) Shutdown(ctx context.Context) error {
+	return ml.memLimiter.Shutdown(ctx)
+}
+
+// GetMemoryLimit returns the memory limit, in bytes, applied to the Go runtime in the
+// "gomemlimit" mode, 0 if none is applied.
+func (ml *memoryLimiterExtension) GetMemoryLimit() uint64 {
+	return ml.memLimiter.GetMemoryLimit()
+}
+
+// GetGCPercent returns the garbage collection target percentage applied to the Go
+// runtime in the "gomemlimit" mode.
+func (ml *memoryLimiterExtension) GetGCPercent() int {
+	return ml.memLimiter.GetGCPercent()
+}
+
+// MustRefuse returns true when the memory usage is above the soft limit, the
+// receivers must then refuse the data before reading it.
+func (ml *memoryLimiterExtension) MustRefuse() bool {
+	return ml.memLimiter.MustRefuse()
+}
@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiterextension
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"go.opentelemetry.io/collector/internal/memorylimiter"
+)
+
+const mibBytes = 1024 * 1024
+
+func TestMustRefuse(t *testing.T) {
+	var currentMemAlloc uint64
+	t.Cleanup(func() {
+		memorylimiter.ReadMemStatsFn = runtime.ReadMemStats
+	})
+	memorylimiter.ReadMemStatsFn = func(ms *runtime.MemStats) {
+		ms.Alloc = currentMemAlloc
+	}
+	ml, err := newMemoryLimiter(&Config{
+		CheckInterval:       time.Second,
+		MemoryLimitMiB:      1024,
+		MemorySpikeLimitMiB: 128,
+	}, extensiontest.NewNopCreateSettings())
+	require.NoError(t, err)
+	assert.False(t, ml.MustRefuse())
+
+	// Above the soft limit.
+	currentMemAlloc = 900 * mibBytes
+	ml.memLimiter.CheckMemLimits()
+	assert.True(t, ml.MustRefuse())
+
+	// Back below the soft limit.
+	currentMemAlloc = 800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
+	assert.False(t, ml.MustRefuse())
+}
@@ -0,0 +1,530 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memorylimiter implements the memory usage checks shared by the memory_limiter
+// processor and the memory_limiter extension.
+package memorylimiter // import "go.opentelemetry.io/collector/internal/memorylimiter"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/featuregate"
+	"go.opentelemetry.io/collector/internal/iruntime"
+	"go.opentelemetry.io/collector/internal/obsreportconfig"
+)
+
+const (
+	mibBytes = 1024 * 1024
+
+	// ModeGC enforces the hard limit by forcing garbage collections.
+	ModeGC = "gc"
+	// ModeGoMemLimit applies the hard limit as the soft memory limit of the Go runtime,
+	// which collects the garbage as needed to stay below it.
+	ModeGoMemLimit = "gomemlimit"
+)
+
+var (
+	// ErrCheckIntervalOutOfRange is returned when the check interval is not positive.
+	ErrCheckIntervalOutOfRange = errors.New(
+		"checkInterval must be greater than zero")
+
+	// ErrLimitOutOfRange is returned when no memory limit is configured.
+	ErrLimitOutOfRange = errors.New(
+		"memAllocLimit or memoryLimitPercentage must be greater than zero")
+
+	// ErrMemSpikeLimitOutOfRange is returned when the spike limit is not below the memory limit.
+	ErrMemSpikeLimitOutOfRange = errors.New(
+		"memSpikeLimit must be smaller than memAllocLimit")
+
+	// ErrPercentageLimitOutOfRange is returned when a percentage is not in the (0, 100] range.
+	ErrPercentageLimitOutOfRange = errors.New(
+		"memoryLimitPercentage and memorySpikePercentage must be greater than zero and less than or equal to hundred",
+	)
+
+	// ErrShutdownNotStarted is returned when shutting down a MemoryLimiter that is not monitoring the memory.
+	ErrShutdownNotStarted = errors.New("no existing monitoring routine is running")
+
+	// ErrGoMemLimitUnsupported is returned when the gomemlimit mode is used with a Go runtime older than 1.19.
+	ErrGoMemLimitUnsupported = errors.New("mode gomemlimit requires a collector built with Go 1.19 or later")
+
+	// ErrGCPercentWithoutGoMemLimit is returned when the GC percent is set in another mode than gomemlimit.
+	ErrGCPercentWithoutGoMemLimit = errors.New("gc_percent can only be set in the gomemlimit mode")
+
+	// ErrGoMemLimitInUse is returned when starting a MemoryLimiter in the gomemlimit mode while another
+	// one is already applying its limit to the Go runtime.
+	ErrGoMemLimitInUse = errors.New("the Go runtime memory limit is already applied by another memory limiter in the gomemlimit mode")
+)
+
+var (
+	goMemLimitLock sync.Mutex
+	// goMemLimitOwner is the MemoryLimiter applying its limit to the Go runtime, the settings are
+	// process-wide so only one MemoryLimiter in the ModeGoMemLimit mode can run at a time.
+	goMemLimitOwner *MemoryLimiter
+)
+
+// GetMemoryFn returns the total memory of the host, it is overridable by tests.
+var GetMemoryFn = iruntime.TotalMemory
+
+// ReadMemStatsFn reads the memory statistics, it is overridable by tests.
+var ReadMemStatsFn = runtime.ReadMemStats
+
+// make them overridable by tests
+var (
+	setMemoryLimitFn = setMemoryLimit
+	setGCPercentFn   = debug.SetGCPercent
+)
+
+// Config defines the memory limits and how often they are checked.
+type Config struct {
+	// CheckInterval is the time between measurements of memory usage for the
+	// purposes of avoiding going over the limits. Defaults to zero, so no
+	// checks will be performed.
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+
+	// MemoryLimitMiB is the maximum amount of memory, in MiB, targeted to be
+	// allocated by the process.
+	MemoryLimitMiB uint32 `mapstructure:"limit_mib"`
+
+	// MemorySpikeLimitMiB is the maximum, in MiB, spike expected between the
+	// measurements of memory usage.
+	MemorySpikeLimitMiB uint32 `mapstructure:"spike_limit_mib"`
+
+	// MemoryLimitPercentage is the maximum amount of memory, in %, targeted to be
+	// allocated by the process. The fixed memory settings MemoryLimitMiB has a higher precedence.
+	MemoryLimitPercentage uint32 `mapstructure:"limit_percentage"`
+
+	// MemorySpikePercentage is the maximum, in percents against the total memory,
+	// spike expected between the measurements of memory usage.
+	MemorySpikePercentage uint32 `mapstructure:"spike_limit_percentage"`
+
+	// Mode is how the hard limit is enforced, ModeGC (default) or ModeGoMemLimit.
+	Mode string `mapstructure:"mode"`
+
+	// GCPercent is the garbage collection target percentage, see runtime/debug.SetGCPercent,
+	// applied in the ModeGoMemLimit mode. Zero keeps the current value, a negative value
+	// only collects the garbage when approaching the memory limit.
+	GCPercent int `mapstructure:"gc_percent"`
+}
+
+// MemoryLimiter periodically checks the memory usage of the process and tells
+// whether the data must be refused because the usage is above the soft limit.
+type MemoryLimiter struct {
+	usageChecker memUsageChecker
+
+	memCheckWait time.Duration
+	ballastSize  uint64
+
+	// mustRefuse is used atomically to indicate when data should be refused.
+	mustRefuse *atomic.Bool
+	// aboveHardLimit tells whether the memory usage is above the hard limit at
+	// the last check, even after the forced GC.
+	aboveHardLimit *atomic.Bool
+
+	// State reported by the telemetry and on the zPages.
+	mode          string
+	memoryUsage   *atomic.Uint64
+	forcedGCCount *atomic.Int64
+	refusingTime  *atomic.Duration
+	lastCheck     time.Time
+	telemetry     *telemetry
+
+	// Fields used in the ModeGoMemLimit mode, the applied settings are reported
+	// and the previous ones restored on shutdown.
+	goMemLimit          bool
+	gcPercent           int
+	memoryLimit         *atomic.Uint64
+	appliedGCPercent    *atomic.Int64
+	previousMemoryLimit int64
+	previousGCPercent   int
+
+	ticker *time.Ticker
+
+	lastGCDone time.Time
+
+	// The function to read the mem values is set as a reference to help with
+	// testing different values.
+	readMemStatsFn func(m *runtime.MemStats)
+
+	// Fields used for logging.
+	logger                 *zap.Logger
+	configMismatchedLogged bool
+
+	refCounterLock sync.Mutex
+	refCounter     int
+}
+
+// Minimum interval between forced GC when in soft limited mode. We don't want to
+// do GCs too frequently since it is a CPU-heavy operation.
+const minGCIntervalWhenSoftLimited = 10 * time.Second
+
+// NewMemoryLimiter returns a new MemoryLimiter, the memory is not checked until Start is called.
+// Its telemetry is reported as the component described by the settings.
+func NewMemoryLimiter(cfg *Config, set Settings) (*MemoryLimiter, error) {
+	return newMemoryLimiter(cfg, set, featuregate.GetRegistry())
+}
+
+func newMemoryLimiter(cfg *Config, set Settings, registry *featuregate.Registry) (*MemoryLimiter, error) {
+	logger := set.Logger
+	if cfg.CheckInterval <= 0 {
+		return nil, ErrCheckIntervalOutOfRange
+	}
+	if cfg.MemoryLimitMiB == 0 && cfg.MemoryLimitPercentage == 0 {
+		return nil, ErrLimitOutOfRange
+	}
+	switch cfg.Mode {
+	case "", ModeGC:
+		if cfg.GCPercent != 0 {
+			return nil, ErrGCPercentWithoutGoMemLimit
+		}
+	case ModeGoMemLimit:
+		if !goMemLimitSupported {
+			return nil, ErrGoMemLimitUnsupported
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %q, must be %q or %q", cfg.Mode, ModeGC, ModeGoMemLimit)
+	}
+
+	usageChecker, err := getMemUsageChecker(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Memory limiter configured",
+		zap.Uint64("limit_mib", usageChecker.memAllocLimit/mibBytes),
+		zap.Uint64("spike_limit_mib", usageChecker.memSpikeLimit/mibBytes),
+		zap.Duration("check_interval", cfg.CheckInterval),
+		zap.String("mode", cfg.Mode))
+
+	mode := cfg.Mode
+	if mode == "" {
+		mode = ModeGC
+	}
+	ml := &MemoryLimiter{
+		usageChecker:     *usageChecker,
+		memCheckWait:     cfg.CheckInterval,
+		ticker:           time.NewTicker(cfg.CheckInterval),
+		readMemStatsFn:   ReadMemStatsFn,
+		logger:           logger,
+		mustRefuse:       atomic.NewBool(false),
+		aboveHardLimit:   atomic.NewBool(false),
+		mode:             mode,
+		memoryUsage:      atomic.NewUint64(0),
+		forcedGCCount:    atomic.NewInt64(0),
+		refusingTime:     atomic.NewDuration(0),
+		goMemLimit:       cfg.Mode == ModeGoMemLimit,
+		gcPercent:        cfg.GCPercent,
+		memoryLimit:      atomic.NewUint64(0),
+		appliedGCPercent: atomic.NewInt64(0),
+	}
+	ml.telemetry, err = newTelemetry(set, registry.IsEnabled(obsreportconfig.UseOtelForInternalMetricsfeatureGateID), ml)
+	if err != nil {
+		return nil, err
+	}
+	return ml, nil
+}
+
+func getMemUsageChecker(cfg *Config, logger *zap.Logger) (*memUsageChecker, error) {
+	memAllocLimit := uint64(cfg.MemoryLimitMiB) * mibBytes
+	memSpikeLimit := uint64(cfg.MemorySpikeLimitMiB) * mibBytes
+	if cfg.MemoryLimitMiB != 0 {
+		return newFixedMemUsageChecker(memAllocLimit, memSpikeLimit)
+	}
+	totalMemory, err := GetMemoryFn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get total memory, use fixed memory settings (limit_mib): %w", err)
+	}
+	logger.Info("Using percentage memory limiter",
+		zap.Uint64("total_memory_mib", totalMemory/mibBytes),
+		zap.Uint32("limit_percentage", cfg.MemoryLimitPercentage),
+		zap.Uint32("spike_limit_percentage", cfg.MemorySpikePercentage))
+	return newPercentageMemUsageChecker(totalMemory, uint64(cfg.MemoryLimitPercentage), uint64(cfg.MemorySpikePercentage))
+}
+
+// Start looks for the ballast extension to exclude it from the memory usage and
+// starts monitoring the memory, if it is not already running. In the ModeGoMemLimit
+// mode it fails with ErrGoMemLimitInUse if another MemoryLimiter applies its limit.
+func (ml *MemoryLimiter) Start(_ context.Context, host component.Host) error {
+	extensions := host.GetExtensions()
+	for _, extension := range extensions {
+		if ext, ok := extension.(interface{ GetBallastSize() uint64 }); ok {
+			ml.ballastSize = ext.GetBallastSize()
+			break
+		}
+	}
+	return ml.startMonitoring()
+}
+
+// Shutdown stops monitoring the memory once every Start has been matched by a Shutdown.
+func (ml *MemoryLimiter) Shutdown(context.Context) error {
+	ml.refCounterLock.Lock()
+	defer ml.refCounterLock.Unlock()
+
+	if ml.refCounter == 0 {
+		return ErrShutdownNotStarted
+	} else if ml.refCounter == 1 {
+		ml.ticker.Stop()
+		if ml.goMemLimit {
+			ml.restoreGoMemoryLimit()
+		}
+	}
+	ml.refCounter--
+	return nil
+}
+
+// GetMemoryLimit returns the memory limit, in bytes, applied to the Go runtime in the
+// ModeGoMemLimit mode, 0 if none is applied.
+func (ml *MemoryLimiter) GetMemoryLimit() uint64 {
+	return ml.memoryLimit.Load()
+}
+
+// GetGCPercent returns the garbage collection target percentage applied to the Go runtime
+// in the ModeGoMemLimit mode, only meaningful when GetMemoryLimit is not 0.
+func (ml *MemoryLimiter) GetGCPercent() int {
+	return int(ml.appliedGCPercent.Load())
+}
+
+// MustRefuse returns true when the memory usage is above the soft limit and the
+// data must be refused.
+func (ml *MemoryLimiter) MustRefuse() bool {
+	return ml.mustRefuse.Load()
+}
+
+// RefuseCause returns why the data is refused, CauseHardLimit or CauseSoftLimit.
+// It is only meaningful when MustRefuse returns true.
+func (ml *MemoryLimiter) RefuseCause() string {
+	if ml.aboveHardLimit.Load() {
+		return CauseHardLimit
+	}
+	return CauseSoftLimit
+}
+
+// RecordRefused records numItems items of the given data type refused because of
+// the memory usage, tagged with the current refusal cause.
+func (ml *MemoryLimiter) RecordRefused(dataType component.DataType, numItems int) {
+	ml.telemetry.recordRefused(dataType, ml.RefuseCause(), numItems)
+}
+
+// ZPagesProperties returns the state of the memory limiter to be displayed on the zPages.
+func (ml *MemoryLimiter) ZPagesProperties() [][2]string {
+	state := "Accepting data"
+	if ml.MustRefuse() {
+		state = "Refusing data, memory usage above the soft limit"
+		if ml.RefuseCause() == CauseHardLimit {
+			state = "Refusing data, memory usage above the hard limit"
+		}
+	}
+	props := [][2]string{
+		{"Memory limiter state", state},
+		{"Memory limiter mode", ml.mode},
+		{"Memory usage (MiB)", strconv.FormatUint(ml.memoryUsage.Load()/mibBytes, 10)},
+		{"Soft limit (MiB)", strconv.FormatUint((ml.usageChecker.memAllocLimit-ml.usageChecker.memSpikeLimit)/mibBytes, 10)},
+		{"Hard limit (MiB)", strconv.FormatUint(ml.usageChecker.memAllocLimit/mibBytes, 10)},
+		{"Forced garbage collections", strconv.FormatInt(ml.forcedGCCount.Load(), 10)},
+		{"Time spent refusing data", ml.refusingTime.Load().String()},
+	}
+	if limit := ml.GetMemoryLimit(); limit != 0 {
+		props = append(props,
+			[2]string{"Go runtime memory limit (MiB)", strconv.FormatUint(limit/mibBytes, 10)},
+			[2]string{"Go runtime GC percent", strconv.Itoa(ml.GetGCPercent())})
+	}
+	return props
+}
+
+func (ml *MemoryLimiter) readMemStats() *runtime.MemStats {
+	ms := &runtime.MemStats{}
+	ml.readMemStatsFn(ms)
+	// If proper configured ms.Alloc should be at least ml.ballastSize but since
+	// a misconfiguration is possible check for that here.
+	if ms.Alloc >= ml.ballastSize {
+		ms.Alloc -= ml.ballastSize
+	} else if !ml.configMismatchedLogged {
+		// This indicates misconfiguration. Log it once.
+		ml.configMismatchedLogged = true
+		ml.logger.Warn(`"size_mib" in ballast extension is likely incorrectly configured.`)
+	}
+
+	return ms
+}
+
+// startMonitoring starts a single ticker'd goroutine per instance
+// that will check memory usage every checkInterval period.
+func (ml *MemoryLimiter) startMonitoring() error {
+	ml.refCounterLock.Lock()
+	defer ml.refCounterLock.Unlock()
+
+	if ml.refCounter == 0 {
+		if ml.goMemLimit {
+			if err := ml.applyGoMemoryLimit(); err != nil {
+				return err
+			}
+		}
+		go func() {
+			for range ml.ticker.C {
+				ml.CheckMemLimits()
+			}
+		}()
+	}
+	ml.refCounter++
+	return nil
+}
+
+// applyGoMemoryLimit sets the hard limit as the Go runtime memory limit. The ballast
+// is part of the heap seen by the runtime, hence added to the limit.
+func (ml *MemoryLimiter) applyGoMemoryLimit() error {
+	goMemLimitLock.Lock()
+	defer goMemLimitLock.Unlock()
+	if goMemLimitOwner != nil {
+		return ErrGoMemLimitInUse
+	}
+	goMemLimitOwner = ml
+
+	limit := ml.usageChecker.memAllocLimit + ml.ballastSize
+	ml.previousMemoryLimit = setMemoryLimitFn(int64(limit))
+	gcPercent := ml.gcPercent
+	if gcPercent == 0 {
+		// Read the current value, setting it back right away.
+		gcPercent = setGCPercentFn(-1)
+		setGCPercentFn(gcPercent)
+		ml.previousGCPercent = gcPercent
+	} else {
+		ml.previousGCPercent = setGCPercentFn(gcPercent)
+	}
+	ml.memoryLimit.Store(limit)
+	ml.appliedGCPercent.Store(int64(gcPercent))
+	ml.logger.Info("Go runtime memory limit applied",
+		zap.Uint64("memory_limit_mib", limit/mibBytes),
+		zap.Int("gc_percent", gcPercent))
+	return nil
+}
+
+func (ml *MemoryLimiter) restoreGoMemoryLimit() {
+	goMemLimitLock.Lock()
+	defer goMemLimitLock.Unlock()
+	setMemoryLimitFn(ml.previousMemoryLimit)
+	setGCPercentFn(ml.previousGCPercent)
+	ml.memoryLimit.Store(0)
+	ml.appliedGCPercent.Store(0)
+	goMemLimitOwner = nil
+}
+
+func memstatToZapField(ms *runtime.MemStats) zap.Field {
+	return zap.Uint64("cur_mem_mib", ms.Alloc/mibBytes)
+}
+
+func (ml *MemoryLimiter) doGCandReadMemStats() *runtime.MemStats {
+	runtime.GC()
+	ml.lastGCDone = time.Now()
+	ml.forcedGCCount.Inc()
+	ml.telemetry.recordForcedGC()
+	ms := ml.readMemStats()
+	ml.logger.Info("Memory usage after GC.", memstatToZapField(ms))
+	return ms
+}
+
+// CheckMemLimits reads the memory usage, forces a GC if needed and updates the refusal state.
+func (ml *MemoryLimiter) CheckMemLimits() {
+	ms := ml.readMemStats()
+
+	ml.logger.Debug("Currently used memory.", memstatToZapField(ms))
+
+	// In the ModeGoMemLimit mode the runtime collects the garbage as needed, only refuse the data.
+	if !ml.goMemLimit && ml.usageChecker.aboveHardLimit(ms) {
+		ml.logger.Warn("Memory usage is above hard limit. Forcing a GC.", memstatToZapField(ms))
+		ms = ml.doGCandReadMemStats()
+	}
+
+	// Remember current refusing state.
+	wasRefusing := ml.mustRefuse.Load()
+
+	now := time.Now()
+	if wasRefusing {
+		refusing := now.Sub(ml.lastCheck)
+		ml.refusingTime.Add(refusing)
+		ml.telemetry.recordRefusingTime(refusing)
+	}
+	ml.lastCheck = now
+
+	// Check if the memory usage is above the soft limit.
+	mustRefuse := ml.usageChecker.aboveSoftLimit(ms)
+
+	if wasRefusing && !mustRefuse {
+		// Was previously refusing but enough memory is available now, no need to limit.
+		ml.logger.Info("Memory usage back within limits. Resuming normal operation.", memstatToZapField(ms))
+	}
+
+	if !wasRefusing && mustRefuse {
+		// We are above soft limit, do a GC if it wasn't done recently and see if
+		// it brings memory usage below the soft limit.
+		if !ml.goMemLimit && time.Since(ml.lastGCDone) > minGCIntervalWhenSoftLimited {
+			ml.logger.Info("Memory usage is above soft limit. Forcing a GC.", memstatToZapField(ms))
+			ms = ml.doGCandReadMemStats()
+			// Check the limit again to see if GC helped.
+			mustRefuse = ml.usageChecker.aboveSoftLimit(ms)
+		}
+
+		if mustRefuse {
+			ml.logger.Warn("Memory usage is above soft limit. Refusing data.", memstatToZapField(ms))
+		}
+	}
+
+	ml.memoryUsage.Store(ms.Alloc)
+	ml.aboveHardLimit.Store(ml.usageChecker.aboveHardLimit(ms))
+	ml.mustRefuse.Store(mustRefuse)
+	ml.telemetry.recordMemoryUsage(ms.Alloc, ml.usageChecker)
+}
+
+type memUsageChecker struct {
+	memAllocLimit uint64
+	memSpikeLimit uint64
+}
+
+func (d memUsageChecker) aboveSoftLimit(ms *runtime.MemStats) bool {
+	return ms.Alloc >= d.memAllocLimit-d.memSpikeLimit
+}
+
+func (d memUsageChecker) aboveHardLimit(ms *runtime.MemStats) bool {
+	return ms.Alloc >= d.memAllocLimit
+}
+
+func newFixedMemUsageChecker(memAllocLimit, memSpikeLimit uint64) (*memUsageChecker, error) {
+	if memSpikeLimit >= memAllocLimit {
+		return nil, ErrMemSpikeLimitOutOfRange
+	}
+	if memSpikeLimit == 0 {
+		// If spike limit is unspecified use 20% of mem limit.
+		memSpikeLimit = memAllocLimit / 5
+	}
+	return &memUsageChecker{
+		memAllocLimit: memAllocLimit,
+		memSpikeLimit: memSpikeLimit,
+	}, nil
+}
+
+func newPercentageMemUsageChecker(totalMemory uint64, percentageLimit, percentageSpike uint64) (*memUsageChecker, error) {
+	if percentageLimit > 100 || percentageLimit <= 0 || percentageSpike > 100 || percentageSpike <= 0 {
+		return nil, ErrPercentageLimitOutOfRange
+	}
+	return newFixedMemUsageChecker(percentageLimit*totalMemory/100, percentageSpike*totalMemory/100)
+}
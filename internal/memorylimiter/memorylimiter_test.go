@@ -0,0 +1,398 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/internal/iruntime"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr error
+	}{
+		{
+			name:    "zero_checkInterval",
+			cfg:     &Config{},
+			wantErr: ErrCheckIntervalOutOfRange,
+		},
+		{
+			name:    "zero_memAllocLimit",
+			cfg:     &Config{CheckInterval: 100 * time.Millisecond},
+			wantErr: ErrLimitOutOfRange,
+		},
+		{
+			name: "memSpikeLimit_gt_memAllocLimit",
+			cfg: &Config{
+				CheckInterval:       100 * time.Millisecond,
+				MemoryLimitMiB:      1,
+				MemorySpikeLimitMiB: 2,
+			},
+			wantErr: ErrMemSpikeLimitOutOfRange,
+		},
+		{
+			name: "unknown_mode",
+			cfg: &Config{
+				CheckInterval:  100 * time.Millisecond,
+				MemoryLimitMiB: 1024,
+				Mode:           "unknown",
+			},
+			wantErr: errors.New(`unknown mode "unknown", must be "gc" or "gomemlimit"`),
+		},
+		{
+			name: "gcPercent_without_gomemlimit",
+			cfg: &Config{
+				CheckInterval:  100 * time.Millisecond,
+				MemoryLimitMiB: 1024,
+				GCPercent:      50,
+			},
+			wantErr: ErrGCPercentWithoutGoMemLimit,
+		},
+		{
+			name: "success",
+			cfg: &Config{
+				CheckInterval:  100 * time.Millisecond,
+				MemoryLimitMiB: 1024,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMemoryLimiter(tt.cfg, newNopSettings())
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.ErrorIs(t, got.Shutdown(context.Background()), ErrShutdownNotStarted)
+			assert.NoError(t, got.Start(context.Background(), componenttest.NewNopHost()))
+			assert.NoError(t, got.Start(context.Background(), componenttest.NewNopHost()))
+			assert.NoError(t, got.Shutdown(context.Background()))
+			assert.NoError(t, got.Shutdown(context.Background()))
+			assert.ErrorIs(t, got.Shutdown(context.Background()), ErrShutdownNotStarted)
+		})
+	}
+}
+
+// TestMemoryPressureResponse manipulates results from querying memory and
+// check expected side effects.
+func TestMemoryPressureResponse(t *testing.T) {
+	var currentMemAlloc uint64
+	ml, err := NewMemoryLimiter(&Config{CheckInterval: time.Minute, MemoryLimitMiB: 1}, newNopSettings())
+	require.NoError(t, err)
+	ml.usageChecker = memUsageChecker{
+		memAllocLimit: 1024,
+	}
+	ml.readMemStatsFn = func(ms *runtime.MemStats) {
+		ms.Alloc = currentMemAlloc
+	}
+
+	// Below memAllocLimit.
+	currentMemAlloc = 800
+	ml.CheckMemLimits()
+	assert.False(t, ml.MustRefuse())
+
+	// Above memAllocLimit.
+	currentMemAlloc = 1800
+	ml.CheckMemLimits()
+	assert.True(t, ml.MustRefuse())
+
+	// Check ballast effect
+	ml.ballastSize = 1000
+
+	// Below memAllocLimit accounting for ballast.
+	currentMemAlloc = 800 + ml.ballastSize
+	ml.CheckMemLimits()
+	assert.False(t, ml.MustRefuse())
+
+	// Above memAllocLimit even accountiing for ballast.
+	currentMemAlloc = 1800 + ml.ballastSize
+	ml.CheckMemLimits()
+	assert.True(t, ml.MustRefuse())
+
+	// Restore ballast to default.
+	ml.ballastSize = 0
+
+	// Check spike limit
+	ml.usageChecker.memSpikeLimit = 512
+
+	// Below memSpikeLimit.
+	currentMemAlloc = 500
+	ml.CheckMemLimits()
+	assert.False(t, ml.MustRefuse())
+
+	// Above memSpikeLimit.
+	currentMemAlloc = 550
+	ml.CheckMemLimits()
+	assert.True(t, ml.MustRefuse())
+}
+
+func TestGoMemLimitMode(t *testing.T) {
+	memoryLimit, gcPercent := int64(math.MaxInt64), 100
+	t.Cleanup(func() {
+		setMemoryLimitFn = setMemoryLimit
+		setGCPercentFn = debug.SetGCPercent
+	})
+	setMemoryLimitFn = func(limit int64) int64 {
+		previous := memoryLimit
+		memoryLimit = limit
+		return previous
+	}
+	setGCPercentFn = func(percent int) int {
+		previous := gcPercent
+		gcPercent = percent
+		return previous
+	}
+
+	ml, err := NewMemoryLimiter(&Config{
+		CheckInterval:  time.Minute,
+		MemoryLimitMiB: 1024,
+		Mode:           ModeGoMemLimit,
+		GCPercent:      400,
+	}, newNopSettings())
+	require.NoError(t, err)
+	var currentMemAlloc uint64
+	ml.readMemStatsFn = func(ms *runtime.MemStats) {
+		ms.Alloc = currentMemAlloc
+	}
+	assert.Equal(t, uint64(0), ml.GetMemoryLimit())
+
+	// The ballast is part of the heap seen by the runtime.
+	require.NoError(t, ml.Start(context.Background(), &host{ballastSize: 100 * mibBytes}))
+	assert.Equal(t, int64(1124*mibBytes), memoryLimit)
+	assert.Equal(t, 400, gcPercent)
+	assert.Equal(t, uint64(1124*mibBytes), ml.GetMemoryLimit())
+	assert.Equal(t, 400, ml.GetGCPercent())
+
+	// Above the hard limit the data is refused, but no garbage collection is forced.
+	currentMemAlloc = 1200*mibBytes + ml.ballastSize
+	ml.CheckMemLimits()
+	assert.True(t, ml.MustRefuse())
+	assert.True(t, ml.lastGCDone.IsZero())
+
+	require.NoError(t, ml.Shutdown(context.Background()))
+	assert.Equal(t, int64(math.MaxInt64), memoryLimit)
+	assert.Equal(t, 100, gcPercent)
+	assert.Equal(t, uint64(0), ml.GetMemoryLimit())
+}
+
+func TestGoMemLimitModeKeepGCPercent(t *testing.T) {
+	gcPercent := 150
+	t.Cleanup(func() {
+		setMemoryLimitFn = setMemoryLimit
+		setGCPercentFn = debug.SetGCPercent
+	})
+	setMemoryLimitFn = func(int64) int64 { return math.MaxInt64 }
+	setGCPercentFn = func(percent int) int {
+		previous := gcPercent
+		gcPercent = percent
+		return previous
+	}
+
+	ml, err := NewMemoryLimiter(&Config{
+		CheckInterval:  time.Minute,
+		MemoryLimitMiB: 1024,
+		Mode:           ModeGoMemLimit,
+	}, newNopSettings())
+	require.NoError(t, err)
+	require.NoError(t, ml.Start(context.Background(), componenttest.NewNopHost()))
+	assert.Equal(t, 150, gcPercent)
+	assert.Equal(t, 150, ml.GetGCPercent())
+	require.NoError(t, ml.Shutdown(context.Background()))
+	assert.Equal(t, 150, gcPercent)
+}
+
+func TestGoMemLimitModeSingleLimiter(t *testing.T) {
+	t.Cleanup(func() {
+		setMemoryLimitFn = setMemoryLimit
+		setGCPercentFn = debug.SetGCPercent
+	})
+	setMemoryLimitFn = func(int64) int64 { return math.MaxInt64 }
+	setGCPercentFn = func(int) int { return 100 }
+
+	cfg := &Config{
+		CheckInterval:  time.Minute,
+		MemoryLimitMiB: 1024,
+		Mode:           ModeGoMemLimit,
+	}
+	first, err := NewMemoryLimiter(cfg, newNopSettings())
+	require.NoError(t, err)
+	second, err := NewMemoryLimiter(cfg, newNopSettings())
+	require.NoError(t, err)
+
+	require.NoError(t, first.Start(context.Background(), componenttest.NewNopHost()))
+	// Starting the same limiter again, e.g. from several pipelines, is allowed.
+	require.NoError(t, first.Start(context.Background(), componenttest.NewNopHost()))
+	assert.ErrorIs(t, second.Start(context.Background(), componenttest.NewNopHost()), ErrGoMemLimitInUse)
+	assert.ErrorIs(t, second.Shutdown(context.Background()), ErrShutdownNotStarted)
+	assert.Equal(t, uint64(0), second.GetMemoryLimit())
+
+	require.NoError(t, first.Shutdown(context.Background()))
+	require.NoError(t, first.Shutdown(context.Background()))
+	// Once the first limiter restored the settings, another one can apply its limit.
+	require.NoError(t, second.Start(context.Background(), componenttest.NewNopHost()))
+	assert.Equal(t, uint64(1024*mibBytes), second.GetMemoryLimit())
+	require.NoError(t, second.Shutdown(context.Background()))
+}
+
+func TestGetDecision(t *testing.T) {
+	t.Run("fixed_limit", func(t *testing.T) {
+		d, err := getMemUsageChecker(&Config{MemoryLimitMiB: 100, MemorySpikeLimitMiB: 20}, zap.NewNop())
+		require.NoError(t, err)
+		assert.Equal(t, &memUsageChecker{
+			memAllocLimit: 100 * mibBytes,
+			memSpikeLimit: 20 * mibBytes,
+		}, d)
+	})
+	t.Run("fixed_limit_error", func(t *testing.T) {
+		d, err := getMemUsageChecker(&Config{MemoryLimitMiB: 20, MemorySpikeLimitMiB: 100}, zap.NewNop())
+		require.Error(t, err)
+		assert.Nil(t, d)
+	})
+
+	t.Cleanup(func() {
+		GetMemoryFn = iruntime.TotalMemory
+	})
+	GetMemoryFn = func() (uint64, error) {
+		return 100 * mibBytes, nil
+	}
+	t.Run("percentage_limit", func(t *testing.T) {
+		d, err := getMemUsageChecker(&Config{MemoryLimitPercentage: 50, MemorySpikePercentage: 10}, zap.NewNop())
+		require.NoError(t, err)
+		assert.Equal(t, &memUsageChecker{
+			memAllocLimit: 50 * mibBytes,
+			memSpikeLimit: 10 * mibBytes,
+		}, d)
+	})
+	t.Run("percentage_limit_error", func(t *testing.T) {
+		d, err := getMemUsageChecker(&Config{MemoryLimitPercentage: 101, MemorySpikePercentage: 10}, zap.NewNop())
+		require.Error(t, err)
+		assert.Nil(t, d)
+		d, err = getMemUsageChecker(&Config{MemoryLimitPercentage: 99, MemorySpikePercentage: 101}, zap.NewNop())
+		require.Error(t, err)
+		assert.Nil(t, d)
+	})
+}
+
+func TestDropDecision(t *testing.T) {
+	decison1000Limit30Spike30, err := newPercentageMemUsageChecker(1000, 60, 30)
+	require.NoError(t, err)
+	decison1000Limit60Spike50, err := newPercentageMemUsageChecker(1000, 60, 50)
+	require.NoError(t, err)
+	decison1000Limit40Spike20, err := newPercentageMemUsageChecker(1000, 40, 20)
+	require.NoError(t, err)
+	decison1000Limit40Spike60, err := newPercentageMemUsageChecker(1000, 40, 60)
+	require.Error(t, err)
+	assert.Nil(t, decison1000Limit40Spike60)
+
+	tests := []struct {
+		name         string
+		usageChecker memUsageChecker
+		ms           *runtime.MemStats
+		shouldDrop   bool
+	}{
+		{
+			name:         "should drop over limit",
+			usageChecker: *decison1000Limit30Spike30,
+			ms:           &runtime.MemStats{Alloc: 600},
+			shouldDrop:   true,
+		},
+		{
+			name:         "should not drop",
+			usageChecker: *decison1000Limit30Spike30,
+			ms:           &runtime.MemStats{Alloc: 100},
+			shouldDrop:   false,
+		},
+		{
+			name: "should not drop spike, fixed usageChecker",
+			usageChecker: memUsageChecker{
+				memAllocLimit: 600,
+				memSpikeLimit: 500,
+			},
+			ms:         &runtime.MemStats{Alloc: 300},
+			shouldDrop: true,
+		},
+		{
+			name:         "should drop, spike, percentage usageChecker",
+			usageChecker: *decison1000Limit60Spike50,
+			ms:           &runtime.MemStats{Alloc: 300},
+			shouldDrop:   true,
+		},
+		{
+			name:         "should drop, spike, percentage usageChecker",
+			usageChecker: *decison1000Limit40Spike20,
+			ms:           &runtime.MemStats{Alloc: 250},
+			shouldDrop:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shouldDrop := test.usageChecker.aboveSoftLimit(test.ms)
+			assert.Equal(t, test.shouldDrop, shouldDrop)
+		})
+	}
+}
+
+func TestBallastSize(t *testing.T) {
+	got, err := NewMemoryLimiter(&Config{CheckInterval: 10 * time.Second, MemoryLimitMiB: 1024}, newNopSettings())
+	require.NoError(t, err)
+	require.NoError(t, got.Start(context.Background(), &host{ballastSize: 113}))
+	assert.Equal(t, uint64(113), got.ballastSize)
+	require.NoError(t, got.Shutdown(context.Background()))
+}
+
+type host struct {
+	ballastSize uint64
+	component.Host
+}
+
+func (h *host) GetExtensions() map[component.ID]component.Component {
+	ret := make(map[component.ID]component.Component)
+	ret[component.NewID("ballast")] = &ballastExtension{ballastSize: h.ballastSize}
+	return ret
+}
+
+type ballastExtension struct {
+	ballastSize uint64
+	component.StartFunc
+	component.ShutdownFunc
+}
+
+func (be *ballastExtension) GetBallastSize() uint64 {
+	return be.ballastSize
+}
+
+func newNopSettings() Settings {
+	return Settings{
+		TelemetrySettings: componenttest.NewNopTelemetrySettings(),
+		ID:                component.NewID(typeStr),
+		Kind:              component.KindProcessor,
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiter // import "go.opentelemetry.io/collector/internal/memorylimiter"
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/asyncint64"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/unit"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/internal/obsreportconfig/obsmetrics"
+)
+
+const (
+	scopeName = "go.opentelemetry.io/collector/internal/memorylimiter"
+	typeStr   = "memory_limiter"
+
+	// CauseSoftLimit is the refusal cause when the memory usage is above the soft limit.
+	CauseSoftLimit = "soft_limit"
+	// CauseHardLimit is the refusal cause when the memory usage is still above the hard
+	// limit, even after the forced garbage collection.
+	CauseHardLimit = "hard_limit"
+)
+
+var causeTagKey = tag.MustNewKey("cause")
+
+// Settings identifies the component using the MemoryLimiter, which reports its
+// telemetry as this component.
+type Settings struct {
+	component.TelemetrySettings
+
+	// ID is the ID of the component.
+	ID component.ID
+
+	// Kind is the kind of the component, component.KindProcessor or component.KindExtension.
+	Kind component.Kind
+}
+
+// ocMeasures are the OpenCensus measures of the memory limiters of one kind of
+// component, named after this kind and tagged with the component ID.
+type ocMeasures struct {
+	tagKey tag.Key
+
+	memoryUsage         *stats.Int64Measure
+	softLimit           *stats.Int64Measure
+	hardLimit           *stats.Int64Measure
+	forcedGC            *stats.Int64Measure
+	refusingTime        *stats.Float64Measure
+	refusedSpans        *stats.Int64Measure
+	refusedMetricPoints *stats.Int64Measure
+	refusedLogRecords   *stats.Int64Measure
+}
+
+var (
+	processorMeasures = newOCMeasures(component.KindProcessor)
+	extensionMeasures = newOCMeasures(component.KindExtension)
+)
+
+func init() {
+	// TODO: Find a way to handle the error.
+	_ = view.Register(append(processorMeasures.views(), extensionMeasures.views()...)...)
+}
+
+func kindName(kind component.Kind) string {
+	if kind == component.KindExtension {
+		return "extension"
+	}
+	return obsmetrics.ProcessorKey
+}
+
+func metricName(kind component.Kind, metric string) string {
+	return kindName(kind) + obsmetrics.NameSep + typeStr + obsmetrics.NameSep + metric
+}
+
+func newOCMeasures(kind component.Kind) *ocMeasures {
+	return &ocMeasures{
+		tagKey:              tag.MustNewKey(kindName(kind)),
+		memoryUsage:         stats.Int64(metricName(kind, "memory_usage"), "Memory usage of the process, ballast excluded, at the last check", stats.UnitBytes),
+		softLimit:           stats.Int64(metricName(kind, "soft_limit"), "Memory usage above which the data is refused", stats.UnitBytes),
+		hardLimit:           stats.Int64(metricName(kind, "hard_limit"), "Memory usage above which the garbage collection is forced", stats.UnitBytes),
+		forcedGC:            stats.Int64(metricName(kind, "forced_gc"), "Number of garbage collections forced by the memory limiter", stats.UnitDimensionless),
+		refusingTime:        stats.Float64(metricName(kind, "refusing_time"), "Time spent refusing the data because of the memory usage", "s"),
+		refusedSpans:        stats.Int64(metricName(kind, "refused_spans"), "Number of spans refused because of the memory usage", stats.UnitDimensionless),
+		refusedMetricPoints: stats.Int64(metricName(kind, "refused_metric_points"), "Number of metric points refused because of the memory usage", stats.UnitDimensionless),
+		refusedLogRecords:   stats.Int64(metricName(kind, "refused_log_records"), "Number of log records refused because of the memory usage", stats.UnitDimensionless),
+	}
+}
+
+func (m *ocMeasures) views() []*view.View {
+	tagKeys := []tag.Key{m.tagKey}
+	causeTagKeys := []tag.Key{m.tagKey, causeTagKey}
+	newView := func(measure stats.Measure, keys []tag.Key, aggregation *view.Aggregation) *view.View {
+		return &view.View{
+			Name:        measure.Name(),
+			Measure:     measure,
+			Description: measure.Description(),
+			TagKeys:     keys,
+			Aggregation: aggregation,
+		}
+	}
+	return []*view.View{
+		newView(m.memoryUsage, tagKeys, view.LastValue()),
+		newView(m.softLimit, tagKeys, view.LastValue()),
+		newView(m.hardLimit, tagKeys, view.LastValue()),
+		newView(m.forcedGC, tagKeys, view.Sum()),
+		newView(m.refusingTime, tagKeys, view.Sum()),
+		newView(m.refusedSpans, causeTagKeys, view.Sum()),
+		newView(m.refusedMetricPoints, causeTagKeys, view.Sum()),
+		newView(m.refusedLogRecords, causeTagKeys, view.Sum()),
+	}
+}
+
+// telemetry records the metrics of a MemoryLimiter with OpenCensus, or with the
+// OpenTelemetry MeterProvider of the component when useOtel is set.
+type telemetry struct {
+	useOtel bool
+
+	ocCtx      context.Context
+	ocMeasures *ocMeasures
+
+	otelAttrs           []attribute.KeyValue
+	forcedGC            syncint64.Counter
+	refusingTime        syncfloat64.Counter
+	refusedSpans        syncint64.Counter
+	refusedMetricPoints syncint64.Counter
+	refusedLogRecords   syncint64.Counter
+}
+
+func newTelemetry(set Settings, useOtel bool, ml *MemoryLimiter) (*telemetry, error) {
+	measures := processorMeasures
+	if set.Kind == component.KindExtension {
+		measures = extensionMeasures
+	}
+	ocCtx, err := tag.New(context.Background(), tag.Insert(measures.tagKey, set.ID.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	tel := &telemetry{
+		useOtel:    useOtel,
+		ocCtx:      ocCtx,
+		ocMeasures: measures,
+		otelAttrs:  []attribute.KeyValue{attribute.String(kindName(set.Kind), set.ID.String())},
+	}
+	if useOtel {
+		if err = tel.createOtelMetrics(set.MeterProvider, set.Kind, ml); err != nil {
+			return nil, err
+		}
+	}
+	return tel, nil
+}
+
+func (tel *telemetry) createOtelMetrics(mp metric.MeterProvider, kind component.Kind, ml *MemoryLimiter) error {
+	var err error
+	meter := mp.Meter(scopeName)
+
+	gauge := func(name string, desc string) asyncint64.Gauge {
+		if err != nil {
+			return nil
+		}
+		var g asyncint64.Gauge
+		g, err = meter.AsyncInt64().Gauge(metricName(kind, name), instrument.WithDescription(desc), instrument.WithUnit(unit.Bytes))
+		return g
+	}
+	memoryUsage := gauge("memory_usage", "Memory usage of the process, ballast excluded, at the last check")
+	softLimit := gauge("soft_limit", "Memory usage above which the data is refused")
+	hardLimit := gauge("hard_limit", "Memory usage above which the garbage collection is forced")
+	if err != nil {
+		return err
+	}
+	err = meter.RegisterCallback([]instrument.Asynchronous{memoryUsage, softLimit, hardLimit}, func(ctx context.Context) {
+		memoryUsage.Observe(ctx, int64(ml.memoryUsage.Load()), tel.otelAttrs...)
+		softLimit.Observe(ctx, int64(ml.usageChecker.memAllocLimit-ml.usageChecker.memSpikeLimit), tel.otelAttrs...)
+		hardLimit.Observe(ctx, int64(ml.usageChecker.memAllocLimit), tel.otelAttrs...)
+	})
+	if err != nil {
+		return err
+	}
+
+	counter := func(name string, desc string) syncint64.Counter {
+		if err != nil {
+			return nil
+		}
+		var c syncint64.Counter
+		c, err = meter.SyncInt64().Counter(metricName(kind, name), instrument.WithDescription(desc), instrument.WithUnit(unit.Dimensionless))
+		return c
+	}
+	tel.forcedGC = counter("forced_gc", "Number of garbage collections forced by the memory limiter")
+	tel.refusedSpans = counter("refused_spans", "Number of spans refused because of the memory usage")
+	tel.refusedMetricPoints = counter("refused_metric_points", "Number of metric points refused because of the memory usage")
+	tel.refusedLogRecords = counter("refused_log_records", "Number of log records refused because of the memory usage")
+	if err != nil {
+		return err
+	}
+
+	tel.refusingTime, err = meter.SyncFloat64().Counter(
+		metricName(kind, "refusing_time"),
+		instrument.WithDescription("Time spent refusing the data because of the memory usage"),
+		instrument.WithUnit("s"),
+	)
+	return err
+}
+
+// recordMemoryUsage records the gauges with OpenCensus, they are observed when
+// collected with OpenTelemetry.
+func (tel *telemetry) recordMemoryUsage(usage uint64, checker memUsageChecker) {
+	if tel.useOtel {
+		return
+	}
+	stats.Record(tel.ocCtx,
+		tel.ocMeasures.memoryUsage.M(int64(usage)),
+		tel.ocMeasures.softLimit.M(int64(checker.memAllocLimit-checker.memSpikeLimit)),
+		tel.ocMeasures.hardLimit.M(int64(checker.memAllocLimit)))
+}
+
+func (tel *telemetry) recordForcedGC() {
+	if tel.useOtel {
+		tel.forcedGC.Add(tel.ocCtx, 1, tel.otelAttrs...)
+		return
+	}
+	stats.Record(tel.ocCtx, tel.ocMeasures.forcedGC.M(1))
+}
+
+func (tel *telemetry) recordRefusingTime(d time.Duration) {
+	if tel.useOtel {
+		tel.refusingTime.Add(tel.ocCtx, d.Seconds(), tel.otelAttrs...)
+		return
+	}
+	stats.Record(tel.ocCtx, tel.ocMeasures.refusingTime.M(d.Seconds()))
+}
+
+func (tel *telemetry) recordRefused(dataType component.DataType, cause string, numItems int) {
+	var (
+		counter syncint64.Counter
+		measure *stats.Int64Measure
+	)
+	switch dataType {
+	case component.DataTypeTraces:
+		counter, measure = tel.refusedSpans, tel.ocMeasures.refusedSpans
+	case component.DataTypeMetrics:
+		counter, measure = tel.refusedMetricPoints, tel.ocMeasures.refusedMetricPoints
+	case component.DataTypeLogs:
+		counter, measure = tel.refusedLogRecords, tel.ocMeasures.refusedLogRecords
+	default:
+		panic(fmt.Sprintf("memorylimiter: unsupported data type %q", dataType))
+	}
+
+	if tel.useOtel {
+		counter.Add(tel.ocCtx, int64(numItems), append([]attribute.KeyValue{attribute.String(causeTagKey.Name(), cause)}, tel.otelAttrs...)...)
+		return
+	}
+	_ = stats.RecordWithTags(tel.ocCtx, []tag.Mutator{tag.Upsert(causeTagKey, cause)}, measure.M(int64(numItems)))
+}
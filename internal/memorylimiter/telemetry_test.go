@@ -0,0 +1,152 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiter
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/featuregate"
+	"go.opentelemetry.io/collector/internal/obsreportconfig"
+)
+
+// newTestTelemetryMemoryLimiter returns a MemoryLimiter with a 1000 bytes hard limit
+// and a 800 bytes soft limit, reading the memory usage from currentMemAlloc.
+func newTestTelemetryMemoryLimiter(t *testing.T, set Settings, registry *featuregate.Registry, currentMemAlloc *uint64) *MemoryLimiter {
+	ml, err := newMemoryLimiter(&Config{CheckInterval: time.Minute, MemoryLimitMiB: 1}, set, registry)
+	require.NoError(t, err)
+	ml.usageChecker = memUsageChecker{memAllocLimit: 1000, memSpikeLimit: 200}
+	ml.readMemStatsFn = func(ms *runtime.MemStats) {
+		ms.Alloc = *currentMemAlloc
+	}
+	return ml
+}
+
+// exerciseMemoryLimiter checks the memory usage above the hard limit, forcing a GC, refuses
+// 5 spans, then checks the memory usage back to 500 bytes.
+func exerciseMemoryLimiter(t *testing.T, ml *MemoryLimiter, currentMemAlloc *uint64) {
+	*currentMemAlloc = 1500
+	ml.CheckMemLimits()
+	require.True(t, ml.MustRefuse())
+	assert.Equal(t, CauseHardLimit, ml.RefuseCause())
+	ml.RecordRefused(component.DataTypeTraces, 5)
+
+	// Pretend the previous check happened earlier to account for the time spent refusing.
+	ml.lastCheck = ml.lastCheck.Add(-time.Second)
+	*currentMemAlloc = 500
+	ml.CheckMemLimits()
+	require.False(t, ml.MustRefuse())
+}
+
+func TestTelemetryWithOC(t *testing.T) {
+	set := newNopSettings()
+	set.ID = component.NewIDWithName(typeStr, "oc")
+	var currentMemAlloc uint64
+	ml := newTestTelemetryMemoryLimiter(t, set, featuregate.NewRegistry(), &currentMemAlloc)
+	exerciseMemoryLimiter(t, ml, &currentMemAlloc)
+
+	retrieve := func(metric string, cause string) view.AggregationData {
+		rows, err := view.RetrieveData(metricName(component.KindProcessor, metric))
+		require.NoError(t, err)
+		for _, row := range rows {
+			var idMatch, causeMatch = false, cause == ""
+			for _, tg := range row.Tags {
+				idMatch = idMatch || (tg.Key == processorMeasures.tagKey && tg.Value == set.ID.String())
+				causeMatch = causeMatch || (tg.Key == causeTagKey && tg.Value == cause)
+			}
+			if idMatch && causeMatch {
+				return row.Data
+			}
+		}
+		t.Fatalf("no data for metric %q", metric)
+		return nil
+	}
+
+	assert.Equal(t, float64(500), retrieve("memory_usage", "").(*view.LastValueData).Value)
+	assert.Equal(t, float64(800), retrieve("soft_limit", "").(*view.LastValueData).Value)
+	assert.Equal(t, float64(1000), retrieve("hard_limit", "").(*view.LastValueData).Value)
+	assert.Equal(t, float64(1), retrieve("forced_gc", "").(*view.SumData).Value)
+	assert.GreaterOrEqual(t, retrieve("refusing_time", "").(*view.SumData).Value, float64(1))
+	assert.Equal(t, float64(5), retrieve("refused_spans", CauseHardLimit).(*view.SumData).Value)
+}
+
+func TestTelemetryWithOTel(t *testing.T) {
+	registry := featuregate.NewRegistry()
+	obsreportconfig.RegisterInternalMetricFeatureGate(registry)
+	require.NoError(t, registry.Apply(map[string]bool{obsreportconfig.UseOtelForInternalMetricsfeatureGateID: true}))
+
+	reader := sdkmetric.NewManualReader()
+	set := newNopSettings()
+	set.ID = component.NewIDWithName(typeStr, "otel")
+	set.Kind = component.KindExtension
+	set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	var currentMemAlloc uint64
+	ml := newTestTelemetryMemoryLimiter(t, set, registry, &currentMemAlloc)
+	exerciseMemoryLimiter(t, ml, &currentMemAlloc)
+
+	rm, err := reader.Collect(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rm.ScopeMetrics, 1)
+	metrics := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	assert.Equal(t, int64(500), metrics["extension/memory_limiter/memory_usage"].(metricdata.Gauge[int64]).DataPoints[0].Value)
+	assert.Equal(t, int64(800), metrics["extension/memory_limiter/soft_limit"].(metricdata.Gauge[int64]).DataPoints[0].Value)
+	assert.Equal(t, int64(1000), metrics["extension/memory_limiter/hard_limit"].(metricdata.Gauge[int64]).DataPoints[0].Value)
+	assert.Equal(t, int64(1), metrics["extension/memory_limiter/forced_gc"].(metricdata.Sum[int64]).DataPoints[0].Value)
+	assert.GreaterOrEqual(t, metrics["extension/memory_limiter/refusing_time"].(metricdata.Sum[float64]).DataPoints[0].Value, float64(1))
+	refused := metrics["extension/memory_limiter/refused_spans"].(metricdata.Sum[int64]).DataPoints[0]
+	assert.Equal(t, int64(5), refused.Value)
+	cause, ok := refused.Attributes.Value("cause")
+	require.True(t, ok)
+	assert.Equal(t, CauseHardLimit, cause.AsString())
+	id, ok := refused.Attributes.Value("extension")
+	require.True(t, ok)
+	assert.Equal(t, set.ID.String(), id.AsString())
+}
+
+func TestZPagesProperties(t *testing.T) {
+	var currentMemAlloc uint64
+	ml := newTestTelemetryMemoryLimiter(t, newNopSettings(), featuregate.NewRegistry(), &currentMemAlloc)
+	ml.usageChecker = memUsageChecker{memAllocLimit: 1000 * mibBytes, memSpikeLimit: 200 * mibBytes}
+
+	currentMemAlloc = 900 * mibBytes
+	ml.CheckMemLimits()
+	props := ml.ZPagesProperties()
+	assert.Contains(t, props, [2]string{"Memory limiter state", "Refusing data, memory usage above the soft limit"})
+	assert.Contains(t, props, [2]string{"Memory limiter mode", ModeGC})
+	assert.Contains(t, props, [2]string{"Memory usage (MiB)", "900"})
+	assert.Contains(t, props, [2]string{"Soft limit (MiB)", "800"})
+	assert.Contains(t, props, [2]string{"Hard limit (MiB)", "1000"})
+
+	ml.lastCheck = ml.lastCheck.Add(-time.Minute)
+	currentMemAlloc = 100 * mibBytes
+	ml.CheckMemLimits()
+	props = ml.ZPagesProperties()
+	assert.Contains(t, props, [2]string{"Memory limiter state", "Accepting data"})
+	assert.Contains(t, props, [2]string{"Memory usage (MiB)", "100"})
+	assert.GreaterOrEqual(t, ml.refusingTime.Load(), time.Minute)
+}
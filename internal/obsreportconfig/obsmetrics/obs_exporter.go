@@ -37,6 +37,11 @@ const (
 	SentLogRecordsKey = "sent_log_records"
 	// FailedToSendLogRecordsKey used to track logs that failed to be sent by exporters.
 	FailedToSendLogRecordsKey = "send_failed_log_records"
+
+	// ThrottledRequestsKey used to track requests delayed by the rate limiter of exporters.
+	ThrottledRequestsKey = "throttled_requests"
+	// ThrottleWaitTimeKey used to track the time the last throttled request waited for the rate limiter of exporters.
+	ThrottleWaitTimeKey = "throttle_wait_time"
 )
 
 var (
@@ -76,4 +81,12 @@ var (
 		ExporterPrefix+FailedToSendLogRecordsKey,
 		"Number of log records in failed attempts to send to destination.",
 		stats.UnitDimensionless)
+	ExporterThrottledRequests = stats.Int64(
+		ExporterPrefix+ThrottledRequestsKey,
+		"Number of requests delayed by the rate limiter.",
+		stats.UnitDimensionless)
+	ExporterThrottleWaitTime = stats.Int64(
+		ExporterPrefix+ThrottleWaitTimeKey,
+		"Time the last throttled request waited for the rate limiter.",
+		stats.UnitMilliseconds)
 )
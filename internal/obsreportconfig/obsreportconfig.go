@@ -80,6 +80,9 @@ func AllViews(level configtelemetry.Level) []*view.View {
 	}
 	views = append(views, errorNumberView)
 
+	views = append(views, genViews([]*stats.Int64Measure{obsmetrics.ExporterThrottledRequests}, tagKeys, view.Sum())...)
+	views = append(views, genViews([]*stats.Int64Measure{obsmetrics.ExporterThrottleWaitTime}, tagKeys, view.LastValue())...)
+
 	// Processor views.
 	measures = []*stats.Int64Measure{
 		obsmetrics.ProcessorAcceptedSpans,
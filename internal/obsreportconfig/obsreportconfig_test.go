@@ -35,17 +35,17 @@ func TestConfigure(t *testing.T) {
 		{
 			name:         "basic",
 			level:        configtelemetry.LevelBasic,
-			wantViewsLen: 24,
+			wantViewsLen: 26,
 		},
 		{
 			name:         "normal",
 			level:        configtelemetry.LevelNormal,
-			wantViewsLen: 24,
+			wantViewsLen: 26,
 		},
 		{
 			name:         "detailed",
 			level:        configtelemetry.LevelDetailed,
-			wantViewsLen: 24,
+			wantViewsLen: 26,
 		},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,133 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pdatautil provides utilities to manipulate pdata.
+package pdatautil // import "go.opentelemetry.io/collector/internal/pdatautil"
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// DedupTraces merges the ResourceSpans with equal resources and schema URLs, and then, within each ResourceSpans, the
+// ScopeSpans with equal instrumentation scopes and schema URLs. The merged entries are at the position of the first
+// one, and the spans keep their order.
+func DedupTraces(td ptrace.Traces) {
+	var kb keyBuilder
+	rss := td.ResourceSpans()
+	if rss.Len() > 1 {
+		index := make(map[string]ptrace.ResourceSpans, rss.Len())
+		rss.RemoveIf(func(rs ptrace.ResourceSpans) bool {
+			key := kb.resourceKey(rs.Resource(), rs.SchemaUrl())
+			if first, ok := index[key]; ok {
+				rs.ScopeSpans().MoveAndAppendTo(first.ScopeSpans())
+				return true
+			}
+			index[key] = rs
+			return false
+		})
+	}
+
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		if sss.Len() < 2 {
+			continue
+		}
+		index := make(map[string]ptrace.ScopeSpans, sss.Len())
+		sss.RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			key := kb.scopeKey(ss.Scope(), ss.SchemaUrl())
+			if first, ok := index[key]; ok {
+				ss.Spans().MoveAndAppendTo(first.Spans())
+				return true
+			}
+			index[key] = ss
+			return false
+		})
+	}
+}
+
+// DedupMetrics merges the ResourceMetrics with equal resources and schema URLs, and then, within each
+// ResourceMetrics, the ScopeMetrics with equal instrumentation scopes and schema URLs. The merged entries are at the
+// position of the first one, and the metrics keep their order. Metrics with the same name are not merged.
+func DedupMetrics(md pmetric.Metrics) {
+	var kb keyBuilder
+	rms := md.ResourceMetrics()
+	if rms.Len() > 1 {
+		index := make(map[string]pmetric.ResourceMetrics, rms.Len())
+		rms.RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+			key := kb.resourceKey(rm.Resource(), rm.SchemaUrl())
+			if first, ok := index[key]; ok {
+				rm.ScopeMetrics().MoveAndAppendTo(first.ScopeMetrics())
+				return true
+			}
+			index[key] = rm
+			return false
+		})
+	}
+
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		if sms.Len() < 2 {
+			continue
+		}
+		index := make(map[string]pmetric.ScopeMetrics, sms.Len())
+		sms.RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			key := kb.scopeKey(sm.Scope(), sm.SchemaUrl())
+			if first, ok := index[key]; ok {
+				sm.Metrics().MoveAndAppendTo(first.Metrics())
+				return true
+			}
+			index[key] = sm
+			return false
+		})
+	}
+}
+
+// DedupLogs merges the ResourceLogs with equal resources and schema URLs, and then, within each ResourceLogs, the
+// ScopeLogs with equal instrumentation scopes and schema URLs. The merged entries are at the position of the first
+// one, and the log records keep their order.
+func DedupLogs(ld plog.Logs) {
+	var kb keyBuilder
+	rls := ld.ResourceLogs()
+	if rls.Len() > 1 {
+		index := make(map[string]plog.ResourceLogs, rls.Len())
+		rls.RemoveIf(func(rl plog.ResourceLogs) bool {
+			key := kb.resourceKey(rl.Resource(), rl.SchemaUrl())
+			if first, ok := index[key]; ok {
+				rl.ScopeLogs().MoveAndAppendTo(first.ScopeLogs())
+				return true
+			}
+			index[key] = rl
+			return false
+		})
+	}
+
+	for i := 0; i < rls.Len(); i++ {
+		sls := rls.At(i).ScopeLogs()
+		if sls.Len() < 2 {
+			continue
+		}
+		index := make(map[string]plog.ScopeLogs, sls.Len())
+		sls.RemoveIf(func(sl plog.ScopeLogs) bool {
+			key := kb.scopeKey(sl.Scope(), sl.SchemaUrl())
+			if first, ok := index[key]; ok {
+				sl.LogRecords().MoveAndAppendTo(first.LogRecords())
+				return true
+			}
+			index[key] = sl
+			return false
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdatautil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// appendTraces appends a resource with the given service name, holding a scope with the given name and a span.
+func appendTraces(td ptrace.Traces, service, scope, span string) {
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", service)
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName(scope)
+	ss.Spans().AppendEmpty().SetName(span)
+}
+
+func TestDedupTraces(t *testing.T) {
+	td := ptrace.NewTraces()
+	appendTraces(td, "a", "lib1", "span1")
+	appendTraces(td, "b", "lib1", "span2")
+	appendTraces(td, "a", "lib2", "span3")
+	appendTraces(td, "a", "lib1", "span4")
+	appendTraces(td, "b", "lib1", "span5")
+	td.ResourceSpans().At(1).SetSchemaUrl("schema")
+
+	expected := ptrace.NewTraces()
+	appendTraces(expected, "a", "lib1", "span1")
+	expected.ResourceSpans().At(0).ScopeSpans().At(0).Spans().AppendEmpty().SetName("span4")
+	ss := expected.ResourceSpans().At(0).ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("lib2")
+	ss.Spans().AppendEmpty().SetName("span3")
+	appendTraces(expected, "b", "lib1", "span2")
+	expected.ResourceSpans().At(1).SetSchemaUrl("schema")
+	appendTraces(expected, "b", "lib1", "span5")
+
+	DedupTraces(td)
+	assert.Equal(t, expected, td)
+}
+
+// appendMetrics appends a resource with the given service name, holding a scope with the given name and a metric.
+func appendMetrics(md pmetric.Metrics, service, scope, metric string) {
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", service)
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scope)
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(metric)
+	m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+}
+
+func TestDedupMetrics(t *testing.T) {
+	md := pmetric.NewMetrics()
+	appendMetrics(md, "a", "lib1", "metric1")
+	appendMetrics(md, "b", "lib1", "metric2")
+	appendMetrics(md, "a", "lib2", "metric3")
+	appendMetrics(md, "a", "lib1", "metric1")
+	md.ResourceMetrics().At(1).ScopeMetrics().At(0).SetSchemaUrl("schema")
+	appendMetrics(md, "b", "lib1", "metric4")
+
+	DedupMetrics(md)
+	assert.Equal(t, 2, md.ResourceMetrics().Len())
+	assert.Equal(t, 5, md.DataPointCount())
+
+	sms := md.ResourceMetrics().At(0).ScopeMetrics()
+	assert.Equal(t, 2, sms.Len())
+	// The metrics with the same name are not merged.
+	assert.Equal(t, 2, sms.At(0).Metrics().Len())
+	assert.Equal(t, "metric1", sms.At(0).Metrics().At(1).Name())
+	assert.Equal(t, "lib2", sms.At(1).Scope().Name())
+
+	// The scopes of the resources "b" differ by their schema URL.
+	sms = md.ResourceMetrics().At(1).ScopeMetrics()
+	assert.Equal(t, 2, sms.Len())
+	assert.Equal(t, "metric2", sms.At(0).Metrics().At(0).Name())
+	assert.Equal(t, "metric4", sms.At(1).Metrics().At(0).Name())
+}
+
+// appendLogs appends a resource with the given service name, holding a scope with the given name and a log record.
+func appendLogs(ld plog.Logs, service, scope, body string) {
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", service)
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName(scope)
+	sl.LogRecords().AppendEmpty().Body().SetStr(body)
+}
+
+func TestDedupLogs(t *testing.T) {
+	ld := plog.NewLogs()
+	appendLogs(ld, "a", "lib1", "log1")
+	appendLogs(ld, "b", "lib1", "log2")
+	appendLogs(ld, "a", "lib1", "log3")
+	appendLogs(ld, "a", "lib2", "log4")
+
+	expected := plog.NewLogs()
+	appendLogs(expected, "a", "lib1", "log1")
+	expected.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().AppendEmpty().Body().SetStr("log3")
+	sl := expected.ResourceLogs().At(0).ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("lib2")
+	sl.LogRecords().AppendEmpty().Body().SetStr("log4")
+	appendLogs(expected, "b", "lib1", "log2")
+
+	DedupLogs(ld)
+	assert.Equal(t, expected, ld)
+}
+
+func TestDedupNoDuplicates(t *testing.T) {
+	td := testdata.GenerateTraces(10)
+	expected := ptrace.NewTraces()
+	td.CopyTo(expected)
+	DedupTraces(td)
+	assert.Equal(t, expected, td)
+}
+
+// BenchmarkDedupTraces measures the cost of DedupTraces on batches of requests from the same number of services, and
+// reports the size of the deduplicated batches relative to the original ones.
+func BenchmarkDedupTraces(b *testing.B) {
+	sizer := &ptrace.ProtoMarshaler{}
+	for _, services := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("services=%d", services), func(b *testing.B) {
+			batch := ptrace.NewTraces()
+			for i := 0; i < 100; i++ {
+				td := testdata.GenerateTraces(10)
+				td.ResourceSpans().At(0).Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", i%services))
+				td.ResourceSpans().MoveAndAppendTo(batch.ResourceSpans())
+			}
+			before := sizer.TracesSize(batch)
+
+			td := ptrace.NewTraces()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				batch.CopyTo(td)
+				b.StartTimer()
+				DedupTraces(td)
+			}
+			b.ReportMetric(float64(sizer.TracesSize(td))/float64(before), "size_ratio")
+		})
+	}
+}
+
+// BenchmarkDedupMetrics measures the cost of DedupMetrics on batches of requests from the same number of services,
+// and reports the size of the deduplicated batches relative to the original ones.
+func BenchmarkDedupMetrics(b *testing.B) {
+	sizer := &pmetric.ProtoMarshaler{}
+	for _, services := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("services=%d", services), func(b *testing.B) {
+			batch := pmetric.NewMetrics()
+			for i := 0; i < 100; i++ {
+				md := testdata.GenerateMetrics(10)
+				md.ResourceMetrics().At(0).Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", i%services))
+				md.ResourceMetrics().MoveAndAppendTo(batch.ResourceMetrics())
+			}
+			before := sizer.MetricsSize(batch)
+
+			md := pmetric.NewMetrics()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				batch.CopyTo(md)
+				b.StartTimer()
+				DedupMetrics(md)
+			}
+			b.ReportMetric(float64(sizer.MetricsSize(md))/float64(before), "size_ratio")
+		})
+	}
+}
+
+// BenchmarkDedupLogs measures the cost of DedupLogs on batches of requests from the same number of services, and
+// reports the size of the deduplicated batches relative to the original ones.
+func BenchmarkDedupLogs(b *testing.B) {
+	sizer := &plog.ProtoMarshaler{}
+	for _, services := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("services=%d", services), func(b *testing.B) {
+			batch := plog.NewLogs()
+			for i := 0; i < 100; i++ {
+				ld := testdata.GenerateLogs(10)
+				ld.ResourceLogs().At(0).Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", i%services))
+				ld.ResourceLogs().MoveAndAppendTo(batch.ResourceLogs())
+			}
+			before := sizer.LogsSize(batch)
+
+			ld := plog.NewLogs()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				batch.CopyTo(ld)
+				b.StartTimer()
+				DedupLogs(ld)
+			}
+			b.ReportMetric(float64(sizer.LogsSize(ld))/float64(before), "size_ratio")
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdatautil // import "go.opentelemetry.io/collector/internal/pdatautil"
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// keyBuilder builds keys identifying resources and scopes, equal if and only if the resources or scopes are equal.
+// The keys are the encoding of the resources and scopes, with their attributes sorted by key. The buffers are reused
+// between the keys, so a keyBuilder is not safe for concurrent use.
+type keyBuilder struct {
+	buf     []byte
+	scratch [binary.MaxVarintLen64]byte
+	keys    []string
+}
+
+// resourceKey returns the key of the resource, and the schema URL of the resource data holding it.
+func (kb *keyBuilder) resourceKey(res pcommon.Resource, schemaURL string) string {
+	kb.buf = kb.buf[:0]
+	kb.appendString(schemaURL)
+	kb.appendUint(uint64(res.DroppedAttributesCount()))
+	kb.appendMap(res.Attributes())
+	return string(kb.buf)
+}
+
+// scopeKey returns the key of the instrumentation scope, and the schema URL of the scope data holding it.
+func (kb *keyBuilder) scopeKey(scope pcommon.InstrumentationScope, schemaURL string) string {
+	kb.buf = kb.buf[:0]
+	kb.appendString(schemaURL)
+	kb.appendString(scope.Name())
+	kb.appendString(scope.Version())
+	kb.appendUint(uint64(scope.DroppedAttributesCount()))
+	kb.appendMap(scope.Attributes())
+	return string(kb.buf)
+}
+
+func (kb *keyBuilder) appendUint(v uint64) {
+	n := binary.PutUvarint(kb.scratch[:], v)
+	kb.buf = append(kb.buf, kb.scratch[:n]...)
+}
+
+func (kb *keyBuilder) appendUint64(v uint64) {
+	binary.LittleEndian.PutUint64(kb.scratch[:8], v)
+	kb.buf = append(kb.buf, kb.scratch[:8]...)
+}
+
+// appendString appends the length of the string before it, so that the encoding of consecutive strings is not
+// ambiguous.
+func (kb *keyBuilder) appendString(s string) {
+	kb.appendUint(uint64(len(s)))
+	kb.buf = append(kb.buf, s...)
+}
+
+func (kb *keyBuilder) appendMap(m pcommon.Map) {
+	kb.appendUint(uint64(m.Len()))
+	if m.Len() == 0 {
+		return
+	}
+	// The keys buffer is shared by the nested maps, the keys of a map are appended after the keys of its parents.
+	start := len(kb.keys)
+	m.Range(func(k string, _ pcommon.Value) bool {
+		kb.keys = append(kb.keys, k)
+		return true
+	})
+	keys := kb.keys[start:]
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, _ := m.Get(k)
+		kb.appendString(k)
+		kb.appendValue(v)
+	}
+	kb.keys = kb.keys[:start]
+}
+
+func (kb *keyBuilder) appendValue(v pcommon.Value) {
+	kb.buf = append(kb.buf, byte(v.Type()))
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		kb.appendString(v.Str())
+	case pcommon.ValueTypeInt:
+		kb.appendUint64(uint64(v.Int()))
+	case pcommon.ValueTypeDouble:
+		kb.appendUint64(math.Float64bits(v.Double()))
+	case pcommon.ValueTypeBool:
+		if v.Bool() {
+			kb.buf = append(kb.buf, 1)
+		} else {
+			kb.buf = append(kb.buf, 0)
+		}
+	case pcommon.ValueTypeBytes:
+		kb.appendString(string(v.Bytes().AsRaw()))
+	case pcommon.ValueTypeSlice:
+		s := v.Slice()
+		kb.appendUint(uint64(s.Len()))
+		for i := 0; i < s.Len(); i++ {
+			kb.appendValue(s.At(i))
+		}
+	case pcommon.ValueTypeMap:
+		kb.appendMap(v.Map())
+	}
+}
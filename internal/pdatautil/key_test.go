@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdatautil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestResourceKey(t *testing.T) {
+	newResource := func(attrs map[string]any) pcommon.Resource {
+		res := pcommon.NewResource()
+		assert.NoError(t, res.Attributes().FromRaw(attrs))
+		return res
+	}
+	var kb keyBuilder
+	key := func(res pcommon.Resource) string {
+		return kb.resourceKey(res, "")
+	}
+
+	base := map[string]any{
+		"service.name": "svc",
+		"int":          int64(1),
+		"double":       1.5,
+		"bool":         true,
+		"bytes":        []byte{1, 2},
+		"slice":        []any{"a", int64(2)},
+		"map":          map[string]any{"b": "1", "a": "2"},
+	}
+	// The order of the attributes does not matter.
+	orig := newResource(base)
+	om := orig.Attributes().PutEmptyMap("map")
+	om.PutStr("b", "1")
+	om.PutStr("a", "2")
+	reordered := pcommon.NewResource()
+	for _, k := range []string{"slice", "bytes", "bool", "double", "int", "service.name"} {
+		v, _ := orig.Attributes().Get(k)
+		v.CopyTo(reordered.Attributes().PutEmpty(k))
+	}
+	m := reordered.Attributes().PutEmptyMap("map")
+	m.PutStr("a", "2")
+	m.PutStr("b", "1")
+	assert.Equal(t, key(orig), key(reordered))
+	assert.Equal(t, key(pcommon.NewResource()), key(pcommon.NewResource()))
+
+	different := []map[string]any{
+		{},
+		{"service.name": "svc"},
+		{"service.name": "other"},
+		{"int": "1"},
+		{"int": 1.0},
+		{"ab": "c"},
+		{"a": "bc"},
+		{"slice": []any{"a", "2"}},
+		{"slice": []any{[]any{"a", int64(2)}}},
+		{"map": map[string]any{"a": "2"}},
+		{"map": map[string]any{"a": "2", "b": "1"}},
+		{"map": map[string]any{"a": "2", "b": "1"}, "int": int64(1)},
+	}
+	seen := map[string]int{}
+	for i, attrs := range different {
+		k := key(newResource(attrs))
+		if j, ok := seen[k]; ok {
+			t.Errorf("resources %d and %d have the same key", j, i)
+		}
+		seen[k] = i
+	}
+
+	res := newResource(map[string]any{"service.name": "svc"})
+	dropped := newResource(map[string]any{"service.name": "svc"})
+	dropped.SetDroppedAttributesCount(1)
+	assert.NotEqual(t, key(res), key(dropped))
+	assert.NotEqual(t, kb.resourceKey(res, ""), kb.resourceKey(res, "https://opentelemetry.io/schemas/1.9.0"))
+}
+
+func TestScopeKey(t *testing.T) {
+	var kb keyBuilder
+	newScope := func(name, version string) pcommon.InstrumentationScope {
+		scope := pcommon.NewInstrumentationScope()
+		scope.SetName(name)
+		scope.SetVersion(version)
+		return scope
+	}
+
+	assert.Equal(t, kb.scopeKey(newScope("lib", "1.0"), ""), kb.scopeKey(newScope("lib", "1.0"), ""))
+	assert.NotEqual(t, kb.scopeKey(newScope("lib", "1.0"), ""), kb.scopeKey(newScope("lib", "1.1"), ""))
+	assert.NotEqual(t, kb.scopeKey(newScope("lib1", ".0"), ""), kb.scopeKey(newScope("lib", "1.0"), ""))
+	assert.NotEqual(t, kb.scopeKey(newScope("lib", "1.0"), ""), kb.scopeKey(newScope("lib", "1.0"), "schema"))
+
+	withAttrs := newScope("lib", "1.0")
+	withAttrs.Attributes().PutStr("k", "v")
+	assert.NotEqual(t, kb.scopeKey(newScope("lib", "1.0"), ""), kb.scopeKey(withAttrs, ""))
+}
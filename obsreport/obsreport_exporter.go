@@ -16,11 +16,15 @@ package obsreport // import "go.opentelemetry.io/collector/obsreport"
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
+	"time"
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/asyncint64"
 	"go.opentelemetry.io/otel/metric/instrument/syncint64"
 	"go.opentelemetry.io/otel/metric/unit"
 	"go.opentelemetry.io/otel/trace"
@@ -30,6 +34,7 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configtelemetry"
 	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportererror"
 	"go.opentelemetry.io/collector/featuregate"
 	"go.opentelemetry.io/collector/internal/obsreportconfig"
 	"go.opentelemetry.io/collector/internal/obsreportconfig/obsmetrics"
@@ -57,6 +62,10 @@ type Exporter struct {
 	failedToSendMetricPoints syncint64.Counter
 	sentLogRecords           syncint64.Counter
 	failedToSendLogRecords   syncint64.Counter
+	throttledRequests        syncint64.Counter
+	throttleWaitTime         asyncint64.Gauge
+	// lastThrottleWaitMs is observed by the throttleWaitTime gauge.
+	lastThrottleWaitMs int64
 }
 
 // ExporterSettings are settings for creating an Exporter.
@@ -135,6 +144,23 @@ func (exp *Exporter) createOtelMetrics(cfg ExporterSettings) error {
 		instrument.WithUnit(unit.Dimensionless))
 	errors = multierr.Append(errors, err)
 
+	exp.throttledRequests, err = meter.SyncInt64().Counter(
+		obsmetrics.ExporterPrefix+obsmetrics.ThrottledRequestsKey,
+		instrument.WithDescription("Number of requests delayed by the rate limiter."),
+		instrument.WithUnit(unit.Dimensionless))
+	errors = multierr.Append(errors, err)
+
+	exp.throttleWaitTime, err = meter.AsyncInt64().Gauge(
+		obsmetrics.ExporterPrefix+obsmetrics.ThrottleWaitTimeKey,
+		instrument.WithDescription("Time the last throttled request waited for the rate limiter."),
+		instrument.WithUnit(unit.Milliseconds))
+	errors = multierr.Append(errors, err)
+	if err == nil {
+		errors = multierr.Append(errors, meter.RegisterCallback([]instrument.Asynchronous{exp.throttleWaitTime}, func(ctx context.Context) {
+			exp.throttleWaitTime.Observe(ctx, atomic.LoadInt64(&exp.lastThrottleWaitMs), exp.otelAttrs...)
+		}))
+	}
+
 	return errors
 }
 
@@ -181,6 +207,23 @@ func (exp *Exporter) EndLogsOp(ctx context.Context, numLogRecords int, err error
 	endSpan(ctx, err, numSent, numFailedToSend, obsmetrics.SentLogRecordsKey, obsmetrics.FailedToSendLogRecordsKey)
 }
 
+// RecordThrottle records that a request was delayed by the rate limiter for the given duration.
+func (exp *Exporter) RecordThrottle(ctx context.Context, wait time.Duration) {
+	if exp.level == configtelemetry.LevelNone {
+		return
+	}
+	if exp.useOtelForMetrics {
+		exp.throttledRequests.Add(ctx, 1, exp.otelAttrs...)
+		atomic.StoreInt64(&exp.lastThrottleWaitMs, wait.Milliseconds())
+		return
+	}
+	_ = stats.RecordWithTags(
+		ctx,
+		exp.mutators,
+		obsmetrics.ExporterThrottledRequests.M(1),
+		obsmetrics.ExporterThrottleWaitTime.M(wait.Milliseconds()))
+}
+
 // startOp creates the span used to trace the operation. Returning
 // the updated context and the created span.
 func (exp *Exporter) startOp(ctx context.Context, operationSuffix string) context.Context {
@@ -254,6 +297,14 @@ func endSpan(ctx context.Context, err error, numSent, numFailedToSend int64, sen
 
 func toNumItems(numExportedItems int, err error) (int64, int64) {
 	if err != nil {
+		var partialErr exportererror.PartialExportError
+		if errors.As(err, &partialErr) {
+			numRejected := partialErr.Rejected
+			if numRejected > numExportedItems {
+				numRejected = numExportedItems
+			}
+			return int64(numExportedItems - numRejected), int64(numRejected)
+		}
 		return 0, int64(numExportedItems)
 	}
 	return int64(numExportedItems), 0
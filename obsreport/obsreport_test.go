@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,6 +26,7 @@ import (
 	"go.opentelemetry.io/otel/codes"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter/exportererror"
 	"go.opentelemetry.io/collector/featuregate"
 	"go.opentelemetry.io/collector/internal/obsreportconfig"
 	"go.opentelemetry.io/collector/internal/obsreportconfig/obsmetrics"
@@ -326,6 +328,27 @@ func TestExportTraceDataOp(t *testing.T) {
 	})
 }
 
+func TestExportTraceDataOpPartialSuccess(t *testing.T) {
+	testTelemetry(t, exporterID, func(t *testing.T, tt obsreporttest.TestTelemetry, registry *featuregate.Registry) {
+		obsrep, err := newExporter(ExporterSettings{
+			ExporterID:             exporterID,
+			ExporterCreateSettings: tt.ToExporterCreateSettings(),
+		}, registry)
+		require.NoError(t, err)
+
+		ctx := obsrep.StartTracesOp(context.Background())
+		obsrep.EndTracesOp(ctx, 22, exportererror.NewPartialExportError(errFake, 5))
+
+		spans := tt.SpanRecorder.Ended()
+		require.Equal(t, 1, len(spans))
+		require.Contains(t, spans[0].Attributes(), attribute.KeyValue{Key: obsmetrics.SentSpansKey, Value: attribute.Int64Value(17)})
+		require.Contains(t, spans[0].Attributes(), attribute.KeyValue{Key: obsmetrics.FailedToSendSpansKey, Value: attribute.Int64Value(5)})
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+		require.NoError(t, tt.CheckExporterTraces(17, 5))
+	})
+}
+
 func TestExportMetricsOp(t *testing.T) {
 	testTelemetry(t, exporterID, func(t *testing.T, tt obsreporttest.TestTelemetry, registry *featuregate.Registry) {
 		parentCtx, parentSpan := tt.TracerProvider.Tracer("test").Start(context.Background(), t.Name())
@@ -424,6 +447,21 @@ func TestExportLogsOp(t *testing.T) {
 	})
 }
 
+func TestExportThrottle(t *testing.T) {
+	testTelemetry(t, exporterID, func(t *testing.T, tt obsreporttest.TestTelemetry, registry *featuregate.Registry) {
+		obsrep, err := newExporter(ExporterSettings{
+			ExporterID:             exporterID,
+			ExporterCreateSettings: tt.ToExporterCreateSettings(),
+		}, registry)
+		require.NoError(t, err)
+
+		obsrep.RecordThrottle(context.Background(), 250*time.Millisecond)
+		obsrep.RecordThrottle(context.Background(), 40*time.Millisecond)
+
+		require.NoError(t, tt.CheckExporterThrottle(2, 40))
+	})
+}
+
 func TestReceiveWithLongLivedCtx(t *testing.T) {
 	tt, err := obsreporttest.SetupTelemetry(receiverID)
 	require.NoError(t, err)
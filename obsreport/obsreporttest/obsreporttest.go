@@ -106,6 +106,12 @@ func (tts *TestTelemetry) CheckExporterLogs(sentLogRecords, sendFailedLogRecords
 	return tts.otelPrometheusChecker.checkExporterLogs(tts.id, sentLogRecords, sendFailedLogRecords)
 }
 
+// CheckExporterThrottle checks that for the current exported values for the exporter rate limiter metrics match given values.
+// When this function is called it is required to also call SetupTelemetry as first thing.
+func (tts *TestTelemetry) CheckExporterThrottle(throttledRequests, throttleWaitTimeMs int64) error {
+	return tts.otelPrometheusChecker.checkExporterThrottle(tts.id, throttledRequests, throttleWaitTimeMs)
+}
+
 // CheckProcessorTraces checks that for the current exported values for trace exporter metrics match given values.
 // When this function is called it is required to also call SetupTelemetry as first thing.
 func (tts *TestTelemetry) CheckProcessorTraces(acceptedSpans, refusedSpans, droppedSpans int64) error {
@@ -107,6 +107,30 @@ func (pc *prometheusChecker) checkExporterMetrics(exporter component.ID, sentMet
 		pc.checkCounter("exporter_send_failed_metric_points", sendFailedMetricPoints, exporterAttrs))
 }
 
+func (pc *prometheusChecker) checkExporterThrottle(exporter component.ID, throttledRequests, throttleWaitTimeMs int64) error {
+	exporterAttrs := attributesForExporterMetrics(exporter)
+	return multierr.Combine(
+		pc.checkCounter("exporter_throttled_requests", throttledRequests, exporterAttrs),
+		pc.checkGauge("exporter_throttle_wait_time", throttleWaitTimeMs, exporterAttrs))
+}
+
+func (pc *prometheusChecker) checkGauge(expectedMetric string, value int64, attrs []attribute.KeyValue) error {
+	// Forces a flush for the opencensus view data.
+	_, _ = view.RetrieveData(expectedMetric)
+
+	ts, err := pc.getMetric(expectedMetric, io_prometheus_client.MetricType_GAUGE, attrs)
+	if err != nil {
+		return err
+	}
+
+	expected := float64(value)
+	if math.Abs(expected-ts.GetGauge().GetValue()) > 0.0001 {
+		return fmt.Errorf("values for metric '%s' did no match, expected '%f' got '%f'", expectedMetric, expected, ts.GetGauge().GetValue())
+	}
+
+	return nil
+}
+
 func (pc *prometheusChecker) checkCounter(expectedMetric string, value int64, attrs []attribute.KeyValue) error {
 	// Forces a flush for the opencensus view data.
 	_, _ = view.RetrieveData(expectedMetric)
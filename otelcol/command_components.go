@@ -28,6 +28,7 @@ type componentsOutput struct {
 	Receivers  []component.Type
 	Processors []component.Type
 	Exporters  []component.Type
+	Connectors []component.Type
 	Extensions []component.Type
 }
 
@@ -52,6 +53,9 @@ func newBuildSubCommand(set CollectorSettings) *cobra.Command {
 			for exp := range set.Factories.Exporters {
 				components.Exporters = append(components.Exporters, exp)
 			}
+			for conn := range set.Factories.Connectors {
+				components.Connectors = append(components.Connectors, conn)
+			}
 			components.BuildInfo = set.BuildInfo
 			yamlData, err := yaml.Marshal(components)
 			if err != nil {
@@ -17,6 +17,7 @@ package otelcol // import "go.opentelemetry.io/collector/otelcol"
 import (
 	"errors"
 	"fmt"
+	"sort"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/service"
@@ -38,6 +39,9 @@ type Config struct {
 	// Processors is a map of ComponentID to Processors.
 	Processors map[component.ID]component.Config
 
+	// Connectors is a map of ComponentID to connectors.
+	Connectors map[component.ID]component.Config
+
 	// Extensions is a map of ComponentID to extensions.
 	Extensions map[component.ID]component.Config
 
@@ -83,6 +87,21 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	// Validate the connector configuration.
+	for connID, connCfg := range cfg.Connectors {
+		if err := component.ValidateConfig(connCfg); err != nil {
+			return fmt.Errorf("connectors::%s: %w", connID, err)
+		}
+
+		// Pipelines reference receivers and connectors, or exporters and connectors, by ID.
+		if _, ok := cfg.Receivers[connID]; ok {
+			return fmt.Errorf("connectors::%s: ambiguous ID: found both %q receiver and %q connector", connID, connID, connID)
+		}
+		if _, ok := cfg.Exporters[connID]; ok {
+			return fmt.Errorf("connectors::%s: ambiguous ID: found both %q exporter and %q connector", connID, connID, connID)
+		}
+	}
+
 	// Validate the extension configuration.
 	for extID, extCfg := range cfg.Extensions {
 		if err := component.ValidateConfig(extCfg); err != nil {
@@ -106,8 +125,8 @@ func (cfg *Config) Validate() error {
 	for pipelineID, pipeline := range cfg.Service.Pipelines {
 		// Validate pipeline receiver name references.
 		for _, ref := range pipeline.Receivers {
-			// Check that the name referenced in the pipeline's receivers exists in the top-level receivers.
-			if cfg.Receivers[ref] == nil {
+			// Check that the name referenced in the pipeline's receivers exists in the top-level receivers or connectors.
+			if cfg.Receivers[ref] == nil && cfg.Connectors[ref] == nil {
 				return fmt.Errorf("service::pipeline::%s: references receiver %q which is not configured", pipelineID, ref)
 			}
 		}
@@ -122,12 +141,57 @@ func (cfg *Config) Validate() error {
 
 		// Validate pipeline exporter name references.
 		for _, ref := range pipeline.Exporters {
-			// Check that the name referenced in the pipeline's Exporters exists in the top-level Exporters.
-			if cfg.Exporters[ref] == nil {
+			// Check that the name referenced in the pipeline's Exporters exists in the top-level Exporters or connectors.
+			if cfg.Exporters[ref] == nil && cfg.Connectors[ref] == nil {
 				return fmt.Errorf("service::pipeline::%s: references exporter %q which is not configured", pipelineID, ref)
 			}
 		}
 	}
 
+	return cfg.validateConnectorsUse()
+}
+
+// validateConnectorsUse checks that every connector referenced by a pipeline is used
+// both as an exporter and as a receiver, so that the data it consumes goes somewhere.
+// Whether the connector supports the data types of these pipelines, and whether they
+// form a cycle, is checked by the service when building the pipelines.
+func (cfg *Config) validateConnectorsUse() error {
+	pipelineIDs := make([]component.ID, 0, len(cfg.Service.Pipelines))
+	for pipelineID := range cfg.Service.Pipelines {
+		pipelineIDs = append(pipelineIDs, pipelineID)
+	}
+	sort.Slice(pipelineIDs, func(i, j int) bool { return pipelineIDs[i].String() < pipelineIDs[j].String() })
+
+	usedAsExporter := make(map[component.ID]struct{})
+	usedAsReceiver := make(map[component.ID]struct{})
+	for _, pipeline := range cfg.Service.Pipelines {
+		for _, ref := range pipeline.Exporters {
+			usedAsExporter[ref] = struct{}{}
+		}
+		for _, ref := range pipeline.Receivers {
+			usedAsReceiver[ref] = struct{}{}
+		}
+	}
+
+	for _, pipelineID := range pipelineIDs {
+		pipeline := cfg.Service.Pipelines[pipelineID]
+		for _, ref := range pipeline.Exporters {
+			if _, ok := cfg.Connectors[ref]; !ok {
+				continue
+			}
+			if _, ok := usedAsReceiver[ref]; !ok {
+				return fmt.Errorf("connectors::%s: used as exporter in pipeline %q but not used as receiver in any pipeline", ref, pipelineID)
+			}
+		}
+		for _, ref := range pipeline.Receivers {
+			if _, ok := cfg.Connectors[ref]; !ok {
+				continue
+			}
+			if _, ok := usedAsExporter[ref]; !ok {
+				return fmt.Errorf("connectors::%s: used as receiver in pipeline %q but not used as exporter in any pipeline", ref, pipelineID)
+			}
+		}
+	}
+
 	return nil
 }
@@ -33,6 +33,7 @@ var (
 	errInvalidExpConfig  = errors.New("invalid exporter config")
 	errInvalidProcConfig = errors.New("invalid processor config")
 	errInvalidExtConfig  = errors.New("invalid extension config")
+	errInvalidConnConfig = errors.New("invalid connector config")
 )
 
 type nopRecvConfig struct {
@@ -67,6 +68,14 @@ func (nc *nopExtConfig) Validate() error {
 	return nc.validateErr
 }
 
+type nopConnConfig struct {
+	validateErr error
+}
+
+func (nc *nopConnConfig) Validate() error {
+	return nc.validateErr
+}
+
 func TestConfigValidate(t *testing.T) {
 	var testCases = []struct {
 		name     string // test case name (also file name containing config yaml)
@@ -188,6 +197,69 @@ func TestConfigValidate(t *testing.T) {
 			},
 			expected: fmt.Errorf(`extensions::nop: %w`, errInvalidExtConfig),
 		},
+		{
+			name: "invalid-connector-config",
+			cfgFn: func() *Config {
+				cfg := generateConfig()
+				cfg.Connectors[component.NewIDWithName("nop", "conn")] = &nopConnConfig{
+					validateErr: errInvalidConnConfig,
+				}
+				return cfg
+			},
+			expected: fmt.Errorf(`connectors::nop/conn: %w`, errInvalidConnConfig),
+		},
+		{
+			name: "valid-connector-use",
+			cfgFn: func() *Config {
+				cfg := generateConfig()
+				pipe := cfg.Service.Pipelines[component.NewID("traces")]
+				pipe.Exporters = append(pipe.Exporters, component.NewIDWithName("nop", "conn"))
+				cfg.Service.Pipelines[component.NewIDWithName("metrics", "out")] = &service.PipelineConfig{
+					Receivers: []component.ID{component.NewIDWithName("nop", "conn")},
+					Exporters: []component.ID{component.NewID("nop")},
+				}
+				return cfg
+			},
+			expected: nil,
+		},
+		{
+			name: "ambiguous-connector-name-as-receiver",
+			cfgFn: func() *Config {
+				cfg := generateConfig()
+				cfg.Receivers[component.NewIDWithName("nop", "conn")] = &nopRecvConfig{}
+				return cfg
+			},
+			expected: errors.New(`connectors::nop/conn: ambiguous ID: found both "nop/conn" receiver and "nop/conn" connector`),
+		},
+		{
+			name: "ambiguous-connector-name-as-exporter",
+			cfgFn: func() *Config {
+				cfg := generateConfig()
+				cfg.Exporters[component.NewIDWithName("nop", "conn")] = &nopExpConfig{}
+				return cfg
+			},
+			expected: errors.New(`connectors::nop/conn: ambiguous ID: found both "nop/conn" exporter and "nop/conn" connector`),
+		},
+		{
+			name: "connector-not-used-as-receiver",
+			cfgFn: func() *Config {
+				cfg := generateConfig()
+				pipe := cfg.Service.Pipelines[component.NewID("traces")]
+				pipe.Exporters = append(pipe.Exporters, component.NewIDWithName("nop", "conn"))
+				return cfg
+			},
+			expected: errors.New(`connectors::nop/conn: used as exporter in pipeline "traces" but not used as receiver in any pipeline`),
+		},
+		{
+			name: "connector-not-used-as-exporter",
+			cfgFn: func() *Config {
+				cfg := generateConfig()
+				pipe := cfg.Service.Pipelines[component.NewID("traces")]
+				pipe.Receivers = append(pipe.Receivers, component.NewIDWithName("nop", "conn"))
+				return cfg
+			},
+			expected: errors.New(`connectors::nop/conn: used as receiver in pipeline "traces" but not used as exporter in any pipeline`),
+		},
 		{
 			name: "invalid-service-config",
 			cfgFn: func() *Config {
@@ -218,6 +290,9 @@ func generateConfig() *Config {
 		Processors: map[component.ID]component.Config{
 			component.NewID("nop"): &nopProcConfig{},
 		},
+		Connectors: map[component.ID]component.Config{
+			component.NewIDWithName("nop", "conn"): &nopConnConfig{},
+		},
 		Extensions: map[component.ID]component.Config{
 			component.NewID("nop"): &nopExtConfig{},
 		},
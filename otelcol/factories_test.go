@@ -15,6 +15,8 @@
 package otelcol
 
 import (
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/connector/connectortest"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/exportertest"
 	"go.opentelemetry.io/collector/extension"
@@ -45,5 +47,9 @@ func nopFactories() (Factories, error) {
 		return Factories{}, err
 	}
 
+	if factories.Connectors, err = connector.MakeFactoryMap(connectortest.NewNopFactory()); err != nil {
+		return Factories{}, err
+	}
+
 	return factories, err
 }
@@ -52,18 +52,29 @@ func TestLoadConfig(t *testing.T) {
 	assert.Contains(t, cfg.Processors, component.NewID("nop"))
 	assert.Contains(t, cfg.Processors, component.NewIDWithName("nop", "myprocessor"))
 
+	// Verify connectors
+	assert.Len(t, cfg.Connectors, 1)
+	assert.Contains(t, cfg.Connectors, component.NewIDWithName("nop", "myconnector"))
+
 	// Verify service.
 	require.Len(t, cfg.Service.Extensions, 1)
 	assert.Contains(t, cfg.Service.Extensions, component.NewID("nop"))
-	require.Len(t, cfg.Service.Pipelines, 1)
+	require.Len(t, cfg.Service.Pipelines, 2)
 	assert.Equal(t,
 		&service.PipelineConfig{
 			Receivers:  []component.ID{component.NewID("nop")},
 			Processors: []component.ID{component.NewID("nop")},
-			Exporters:  []component.ID{component.NewID("nop")},
+			Exporters:  []component.ID{component.NewID("nop"), component.NewIDWithName("nop", "myconnector")},
 		},
 		cfg.Service.Pipelines[component.NewID("traces")],
 		"Did not load pipeline config correctly")
+	assert.Equal(t,
+		&service.PipelineConfig{
+			Receivers: []component.ID{component.NewIDWithName("nop", "myconnector")},
+			Exporters: []component.ID{component.NewID("nop")},
+		},
+		cfg.Service.Pipelines[component.NewID("metrics")],
+		"Did not load pipeline config correctly")
 }
 
 func TestLoadConfigAndValidate(t *testing.T) {
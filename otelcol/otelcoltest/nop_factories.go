@@ -14,7 +14,44 @@
 
 package otelcoltest // import "go.opentelemetry.io/collector/otelcol/otelcoltest"
 
-import "go.opentelemetry.io/collector/component/componenttest"
+import (
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/connector/connectortest"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"go.opentelemetry.io/collector/otelcol"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
 
 // NopFactories returns a otelcol.Factories with all nop factories.
-var NopFactories = componenttest.NopFactories // nolint:staticcheck
+func NopFactories() (otelcol.Factories, error) {
+	var factories otelcol.Factories
+	var err error
+
+	if factories.Extensions, err = extension.MakeFactoryMap(extensiontest.NewNopFactory()); err != nil {
+		return otelcol.Factories{}, err
+	}
+
+	if factories.Receivers, err = receiver.MakeFactoryMap(receivertest.NewNopFactory()); err != nil {
+		return otelcol.Factories{}, err
+	}
+
+	if factories.Exporters, err = exporter.MakeFactoryMap(exportertest.NewNopFactory()); err != nil {
+		return otelcol.Factories{}, err
+	}
+
+	if factories.Processors, err = processor.MakeFactoryMap(processortest.NewNopFactory()); err != nil {
+		return otelcol.Factories{}, err
+	}
+
+	if factories.Connectors, err = connector.MakeFactoryMap(connectortest.NewNopFactory()); err != nil {
+		return otelcol.Factories{}, err
+	}
+
+	return factories, err
+}
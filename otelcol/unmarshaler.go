@@ -15,9 +15,10 @@
 package otelcol // import "go.opentelemetry.io/collector/otelcol"
 
 import (
+	"fmt"
+
 	"go.uber.org/zap/zapcore"
 
-	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configtelemetry"
 	"go.opentelemetry.io/collector/confmap"
 	"go.opentelemetry.io/collector/connector"
@@ -47,7 +48,7 @@ func unmarshal(v *confmap.Conf, factories Factories) (*configSettings, error) {
 		Receivers:  configunmarshaler.NewConfigs(factories.Receivers),
 		Processors: configunmarshaler.NewConfigs(factories.Processors),
 		Exporters:  configunmarshaler.NewConfigs(factories.Exporters),
-		Connectors: configunmarshaler.NewConfigs(map[component.Type]connector.Factory{}),
+		Connectors: configunmarshaler.NewConfigs(factories.Connectors),
 		Extensions: configunmarshaler.NewConfigs(factories.Extensions),
 		// TODO: Add a component.ServiceFactory to allow this to be defined by the Service.
 		Service: service.Config{
@@ -74,5 +75,17 @@ func unmarshal(v *confmap.Conf, factories Factories) (*configSettings, error) {
 		},
 	}
 
-	return cfg, v.Unmarshal(&cfg, confmap.WithErrorUnused())
+	if err := v.Unmarshal(&cfg, confmap.WithErrorUnused()); err != nil {
+		return cfg, err
+	}
+
+	// The exporters creating other exporters decode the nested configurations with the exporter factories.
+	for id, expCfg := range cfg.Exporters.Configs() {
+		if nested, ok := expCfg.(exporter.NestedExportersConfig); ok {
+			if err := nested.ResolveExporters(factories.Exporters); err != nil {
+				return cfg, fmt.Errorf("error reading configuration for %q: %w", id, err)
+			}
+		}
+	}
+	return cfg, nil
 }
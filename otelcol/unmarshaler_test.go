@@ -15,6 +15,7 @@
 package otelcol
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +24,7 @@ import (
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/service"
 	"go.opentelemetry.io/collector/service/telemetry"
 )
@@ -79,6 +81,45 @@ func TestUnmarshalUnknownTopLevel(t *testing.T) {
 	assert.Contains(t, err.Error(), "'' has invalid keys: unknown_section")
 }
 
+// nestedExportersConfig records the exporter factories it is resolved with.
+type nestedExportersConfig struct {
+	Exporter  component.ID `mapstructure:"exporter"`
+	factories map[component.Type]exporter.Factory
+}
+
+func (cfg *nestedExportersConfig) ResolveExporters(factories map[component.Type]exporter.Factory) error {
+	if _, ok := factories[cfg.Exporter.Type()]; !ok {
+		return fmt.Errorf("exporter factory not available for %q", cfg.Exporter)
+	}
+	cfg.factories = factories
+	return nil
+}
+
+func TestUnmarshalNestedExporters(t *testing.T) {
+	factories, err := nopFactories()
+	require.NoError(t, err)
+	nestedFactory := exporter.NewFactory("nested", func() component.Config { return &nestedExportersConfig{} })
+	factories.Exporters[nestedFactory.Type()] = nestedFactory
+
+	conf := confmap.NewFromStringMap(map[string]interface{}{
+		"exporters": map[string]interface{}{
+			"nested": map[string]interface{}{"exporter": "nop"},
+		},
+	})
+	cfg, err := unmarshal(conf, factories)
+	require.NoError(t, err)
+	nestedCfg := cfg.Exporters.Configs()[component.NewID("nested")].(*nestedExportersConfig)
+	assert.Equal(t, factories.Exporters, nestedCfg.factories)
+
+	conf = confmap.NewFromStringMap(map[string]interface{}{
+		"exporters": map[string]interface{}{
+			"nested": map[string]interface{}{"exporter": "unknown"},
+		},
+	})
+	_, err = unmarshal(conf, factories)
+	assert.EqualError(t, err, `error reading configuration for "nested": exporter factory not available for "unknown"`)
+}
+
 func TestPipelineConfigUnmarshalError(t *testing.T) {
 	var testCases = []struct {
 		// test case name (also file name containing config yaml)
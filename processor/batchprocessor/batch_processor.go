@@ -16,16 +16,23 @@ package batchprocessor // import "go.opentelemetry.io/collector/processor/batchp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
+	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/featuregate"
+	"go.opentelemetry.io/collector/internal/pdatautil"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -39,57 +46,182 @@ import (
 //
 // Batches are sent out with any of the following conditions:
 // - batch size reaches cfg.SendBatchSize
+// - batch byte size reaches cfg.SendBatchSizeBytes, when set
 // - cfg.Timeout is elapsed since the timestamp when the previous batch was sent out.
+//
+// When cfg.WaitForExport is set, the callers wait until the batches holding their data are exported and get the
+// error of the exports.
 type batchProcessor struct {
 	logger           *zap.Logger
-	exportCtx        context.Context
-	timer            *time.Timer
 	timeout          time.Duration
 	sendBatchSize    int
 	sendBatchMaxSize int
 
-	newItem chan interface{}
-	batch   batch
+	// sendBatchSizeBytes and sendBatchMaxSizeBytes are the byte size limits of the batches, 0 when not set.
+	sendBatchSizeBytes    int
+	sendBatchMaxSizeBytes int
+
+	// batchFunc creates the batches of the shards, for the signal of the processor.
+	batchFunc func() batch
+
+	// metadataKeys are the lower-cased and sorted metadata keys of the configuration. When empty, a single shard
+	// batches all the data, otherwise each distinct combination of their values is batched by its own shard.
+	metadataKeys []string
+
+	// metadataLimit is the maximum number of shards, when metadataKeys is not empty.
+	metadataLimit int
+
+	// waitForExport makes the callers wait for the export of their data.
+	waitForExport bool
+	// waitingRequests limits the number of callers waiting for the export of their data, nil when not limited.
+	waitingRequests chan struct{}
+
+	// exportSlots limits the number of batches exported concurrently, nil when the batches are exported one at a
+	// time by the goroutines of the shards.
+	exportSlots chan struct{}
 
 	shutdownC  chan struct{}
 	goroutines sync.WaitGroup
 
 	telemetry *batchProcessorTelemetry
+
+	// batcher is either a *singleShardBatcher or a *multiShardBatcher.
+	batcher batcher
+}
+
+// batcher sends the data to the shard batching it.
+type batcher interface {
+	consume(ctx context.Context, data interface{}) error
+	start()
+}
+
+// shard batches the data of a single combination of metadata values, or all the data when no metadata keys are
+// configured.
+type shard struct {
+	processor *batchProcessor
+
+	// exportCtx is the context of the exports, carrying the metadata values of the shard.
+	exportCtx context.Context
+	timer     *time.Timer
+	newItem   chan interface{}
+	batch     batch
+
+	// waiters are the callers waiting for the export of their data, in the order of the data in the batch, with
+	// their items in the batch.
+	waiters []waiterItems
+}
+
+// pendingItem is the data of a caller waiting for its export.
+type pendingItem struct {
+	data interface{}
+	// done receives the error of the export, it is buffered so that the shard does not wait for the caller.
+	done chan error
+}
+
+// waiter tracks the items of a pendingItem not exported yet, and the first error of their exports. The items may be
+// split across batches exported concurrently.
+type waiter struct {
+	mu    sync.Mutex
+	items int
+	err   error
+	done  chan error
+}
+
+// exported records the export of items of the caller, and returns the result to the caller once all its items are
+// exported.
+func (w *waiter) exported(items int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.items -= items
+	if w.err == nil {
+		w.err = err
+	}
+	if w.items == 0 {
+		w.done <- w.err
+	}
+}
+
+// waiterItems are items of a waiter in a batch, or in a request split from it.
+type waiterItems struct {
+	waiter *waiter
+	items  int
 }
 
 type batch interface {
-	// export the current batch
-	export(ctx context.Context, sendBatchMaxSize int, returnBytes bool) (sentBatchSize int, sentBatchBytes int, err error)
+	// split removes a request from the current batch, of at most sendBatchMaxSize items and sendBatchMaxSizeBytes
+	// bytes when they are set, and returns it with its number of items
+	split(sendBatchMaxSize int, sendBatchMaxSizeBytes int) (sentBatchSize int, req interface{})
+
+	// export a request split from the batch, it may be called concurrently with the other methods
+	export(ctx context.Context, req interface{}, returnBytes bool) (sentBatchBytes int, err error)
 
 	// itemCount returns the size of the current batch
 	itemCount() int
 
+	// byteCount returns the byte size of the current batch, it is only tracked when the batch sizes are limited in bytes
+	byteCount() int
+
 	// add item to the current batch
 	add(item interface{})
 }
 
+// errTooManyBatchers is returned when the data would need a new shard while metadataLimit shards already exist.
+var errTooManyBatchers = consumererror.NewPermanent(errors.New("too many batcher metadata-value combinations"))
+
 var _ consumer.Traces = (*batchProcessor)(nil)
 var _ consumer.Metrics = (*batchProcessor)(nil)
 var _ consumer.Logs = (*batchProcessor)(nil)
 
-func newBatchProcessor(set processor.CreateSettings, cfg *Config, batch batch, registry *featuregate.Registry) (*batchProcessor, error) {
+func newBatchProcessor(set processor.CreateSettings, cfg *Config, batchFunc func() batch, registry *featuregate.Registry) (*batchProcessor, error) {
 	bpt, err := newBatchProcessorTelemetry(set, registry)
 	if err != nil {
 		return nil, fmt.Errorf("error to create batch processor telemetry %w", err)
 	}
 
-	return &batchProcessor{
+	// Lower-case the keys, to be consistent with HTTP/2 headers.
+	mks := make([]string, len(cfg.MetadataKeys))
+	for i, k := range cfg.MetadataKeys {
+		mks[i] = strings.ToLower(k)
+	}
+	sort.Strings(mks)
+
+	bp := &batchProcessor{
 		logger:    set.Logger,
-		exportCtx: bpt.exportCtx,
 		telemetry: bpt,
 
-		sendBatchSize:    int(cfg.SendBatchSize),
-		sendBatchMaxSize: int(cfg.SendBatchMaxSize),
-		timeout:          cfg.Timeout,
-		newItem:          make(chan interface{}, runtime.NumCPU()),
-		batch:            batch,
-		shutdownC:        make(chan struct{}, 1),
-	}, nil
+		sendBatchSize:         int(cfg.SendBatchSize),
+		sendBatchMaxSize:      int(cfg.SendBatchMaxSize),
+		sendBatchSizeBytes:    int(cfg.SendBatchSizeBytes),
+		sendBatchMaxSizeBytes: int(cfg.SendBatchMaxSizeBytes),
+		timeout:               cfg.Timeout,
+		batchFunc:             batchFunc,
+		metadataKeys:          mks,
+		metadataLimit:         int(cfg.MetadataCardinalityLimit),
+		waitForExport:         cfg.WaitForExport,
+		shutdownC:             make(chan struct{}, 1),
+	}
+	if cfg.MaxConcurrentExports > 1 {
+		bp.exportSlots = make(chan struct{}, cfg.MaxConcurrentExports)
+	}
+	if cfg.WaitForExport && cfg.MaxWaitingRequests > 0 {
+		bp.waitingRequests = make(chan struct{}, cfg.MaxWaitingRequests)
+	}
+	if len(bp.metadataKeys) == 0 {
+		bp.batcher = &singleShardBatcher{shard: bp.newShard(bpt.exportCtx)}
+	} else {
+		bp.batcher = &multiShardBatcher{batchProcessor: bp}
+	}
+	return bp, nil
+}
+
+// newShard creates a shard exporting the batches with the given context.
+func (bp *batchProcessor) newShard(exportCtx context.Context) *shard {
+	return &shard{
+		processor: bp,
+		exportCtx: exportCtx,
+		newItem:   make(chan interface{}, runtime.NumCPU()),
+		batch:     bp.batchFunc(),
+	}
 }
 
 func (bp *batchProcessor) Capabilities() consumer.Capabilities {
@@ -98,8 +230,7 @@ func (bp *batchProcessor) Capabilities() consumer.Capabilities {
 
 // Start is invoked during service startup.
 func (bp *batchProcessor) Start(context.Context, component.Host) error {
-	bp.goroutines.Add(1)
-	go bp.startProcessingCycle()
+	bp.batcher.start()
 	return nil
 }
 
@@ -112,107 +243,284 @@ func (bp *batchProcessor) Shutdown(context.Context) error {
 	return nil
 }
 
-func (bp *batchProcessor) startProcessingCycle() {
-	defer bp.goroutines.Done()
-	bp.timer = time.NewTimer(bp.timeout)
+func (b *shard) start() {
+	b.processor.goroutines.Add(1)
+	go b.startProcessingCycle()
+}
+
+func (b *shard) startProcessingCycle() {
+	defer b.processor.goroutines.Done()
+	b.timer = time.NewTimer(b.processor.timeout)
 	for {
 		select {
-		case <-bp.shutdownC:
+		case <-b.processor.shutdownC:
 		DONE:
 			for {
 				select {
-				case item := <-bp.newItem:
-					bp.processItem(item)
+				case item := <-b.newItem:
+					b.processItem(item)
 				default:
 					break DONE
 				}
 			}
-			// This is the close of the channel
-			if bp.batch.itemCount() > 0 {
+			// This is the close of the channel. The max sizes may split the remaining data in several requests,
+			// send them all so that no caller waits for data that is never exported.
+			for b.batch.itemCount() > 0 {
 				// TODO: Set a timeout on sendTraces or
 				// make it cancellable using the context that Shutdown gets as a parameter
-				bp.sendItems(triggerTimeout)
+				b.sendItems(triggerTimeout)
 			}
 			return
-		case item := <-bp.newItem:
+		case item := <-b.newItem:
 			if item == nil {
 				continue
 			}
-			bp.processItem(item)
-		case <-bp.timer.C:
-			if bp.batch.itemCount() > 0 {
-				bp.sendItems(triggerTimeout)
+			b.processItem(item)
+		case <-b.timer.C:
+			if b.batch.itemCount() > 0 {
+				b.sendItems(triggerTimeout)
 			}
-			bp.resetTimer()
+			b.resetTimer()
 		}
 	}
 }
 
-func (bp *batchProcessor) processItem(item interface{}) {
-	bp.batch.add(item)
+func (b *shard) processItem(item interface{}) {
+	if pi, ok := item.(*pendingItem); ok {
+		before := b.batch.itemCount()
+		b.batch.add(pi.data)
+		items := b.batch.itemCount() - before
+		if items == 0 {
+			// Empty data is not exported.
+			pi.done <- nil
+		} else {
+			b.waiters = append(b.waiters, waiterItems{waiter: &waiter{items: items, done: pi.done}, items: items})
+		}
+	} else {
+		b.batch.add(item)
+	}
 	sent := false
-	for bp.batch.itemCount() >= bp.sendBatchSize {
+	for b.isFull() {
 		sent = true
-		bp.sendItems(triggerBatchSize)
+		b.sendItems(triggerBatchSize)
 	}
 
 	if sent {
-		bp.stopTimer()
-		bp.resetTimer()
+		b.stopTimer()
+		b.resetTimer()
+	}
+}
+
+// isFull returns whether the batch reached the size, or the byte size, which triggers sending it.
+func (b *shard) isFull() bool {
+	if b.batch.itemCount() >= b.processor.sendBatchSize {
+		return true
 	}
+	return b.processor.sendBatchSizeBytes > 0 && b.batch.itemCount() > 0 && b.batch.byteCount() >= b.processor.sendBatchSizeBytes
 }
 
-func (bp *batchProcessor) stopTimer() {
-	if !bp.timer.Stop() {
-		<-bp.timer.C
+func (b *shard) stopTimer() {
+	if !b.timer.Stop() {
+		<-b.timer.C
 	}
 }
 
-func (bp *batchProcessor) resetTimer() {
-	bp.timer.Reset(bp.timeout)
+func (b *shard) resetTimer() {
+	b.timer.Reset(b.processor.timeout)
 }
 
-func (bp *batchProcessor) sendItems(trigger trigger) {
-	sent, bytes, err := bp.batch.export(bp.exportCtx, bp.sendBatchMaxSize, bp.telemetry.detailed)
+// sendItems splits a request from the batch and exports it. When several batches can be exported concurrently, the
+// request is exported by another goroutine once an export slot is available, so that the shard keeps batching the
+// data meanwhile. The request is split before waiting for the slot, so that it holds the data that triggered it.
+func (b *shard) sendItems(trigger trigger) {
+	sent, req := b.batch.split(b.processor.sendBatchMaxSize, b.processor.sendBatchMaxSizeBytes)
+	waiters := b.takeWaiters(sent)
+	if b.processor.exportSlots == nil {
+		b.export(trigger, sent, req, waiters)
+		return
+	}
+
+	b.processor.exportSlots <- struct{}{}
+	b.processor.goroutines.Add(1)
+	go func() {
+		defer b.processor.goroutines.Done()
+		defer func() { <-b.processor.exportSlots }()
+		b.export(trigger, sent, req, waiters)
+	}()
+}
+
+// export exports a request split from the batch, and returns the result to the callers waiting for it.
+func (b *shard) export(trigger trigger, sent int, req interface{}, waiters []waiterItems) {
+	b.processor.telemetry.recordInFlightBatches(1)
+	defer b.processor.telemetry.recordInFlightBatches(-1)
+
+	bytes, err := b.batch.export(b.exportCtx, req, b.processor.telemetry.detailed)
 	if err != nil {
-		bp.logger.Warn("Sender failed", zap.Error(err))
+		b.processor.logger.Warn("Sender failed", zap.Error(err))
 	} else {
-		bp.telemetry.record(trigger, int64(sent), int64(bytes))
+		b.processor.telemetry.record(trigger, int64(sent), int64(bytes))
+	}
+	for _, wi := range waiters {
+		wi.waiter.exported(wi.items, err)
+	}
+}
+
+// takeWaiters removes the first sent items of the batch from the waiters, and returns the waiters of these items.
+func (b *shard) takeWaiters(sent int) []waiterItems {
+	var taken []waiterItems
+	for sent > 0 && len(b.waiters) > 0 {
+		wi := &b.waiters[0]
+		items := wi.items
+		if items > sent {
+			items = sent
+		}
+		taken = append(taken, waiterItems{waiter: wi.waiter, items: items})
+		wi.items -= items
+		sent -= items
+		if wi.items > 0 {
+			break
+		}
+		b.waiters[0] = waiterItems{}
+		b.waiters = b.waiters[1:]
 	}
+	return taken
+}
+
+// consume sends the data to the shard. When the processor waits for the exports, it waits until the data is exported
+// and returns the error of the export, or until the context is done.
+func (b *shard) consume(ctx context.Context, data interface{}) error {
+	if !b.processor.waitForExport {
+		b.newItem <- data
+		return nil
+	}
+
+	if b.processor.waitingRequests != nil {
+		select {
+		case b.processor.waitingRequests <- struct{}{}:
+			defer func() { <-b.processor.waitingRequests }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	pi := &pendingItem{data: data, done: make(chan error, 1)}
+	select {
+	case b.newItem <- pi:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-pi.done:
+		return err
+	case <-ctx.Done():
+		// The data is exported anyway, the caller only stops waiting for it.
+		return ctx.Err()
+	}
+}
+
+// singleShardBatcher is used when no metadata keys are configured, to avoid the lookup of the shard.
+type singleShardBatcher struct {
+	shard *shard
+}
+
+func (sb *singleShardBatcher) consume(ctx context.Context, data interface{}) error {
+	return sb.shard.consume(ctx, data)
+}
+
+func (sb *singleShardBatcher) start() {
+	sb.shard.start()
+}
+
+// multiShardBatcher is used when metadata keys are configured, it creates a shard for each distinct combination of
+// their values. The shards are kept until the processor shuts down.
+type multiShardBatcher struct {
+	*batchProcessor
+	shards sync.Map
+
+	// lock guards size, to ensure no more than metadataLimit shards are created.
+	lock sync.Mutex
+	size int
+}
+
+func (mb *multiShardBatcher) consume(ctx context.Context, data interface{}) error {
+	// Get the values of the metadata keys, to copy them into the metadata of the exports, and build the attribute
+	// set identifying the shard.
+	info := client.FromContext(ctx)
+	md := map[string][]string{}
+	var attrs []attribute.KeyValue
+	for _, k := range mb.metadataKeys {
+		vs := info.Metadata.Get(k)
+		md[k] = vs
+		if len(vs) == 1 {
+			attrs = append(attrs, attribute.String(k, vs[0]))
+		} else {
+			attrs = append(attrs, attribute.StringSlice(k, vs))
+		}
+	}
+	aset := attribute.NewSet(attrs...)
+
+	s, ok := mb.shards.Load(aset)
+	if !ok {
+		mb.lock.Lock()
+		if mb.metadataLimit != 0 && mb.size >= mb.metadataLimit {
+			mb.lock.Unlock()
+			return errTooManyBatchers
+		}
+
+		exportCtx := client.NewContext(mb.telemetry.exportCtx, client.Info{
+			Metadata: client.NewMetadata(md),
+		})
+		var loaded bool
+		s, loaded = mb.shards.LoadOrStore(aset, mb.newShard(exportCtx))
+		if !loaded {
+			// The shard is only started once it is stored, a shard created concurrently is dropped.
+			s.(*shard).start()
+			mb.size++
+			mb.telemetry.recordMetadataCardinality(int64(mb.size))
+		}
+		mb.lock.Unlock()
+	}
+	return s.(*shard).consume(ctx, data)
+}
+
+// start does nothing, the shards are started as they are created.
+func (mb *multiShardBatcher) start() {}
+
+// currentMetadataCardinality returns the number of shards.
+func (mb *multiShardBatcher) currentMetadataCardinality() int {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+	return mb.size
 }
 
 // ConsumeTraces implements TracesProcessor
-func (bp *batchProcessor) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
-	bp.newItem <- td
-	return nil
+func (bp *batchProcessor) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	return bp.batcher.consume(ctx, td)
 }
 
 // ConsumeMetrics implements MetricsProcessor
-func (bp *batchProcessor) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
-	// First thing is convert into a different internal format
-	bp.newItem <- md
-	return nil
+func (bp *batchProcessor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	return bp.batcher.consume(ctx, md)
 }
 
 // ConsumeLogs implements LogsProcessor
-func (bp *batchProcessor) ConsumeLogs(_ context.Context, ld plog.Logs) error {
-	bp.newItem <- ld
-	return nil
+func (bp *batchProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	return bp.batcher.consume(ctx, ld)
 }
 
 // newBatchTracesProcessor creates a new batch processor that batches traces by size or with timeout
 func newBatchTracesProcessor(set processor.CreateSettings, next consumer.Traces, cfg *Config, registry *featuregate.Registry) (*batchProcessor, error) {
-	return newBatchProcessor(set, cfg, newBatchTraces(next), registry)
+	return newBatchProcessor(set, cfg, func() batch { return newBatchTraces(next, cfg) }, registry)
 }
 
 // newBatchMetricsProcessor creates a new batch processor that batches metrics by size or with timeout
 func newBatchMetricsProcessor(set processor.CreateSettings, next consumer.Metrics, cfg *Config, registry *featuregate.Registry) (*batchProcessor, error) {
-	return newBatchProcessor(set, cfg, newBatchMetrics(next), registry)
+	return newBatchProcessor(set, cfg, func() batch { return newBatchMetrics(next, cfg) }, registry)
 }
 
 // newBatchLogsProcessor creates a new batch processor that batches logs by size or with timeout
 func newBatchLogsProcessor(set processor.CreateSettings, next consumer.Logs, cfg *Config, registry *featuregate.Registry) (*batchProcessor, error) {
-	return newBatchProcessor(set, cfg, newBatchLogs(next), registry)
+	return newBatchProcessor(set, cfg, func() batch { return newBatchLogs(next, cfg) }, registry)
 }
 
 type batchTraces struct {
@@ -220,10 +528,21 @@ type batchTraces struct {
 	traceData    ptrace.Traces
 	spanCount    int
 	sizer        ptrace.Sizer
-}
-
-func newBatchTraces(nextConsumer consumer.Traces) *batchTraces {
-	return &batchTraces{nextConsumer: nextConsumer, traceData: ptrace.NewTraces(), sizer: &ptrace.ProtoMarshaler{}}
+	// bytes is the byte size of the batch, tracked when trackBytes is set.
+	bytes      int
+	trackBytes bool
+	// dedup merges the duplicate resources and scopes of the exported batches.
+	dedup bool
+}
+
+func newBatchTraces(nextConsumer consumer.Traces, cfg *Config) *batchTraces {
+	return &batchTraces{
+		nextConsumer: nextConsumer,
+		traceData:    ptrace.NewTraces(),
+		sizer:        &ptrace.ProtoMarshaler{},
+		trackBytes:   cfg.tracksBytes(),
+		dedup:        cfg.DeduplicateResources,
+	}
 }
 
 // add updates current batchTraces by adding new TraceData object
@@ -235,68 +554,114 @@ func (bt *batchTraces) add(item interface{}) {
 	}
 
 	bt.spanCount += newSpanCount
+	if bt.trackBytes {
+		bt.bytes += bt.sizer.TracesSize(td)
+	}
 	td.ResourceSpans().MoveAndAppendTo(bt.traceData.ResourceSpans())
 }
 
-func (bt *batchTraces) export(ctx context.Context, sendBatchMaxSize int, returnBytes bool) (int, int, error) {
+func (bt *batchTraces) split(sendBatchMaxSize int, sendBatchMaxSizeBytes int) (int, interface{}) {
 	var req ptrace.Traces
 	var sent int
-	var bytes int
-	if sendBatchMaxSize > 0 && bt.itemCount() > sendBatchMaxSize {
-		req = splitTraces(sendBatchMaxSize, bt.traceData)
-		bt.spanCount -= sendBatchMaxSize
-		sent = sendBatchMaxSize
+	if (sendBatchMaxSize > 0 && bt.itemCount() > sendBatchMaxSize) || (sendBatchMaxSizeBytes > 0 && bt.bytes > sendBatchMaxSizeBytes) {
+		req = splitTraces(sendBatchMaxSize, sendBatchMaxSizeBytes, bt.sizer, bt.traceData)
+		sent = req.SpanCount()
+		bt.spanCount -= sent
+		if bt.trackBytes {
+			bt.bytes = bt.sizer.TracesSize(bt.traceData)
+		}
 	} else {
 		req = bt.traceData
 		sent = bt.spanCount
 		bt.traceData = ptrace.NewTraces()
 		bt.spanCount = 0
+		bt.bytes = 0
+	}
+	return sent, req
+}
+
+func (bt *batchTraces) export(ctx context.Context, req interface{}, returnBytes bool) (int, error) {
+	data := req.(ptrace.Traces)
+	if bt.dedup {
+		pdatautil.DedupTraces(data)
 	}
+	var bytes int
 	if returnBytes {
-		bytes = bt.sizer.TracesSize(req)
+		bytes = bt.sizer.TracesSize(data)
 	}
-	return sent, bytes, bt.nextConsumer.ConsumeTraces(ctx, req)
+	return bytes, bt.nextConsumer.ConsumeTraces(ctx, data)
 }
 
 func (bt *batchTraces) itemCount() int {
 	return bt.spanCount
 }
 
+func (bt *batchTraces) byteCount() int {
+	return bt.bytes
+}
+
 type batchMetrics struct {
 	nextConsumer   consumer.Metrics
 	metricData     pmetric.Metrics
 	dataPointCount int
 	sizer          pmetric.Sizer
+	// bytes is the byte size of the batch, tracked when trackBytes is set.
+	bytes      int
+	trackBytes bool
+	// dedup merges the duplicate resources and scopes of the exported batches.
+	dedup bool
+}
+
+func newBatchMetrics(nextConsumer consumer.Metrics, cfg *Config) *batchMetrics {
+	return &batchMetrics{
+		nextConsumer: nextConsumer,
+		metricData:   pmetric.NewMetrics(),
+		sizer:        &pmetric.ProtoMarshaler{},
+		trackBytes:   cfg.tracksBytes(),
+		dedup:        cfg.DeduplicateResources,
+	}
 }
 
-func newBatchMetrics(nextConsumer consumer.Metrics) *batchMetrics {
-	return &batchMetrics{nextConsumer: nextConsumer, metricData: pmetric.NewMetrics(), sizer: &pmetric.ProtoMarshaler{}}
-}
-
-func (bm *batchMetrics) export(ctx context.Context, sendBatchMaxSize int, returnBytes bool) (int, int, error) {
+func (bm *batchMetrics) split(sendBatchMaxSize int, sendBatchMaxSizeBytes int) (int, interface{}) {
 	var req pmetric.Metrics
 	var sent int
-	var bytes int
-	if sendBatchMaxSize > 0 && bm.dataPointCount > sendBatchMaxSize {
-		req = splitMetrics(sendBatchMaxSize, bm.metricData)
-		bm.dataPointCount -= sendBatchMaxSize
-		sent = sendBatchMaxSize
+	if (sendBatchMaxSize > 0 && bm.dataPointCount > sendBatchMaxSize) || (sendBatchMaxSizeBytes > 0 && bm.bytes > sendBatchMaxSizeBytes) {
+		req = splitMetrics(sendBatchMaxSize, sendBatchMaxSizeBytes, bm.sizer, bm.metricData)
+		sent = req.DataPointCount()
+		bm.dataPointCount -= sent
+		if bm.trackBytes {
+			bm.bytes = bm.sizer.MetricsSize(bm.metricData)
+		}
 	} else {
 		req = bm.metricData
 		sent = bm.dataPointCount
 		bm.metricData = pmetric.NewMetrics()
 		bm.dataPointCount = 0
+		bm.bytes = 0
 	}
+	return sent, req
+}
+
+func (bm *batchMetrics) export(ctx context.Context, req interface{}, returnBytes bool) (int, error) {
+	data := req.(pmetric.Metrics)
+	if bm.dedup {
+		pdatautil.DedupMetrics(data)
+	}
+	var bytes int
 	if returnBytes {
-		bytes = bm.sizer.MetricsSize(req)
+		bytes = bm.sizer.MetricsSize(data)
 	}
-	return sent, bytes, bm.nextConsumer.ConsumeMetrics(ctx, req)
+	return bytes, bm.nextConsumer.ConsumeMetrics(ctx, data)
 }
 
 func (bm *batchMetrics) itemCount() int {
 	return bm.dataPointCount
 }
 
+func (bm *batchMetrics) byteCount() int {
+	return bm.bytes
+}
+
 func (bm *batchMetrics) add(item interface{}) {
 	md := item.(pmetric.Metrics)
 
@@ -305,6 +670,9 @@ func (bm *batchMetrics) add(item interface{}) {
 		return
 	}
 	bm.dataPointCount += newDataPointCount
+	if bm.trackBytes {
+		bm.bytes += bm.sizer.MetricsSize(md)
+	}
 	md.ResourceMetrics().MoveAndAppendTo(bm.metricData.ResourceMetrics())
 }
 
@@ -313,36 +681,63 @@ type batchLogs struct {
 	logData      plog.Logs
 	logCount     int
 	sizer        plog.Sizer
+	// bytes is the byte size of the batch, tracked when trackBytes is set.
+	bytes      int
+	trackBytes bool
+	// dedup merges the duplicate resources and scopes of the exported batches.
+	dedup bool
+}
+
+func newBatchLogs(nextConsumer consumer.Logs, cfg *Config) *batchLogs {
+	return &batchLogs{
+		nextConsumer: nextConsumer,
+		logData:      plog.NewLogs(),
+		sizer:        &plog.ProtoMarshaler{},
+		trackBytes:   cfg.tracksBytes(),
+		dedup:        cfg.DeduplicateResources,
+	}
 }
 
-func newBatchLogs(nextConsumer consumer.Logs) *batchLogs {
-	return &batchLogs{nextConsumer: nextConsumer, logData: plog.NewLogs(), sizer: &plog.ProtoMarshaler{}}
-}
-
-func (bl *batchLogs) export(ctx context.Context, sendBatchMaxSize int, returnBytes bool) (int, int, error) {
+func (bl *batchLogs) split(sendBatchMaxSize int, sendBatchMaxSizeBytes int) (int, interface{}) {
 	var req plog.Logs
 	var sent int
-	var bytes int
-	if sendBatchMaxSize > 0 && bl.logCount > sendBatchMaxSize {
-		req = splitLogs(sendBatchMaxSize, bl.logData)
-		bl.logCount -= sendBatchMaxSize
-		sent = sendBatchMaxSize
+	if (sendBatchMaxSize > 0 && bl.logCount > sendBatchMaxSize) || (sendBatchMaxSizeBytes > 0 && bl.bytes > sendBatchMaxSizeBytes) {
+		req = splitLogs(sendBatchMaxSize, sendBatchMaxSizeBytes, bl.sizer, bl.logData)
+		sent = req.LogRecordCount()
+		bl.logCount -= sent
+		if bl.trackBytes {
+			bl.bytes = bl.sizer.LogsSize(bl.logData)
+		}
 	} else {
 		req = bl.logData
 		sent = bl.logCount
 		bl.logData = plog.NewLogs()
 		bl.logCount = 0
+		bl.bytes = 0
 	}
+	return sent, req
+}
+
+func (bl *batchLogs) export(ctx context.Context, req interface{}, returnBytes bool) (int, error) {
+	data := req.(plog.Logs)
+	if bl.dedup {
+		pdatautil.DedupLogs(data)
+	}
+	var bytes int
 	if returnBytes {
-		bytes = bl.sizer.LogsSize(req)
+		bytes = bl.sizer.LogsSize(data)
 	}
-	return sent, bytes, bl.nextConsumer.ConsumeLogs(ctx, req)
+	return bytes, bl.nextConsumer.ConsumeLogs(ctx, data)
 }
 
 func (bl *batchLogs) itemCount() int {
 	return bl.logCount
 }
 
+func (bl *batchLogs) byteCount() int {
+	return bl.bytes
+}
+
 func (bl *batchLogs) add(item interface{}) {
 	ld := item.(plog.Logs)
 
@@ -351,5 +746,8 @@ func (bl *batchLogs) add(item interface{}) {
 		return
 	}
 	bl.logCount += newLogsCount
+	if bl.trackBytes {
+		bl.bytes += bl.sizer.LogsSize(ld)
+	}
 	ld.ResourceLogs().MoveAndAppendTo(bl.logData.ResourceLogs())
 }
@@ -16,6 +16,7 @@ package batchprocessor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sync"
@@ -25,9 +26,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/configtelemetry"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/featuregate"
 	"go.opentelemetry.io/collector/internal/testdata"
@@ -277,6 +280,276 @@ func TestBatchProcessorSentByTimeout(t *testing.T) {
 	}
 }
 
+func TestBatchProcessorSentBySizeBytes(t *testing.T) {
+	sizer := &ptrace.ProtoMarshaler{}
+	requestBytes := sizer.TracesSize(testdata.GenerateTraces(10))
+	cfg := Config{
+		Timeout:               time.Minute,
+		SendBatchSize:         100000,
+		SendBatchSizeBytes:    uint32(5 * requestBytes),
+		SendBatchMaxSizeBytes: uint32(6 * requestBytes),
+	}
+	sink := new(consumertest.TracesSink)
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, &cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	requestCount := 20
+	for requestNum := 0; requestNum < requestCount; requestNum++ {
+		// Every fourth request is twice larger, so that some batches exceed the max size and are split.
+		spansPerRequest := 10
+		if requestNum%4 == 0 {
+			spansPerRequest = 20
+		}
+		td := testdata.GenerateTraces(spansPerRequest)
+		require.NoError(t, batcher.ConsumeTraces(context.Background(), td))
+	}
+
+	// The batches are sent by size, before the timeout.
+	require.Eventually(t, func() bool { return len(sink.AllTraces()) >= 4 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, batcher.Shutdown(context.Background()))
+
+	spans := 0
+	for _, td := range sink.AllTraces() {
+		assert.LessOrEqual(t, sizer.TracesSize(td), int(cfg.SendBatchMaxSizeBytes))
+		spans += td.SpanCount()
+	}
+	assert.Equal(t, 250, spans)
+}
+
+// errorTracesSink records the traces it receives and returns err.
+type errorTracesSink struct {
+	consumertest.TracesSink
+	err error
+}
+
+func (s *errorTracesSink) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	_ = s.TracesSink.ConsumeTraces(ctx, td)
+	return s.err
+}
+
+func TestBatchProcessorWaitForExport(t *testing.T) {
+	errExport := errors.New("export failed")
+	sink := &errorTracesSink{err: errExport}
+	cfg := createDefaultConfig().(*Config)
+	cfg.SendBatchSize = 20
+	cfg.SendBatchMaxSize = 20
+	cfg.Timeout = 50 * time.Millisecond
+	cfg.WaitForExport = true
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	// The callers get the error of the export of their data, whether it is sent by size, split across batches or
+	// sent by the timeout.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(spans int) {
+			defer wg.Done()
+			assert.ErrorIs(t, batcher.ConsumeTraces(context.Background(), testdata.GenerateTraces(spans)), errExport)
+		}(i*3 + 1)
+	}
+	wg.Wait()
+	// The data is exported when the callers return.
+	assert.Equal(t, 145, sink.SpanCount())
+
+	// Empty data is not exported.
+	assert.NoError(t, batcher.ConsumeTraces(context.Background(), ptrace.NewTraces()))
+
+	sink.err = nil
+	assert.NoError(t, batcher.ConsumeTraces(context.Background(), testdata.GenerateTraces(5)))
+	assert.Equal(t, 150, sink.SpanCount())
+	require.NoError(t, batcher.Shutdown(context.Background()))
+}
+
+func TestBatchProcessorWaitForExportSplitOnShutdown(t *testing.T) {
+	sizer := &ptrace.ProtoMarshaler{}
+	sink := new(consumertest.TracesSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.Timeout = time.Minute
+	cfg.SendBatchMaxSizeBytes = uint32(sizer.TracesSize(testdata.GenerateTraces(10)))
+	cfg.WaitForExport = true
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+
+	// The data is queued before the processor starts, so that it is still in the batch at shutdown.
+	done := make(chan error)
+	go func() {
+		done <- batcher.ConsumeTraces(context.Background(), testdata.GenerateTraces(25))
+	}()
+	newItem := batcher.batcher.(*singleShardBatcher).shard.newItem
+	require.Eventually(t, func() bool { return len(newItem) == 1 }, time.Second, time.Millisecond)
+
+	// The remaining data is split by the max size at shutdown, all of it is exported.
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	select {
+	case err = <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the caller is still waiting for its data")
+	}
+	assert.Equal(t, 25, sink.SpanCount())
+	assert.Greater(t, len(sink.AllTraces()), 1)
+}
+
+func TestBatchProcessorWaitForExportMaxWaitingRequests(t *testing.T) {
+	sink := new(consumertest.TracesSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.Timeout = time.Minute
+	cfg.WaitForExport = true
+	cfg.MaxWaitingRequests = 1
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	// The first caller waits for the timeout of the batch.
+	done := make(chan error)
+	go func() {
+		done <- batcher.ConsumeTraces(context.Background(), testdata.GenerateTraces(1))
+	}()
+	require.Eventually(t, func() bool {
+		return len(batcher.waitingRequests) == 1
+	}, time.Second, time.Millisecond)
+
+	// The second caller is not accepted before its deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, batcher.ConsumeTraces(ctx, testdata.GenerateTraces(1)), context.DeadlineExceeded)
+
+	// The batch is exported at shutdown.
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+	assert.Equal(t, 1, sink.SpanCount())
+}
+
+func TestBatchProcessorWaitForExportContextDone(t *testing.T) {
+	sink := new(consumertest.TracesSink)
+	cfg := createDefaultConfig().(*Config)
+	cfg.Timeout = time.Minute
+	cfg.WaitForExport = true
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, batcher.ConsumeTraces(ctx, testdata.GenerateTraces(1)), context.DeadlineExceeded)
+
+	// The data is still exported.
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	assert.Equal(t, 1, sink.SpanCount())
+}
+
+func TestBatchProcessorDeduplicateResources(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.SendBatchSize = 50
+	cfg.DeduplicateResources = true
+	sink := new(consumertest.TracesSink)
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	// The requests have the same resource and scope.
+	for requestNum := 0; requestNum < 10; requestNum++ {
+		require.NoError(t, batcher.ConsumeTraces(context.Background(), testdata.GenerateTraces(5)))
+	}
+	require.NoError(t, batcher.Shutdown(context.Background()))
+
+	require.Len(t, sink.AllTraces(), 1)
+	td := sink.AllTraces()[0]
+	require.Equal(t, 1, td.ResourceSpans().Len())
+	require.Equal(t, 1, td.ResourceSpans().At(0).ScopeSpans().Len())
+	assert.Equal(t, 50, td.SpanCount())
+}
+
+// blockingTracesSink blocks the exports until release is closed, and counts the exports in progress.
+type blockingTracesSink struct {
+	consumertest.TracesSink
+	release  chan struct{}
+	mu       sync.Mutex
+	inFlight int
+}
+
+func (s *blockingTracesSink) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	s.mu.Lock()
+	s.inFlight++
+	s.mu.Unlock()
+	<-s.release
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	return s.TracesSink.ConsumeTraces(ctx, td)
+}
+
+func (s *blockingTracesSink) exportsInFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight
+}
+
+func TestBatchProcessorConcurrentExports(t *testing.T) {
+	telemetryTest(t, testBatchProcessorConcurrentExports)
+}
+
+func testBatchProcessorConcurrentExports(t *testing.T, tel testTelemetry, registry *featuregate.Registry) {
+	sink := &blockingTracesSink{release: make(chan struct{})}
+	cfg := createDefaultConfig().(*Config)
+	cfg.SendBatchSize = 10
+	cfg.Timeout = time.Minute
+	cfg.MaxConcurrentExports = 3
+	batcher, err := newBatchTracesProcessor(tel.NewProcessorCreateSettings(), sink, cfg, registry)
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	// The batches are exported concurrently, up to the limit, while the data is still accepted.
+	for requestNum := 0; requestNum < 5; requestNum++ {
+		require.NoError(t, batcher.ConsumeTraces(context.Background(), testdata.GenerateTraces(10)))
+	}
+	require.Eventually(t, func() bool { return sink.exportsInFlight() == 3 }, time.Second, time.Millisecond)
+	// The limit is not exceeded.
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 3, sink.exportsInFlight())
+	tel.assertMetrics(t, expectedMetrics{inFlightBatches: 3})
+
+	close(sink.release)
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	assert.Equal(t, 50, sink.SpanCount())
+	assert.Len(t, sink.AllTraces(), 5)
+}
+
+func TestBatchProcessorConcurrentExportsWaitForExport(t *testing.T) {
+	sink := &blockingTracesSink{release: make(chan struct{})}
+	cfg := createDefaultConfig().(*Config)
+	cfg.SendBatchSize = 10
+	cfg.SendBatchMaxSize = 10
+	cfg.Timeout = time.Minute
+	cfg.MaxConcurrentExports = 4
+	cfg.WaitForExport = true
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	// The data of the caller is split across 4 batches exported concurrently, the caller returns once all of them are
+	// exported.
+	done := make(chan error)
+	go func() {
+		done <- batcher.ConsumeTraces(context.Background(), testdata.GenerateTraces(40))
+	}()
+	require.Eventually(t, func() bool { return sink.exportsInFlight() == 4 }, time.Second, time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("the caller returned before the export of its data")
+	default:
+	}
+
+	close(sink.release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 40, sink.SpanCount())
+	require.NoError(t, batcher.Shutdown(context.Background()))
+}
+
 func TestBatchProcessorTraceSendWhenClosing(t *testing.T) {
 	cfg := Config{
 		Timeout:       3 * time.Second,
@@ -418,12 +691,13 @@ func TestBatchMetrics_UnevenBatchMaxSize(t *testing.T) {
 	dataPointsPerMetric := 2
 	sendBatchMaxSize := 99
 
-	batchMetrics := newBatchMetrics(sink)
+	batchMetrics := newBatchMetrics(sink, createDefaultConfig().(*Config))
 	md := testdata.GenerateMetrics(metricsCount)
 
 	batchMetrics.add(md)
 	require.Equal(t, dataPointsPerMetric*metricsCount, batchMetrics.dataPointCount)
-	sent, _, sendErr := batchMetrics.export(ctx, sendBatchMaxSize, false)
+	sent, req := batchMetrics.split(sendBatchMaxSize, 0)
+	_, sendErr := batchMetrics.export(ctx, req, false)
 	require.NoError(t, sendErr)
 	require.Equal(t, sendBatchMaxSize, sent)
 	remainingDataPointCount := metricsCount*dataPointsPerMetric - sendBatchMaxSize
@@ -816,6 +1090,102 @@ func logsReceivedBySeverityText(lds []plog.Logs) map[string]plog.LogRecord {
 	return logsReceivedBySeverityText
 }
 
+// metadataTracesSink records the spans it receives by the value of the "tenant" metadata of the context.
+type metadataTracesSink struct {
+	mu              sync.Mutex
+	spansByTenant   map[string]int
+	batchesByTenant map[string]int
+}
+
+func newMetadataTracesSink() *metadataTracesSink {
+	return &metadataTracesSink{spansByTenant: map[string]int{}, batchesByTenant: map[string]int{}}
+}
+
+func (s *metadataTracesSink) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (s *metadataTracesSink) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tenant := fmt.Sprint(client.FromContext(ctx).Metadata.Get("tenant"))
+	s.spansByTenant[tenant] += td.SpanCount()
+	s.batchesByTenant[tenant]++
+	return nil
+}
+
+func TestBatchProcessorSpansBatchedByMetadata(t *testing.T) {
+	telemetryTest(t, testBatchProcessorSpansBatchedByMetadata)
+}
+
+func testBatchProcessorSpansBatchedByMetadata(t *testing.T, tel testTelemetry, registry *featuregate.Registry) {
+	sink := newMetadataTracesSink()
+	cfg := createDefaultConfig().(*Config)
+	cfg.SendBatchSize = 100
+	cfg.Timeout = time.Minute
+	cfg.MetadataKeys = []string{"Tenant"}
+	batcher, err := newBatchTracesProcessor(tel.NewProcessorCreateSettings(), sink, cfg, registry)
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	contexts := map[string]context.Context{
+		"[a]": client.NewContext(context.Background(), client.Info{
+			Metadata: client.NewMetadata(map[string][]string{"tenant": {"a"}}),
+		}),
+		// The keys are case-insensitive.
+		"[b]": client.NewContext(context.Background(), client.Info{
+			Metadata: client.NewMetadata(map[string][]string{"TENANT": {"b"}}),
+		}),
+		"[a b]": client.NewContext(context.Background(), client.Info{
+			Metadata: client.NewMetadata(map[string][]string{"tenant": {"a", "b"}}),
+		}),
+		"[]": context.Background(),
+	}
+	for i := 0; i < 10; i++ {
+		for _, ctx := range contexts {
+			require.NoError(t, batcher.ConsumeTraces(ctx, testdata.GenerateTraces(30)))
+		}
+	}
+	assert.Equal(t, len(contexts), batcher.batcher.(*multiShardBatcher).currentMetadataCardinality())
+	require.NoError(t, batcher.Shutdown(context.Background()))
+
+	// Each tenant sends 300 spans, in batches of at least 100 spans plus the remainder at shutdown.
+	for tenant := range contexts {
+		assert.Equal(t, 300, sink.spansByTenant[tenant], tenant)
+		assert.Equal(t, 3, sink.batchesByTenant[tenant], tenant)
+	}
+	tel.assertMetrics(t, expectedMetrics{
+		metadataCardinality: float64(len(contexts)),
+	})
+}
+
+func TestBatchProcessorMetadataCardinalityLimit(t *testing.T) {
+	sink := newMetadataTracesSink()
+	cfg := createDefaultConfig().(*Config)
+	cfg.MetadataKeys = []string{"tenant"}
+	cfg.MetadataCardinalityLimit = 2
+	batcher, err := newBatchTracesProcessor(processortest.NewNopCreateSettings(), sink, cfg, featuregate.GetRegistry())
+	require.NoError(t, err)
+	require.NoError(t, batcher.Start(context.Background(), componenttest.NewNopHost()))
+
+	consume := func(tenant string) error {
+		ctx := client.NewContext(context.Background(), client.Info{
+			Metadata: client.NewMetadata(map[string][]string{"tenant": {tenant}}),
+		})
+		return batcher.ConsumeTraces(ctx, testdata.GenerateTraces(1))
+	}
+	require.NoError(t, consume("a"))
+	require.NoError(t, consume("b"))
+	err = consume("c")
+	assert.ErrorIs(t, err, errTooManyBatchers)
+	assert.True(t, consumererror.IsPermanent(err))
+	// The existing combinations are still accepted.
+	require.NoError(t, consume("a"))
+
+	require.NoError(t, batcher.Shutdown(context.Background()))
+	assert.Equal(t, map[string]int{"[a]": 2, "[b]": 1}, sink.spansByTenant)
+}
+
 func TestShutdown(t *testing.T) {
 	factory := NewFactory()
 	processortest.VerifyShutdown(t, factory, factory.CreateDefaultConfig())
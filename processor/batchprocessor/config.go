@@ -16,6 +16,8 @@ package batchprocessor // import "go.opentelemetry.io/collector/processor/batchp
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
@@ -37,6 +39,53 @@ type Config struct {
 	// Larger batches are split into smaller units.
 	// Default value is 0, that means no maximum size.
 	SendBatchMaxSize uint32 `mapstructure:"send_batch_max_size"`
+
+	// SendBatchSizeBytes is the byte size of a batch which after hit, will trigger it to be sent, as measured by the
+	// size of its OTLP protobuf encoding. Default value is 0, that means the batches are only sent by SendBatchSize
+	// and Timeout.
+	SendBatchSizeBytes uint32 `mapstructure:"send_batch_size_bytes"`
+
+	// SendBatchMaxSizeBytes is the maximum byte size of a batch, as measured by the size of its OTLP protobuf
+	// encoding. It must be greater or equal to SendBatchSizeBytes. Larger batches are split into smaller units,
+	// except that a single span, data point or log record larger than the maximum is sent on its own.
+	// Default value is 0, that means no maximum byte size.
+	SendBatchMaxSizeBytes uint32 `mapstructure:"send_batch_max_size_bytes"`
+
+	// MetadataKeys is a list of client.Metadata keys that will be
+	// used to form distinct batchers.  If this setting is empty,
+	// a single batcher instance will be used.  When this setting
+	// is not empty, one batcher will be used per distinct
+	// combination of values for the listed metadata keys.
+	//
+	// Empty value and unset metadata are treated as distinct cases.
+	//
+	// Entries are case-insensitive.  Duplicated entries will
+	// trigger a validation error.
+	MetadataKeys []string `mapstructure:"metadata_keys"`
+
+	// MetadataCardinalityLimit indicates the maximum number of
+	// batcher instances that will be created through a distinct
+	// combination of MetadataKeys.
+	MetadataCardinalityLimit uint32 `mapstructure:"metadata_cardinality_limit"`
+
+	// WaitForExport makes the callers of the processor wait until the batches holding their data are exported, and
+	// returns them the error of the exports. By default the data is accepted as soon as it is added to a batch, and
+	// the errors of the exports are only logged.
+	WaitForExport bool `mapstructure:"wait_for_export"`
+
+	// MaxWaitingRequests limits the number of callers waiting for the export of their data when WaitForExport is
+	// set. Further callers wait until one of them returns, or until their context is done. 0 means no limit.
+	MaxWaitingRequests uint32 `mapstructure:"max_waiting_requests"`
+
+	// MaxConcurrentExports is the maximum number of batches exported concurrently. While a batch is exported the
+	// processor keeps batching the data, until this number of batches are being exported. The default value, 1,
+	// and 0 export the batches one at a time without batching the data meanwhile.
+	MaxConcurrentExports uint32 `mapstructure:"max_concurrent_exports"`
+
+	// DeduplicateResources merges the entries of the batches with equal resources, and within them the entries with
+	// equal instrumentation scopes, before exporting the batches. It reduces the size of the batches merged from
+	// requests of the same sources, at the cost of comparing their resources and scopes.
+	DeduplicateResources bool `mapstructure:"deduplicate_resources"`
 }
 
 var _ component.Config = (*Config)(nil)
@@ -46,5 +95,24 @@ func (cfg *Config) Validate() error {
 	if cfg.SendBatchMaxSize > 0 && cfg.SendBatchMaxSize < cfg.SendBatchSize {
 		return errors.New("send_batch_max_size must be greater or equal to send_batch_size")
 	}
+	if cfg.SendBatchMaxSizeBytes > 0 && cfg.SendBatchMaxSizeBytes < cfg.SendBatchSizeBytes {
+		return errors.New("send_batch_max_size_bytes must be greater or equal to send_batch_size_bytes")
+	}
+	uniq := map[string]bool{}
+	for _, k := range cfg.MetadataKeys {
+		l := strings.ToLower(k)
+		if _, has := uniq[l]; has {
+			return fmt.Errorf("duplicate entry in metadata_keys: %q (case-insensitive)", l)
+		}
+		uniq[l] = true
+	}
+	if len(cfg.MetadataKeys) != 0 && cfg.MetadataCardinalityLimit == 0 {
+		return errors.New("metadata_cardinality_limit must be set when metadata_keys is not empty")
+	}
 	return nil
 }
+
+// tracksBytes returns whether the byte size of the batches is limited, so that it needs to be tracked.
+func (cfg *Config) tracksBytes() bool {
+	return cfg.SendBatchSizeBytes > 0 || cfg.SendBatchMaxSizeBytes > 0
+}
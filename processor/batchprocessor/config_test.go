@@ -42,9 +42,13 @@ func TestUnmarshalConfig(t *testing.T) {
 	assert.NoError(t, component.UnmarshalConfig(cm, cfg))
 	assert.Equal(t,
 		&Config{
-			SendBatchSize:    uint32(10000),
-			SendBatchMaxSize: uint32(11000),
-			Timeout:          time.Second * 10,
+			SendBatchSize:            uint32(10000),
+			SendBatchMaxSize:         uint32(11000),
+			Timeout:                  time.Second * 10,
+			MetadataCardinalityLimit: 1000,
+			WaitForExport:            true,
+			MaxWaitingRequests:       100,
+			MaxConcurrentExports:     4,
 		}, cfg)
 }
 
@@ -72,3 +76,28 @@ func TestValidateConfig_InvalidBatchSize(t *testing.T) {
 	}
 	assert.Error(t, cfg.Validate())
 }
+
+func TestValidateConfig_MetadataKeys(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.MetadataKeys = []string{"tenant", "Region"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.MetadataKeys = []string{"tenant", "Tenant"}
+	assert.EqualError(t, cfg.Validate(), `duplicate entry in metadata_keys: "tenant" (case-insensitive)`)
+
+	cfg.MetadataKeys = []string{"tenant"}
+	cfg.MetadataCardinalityLimit = 0
+	assert.EqualError(t, cfg.Validate(), "metadata_cardinality_limit must be set when metadata_keys is not empty")
+}
+
+func TestValidateConfig_SendBatchMaxSizeBytes(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.SendBatchSizeBytes = 1024
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SendBatchMaxSizeBytes = 2048
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SendBatchMaxSizeBytes = 512
+	assert.EqualError(t, cfg.Validate(), "send_batch_max_size_bytes must be greater or equal to send_batch_size_bytes")
+}
@@ -30,6 +30,17 @@ const (
 
 	defaultSendBatchSize = uint32(8192)
 	defaultTimeout       = 200 * time.Millisecond
+
+	// defaultMetadataCardinalityLimit should be set to the number
+	// of metadata configurations the user expects to submit to
+	// the collector.
+	defaultMetadataCardinalityLimit = 1000
+
+	// defaultMaxWaitingRequests limits the number of callers waiting for their data to be exported, when
+	// wait_for_export is set.
+	defaultMaxWaitingRequests = 1000
+
+	defaultMaxConcurrentExports = 1
 )
 
 // NewFactory returns a new factory for the Batch processor.
@@ -44,8 +55,11 @@ func NewFactory() processor.Factory {
 
 func createDefaultConfig() component.Config {
 	return &Config{
-		SendBatchSize: defaultSendBatchSize,
-		Timeout:       defaultTimeout,
+		SendBatchSize:            defaultSendBatchSize,
+		Timeout:                  defaultTimeout,
+		MetadataCardinalityLimit: defaultMetadataCardinalityLimit,
+		MaxWaitingRequests:       defaultMaxWaitingRequests,
+		MaxConcurrentExports:     defaultMaxConcurrentExports,
 	}
 }
 
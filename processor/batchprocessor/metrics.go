@@ -16,6 +16,7 @@ package batchprocessor // import "go.opentelemetry.io/collector/processor/batchp
 
 import (
 	"context"
+	"sync"
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
@@ -46,6 +47,8 @@ var (
 	statTimeoutTriggerSend   = stats.Int64("timeout_trigger_send", "Number of times the batch was sent due to a timeout trigger", stats.UnitDimensionless)
 	statBatchSendSize        = stats.Int64("batch_send_size", "Number of units in the batch", stats.UnitDimensionless)
 	statBatchSendSizeBytes   = stats.Int64("batch_send_size_bytes", "Number of bytes in batch that was sent", stats.UnitBytes)
+	statMetadataCardinality  = stats.Int64("metadata_cardinality", "Number of distinct metadata value combinations being processed", stats.UnitDimensionless)
+	statInFlightBatches      = stats.Int64("in_flight_batches", "Number of batches being exported", stats.UnitDimensionless)
 )
 
 type trigger int
@@ -101,11 +104,29 @@ func metricViews() []*view.View {
 			1000_000, 2000_000, 3000_000, 4000_000, 5000_000, 6000_000, 7000_000, 8000_000, 9000_000),
 	}
 
+	metadataCardinalityView := &view.View{
+		Name:        obsreport.BuildProcessorCustomMetricName(typeStr, statMetadataCardinality.Name()),
+		Measure:     statMetadataCardinality,
+		Description: statMetadataCardinality.Description(),
+		TagKeys:     processorTagKeys,
+		Aggregation: view.LastValue(),
+	}
+
+	inFlightBatchesView := &view.View{
+		Name:        obsreport.BuildProcessorCustomMetricName(typeStr, statInFlightBatches.Name()),
+		Measure:     statInFlightBatches,
+		Description: statInFlightBatches.Description(),
+		TagKeys:     processorTagKeys,
+		Aggregation: view.LastValue(),
+	}
+
 	return []*view.View{
 		countBatchSizeTriggerSendView,
 		countTimeoutTriggerSendView,
 		distributionBatchSendSizeView,
 		distributionBatchSendSizeBytesView,
+		metadataCardinalityView,
+		inFlightBatchesView,
 	}
 }
 
@@ -153,6 +174,13 @@ type batchProcessorTelemetry struct {
 	timeoutTriggerSend   syncint64.Counter
 	batchSendSize        syncint64.Histogram
 	batchSendSizeBytes   syncint64.Histogram
+	metadataCardinality  syncint64.UpDownCounter
+	inFlightBatches      syncint64.UpDownCounter
+
+	// inFlight is the number of batches being exported, recorded by the OpenCensus gauge. inFlightMu orders the
+	// recordings of the gauge with the changes of the number.
+	inFlightMu sync.Mutex
+	inFlight   int64
 }
 
 func newBatchProcessorTelemetry(set processor.CreateSettings, registry *featuregate.Registry) (*batchProcessorTelemetry, error) {
@@ -221,6 +249,24 @@ func (bpt *batchProcessorTelemetry) createOtelMetrics(mp metric.MeterProvider) e
 		return err
 	}
 
+	bpt.metadataCardinality, err = meter.SyncInt64().UpDownCounter(
+		obsreport.BuildProcessorCustomMetricName(typeStr, "metadata_cardinality"),
+		instrument.WithDescription("Number of distinct metadata value combinations being processed"),
+		instrument.WithUnit(unit.Dimensionless),
+	)
+	if err != nil {
+		return err
+	}
+
+	bpt.inFlightBatches, err = meter.SyncInt64().UpDownCounter(
+		obsreport.BuildProcessorCustomMetricName(typeStr, "in_flight_batches"),
+		instrument.WithDescription("Number of batches being exported"),
+		instrument.WithUnit(unit.Dimensionless),
+	)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -260,3 +306,24 @@ func (bpt *batchProcessorTelemetry) recordWithOtel(trigger trigger, sent int64,
 		bpt.batchSendSizeBytes.Record(bpt.exportCtx, bytes, bpt.processorAttr...)
 	}
 }
+
+// recordMetadataCardinality records the number of shards, after one was created.
+func (bpt *batchProcessorTelemetry) recordMetadataCardinality(size int64) {
+	if bpt.useOtel {
+		bpt.metadataCardinality.Add(bpt.exportCtx, 1, bpt.processorAttr...)
+	} else {
+		stats.Record(bpt.exportCtx, statMetadataCardinality.M(size))
+	}
+}
+
+// recordInFlightBatches records a change of the number of batches being exported.
+func (bpt *batchProcessorTelemetry) recordInFlightBatches(delta int64) {
+	if bpt.useOtel {
+		bpt.inFlightBatches.Add(bpt.exportCtx, delta, bpt.processorAttr...)
+		return
+	}
+	bpt.inFlightMu.Lock()
+	defer bpt.inFlightMu.Unlock()
+	bpt.inFlight += delta
+	stats.Record(bpt.exportCtx, statInFlightBatches.M(bpt.inFlight))
+}
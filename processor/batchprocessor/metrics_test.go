@@ -49,6 +49,8 @@ func TestBatchProcessorMetrics(t *testing.T) {
 		"timeout_trigger_send",
 		"batch_send_size",
 		"batch_send_size_bytes",
+		"metadata_cardinality",
+		"in_flight_batches",
 	}
 	views := metricViews()
 	for i, viewName := range viewNames {
@@ -81,6 +83,10 @@ type expectedMetrics struct {
 	sizeTrigger float64
 	// processor_batch_batch_timeout_trigger_send
 	timeoutTrigger float64
+	// processor_batch_metadata_cardinality
+	metadataCardinality float64
+	// processor_batch_in_flight_batches
+	inFlightBatches float64
 }
 
 func telemetryTest(t *testing.T, testFunc func(t *testing.T, tel testTelemetry, registry *featuregate.Registry)) {
@@ -204,6 +210,20 @@ func (tt *testTelemetry) assertMetrics(t *testing.T, expected expectedMetrics) {
 
 		assertFloat(t, expected.timeoutTrigger, metric.GetCounter().GetValue(), name)
 	}
+
+	if expected.metadataCardinality > 0 {
+		name := "processor_batch_metadata_cardinality"
+		metric := tt.getMetric(t, name, io_prometheus_client.MetricType_GAUGE, metrics)
+
+		assertFloat(t, expected.metadataCardinality, metric.GetGauge().GetValue(), name)
+	}
+
+	if expected.inFlightBatches > 0 {
+		name := "processor_batch_in_flight_batches"
+		metric := tt.getMetric(t, name, io_prometheus_client.MetricType_GAUGE, metrics)
+
+		assertFloat(t, expected.inFlightBatches, metric.GetGauge().GetValue(), name)
+	}
 }
 
 func (tt *testTelemetry) assertBoundaries(t *testing.T, expected []float64, histogram *io_prometheus_client.Histogram, metric string) {
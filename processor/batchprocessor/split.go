@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batchprocessor // import "go.opentelemetry.io/collector/processor/batchprocessor"
+
+// splitLimit tracks the size of a batch split from a larger one against the limits of the split batches: their number
+// of items when maxItems is positive, and their byte size when maxBytes is positive.
+type splitLimit struct {
+	maxItems int
+	maxBytes int
+
+	items int
+	bytes int
+	// full is set once an item did not fit in the batch.
+	full bool
+}
+
+func newSplitLimit(maxItems, maxBytes int) *splitLimit {
+	return &splitLimit{maxItems: maxItems, maxBytes: maxBytes}
+}
+
+// isFull returns whether no more items can be added to the batch.
+func (l *splitLimit) isFull() bool {
+	return l.full || (l.maxItems > 0 && l.items >= l.maxItems)
+}
+
+// add adds a group of items, e.g. all the spans of a resource, to the batch if it fits within the limits, and returns
+// whether it was added. The size function returns the byte size of the group, it is only called when the byte size of
+// the batch is limited. A single item always fits in an empty batch, so that an item larger than maxBytes is still
+// sent, on its own.
+func (l *splitLimit) add(items int, size func() int) bool {
+	if l.maxItems > 0 && l.items+items > l.maxItems {
+		return false
+	}
+	bytes := 0
+	if l.maxBytes > 0 {
+		bytes = size()
+		if l.bytes+bytes > l.maxBytes && (l.items != 0 || items != 1) {
+			return false
+		}
+	}
+	l.items += items
+	l.bytes += bytes
+	return true
+}
+
+// addItem adds a single item to the batch if it fits within the limits, otherwise the batch is full.
+func (l *splitLimit) addItem(size func() int) bool {
+	if l.isFull() || !l.add(1, size) {
+		l.full = true
+		return false
+	}
+	return true
+}
+
+// remainingItems returns the number of items the batch can still hold, to preallocate the destination.
+func (l *splitLimit) remainingItems() int {
+	if l.maxItems <= 0 || l.items >= l.maxItems {
+		return 0
+	}
+	return l.maxItems - l.items
+}
+
+// addOverhead adds the byte size of the resource or scope holding the items split from a larger group.
+func (l *splitLimit) addOverhead(size func() int) {
+	if l.maxBytes > 0 {
+		l.bytes += size()
+	}
+}
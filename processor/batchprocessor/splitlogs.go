@@ -18,60 +18,71 @@ import (
 	"go.opentelemetry.io/collector/pdata/plog"
 )
 
-// splitLogs removes logrecords from the input data and returns a new data of the specified size.
-func splitLogs(size int, src plog.Logs) plog.Logs {
-	if src.LogRecordCount() <= size {
+// splitLogs removes logrecords from the input data and returns a new data of at most size log records, when size is
+// positive, and of at most maxBytes bytes as measured by sizer, when maxBytes is positive.
+func splitLogs(size int, maxBytes int, sizer plog.Sizer, src plog.Logs) plog.Logs {
+	if maxBytes <= 0 && src.LogRecordCount() <= size {
 		return src
 	}
-	totalCopiedLogRecords := 0
+	limit := newSplitLimit(size, maxBytes)
+	var bs *logsByteSizer
+	if maxBytes > 0 {
+		bs = newLogsByteSizer(sizer)
+	}
 	dest := plog.NewLogs()
 
 	src.ResourceLogs().RemoveIf(func(srcRl plog.ResourceLogs) bool {
 		// If we are done skip everything else.
-		if totalCopiedLogRecords == size {
+		if limit.isFull() {
 			return false
 		}
 
 		// If it fully fits
-		srcRlLRC := resourceLRC(srcRl)
-		if (totalCopiedLogRecords + srcRlLRC) <= size {
-			totalCopiedLogRecords += srcRlLRC
+		if limit.add(resourceLRC(srcRl), func() int { return bs.resourceLogsSize(srcRl) }) {
 			srcRl.MoveTo(dest.ResourceLogs().AppendEmpty())
 			return true
 		}
 
 		destRl := dest.ResourceLogs().AppendEmpty()
 		srcRl.Resource().CopyTo(destRl.Resource())
+		limit.addOverhead(func() int { return bs.resourceLogsSize(destRl) })
 		srcRl.ScopeLogs().RemoveIf(func(srcIll plog.ScopeLogs) bool {
 			// If we are done skip everything else.
-			if totalCopiedLogRecords == size {
+			if limit.isFull() {
 				return false
 			}
 
 			// If possible to move all metrics do that.
-			srcIllLRC := srcIll.LogRecords().Len()
-			if size >= srcIllLRC+totalCopiedLogRecords {
-				totalCopiedLogRecords += srcIllLRC
+			if limit.add(srcIll.LogRecords().Len(), func() int { return bs.scopeLogsSize(srcIll) }) {
 				srcIll.MoveTo(destRl.ScopeLogs().AppendEmpty())
 				return true
 			}
 
 			destIll := destRl.ScopeLogs().AppendEmpty()
 			srcIll.Scope().CopyTo(destIll.Scope())
+			limit.addOverhead(func() int { return bs.scopeLogsSize(destIll) })
 			srcIll.LogRecords().RemoveIf(func(srcMetric plog.LogRecord) bool {
 				// If we are done skip everything else.
-				if totalCopiedLogRecords == size {
+				if !limit.addItem(func() int { return bs.logRecordSize(srcMetric) }) {
 					return false
 				}
 				srcMetric.MoveTo(destIll.LogRecords().AppendEmpty())
-				totalCopiedLogRecords++
 				return true
 			})
-			return false
+			return srcIll.LogRecords().Len() == 0
 		})
 		return srcRl.ScopeLogs().Len() == 0
 	})
 
+	if maxBytes > 0 {
+		// The resource and the scope split last are empty if their first log record did not fit.
+		dest.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+			rl.ScopeLogs().RemoveIf(func(ill plog.ScopeLogs) bool {
+				return ill.LogRecords().Len() == 0
+			})
+			return rl.ScopeLogs().Len() == 0
+		})
+	}
 	return dest
 }
 
@@ -82,3 +93,43 @@ func resourceLRC(rs plog.ResourceLogs) (count int) {
 	}
 	return
 }
+
+// logsByteSizer measures the byte size of the parts of logs, each one on its own, by moving it into a scratch
+// plog.Logs and back. The sum of the sizes of the parts is at least the size of the logs holding them, so that the
+// split logs do not exceed the limit.
+type logsByteSizer struct {
+	sizer plog.Sizer
+	// rl, ill and lr hold a single empty resource, scope and log record respectively.
+	rl  plog.Logs
+	ill plog.Logs
+	lr  plog.Logs
+}
+
+func newLogsByteSizer(sizer plog.Sizer) *logsByteSizer {
+	bs := &logsByteSizer{sizer: sizer, rl: plog.NewLogs(), ill: plog.NewLogs(), lr: plog.NewLogs()}
+	bs.rl.ResourceLogs().AppendEmpty()
+	bs.ill.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	bs.lr.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	return bs
+}
+
+func (bs *logsByteSizer) resourceLogsSize(rl plog.ResourceLogs) int {
+	tmp := bs.rl.ResourceLogs().At(0)
+	rl.MoveTo(tmp)
+	defer tmp.MoveTo(rl)
+	return bs.sizer.LogsSize(bs.rl)
+}
+
+func (bs *logsByteSizer) scopeLogsSize(ill plog.ScopeLogs) int {
+	tmp := bs.ill.ResourceLogs().At(0).ScopeLogs().At(0)
+	ill.MoveTo(tmp)
+	defer tmp.MoveTo(ill)
+	return bs.sizer.LogsSize(bs.ill)
+}
+
+func (bs *logsByteSizer) logRecordSize(lr plog.LogRecord) int {
+	tmp := bs.lr.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	lr.MoveTo(tmp)
+	defer tmp.MoveTo(lr)
+	return bs.sizer.LogsSize(bs.lr)
+}
@@ -26,7 +26,7 @@ import (
 func TestSplitLogs_noop(t *testing.T) {
 	td := testdata.GenerateLogs(20)
 	splitSize := 40
-	split := splitLogs(splitSize, td)
+	split := splitLogs(splitSize, 0, nil, td)
 	assert.Equal(t, td, split)
 
 	i := 0
@@ -57,24 +57,24 @@ func TestSplitLogs(t *testing.T) {
 	logs.At(4).CopyTo(cpLogs.AppendEmpty())
 
 	splitSize := 5
-	split := splitLogs(splitSize, ld)
+	split := splitLogs(splitSize, 0, nil, ld)
 	assert.Equal(t, splitSize, split.LogRecordCount())
 	assert.Equal(t, cp, split)
 	assert.Equal(t, 15, ld.LogRecordCount())
 	assert.Equal(t, "test-log-int-0-0", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityText())
 	assert.Equal(t, "test-log-int-0-4", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(4).SeverityText())
 
-	split = splitLogs(splitSize, ld)
+	split = splitLogs(splitSize, 0, nil, ld)
 	assert.Equal(t, 10, ld.LogRecordCount())
 	assert.Equal(t, "test-log-int-0-5", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityText())
 	assert.Equal(t, "test-log-int-0-9", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(4).SeverityText())
 
-	split = splitLogs(splitSize, ld)
+	split = splitLogs(splitSize, 0, nil, ld)
 	assert.Equal(t, 5, ld.LogRecordCount())
 	assert.Equal(t, "test-log-int-0-10", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityText())
 	assert.Equal(t, "test-log-int-0-14", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(4).SeverityText())
 
-	split = splitLogs(splitSize, ld)
+	split = splitLogs(splitSize, 0, nil, ld)
 	assert.Equal(t, 5, ld.LogRecordCount())
 	assert.Equal(t, "test-log-int-0-15", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityText())
 	assert.Equal(t, "test-log-int-0-19", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(4).SeverityText())
@@ -95,7 +95,7 @@ func TestSplitLogsMultipleResourceLogs(t *testing.T) {
 	}
 
 	splitSize := 5
-	split := splitLogs(splitSize, td)
+	split := splitLogs(splitSize, 0, nil, td)
 	assert.Equal(t, splitSize, split.LogRecordCount())
 	assert.Equal(t, 35, td.LogRecordCount())
 	assert.Equal(t, "test-log-int-0-0", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityText())
@@ -117,7 +117,7 @@ func TestSplitLogsMultipleResourceLogs_split_size_greater_than_log_size(t *testi
 	}
 
 	splitSize := 25
-	split := splitLogs(splitSize, td)
+	split := splitLogs(splitSize, 0, nil, td)
 	assert.Equal(t, splitSize, split.LogRecordCount())
 	assert.Equal(t, 40-splitSize, td.LogRecordCount())
 	assert.Equal(t, 1, td.ResourceLogs().Len())
@@ -150,9 +150,49 @@ func TestSplitLogsMultipleILL(t *testing.T) {
 	}
 
 	splitSize := 40
-	split := splitLogs(splitSize, td)
+	split := splitLogs(splitSize, 0, nil, td)
 	assert.Equal(t, splitSize, split.LogRecordCount())
 	assert.Equal(t, 20, td.LogRecordCount())
 	assert.Equal(t, "test-log-int-0-0", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityText())
 	assert.Equal(t, "test-log-int-0-4", split.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(4).SeverityText())
 }
+
+func TestSplitLogsBytes(t *testing.T) {
+	ld := testdata.GenerateLogs(20)
+	testdata.GenerateLogs(20).ResourceLogs().At(0).CopyTo(ld.ResourceLogs().AppendEmpty())
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		logs := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+		for j := 0; j < logs.Len(); j++ {
+			logs.At(j).SetSeverityText(getTestLogSeverityText(i, j))
+		}
+	}
+	sizer := &plog.ProtoMarshaler{}
+	maxBytes := sizer.LogsSize(ld) / 7
+
+	var severities []string
+	for ld.LogRecordCount() > 0 {
+		split := splitLogs(0, maxBytes, sizer, ld)
+		assert.LessOrEqual(t, sizer.LogsSize(split), maxBytes)
+		assert.Greater(t, split.LogRecordCount(), 0)
+		for i := 0; i < split.ResourceLogs().Len(); i++ {
+			logs := split.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+			for j := 0; j < logs.Len(); j++ {
+				severities = append(severities, logs.At(j).SeverityText())
+			}
+		}
+	}
+	assert.Len(t, severities, 40)
+	assert.Equal(t, getTestLogSeverityText(0, 0), severities[0])
+	assert.Equal(t, getTestLogSeverityText(1, 0), severities[20])
+	assert.Equal(t, getTestLogSeverityText(1, 19), severities[39])
+}
+
+func TestSplitLogsBytesLargeLogRecord(t *testing.T) {
+	ld := testdata.GenerateLogs(3)
+	sizer := &plog.ProtoMarshaler{}
+
+	// A log record larger than the limit is sent on its own.
+	split := splitLogs(0, 1, sizer, ld)
+	assert.Equal(t, 1, split.LogRecordCount())
+	assert.Equal(t, 2, ld.LogRecordCount())
+}
@@ -18,71 +18,81 @@ import (
 	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
-// splitMetrics removes metrics from the input data and returns a new data of the specified size.
-func splitMetrics(size int, src pmetric.Metrics) pmetric.Metrics {
-	dataPoints := src.DataPointCount()
-	if dataPoints <= size {
+// splitMetrics removes metrics from the input data and returns a new data of at most size data points, when size is
+// positive, and of at most maxBytes bytes as measured by sizer, when maxBytes is positive.
+func splitMetrics(size int, maxBytes int, sizer pmetric.Sizer, src pmetric.Metrics) pmetric.Metrics {
+	if maxBytes <= 0 && src.DataPointCount() <= size {
 		return src
 	}
-	totalCopiedDataPoints := 0
+	limit := newSplitLimit(size, maxBytes)
+	var bs *metricsByteSizer
+	if maxBytes > 0 {
+		bs = newMetricsByteSizer(sizer)
+	}
 	dest := pmetric.NewMetrics()
 
 	src.ResourceMetrics().RemoveIf(func(srcRs pmetric.ResourceMetrics) bool {
 		// If we are done skip everything else.
-		if totalCopiedDataPoints == size {
+		if limit.isFull() {
 			return false
 		}
 
 		// If it fully fits
-		srcRsDataPointCount := resourceMetricsDPC(srcRs)
-		if (totalCopiedDataPoints + srcRsDataPointCount) <= size {
-			totalCopiedDataPoints += srcRsDataPointCount
+		if limit.add(resourceMetricsDPC(srcRs), func() int { return bs.resourceMetricsSize(srcRs) }) {
 			srcRs.MoveTo(dest.ResourceMetrics().AppendEmpty())
 			return true
 		}
 
 		destRs := dest.ResourceMetrics().AppendEmpty()
 		srcRs.Resource().CopyTo(destRs.Resource())
+		limit.addOverhead(func() int { return bs.resourceMetricsSize(destRs) })
 		srcRs.ScopeMetrics().RemoveIf(func(srcIlm pmetric.ScopeMetrics) bool {
 			// If we are done skip everything else.
-			if totalCopiedDataPoints == size {
+			if limit.isFull() {
 				return false
 			}
 
 			// If possible to move all metrics do that.
-			srcIlmDataPointCount := scopeMetricsDPC(srcIlm)
-			if srcIlmDataPointCount+totalCopiedDataPoints <= size {
-				totalCopiedDataPoints += srcIlmDataPointCount
+			if limit.add(scopeMetricsDPC(srcIlm), func() int { return bs.scopeMetricsSize(srcIlm) }) {
 				srcIlm.MoveTo(destRs.ScopeMetrics().AppendEmpty())
 				return true
 			}
 
 			destIlm := destRs.ScopeMetrics().AppendEmpty()
 			srcIlm.Scope().CopyTo(destIlm.Scope())
+			limit.addOverhead(func() int { return bs.scopeMetricsSize(destIlm) })
 			srcIlm.Metrics().RemoveIf(func(srcMetric pmetric.Metric) bool {
 				// If we are done skip everything else.
-				if totalCopiedDataPoints == size {
+				if limit.isFull() {
 					return false
 				}
 
 				// If possible to move all points do that.
-				srcMetricPointCount := metricDPC(srcMetric)
-				if srcMetricPointCount+totalCopiedDataPoints <= size {
-					totalCopiedDataPoints += srcMetricPointCount
+				if limit.add(metricDPC(srcMetric), func() int { return bs.metricSize(srcMetric) }) {
 					srcMetric.MoveTo(destIlm.Metrics().AppendEmpty())
 					return true
 				}
 
 				// If the metric has more data points than free slots we should split it.
-				copiedDataPoints, remove := splitMetric(srcMetric, destIlm.Metrics().AppendEmpty(), size-totalCopiedDataPoints)
-				totalCopiedDataPoints += copiedDataPoints
-				return remove
+				return splitMetric(srcMetric, destIlm.Metrics().AppendEmpty(), limit, bs)
 			})
-			return false
+			return srcIlm.Metrics().Len() == 0
 		})
 		return srcRs.ScopeMetrics().Len() == 0
 	})
 
+	if maxBytes > 0 {
+		// The resource, scope and metric split last are empty if their first data point did not fit.
+		dest.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+			rm.ScopeMetrics().RemoveIf(func(ilm pmetric.ScopeMetrics) bool {
+				ilm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+					return m.Type() != pmetric.MetricTypeEmpty && metricDPC(m) == 0
+				})
+				return ilm.Metrics().Len() == 0
+			})
+			return rm.ScopeMetrics().Len() == 0
+		})
+	}
 	return dest
 }
 
@@ -123,87 +133,179 @@ func metricDPC(ms pmetric.Metric) int {
 	return 0
 }
 
-// splitMetric removes metric points from the input data and moves data of the specified size to destination.
-// Returns size of moved data and boolean describing, whether the metric should be removed from original slice.
-func splitMetric(ms, dest pmetric.Metric, size int) (int, bool) {
+// splitMetric removes metric points from the input data and moves the points fitting in the limit to destination.
+// Returns whether the metric should be removed from original slice.
+func splitMetric(ms, dest pmetric.Metric, limit *splitLimit, bs *metricsByteSizer) bool {
 	dest.SetName(ms.Name())
 	dest.SetDescription(ms.Description())
 	dest.SetUnit(ms.Unit())
 
 	switch ms.Type() {
 	case pmetric.MetricTypeGauge:
-		return splitNumberDataPoints(ms.Gauge().DataPoints(), dest.SetEmptyGauge().DataPoints(), size)
+		dest.SetEmptyGauge()
+		limit.addOverhead(func() int { return bs.metricSize(dest) })
+		return splitNumberDataPoints(ms.Gauge().DataPoints(), dest.Gauge().DataPoints(), limit, bs)
 	case pmetric.MetricTypeSum:
 		destSum := dest.SetEmptySum()
 		destSum.SetAggregationTemporality(ms.Sum().AggregationTemporality())
 		destSum.SetIsMonotonic(ms.Sum().IsMonotonic())
-		return splitNumberDataPoints(ms.Sum().DataPoints(), destSum.DataPoints(), size)
+		limit.addOverhead(func() int { return bs.metricSize(dest) })
+		return splitNumberDataPoints(ms.Sum().DataPoints(), destSum.DataPoints(), limit, bs)
 	case pmetric.MetricTypeHistogram:
 		destHistogram := dest.SetEmptyHistogram()
 		destHistogram.SetAggregationTemporality(ms.Histogram().AggregationTemporality())
-		return splitHistogramDataPoints(ms.Histogram().DataPoints(), destHistogram.DataPoints(), size)
+		limit.addOverhead(func() int { return bs.metricSize(dest) })
+		return splitHistogramDataPoints(ms.Histogram().DataPoints(), destHistogram.DataPoints(), limit, bs)
 	case pmetric.MetricTypeExponentialHistogram:
 		destHistogram := dest.SetEmptyExponentialHistogram()
 		destHistogram.SetAggregationTemporality(ms.ExponentialHistogram().AggregationTemporality())
-		return splitExponentialHistogramDataPoints(ms.ExponentialHistogram().DataPoints(), destHistogram.DataPoints(), size)
+		limit.addOverhead(func() int { return bs.metricSize(dest) })
+		return splitExponentialHistogramDataPoints(ms.ExponentialHistogram().DataPoints(), destHistogram.DataPoints(), limit, bs)
 	case pmetric.MetricTypeSummary:
-		return splitSummaryDataPoints(ms.Summary().DataPoints(), dest.SetEmptySummary().DataPoints(), size)
+		dest.SetEmptySummary()
+		limit.addOverhead(func() int { return bs.metricSize(dest) })
+		return splitSummaryDataPoints(ms.Summary().DataPoints(), dest.Summary().DataPoints(), limit, bs)
 	}
-	return size, false
+	return false
 }
 
-func splitNumberDataPoints(src, dst pmetric.NumberDataPointSlice, size int) (int, bool) {
-	dst.EnsureCapacity(size)
-	i := 0
+func splitNumberDataPoints(src, dst pmetric.NumberDataPointSlice, limit *splitLimit, bs *metricsByteSizer) bool {
+	dst.EnsureCapacity(limit.remainingItems())
 	src.RemoveIf(func(dp pmetric.NumberDataPoint) bool {
-		if i < size {
-			dp.MoveTo(dst.AppendEmpty())
-			i++
-			return true
+		if !limit.addItem(func() int { return bs.numberDataPointSize(dp) }) {
+			return false
 		}
-		return false
+		dp.MoveTo(dst.AppendEmpty())
+		return true
 	})
-	return size, false
+	return src.Len() == 0
 }
 
-func splitHistogramDataPoints(src, dst pmetric.HistogramDataPointSlice, size int) (int, bool) {
-	dst.EnsureCapacity(size)
-	i := 0
+func splitHistogramDataPoints(src, dst pmetric.HistogramDataPointSlice, limit *splitLimit, bs *metricsByteSizer) bool {
+	dst.EnsureCapacity(limit.remainingItems())
 	src.RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
-		if i < size {
-			dp.MoveTo(dst.AppendEmpty())
-			i++
-			return true
+		if !limit.addItem(func() int { return bs.histogramDataPointSize(dp) }) {
+			return false
 		}
-		return false
+		dp.MoveTo(dst.AppendEmpty())
+		return true
 	})
-	return size, false
+	return src.Len() == 0
 }
 
-func splitExponentialHistogramDataPoints(src, dst pmetric.ExponentialHistogramDataPointSlice, size int) (int, bool) {
-	dst.EnsureCapacity(size)
-	i := 0
+func splitExponentialHistogramDataPoints(src, dst pmetric.ExponentialHistogramDataPointSlice, limit *splitLimit, bs *metricsByteSizer) bool {
+	dst.EnsureCapacity(limit.remainingItems())
 	src.RemoveIf(func(dp pmetric.ExponentialHistogramDataPoint) bool {
-		if i < size {
-			dp.MoveTo(dst.AppendEmpty())
-			i++
-			return true
+		if !limit.addItem(func() int { return bs.exponentialHistogramDataPointSize(dp) }) {
+			return false
 		}
-		return false
+		dp.MoveTo(dst.AppendEmpty())
+		return true
 	})
-	return size, false
+	return src.Len() == 0
 }
 
-func splitSummaryDataPoints(src, dst pmetric.SummaryDataPointSlice, size int) (int, bool) {
-	dst.EnsureCapacity(size)
-	i := 0
+func splitSummaryDataPoints(src, dst pmetric.SummaryDataPointSlice, limit *splitLimit, bs *metricsByteSizer) bool {
+	dst.EnsureCapacity(limit.remainingItems())
 	src.RemoveIf(func(dp pmetric.SummaryDataPoint) bool {
-		if i < size {
-			dp.MoveTo(dst.AppendEmpty())
-			i++
-			return true
+		if !limit.addItem(func() int { return bs.summaryDataPointSize(dp) }) {
+			return false
 		}
-		return false
+		dp.MoveTo(dst.AppendEmpty())
+		return true
 	})
-	return size, false
+	return src.Len() == 0
+}
+
+// metricsByteSizer measures the byte size of the parts of metrics, each one on its own, by moving it into a scratch
+// pmetric.Metrics and back. The sum of the sizes of the parts is at least the size of the metrics holding them, so
+// that the split metrics do not exceed the limit.
+type metricsByteSizer struct {
+	sizer pmetric.Sizer
+	// rm, ilm and metric hold a single empty resource, scope and metric respectively.
+	rm     pmetric.Metrics
+	ilm    pmetric.Metrics
+	metric pmetric.Metrics
+	// The following hold a single empty data point of their type.
+	numberDataPoint               pmetric.Metrics
+	histogramDataPoint            pmetric.Metrics
+	exponentialHistogramDataPoint pmetric.Metrics
+	summaryDataPoint              pmetric.Metrics
+}
+
+func newMetricsByteSizer(sizer pmetric.Sizer) *metricsByteSizer {
+	bs := &metricsByteSizer{sizer: sizer, rm: pmetric.NewMetrics(), ilm: pmetric.NewMetrics()}
+	bs.rm.ResourceMetrics().AppendEmpty()
+	bs.ilm.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	bs.metric, _ = newScratchMetric()
+	var m pmetric.Metric
+	bs.numberDataPoint, m = newScratchMetric()
+	m.SetEmptyGauge().DataPoints().AppendEmpty()
+	bs.histogramDataPoint, m = newScratchMetric()
+	m.SetEmptyHistogram().DataPoints().AppendEmpty()
+	bs.exponentialHistogramDataPoint, m = newScratchMetric()
+	m.SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+	bs.summaryDataPoint, m = newScratchMetric()
+	m.SetEmptySummary().DataPoints().AppendEmpty()
+	return bs
+}
+
+// newScratchMetric returns metrics holding a single empty metric, and the metric.
+func newScratchMetric() (pmetric.Metrics, pmetric.Metric) {
+	md := pmetric.NewMetrics()
+	return md, md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+}
+
+// scratchMetric returns the metric of metrics created by newScratchMetric.
+func scratchMetric(md pmetric.Metrics) pmetric.Metric {
+	return md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+}
+
+func (bs *metricsByteSizer) resourceMetricsSize(rm pmetric.ResourceMetrics) int {
+	tmp := bs.rm.ResourceMetrics().At(0)
+	rm.MoveTo(tmp)
+	defer tmp.MoveTo(rm)
+	return bs.sizer.MetricsSize(bs.rm)
+}
+
+func (bs *metricsByteSizer) scopeMetricsSize(ilm pmetric.ScopeMetrics) int {
+	tmp := bs.ilm.ResourceMetrics().At(0).ScopeMetrics().At(0)
+	ilm.MoveTo(tmp)
+	defer tmp.MoveTo(ilm)
+	return bs.sizer.MetricsSize(bs.ilm)
+}
+
+func (bs *metricsByteSizer) metricSize(m pmetric.Metric) int {
+	tmp := scratchMetric(bs.metric)
+	m.MoveTo(tmp)
+	defer tmp.MoveTo(m)
+	return bs.sizer.MetricsSize(bs.metric)
+}
+
+func (bs *metricsByteSizer) numberDataPointSize(dp pmetric.NumberDataPoint) int {
+	tmp := scratchMetric(bs.numberDataPoint).Gauge().DataPoints().At(0)
+	dp.MoveTo(tmp)
+	defer tmp.MoveTo(dp)
+	return bs.sizer.MetricsSize(bs.numberDataPoint)
+}
+
+func (bs *metricsByteSizer) histogramDataPointSize(dp pmetric.HistogramDataPoint) int {
+	tmp := scratchMetric(bs.histogramDataPoint).Histogram().DataPoints().At(0)
+	dp.MoveTo(tmp)
+	defer tmp.MoveTo(dp)
+	return bs.sizer.MetricsSize(bs.histogramDataPoint)
+}
+
+func (bs *metricsByteSizer) exponentialHistogramDataPointSize(dp pmetric.ExponentialHistogramDataPoint) int {
+	tmp := scratchMetric(bs.exponentialHistogramDataPoint).ExponentialHistogram().DataPoints().At(0)
+	dp.MoveTo(tmp)
+	defer tmp.MoveTo(dp)
+	return bs.sizer.MetricsSize(bs.exponentialHistogramDataPoint)
+}
+
+func (bs *metricsByteSizer) summaryDataPointSize(dp pmetric.SummaryDataPoint) int {
+	tmp := scratchMetric(bs.summaryDataPoint).Summary().DataPoints().At(0)
+	dp.MoveTo(tmp)
+	defer tmp.MoveTo(dp)
+	return bs.sizer.MetricsSize(bs.summaryDataPoint)
 }
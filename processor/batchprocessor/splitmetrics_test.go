@@ -26,7 +26,7 @@ import (
 func TestSplitMetrics_noop(t *testing.T) {
 	td := testdata.GenerateMetrics(20)
 	splitSize := 40
-	split := splitMetrics(splitSize, td)
+	split := splitMetrics(splitSize, 0, nil, td)
 	assert.Equal(t, td, split)
 
 	i := 0
@@ -60,24 +60,24 @@ func TestSplitMetrics(t *testing.T) {
 
 	splitMetricCount := 5
 	splitSize := splitMetricCount * dataPointCount
-	split := splitMetrics(splitSize, md)
+	split := splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, splitMetricCount, split.MetricCount())
 	assert.Equal(t, cp, split)
 	assert.Equal(t, 15, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-0", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-4", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 10, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-5", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-9", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 5, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-10", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-14", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 5, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-15", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-19", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
@@ -101,7 +101,7 @@ func TestSplitMetricsMultipleResourceSpans(t *testing.T) {
 
 	splitMetricCount := 5
 	splitSize := splitMetricCount * dataPointCount
-	split := splitMetrics(splitSize, md)
+	split := splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, splitMetricCount, split.MetricCount())
 	assert.Equal(t, 35, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-0", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
@@ -126,7 +126,7 @@ func TestSplitMetricsMultipleResourceSpans_SplitSizeGreaterThanMetricSize(t *tes
 
 	splitMetricCount := 25
 	splitSize := splitMetricCount * dataPointCount
-	split := splitMetrics(splitSize, td)
+	split := splitMetrics(splitSize, 0, nil, td)
 	assert.Equal(t, splitMetricCount, split.MetricCount())
 	assert.Equal(t, 40-splitMetricCount, td.MetricCount())
 	assert.Equal(t, 1, td.ResourceMetrics().Len())
@@ -146,19 +146,19 @@ func TestSplitMetricsUneven(t *testing.T) {
 	}
 
 	splitSize := 9
-	split := splitMetrics(splitSize, md)
+	split := splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 5, split.MetricCount())
 	assert.Equal(t, 6, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-0", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-4", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 5, split.MetricCount())
 	assert.Equal(t, 1, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-4", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-8", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 1, split.MetricCount())
 	assert.Equal(t, "test-metric-int-0-9", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 }
@@ -177,14 +177,14 @@ func TestSplitMetricsAllTypes(t *testing.T) {
 	// and then split by 2 for the rest so that each metric is split in half.
 	// Verify that descriptors are preserved for all data types across splits.
 
-	split := splitMetrics(1, md)
+	split := splitMetrics(1, 0, nil, md)
 	assert.Equal(t, 1, split.MetricCount())
 	assert.Equal(t, 7, md.MetricCount())
 	gaugeInt := split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
 	assert.Equal(t, 1, gaugeInt.Gauge().DataPoints().Len())
 	assert.Equal(t, "test-metric-int-0-0", gaugeInt.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 2, split.MetricCount())
 	assert.Equal(t, 6, md.MetricCount())
 	gaugeInt = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
@@ -194,7 +194,7 @@ func TestSplitMetricsAllTypes(t *testing.T) {
 	assert.Equal(t, 1, gaugeDouble.Gauge().DataPoints().Len())
 	assert.Equal(t, "test-metric-int-0-1", gaugeDouble.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 2, split.MetricCount())
 	assert.Equal(t, 5, md.MetricCount())
 	gaugeDouble = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
@@ -206,7 +206,7 @@ func TestSplitMetricsAllTypes(t *testing.T) {
 	assert.Equal(t, true, sumInt.Sum().IsMonotonic())
 	assert.Equal(t, "test-metric-int-0-2", sumInt.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 2, split.MetricCount())
 	assert.Equal(t, 4, md.MetricCount())
 	sumInt = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
@@ -220,7 +220,7 @@ func TestSplitMetricsAllTypes(t *testing.T) {
 	assert.Equal(t, true, sumDouble.Sum().IsMonotonic())
 	assert.Equal(t, "test-metric-int-0-3", sumDouble.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 2, split.MetricCount())
 	assert.Equal(t, 3, md.MetricCount())
 	sumDouble = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
@@ -233,7 +233,7 @@ func TestSplitMetricsAllTypes(t *testing.T) {
 	assert.Equal(t, pmetric.AggregationTemporalityCumulative, histogram.Histogram().AggregationTemporality())
 	assert.Equal(t, "test-metric-int-0-4", histogram.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 2, split.MetricCount())
 	assert.Equal(t, 2, md.MetricCount())
 	histogram = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
@@ -245,7 +245,7 @@ func TestSplitMetricsAllTypes(t *testing.T) {
 	assert.Equal(t, pmetric.AggregationTemporalityDelta, exponentialHistogram.ExponentialHistogram().AggregationTemporality())
 	assert.Equal(t, "test-metric-int-0-5", exponentialHistogram.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, 2, split.MetricCount())
 	assert.Equal(t, 1, md.MetricCount())
 	exponentialHistogram = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
@@ -256,7 +256,7 @@ func TestSplitMetricsAllTypes(t *testing.T) {
 	assert.Equal(t, 1, summary.Summary().DataPoints().Len())
 	assert.Equal(t, "test-metric-int-0-6", summary.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	summary = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
 	assert.Equal(t, 1, summary.Summary().DataPoints().Len())
 	assert.Equal(t, "test-metric-int-0-6", summary.Name())
@@ -272,25 +272,25 @@ func TestSplitMetricsBatchSizeSmallerThanDataPointCount(t *testing.T) {
 	}
 
 	splitSize := 1
-	split := splitMetrics(splitSize, md)
+	split := splitMetrics(splitSize, 0, nil, md)
 	splitMetric := split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
 	assert.Equal(t, 1, split.MetricCount())
 	assert.Equal(t, 2, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-0", splitMetric.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	splitMetric = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
 	assert.Equal(t, 1, split.MetricCount())
 	assert.Equal(t, 1, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-0", splitMetric.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	splitMetric = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
 	assert.Equal(t, 1, split.MetricCount())
 	assert.Equal(t, 1, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-1", splitMetric.Name())
 
-	split = splitMetrics(splitSize, md)
+	split = splitMetrics(splitSize, 0, nil, md)
 	splitMetric = split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
 	assert.Equal(t, 1, split.MetricCount())
 	assert.Equal(t, 1, md.MetricCount())
@@ -319,9 +319,51 @@ func TestSplitMetricsMultipleILM(t *testing.T) {
 
 	splitMetricCount := 40
 	splitSize := splitMetricCount * dataPointCount
-	split := splitMetrics(splitSize, md)
+	split := splitMetrics(splitSize, 0, nil, md)
 	assert.Equal(t, splitMetricCount, split.MetricCount())
 	assert.Equal(t, 20, md.MetricCount())
 	assert.Equal(t, "test-metric-int-0-0", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-4", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
 }
+
+func TestSplitMetricsBytes(t *testing.T) {
+	md := testdata.GenerateMetrics(20)
+	testdata.GenerateMetrics(20).ResourceMetrics().At(0).CopyTo(md.ResourceMetrics().AppendEmpty())
+	dataPointCount := md.DataPointCount()
+	sizer := &pmetric.ProtoMarshaler{}
+	maxBytes := sizer.MetricsSize(md) / 7
+
+	splitDataPoints := 0
+	for md.DataPointCount() > 0 {
+		split := splitMetrics(0, maxBytes, sizer, md)
+		assert.LessOrEqual(t, sizer.MetricsSize(split), maxBytes)
+		assert.Greater(t, split.DataPointCount(), 0)
+		splitDataPoints += split.DataPointCount()
+	}
+	assert.Equal(t, dataPointCount, splitDataPoints)
+	assert.Equal(t, 0, md.ResourceMetrics().Len())
+}
+
+func TestSplitMetricsBytesDataPoints(t *testing.T) {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("test-metric")
+	dps := m.SetEmptySum().DataPoints()
+	for i := 0; i < 100; i++ {
+		dps.AppendEmpty().SetIntValue(int64(i))
+	}
+	sizer := &pmetric.ProtoMarshaler{}
+	maxBytes := sizer.MetricsSize(md) / 3
+
+	split := splitMetrics(0, maxBytes, sizer, md)
+	assert.LessOrEqual(t, sizer.MetricsSize(split), maxBytes)
+	splitMetric := split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	assert.Equal(t, "test-metric", splitMetric.Name())
+	assert.Equal(t, int64(0), splitMetric.Sum().DataPoints().At(0).IntValue())
+	assert.Equal(t, 100, split.DataPointCount()+md.DataPointCount())
+	assert.Equal(t, int64(splitMetric.Sum().DataPoints().Len()), m.Sum().DataPoints().At(0).IntValue())
+
+	// A data point larger than the limit is sent on its own.
+	split = splitMetrics(0, 1, sizer, md)
+	assert.Equal(t, 1, split.DataPointCount())
+}
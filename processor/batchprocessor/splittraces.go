@@ -18,60 +18,71 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
-// splitTraces removes spans from the input trace and returns a new trace of the specified size.
-func splitTraces(size int, src ptrace.Traces) ptrace.Traces {
-	if src.SpanCount() <= size {
+// splitTraces removes spans from the input trace and returns a new trace of at most size spans, when size is positive,
+// and of at most maxBytes bytes as measured by sizer, when maxBytes is positive.
+func splitTraces(size int, maxBytes int, sizer ptrace.Sizer, src ptrace.Traces) ptrace.Traces {
+	if maxBytes <= 0 && src.SpanCount() <= size {
 		return src
 	}
-	totalCopiedSpans := 0
+	limit := newSplitLimit(size, maxBytes)
+	var bs *tracesByteSizer
+	if maxBytes > 0 {
+		bs = newTracesByteSizer(sizer)
+	}
 	dest := ptrace.NewTraces()
 
 	src.ResourceSpans().RemoveIf(func(srcRs ptrace.ResourceSpans) bool {
 		// If we are done skip everything else.
-		if totalCopiedSpans == size {
+		if limit.isFull() {
 			return false
 		}
 
 		// If it fully fits
-		srcRsSC := resourceSC(srcRs)
-		if (totalCopiedSpans + srcRsSC) <= size {
-			totalCopiedSpans += srcRsSC
+		if limit.add(resourceSC(srcRs), func() int { return bs.resourceSpansSize(srcRs) }) {
 			srcRs.MoveTo(dest.ResourceSpans().AppendEmpty())
 			return true
 		}
 
 		destRs := dest.ResourceSpans().AppendEmpty()
 		srcRs.Resource().CopyTo(destRs.Resource())
+		limit.addOverhead(func() int { return bs.resourceSpansSize(destRs) })
 		srcRs.ScopeSpans().RemoveIf(func(srcIls ptrace.ScopeSpans) bool {
 			// If we are done skip everything else.
-			if totalCopiedSpans == size {
+			if limit.isFull() {
 				return false
 			}
 
 			// If possible to move all metrics do that.
-			srcIlsSC := srcIls.Spans().Len()
-			if size-totalCopiedSpans >= srcIlsSC {
-				totalCopiedSpans += srcIlsSC
+			if limit.add(srcIls.Spans().Len(), func() int { return bs.scopeSpansSize(srcIls) }) {
 				srcIls.MoveTo(destRs.ScopeSpans().AppendEmpty())
 				return true
 			}
 
 			destIls := destRs.ScopeSpans().AppendEmpty()
 			srcIls.Scope().CopyTo(destIls.Scope())
+			limit.addOverhead(func() int { return bs.scopeSpansSize(destIls) })
 			srcIls.Spans().RemoveIf(func(srcSpan ptrace.Span) bool {
 				// If we are done skip everything else.
-				if totalCopiedSpans == size {
+				if !limit.addItem(func() int { return bs.spanSize(srcSpan) }) {
 					return false
 				}
 				srcSpan.MoveTo(destIls.Spans().AppendEmpty())
-				totalCopiedSpans++
 				return true
 			})
-			return false
+			return srcIls.Spans().Len() == 0
 		})
 		return srcRs.ScopeSpans().Len() == 0
 	})
 
+	if maxBytes > 0 {
+		// The resource and the scope split last are empty if their first span did not fit.
+		dest.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+			rs.ScopeSpans().RemoveIf(func(ils ptrace.ScopeSpans) bool {
+				return ils.Spans().Len() == 0
+			})
+			return rs.ScopeSpans().Len() == 0
+		})
+	}
 	return dest
 }
 
@@ -82,3 +93,43 @@ func resourceSC(rs ptrace.ResourceSpans) (count int) {
 	}
 	return
 }
+
+// tracesByteSizer measures the byte size of the parts of traces, each one on its own, by moving it into a scratch
+// ptrace.Traces and back. The sum of the sizes of the parts is at least the size of the traces holding them, so that
+// the split traces do not exceed the limit.
+type tracesByteSizer struct {
+	sizer ptrace.Sizer
+	// rs, ils and span hold a single empty resource, scope and span respectively.
+	rs   ptrace.Traces
+	ils  ptrace.Traces
+	span ptrace.Traces
+}
+
+func newTracesByteSizer(sizer ptrace.Sizer) *tracesByteSizer {
+	bs := &tracesByteSizer{sizer: sizer, rs: ptrace.NewTraces(), ils: ptrace.NewTraces(), span: ptrace.NewTraces()}
+	bs.rs.ResourceSpans().AppendEmpty()
+	bs.ils.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty()
+	bs.span.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	return bs
+}
+
+func (bs *tracesByteSizer) resourceSpansSize(rs ptrace.ResourceSpans) int {
+	tmp := bs.rs.ResourceSpans().At(0)
+	rs.MoveTo(tmp)
+	defer tmp.MoveTo(rs)
+	return bs.sizer.TracesSize(bs.rs)
+}
+
+func (bs *tracesByteSizer) scopeSpansSize(ils ptrace.ScopeSpans) int {
+	tmp := bs.ils.ResourceSpans().At(0).ScopeSpans().At(0)
+	ils.MoveTo(tmp)
+	defer tmp.MoveTo(ils)
+	return bs.sizer.TracesSize(bs.ils)
+}
+
+func (bs *tracesByteSizer) spanSize(span ptrace.Span) int {
+	tmp := bs.span.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	span.MoveTo(tmp)
+	defer tmp.MoveTo(span)
+	return bs.sizer.TracesSize(bs.span)
+}
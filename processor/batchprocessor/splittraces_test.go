@@ -26,7 +26,7 @@ import (
 func TestSplitTraces_noop(t *testing.T) {
 	td := testdata.GenerateTraces(20)
 	splitSize := 40
-	split := splitTraces(splitSize, td)
+	split := splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, td, split)
 
 	i := 0
@@ -57,24 +57,24 @@ func TestSplitTraces(t *testing.T) {
 	spans.At(4).CopyTo(cpSpans.AppendEmpty())
 
 	splitSize := 5
-	split := splitTraces(splitSize, td)
+	split := splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, splitSize, split.SpanCount())
 	assert.Equal(t, cp, split)
 	assert.Equal(t, 15, td.SpanCount())
 	assert.Equal(t, "test-span-0-0", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
 	assert.Equal(t, "test-span-0-4", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(4).Name())
 
-	split = splitTraces(splitSize, td)
+	split = splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, 10, td.SpanCount())
 	assert.Equal(t, "test-span-0-5", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
 	assert.Equal(t, "test-span-0-9", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(4).Name())
 
-	split = splitTraces(splitSize, td)
+	split = splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, 5, td.SpanCount())
 	assert.Equal(t, "test-span-0-10", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
 	assert.Equal(t, "test-span-0-14", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(4).Name())
 
-	split = splitTraces(splitSize, td)
+	split = splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, 5, td.SpanCount())
 	assert.Equal(t, "test-span-0-15", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
 	assert.Equal(t, "test-span-0-19", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(4).Name())
@@ -95,7 +95,7 @@ func TestSplitTracesMultipleResourceSpans(t *testing.T) {
 	}
 
 	splitSize := 5
-	split := splitTraces(splitSize, td)
+	split := splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, splitSize, split.SpanCount())
 	assert.Equal(t, 35, td.SpanCount())
 	assert.Equal(t, "test-span-0-0", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
@@ -117,7 +117,7 @@ func TestSplitTracesMultipleResourceSpans_SplitSizeGreaterThanSpanSize(t *testin
 	}
 
 	splitSize := 25
-	split := splitTraces(splitSize, td)
+	split := splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, splitSize, split.SpanCount())
 	assert.Equal(t, 40-splitSize, td.SpanCount())
 	assert.Equal(t, 1, td.ResourceSpans().Len())
@@ -150,9 +150,63 @@ func TestSplitTracesMultipleILS(t *testing.T) {
 	}
 
 	splitSize := 40
-	split := splitTraces(splitSize, td)
+	split := splitTraces(splitSize, 0, nil, td)
 	assert.Equal(t, splitSize, split.SpanCount())
 	assert.Equal(t, 20, td.SpanCount())
 	assert.Equal(t, "test-span-0-0", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
 	assert.Equal(t, "test-span-0-4", split.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(4).Name())
 }
+
+func TestSplitTracesBytes(t *testing.T) {
+	td := testdata.GenerateTraces(20)
+	testdata.GenerateTraces(20).ResourceSpans().At(0).CopyTo(td.ResourceSpans().AppendEmpty())
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		spans := td.ResourceSpans().At(i).ScopeSpans().At(0).Spans()
+		for j := 0; j < spans.Len(); j++ {
+			spans.At(j).SetName(getTestSpanName(i, j))
+		}
+	}
+	sizer := &ptrace.ProtoMarshaler{}
+	maxBytes := sizer.TracesSize(td) / 7
+
+	var names []string
+	for td.SpanCount() > 0 {
+		split := splitTraces(0, maxBytes, sizer, td)
+		assert.LessOrEqual(t, sizer.TracesSize(split), maxBytes)
+		assert.Greater(t, split.SpanCount(), 0)
+		for i := 0; i < split.ResourceSpans().Len(); i++ {
+			spans := split.ResourceSpans().At(i).ScopeSpans().At(0).Spans()
+			for j := 0; j < spans.Len(); j++ {
+				names = append(names, spans.At(j).Name())
+			}
+		}
+	}
+	assert.Len(t, names, 40)
+	assert.Equal(t, "test-span-0-0", names[0])
+	assert.Equal(t, "test-span-1-0", names[20])
+	assert.Equal(t, "test-span-1-19", names[39])
+}
+
+func TestSplitTracesBytesAndItems(t *testing.T) {
+	td := testdata.GenerateTraces(20)
+	sizer := &ptrace.ProtoMarshaler{}
+
+	// The byte size limit is reached first.
+	split := splitTraces(10, sizer.TracesSize(td)/4, sizer, td)
+	assert.Less(t, split.SpanCount(), 10)
+	assert.Equal(t, 20, split.SpanCount()+td.SpanCount())
+
+	// The number of spans limit is reached first.
+	split = splitTraces(2, sizer.TracesSize(td), sizer, td)
+	assert.Equal(t, 2, split.SpanCount())
+}
+
+func TestSplitTracesBytesLargeSpan(t *testing.T) {
+	td := testdata.GenerateTraces(3)
+	sizer := &ptrace.ProtoMarshaler{}
+
+	// A span larger than the limit is sent on its own.
+	split := splitTraces(0, 1, sizer, td)
+	assert.Equal(t, 1, split.SpanCount())
+	assert.Equal(t, 2, td.SpanCount())
+}
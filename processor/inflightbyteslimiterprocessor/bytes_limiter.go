@@ -0,0 +1,134 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inflightbyteslimiterprocessor // import "go.opentelemetry.io/collector/processor/inflightbyteslimiterprocessor"
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errLimitReached is returned when the bytes cannot be acquired before the wait timeout.
+var errLimitReached = errors.New("in-flight bytes limit reached")
+
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// bytesLimiter is a weighted semaphore bounding the number of bytes in flight.
+// Waiters are served in FIFO order, so that large requests are not starved
+// by a stream of small ones.
+type bytesLimiter struct {
+	limit int64
+
+	mu      sync.Mutex
+	inUse   int64
+	waiters list.List
+}
+
+func newBytesLimiter(limit int64) *bytesLimiter {
+	return &bytesLimiter{limit: limit}
+}
+
+// acquire reserves n bytes, waiting at most timeout for them to be released.
+// It returns errLimitReached if the bytes are not available in time, or the
+// context error if the context is done first.
+func (bl *bytesLimiter) acquire(ctx context.Context, n int64, timeout time.Duration) error {
+	if n > bl.limit {
+		return fmt.Errorf("request of %d bytes exceeds the in-flight bytes limit of %d bytes", n, bl.limit)
+	}
+
+	bl.mu.Lock()
+	if bl.limit-bl.inUse >= n && bl.waiters.Len() == 0 {
+		bl.inUse += n
+		bl.mu.Unlock()
+		return nil
+	}
+	if timeout == 0 {
+		bl.mu.Unlock()
+		return errLimitReached
+	}
+
+	w := waiter{n: n, ready: make(chan struct{})}
+	elem := bl.waiters.PushBack(w)
+	bl.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-w.ready:
+		return nil
+	case <-timer.C:
+		err = errLimitReached
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	select {
+	case <-w.ready:
+		// Acquired concurrently with the timeout, keep the bytes.
+		return nil
+	default:
+	}
+	isFront := bl.waiters.Front() == elem
+	bl.waiters.Remove(elem)
+	// Removing the front waiter may allow the next ones to proceed.
+	if isFront {
+		bl.notifyWaiters()
+	}
+	return err
+}
+
+// release returns n bytes previously acquired.
+func (bl *bytesLimiter) release(n int64) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.inUse -= n
+	if bl.inUse < 0 {
+		panic("inflightbyteslimiterprocessor: released more bytes than held")
+	}
+	bl.notifyWaiters()
+}
+
+// inFlight returns the number of bytes currently held.
+func (bl *bytesLimiter) inFlight() int64 {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	return bl.inUse
+}
+
+func (bl *bytesLimiter) notifyWaiters() {
+	for {
+		next := bl.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(waiter)
+		if bl.limit-bl.inUse < w.n {
+			return
+		}
+		bl.inUse += w.n
+		bl.waiters.Remove(next)
+		close(w.ready)
+	}
+}
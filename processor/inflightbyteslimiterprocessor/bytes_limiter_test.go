@@ -0,0 +1,111 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inflightbyteslimiterprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytesLimiterAcquireRelease(t *testing.T) {
+	bl := newBytesLimiter(100)
+
+	require.NoError(t, bl.acquire(context.Background(), 60, 0))
+	require.NoError(t, bl.acquire(context.Background(), 40, 0))
+	assert.Equal(t, int64(100), bl.inFlight())
+
+	assert.ErrorIs(t, bl.acquire(context.Background(), 1, 0), errLimitReached)
+	assert.ErrorIs(t, bl.acquire(context.Background(), 1, 10*time.Millisecond), errLimitReached)
+
+	bl.release(60)
+	require.NoError(t, bl.acquire(context.Background(), 60, 0))
+	bl.release(60)
+	bl.release(40)
+	assert.Equal(t, int64(0), bl.inFlight())
+}
+
+func TestBytesLimiterTooLarge(t *testing.T) {
+	bl := newBytesLimiter(100)
+	err := bl.acquire(context.Background(), 101, time.Second)
+	assert.EqualError(t, err, "request of 101 bytes exceeds the in-flight bytes limit of 100 bytes")
+	assert.NotErrorIs(t, err, errLimitReached)
+	assert.Equal(t, int64(0), bl.inFlight())
+}
+
+func TestBytesLimiterWait(t *testing.T) {
+	bl := newBytesLimiter(100)
+	require.NoError(t, bl.acquire(context.Background(), 100, 0))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bl.acquire(context.Background(), 50, time.Minute)
+	}()
+
+	// The waiter is only served once the bytes are released.
+	assert.Never(t, func() bool { return len(done) > 0 }, 50*time.Millisecond, 10*time.Millisecond)
+	bl.release(100)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int64(50), bl.inFlight())
+}
+
+func TestBytesLimiterFIFO(t *testing.T) {
+	bl := newBytesLimiter(100)
+	require.NoError(t, bl.acquire(context.Background(), 100, 0))
+
+	large := make(chan error, 1)
+	go func() {
+		large <- bl.acquire(context.Background(), 80, time.Minute)
+	}()
+	require.Eventually(t, func() bool {
+		bl.mu.Lock()
+		defer bl.mu.Unlock()
+		return bl.waiters.Len() == 1
+	}, time.Second, time.Millisecond)
+
+	// A small request must not overtake the waiting large one.
+	bl.release(30)
+	assert.ErrorIs(t, bl.acquire(context.Background(), 10, 0), errLimitReached)
+
+	bl.release(70)
+	assert.NoError(t, <-large)
+	assert.Equal(t, int64(80), bl.inFlight())
+}
+
+func TestBytesLimiterContextCanceled(t *testing.T) {
+	bl := newBytesLimiter(100)
+	require.NoError(t, bl.acquire(context.Background(), 90, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- bl.acquire(ctx, 50, time.Minute)
+	}()
+	require.Eventually(t, func() bool {
+		bl.mu.Lock()
+		defer bl.mu.Unlock()
+		return bl.waiters.Len() == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	// Removing the canceled waiter unblocks the requests queued behind it.
+	assert.NoError(t, bl.acquire(context.Background(), 10, 0))
+	assert.Equal(t, int64(100), bl.inFlight())
+}
@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inflightbyteslimiterprocessor provides a processor for OpenTelemetry
+// Service pipeline that bounds the number of bytes held by the pipeline, from
+// the moment the data enters the processor until the next consumer returns.
+package inflightbyteslimiterprocessor // import "go.opentelemetry.io/collector/processor/inflightbyteslimiterprocessor"
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+)
+
+var (
+	errLimitOutOfRange     = errors.New("'limit_mib' must be greater than zero")
+	errNegativeWaitTimeout = errors.New("'wait_timeout' must not be negative")
+)
+
+// Config defines configuration for the in-flight bytes limiter processor.
+type Config struct {
+	// Deprecated: [v0.68.0] will be removed soon.
+	config.ProcessorSettings `mapstructure:",squash"`
+
+	// LimitMiB is the maximum amount of data, in MiB, that the pipeline can hold
+	// at any time. The size of the data is its OTLP protobuf encoded size.
+	LimitMiB uint32 `mapstructure:"limit_mib"`
+
+	// WaitTimeout is the maximum time to wait for the in-flight data to be released
+	// when the limit is reached. Defaults to zero, so the data is refused immediately.
+	WaitTimeout time.Duration `mapstructure:"wait_timeout"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks if the processor configuration is valid
+func (cfg *Config) Validate() error {
+	if cfg.LimitMiB == 0 {
+		return errLimitOutOfRange
+	}
+	if cfg.WaitTimeout < 0 {
+		return errNegativeWaitTimeout
+	}
+	return nil
+}
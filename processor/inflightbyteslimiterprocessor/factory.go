@@ -0,0 +1,83 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inflightbyteslimiterprocessor // import "go.opentelemetry.io/collector/processor/inflightbyteslimiterprocessor"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+)
+
+const (
+	// The value of "type" key in configuration.
+	typeStr = "inflight_bytes_limiter"
+)
+
+// NewFactory returns a new factory for the in-flight bytes limiter processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		typeStr,
+		createDefaultConfig,
+		processor.WithTraces(createTracesProcessor, component.StabilityLevelDevelopment),
+		processor.WithMetrics(createMetricsProcessor, component.StabilityLevelDevelopment),
+		processor.WithLogs(createLogsProcessor, component.StabilityLevelDevelopment))
+}
+
+// createDefaultConfig creates the default configuration for processor. Notice
+// that the default configuration is expected to fail for this processor.
+func createDefaultConfig() component.Config {
+	return &Config{}
+}
+
+func createTracesProcessor(
+	_ context.Context,
+	set processor.CreateSettings,
+	cfg component.Config,
+	nextConsumer consumer.Traces,
+) (processor.Traces, error) {
+	il, err := newInflightBytesLimiter(set, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return &tracesProcessor{inflightBytesLimiter: il, next: nextConsumer}, nil
+}
+
+func createMetricsProcessor(
+	_ context.Context,
+	set processor.CreateSettings,
+	cfg component.Config,
+	nextConsumer consumer.Metrics,
+) (processor.Metrics, error) {
+	il, err := newInflightBytesLimiter(set, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return &metricsProcessor{inflightBytesLimiter: il, next: nextConsumer}, nil
+}
+
+func createLogsProcessor(
+	_ context.Context,
+	set processor.CreateSettings,
+	cfg component.Config,
+	nextConsumer consumer.Logs,
+) (processor.Logs, error) {
+	il, err := newInflightBytesLimiter(set, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return &logsProcessor{inflightBytesLimiter: il, next: nextConsumer}, nil
+}
@@ -0,0 +1,150 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inflightbyteslimiterprocessor // import "go.opentelemetry.io/collector/processor/inflightbyteslimiterprocessor"
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor"
+)
+
+const mibBytes = 1024 * 1024
+
+var (
+	tracesSizer  ptrace.Sizer  = &ptrace.ProtoMarshaler{}
+	metricsSizer pmetric.Sizer = &pmetric.ProtoMarshaler{}
+	logsSizer    plog.Sizer    = &plog.ProtoMarshaler{}
+)
+
+// inflightBytesLimiter is the state of the processor of a single signal: each
+// create*Processor call builds its own, so each pipeline gets its own budget.
+type inflightBytesLimiter struct {
+	component.StartFunc
+	component.ShutdownFunc
+
+	limiter     *bytesLimiter
+	waitTimeout time.Duration
+
+	obsrep *obsreport.Processor
+}
+
+func newInflightBytesLimiter(set processor.CreateSettings, cfg *Config) (*inflightBytesLimiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	obsrep, err := obsreport.NewProcessor(obsreport.ProcessorSettings{
+		ProcessorID:             set.ID,
+		ProcessorCreateSettings: set,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &inflightBytesLimiter{
+		limiter:     newBytesLimiter(int64(cfg.LimitMiB) * mibBytes),
+		waitTimeout: cfg.WaitTimeout,
+		obsrep:      obsrep,
+	}, nil
+}
+
+func (il *inflightBytesLimiter) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+// acquire reserves size bytes, returning a permanent error if the data can never fit
+// in the budget and a retryable one if the budget is currently exhausted.
+func (il *inflightBytesLimiter) acquire(ctx context.Context, size int64) error {
+	err := il.limiter.acquire(ctx, size, il.waitTimeout)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, errLimitReached):
+		return consumererror.NewRetryable(err, 0)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return consumererror.NewPermanent(err)
+	}
+}
+
+type tracesProcessor struct {
+	*inflightBytesLimiter
+	next consumer.Traces
+}
+
+func (tp *tracesProcessor) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	numSpans := td.SpanCount()
+	size := int64(tracesSizer.TracesSize(td))
+	if err := tp.acquire(ctx, size); err != nil {
+		tp.obsrep.TracesRefused(ctx, numSpans)
+		return err
+	}
+	defer tp.limiter.release(size)
+
+	// Even if the next consumer returns error record the data as accepted by
+	// this processor.
+	tp.obsrep.TracesAccepted(ctx, numSpans)
+	return tp.next.ConsumeTraces(ctx, td)
+}
+
+type metricsProcessor struct {
+	*inflightBytesLimiter
+	next consumer.Metrics
+}
+
+func (mp *metricsProcessor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	numDataPoints := md.DataPointCount()
+	size := int64(metricsSizer.MetricsSize(md))
+	if err := mp.acquire(ctx, size); err != nil {
+		mp.obsrep.MetricsRefused(ctx, numDataPoints)
+		return err
+	}
+	defer mp.limiter.release(size)
+
+	// Even if the next consumer returns error record the data as accepted by
+	// this processor.
+	mp.obsrep.MetricsAccepted(ctx, numDataPoints)
+	return mp.next.ConsumeMetrics(ctx, md)
+}
+
+type logsProcessor struct {
+	*inflightBytesLimiter
+	next consumer.Logs
+}
+
+func (lp *logsProcessor) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	numRecords := ld.LogRecordCount()
+	size := int64(logsSizer.LogsSize(ld))
+	if err := lp.acquire(ctx, size); err != nil {
+		lp.obsrep.LogsRefused(ctx, numRecords)
+		return err
+	}
+	defer lp.limiter.release(size)
+
+	// Even if the next consumer returns error record the data as accepted by
+	// this processor.
+	lp.obsrep.LogsAccepted(ctx, numRecords)
+	return lp.next.ConsumeLogs(ctx, ld)
+}
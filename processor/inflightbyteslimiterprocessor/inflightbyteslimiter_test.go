@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inflightbyteslimiterprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processortest"
+)
+
+func newTestTraces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("operationA")
+	return td
+}
+
+func newTestMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("metricA")
+	m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+	return md
+}
+
+func newTestLogs() plog.Logs {
+	ld := plog.NewLogs()
+	lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.Body().SetStr("logA")
+	return ld
+}
+
+// newTestLimiter returns the state of a processor whose budget holds exactly limit bytes.
+func newTestLimiter(t *testing.T, limit int64, waitTimeout time.Duration) *inflightBytesLimiter {
+	il, err := newInflightBytesLimiter(processortest.NewNopCreateSettings(), &Config{LimitMiB: 1, WaitTimeout: waitTimeout})
+	require.NoError(t, err)
+	il.limiter = newBytesLimiter(limit)
+	return il
+}
+
+func TestTracesInFlight(t *testing.T) {
+	td := newTestTraces()
+	size := int64(tracesSizer.TracesSize(td))
+
+	unblock := make(chan struct{})
+	entered := make(chan struct{})
+	next := func(context.Context, ptrace.Traces) error {
+		entered <- struct{}{}
+		<-unblock
+		return nil
+	}
+	nextConsumer, err := consumer.NewTraces(next)
+	require.NoError(t, err)
+	tp := &tracesProcessor{inflightBytesLimiter: newTestLimiter(t, size, 0), next: nextConsumer}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tp.ConsumeTraces(context.Background(), td)
+	}()
+	<-entered
+	assert.Equal(t, size, tp.limiter.inFlight())
+
+	// The budget is held until the next consumer returns.
+	err = tp.ConsumeTraces(context.Background(), td)
+	assert.ErrorIs(t, err, errLimitReached)
+	assert.True(t, consumererror.IsRetryable(err))
+
+	close(unblock)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int64(0), tp.limiter.inFlight())
+
+	go func() {
+		<-entered
+	}()
+	assert.NoError(t, tp.ConsumeTraces(context.Background(), td))
+}
+
+func TestMetricsWaitForInFlight(t *testing.T) {
+	md := newTestMetrics()
+	size := int64(metricsSizer.MetricsSize(md))
+
+	unblock := make(chan struct{})
+	entered := make(chan struct{}, 2)
+	next := func(context.Context, pmetric.Metrics) error {
+		entered <- struct{}{}
+		<-unblock
+		return nil
+	}
+	nextConsumer, err := consumer.NewMetrics(next)
+	require.NoError(t, err)
+	mp := &metricsProcessor{inflightBytesLimiter: newTestLimiter(t, size, time.Minute), next: nextConsumer}
+
+	first := make(chan error, 1)
+	go func() {
+		first <- mp.ConsumeMetrics(context.Background(), md)
+	}()
+	<-entered
+
+	// The second request blocks until the first one is released.
+	second := make(chan error, 1)
+	go func() {
+		second <- mp.ConsumeMetrics(context.Background(), md)
+	}()
+	assert.Never(t, func() bool { return len(entered) > 0 }, 50*time.Millisecond, 10*time.Millisecond)
+
+	close(unblock)
+	assert.NoError(t, <-first)
+	assert.NoError(t, <-second)
+	assert.Equal(t, int64(0), mp.limiter.inFlight())
+}
+
+func TestLogsTooLarge(t *testing.T) {
+	ld := newTestLogs()
+	size := int64(logsSizer.LogsSize(ld))
+
+	sink := new(consumertest.LogsSink)
+	lp := &logsProcessor{inflightBytesLimiter: newTestLimiter(t, size-1, time.Minute), next: sink}
+
+	err := lp.ConsumeLogs(context.Background(), ld)
+	assert.True(t, consumererror.IsPermanent(err))
+	assert.Equal(t, 0, sink.LogRecordCount())
+
+	lp.limiter = newBytesLimiter(size)
+	assert.NoError(t, lp.ConsumeLogs(context.Background(), ld))
+	assert.Equal(t, 1, sink.LogRecordCount())
+	assert.Equal(t, int64(0), lp.limiter.inFlight())
+}
+
+func TestReleaseOnError(t *testing.T) {
+	td := newTestTraces()
+	tp := &tracesProcessor{inflightBytesLimiter: newTestLimiter(t, int64(tracesSizer.TracesSize(td)), 0), next: consumertest.NewErr(errLimitReached)}
+
+	assert.Error(t, tp.ConsumeTraces(context.Background(), td))
+	assert.Equal(t, int64(0), tp.limiter.inFlight())
+}
@@ -49,6 +49,15 @@ type Config struct {
 	// MemorySpikePercentage is the maximum, in percents against the total memory,
 	// spike expected between the measurements of memory usage.
 	MemorySpikePercentage uint32 `mapstructure:"spike_limit_percentage"`
+
+	// Mode is how the hard limit is enforced: "gc" (default) forces garbage collections
+	// when it is exceeded, "gomemlimit" applies it as the Go runtime memory limit instead.
+	Mode string `mapstructure:"mode"`
+
+	// GCPercent is the garbage collection target percentage applied in the "gomemlimit"
+	// mode. Zero keeps the current value, a negative value only collects the garbage
+	// when approaching the memory limit.
+	GCPercent int `mapstructure:"gc_percent"`
 }
 
 var _ component.Config = (*Config)(nil)
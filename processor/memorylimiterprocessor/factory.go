@@ -67,11 +67,15 @@ func (f *factory) createTracesProcessor(
 	if err != nil {
 		return nil, err
 	}
-	return processorhelper.NewTracesProcessor(ctx, set, cfg, nextConsumer,
+	p, err := processorhelper.NewTracesProcessor(ctx, set, cfg, nextConsumer,
 		memLimiter.processTraces,
 		processorhelper.WithCapabilities(processorCapabilities),
 		processorhelper.WithStart(memLimiter.start),
 		processorhelper.WithShutdown(memLimiter.shutdown))
+	if err != nil {
+		return nil, err
+	}
+	return &tracesProcessor{Traces: p, memLimiter: memLimiter}, nil
 }
 
 func (f *factory) createMetricsProcessor(
@@ -84,11 +88,15 @@ func (f *factory) createMetricsProcessor(
 	if err != nil {
 		return nil, err
 	}
-	return processorhelper.NewMetricsProcessor(ctx, set, cfg, nextConsumer,
+	p, err := processorhelper.NewMetricsProcessor(ctx, set, cfg, nextConsumer,
 		memLimiter.processMetrics,
 		processorhelper.WithCapabilities(processorCapabilities),
 		processorhelper.WithStart(memLimiter.start),
 		processorhelper.WithShutdown(memLimiter.shutdown))
+	if err != nil {
+		return nil, err
+	}
+	return &metricsProcessor{Metrics: p, memLimiter: memLimiter}, nil
 }
 
 func (f *factory) createLogsProcessor(
@@ -101,11 +109,15 @@ func (f *factory) createLogsProcessor(
 	if err != nil {
 		return nil, err
 	}
-	return processorhelper.NewLogsProcessor(ctx, set, cfg, nextConsumer,
+	p, err := processorhelper.NewLogsProcessor(ctx, set, cfg, nextConsumer,
 		memLimiter.processLogs,
 		processorhelper.WithCapabilities(processorCapabilities),
 		processorhelper.WithStart(memLimiter.start),
 		processorhelper.WithShutdown(memLimiter.shutdown))
+	if err != nil {
+		return nil, err
+	}
+	return &logsProcessor{Logs: p, memLimiter: memLimiter}, nil
 }
 
 // getMemoryLimiter checks if we have a cached memoryLimiter with a specific config,
@@ -126,3 +138,60 @@ func (f *factory) getMemoryLimiter(set processor.CreateSettings, cfg component.C
 	f.memoryLimiters[cfg] = memLimiter
 	return memLimiter, nil
 }
+
+// tracesProcessor exposes the state of the memory limiter on the pipelines zPage, and the
+// settings applied to the Go runtime in the "gomemlimit" mode to the service telemetry.
+type tracesProcessor struct {
+	processor.Traces
+	memLimiter *memoryLimiter
+}
+
+func (p *tracesProcessor) ZPagesProperties() [][2]string {
+	return p.memLimiter.zPagesProperties()
+}
+
+func (p *tracesProcessor) GetMemoryLimit() uint64 {
+	return p.memLimiter.getMemoryLimit()
+}
+
+func (p *tracesProcessor) GetGCPercent() int {
+	return p.memLimiter.getGCPercent()
+}
+
+// metricsProcessor exposes the state of the memory limiter on the pipelines zPage, and the
+// settings applied to the Go runtime in the "gomemlimit" mode to the service telemetry.
+type metricsProcessor struct {
+	processor.Metrics
+	memLimiter *memoryLimiter
+}
+
+func (p *metricsProcessor) ZPagesProperties() [][2]string {
+	return p.memLimiter.zPagesProperties()
+}
+
+func (p *metricsProcessor) GetMemoryLimit() uint64 {
+	return p.memLimiter.getMemoryLimit()
+}
+
+func (p *metricsProcessor) GetGCPercent() int {
+	return p.memLimiter.getGCPercent()
+}
+
+// logsProcessor exposes the state of the memory limiter on the pipelines zPage, and the
+// settings applied to the Go runtime in the "gomemlimit" mode to the service telemetry.
+type logsProcessor struct {
+	processor.Logs
+	memLimiter *memoryLimiter
+}
+
+func (p *logsProcessor) ZPagesProperties() [][2]string {
+	return p.memLimiter.zPagesProperties()
+}
+
+func (p *logsProcessor) GetMemoryLimit() uint64 {
+	return p.memLimiter.getMemoryLimit()
+}
+
+func (p *logsProcessor) GetGCPercent() int {
+	return p.memLimiter.getGCPercent()
+}
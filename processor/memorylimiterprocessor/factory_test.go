@@ -24,6 +24,7 @@ import (
 
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/internal/memorylimiter"
 	"go.opentelemetry.io/collector/processor/processortest"
 )
 
@@ -65,7 +66,7 @@ func TestCreateProcessor(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, tp)
 	// test if we can shutdown a monitoring routine that has not started
-	assert.ErrorIs(t, tp.Shutdown(context.Background()), errShutdownNotStarted)
+	assert.ErrorIs(t, tp.Shutdown(context.Background()), memorylimiter.ErrShutdownNotStarted)
 	assert.NoError(t, tp.Start(context.Background(), componenttest.NewNopHost()))
 
 	mp, err = factory.CreateMetricsProcessor(context.Background(), processortest.NewNopCreateSettings(), cfg, consumertest.NewNop())
@@ -88,5 +89,51 @@ func TestCreateProcessor(t *testing.T) {
 	assert.NoError(t, lp.Start(context.Background(), componenttest.NewNopHost()))
 	assert.NoError(t, lp.Shutdown(context.Background()))
 	// calling it again should throw an error
-	assert.ErrorIs(t, lp.Shutdown(context.Background()), errShutdownNotStarted)
+	assert.ErrorIs(t, lp.Shutdown(context.Background()), memorylimiter.ErrShutdownNotStarted)
+}
+
+func TestProcessorZPagesProperties(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.MemoryLimitMiB = 1024
+	cfg.CheckInterval = time.Second
+
+	tp, err := factory.CreateTracesProcessor(context.Background(), processortest.NewNopCreateSettings(), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	mp, err := factory.CreateMetricsProcessor(context.Background(), processortest.NewNopCreateSettings(), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	lp, err := factory.CreateLogsProcessor(context.Background(), processortest.NewNopCreateSettings(), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+
+	for _, p := range []interface{}{tp, mp, lp} {
+		zp, ok := p.(interface{ ZPagesProperties() [][2]string })
+		require.True(t, ok)
+		props := zp.ZPagesProperties()
+		assert.Contains(t, props, [2]string{"Memory limiter state", "Accepting data"})
+		assert.Contains(t, props, [2]string{"Hard limit (MiB)", "1024"})
+	}
+}
+
+func TestProcessorGoMemoryLimit(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.MemoryLimitMiB = 1024
+	cfg.CheckInterval = time.Second
+	cfg.Mode = memorylimiter.ModeGoMemLimit
+	cfg.GCPercent = 200
+
+	tp, err := factory.CreateTracesProcessor(context.Background(), processortest.NewNopCreateSettings(), cfg, consumertest.NewNop())
+	require.NoError(t, err)
+	gml, ok := tp.(interface {
+		GetMemoryLimit() uint64
+		GetGCPercent() int
+	})
+	require.True(t, ok)
+	assert.Equal(t, uint64(0), gml.GetMemoryLimit())
+
+	require.NoError(t, tp.Start(context.Background(), componenttest.NewNopHost()))
+	assert.Equal(t, uint64(1024*1024*1024), gml.GetMemoryLimit())
+	assert.Equal(t, 200, gml.GetGCPercent())
+	require.NoError(t, tp.Shutdown(context.Background()))
+	assert.Equal(t, uint64(0), gml.GetMemoryLimit())
 }
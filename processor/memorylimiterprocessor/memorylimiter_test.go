@@ -22,16 +22,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"go.uber.org/atomic"
-	"go.uber.org/zap"
 
-	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/component/componenttest"
-	"go.opentelemetry.io/collector/config/configtelemetry"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumertest"
-	"go.opentelemetry.io/collector/internal/iruntime"
-	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/internal/memorylimiter"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -57,7 +52,7 @@ func TestNew(t *testing.T) {
 			args: args{
 				nextConsumer: sink,
 			},
-			wantErr: errCheckIntervalOutOfRange,
+			wantErr: memorylimiter.ErrCheckIntervalOutOfRange,
 		},
 		{
 			name: "zero_memAllocLimit",
@@ -65,7 +60,7 @@ func TestNew(t *testing.T) {
 				nextConsumer:  sink,
 				checkInterval: 100 * time.Millisecond,
 			},
-			wantErr: errLimitOutOfRange,
+			wantErr: memorylimiter.ErrLimitOutOfRange,
 		},
 		{
 			name: "memSpikeLimit_gt_memAllocLimit",
@@ -75,7 +70,7 @@ func TestNew(t *testing.T) {
 				memoryLimitMiB:      1,
 				memorySpikeLimitMiB: 2,
 			},
-			wantErr: errMemSpikeLimitOutOfRange,
+			wantErr: memorylimiter.ErrMemSpikeLimitOutOfRange,
 		},
 		{
 			name: "success",
@@ -104,21 +99,30 @@ func TestNew(t *testing.T) {
 	}
 }
 
+const mibBytes = 1024 * 1024
+
+// newTestMemoryLimiter returns a memoryLimiter with a 1GiB limit reading the
+// memory usage from currentMemAlloc.
+func newTestMemoryLimiter(t *testing.T, currentMemAlloc *uint64) *memoryLimiter {
+	t.Cleanup(func() {
+		memorylimiter.ReadMemStatsFn = runtime.ReadMemStats
+	})
+	memorylimiter.ReadMemStatsFn = func(ms *runtime.MemStats) {
+		ms.Alloc = *currentMemAlloc
+	}
+	ml, err := newMemoryLimiter(processortest.NewNopCreateSettings(), &Config{
+		CheckInterval:  time.Second,
+		MemoryLimitMiB: 1024,
+	})
+	require.NoError(t, err)
+	return ml
+}
+
 // TestMetricsMemoryPressureResponse manipulates results from querying memory and
 // check expected side effects.
 func TestMetricsMemoryPressureResponse(t *testing.T) {
 	var currentMemAlloc uint64
-	ml := &memoryLimiter{
-		usageChecker: memUsageChecker{
-			memAllocLimit: 1024,
-		},
-		forceDrop: atomic.NewBool(false),
-		readMemStatsFn: func(ms *runtime.MemStats) {
-			ms.Alloc = currentMemAlloc
-		},
-		obsrep: newObsReport(t),
-		logger: zap.NewNop(),
-	}
+	ml := newTestMemoryLimiter(t, &currentMemAlloc)
 	mp, err := processorhelper.NewMetricsProcessor(
 		context.Background(),
 		processortest.NewNopCreateSettings(),
@@ -132,62 +136,32 @@ func TestMetricsMemoryPressureResponse(t *testing.T) {
 	ctx := context.Background()
 	md := pmetric.NewMetrics()
 
-	// Below memAllocLimit.
-	currentMemAlloc = 800
-	ml.checkMemLimits()
+	// Below the soft limit.
+	currentMemAlloc = 800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.NoError(t, mp.ConsumeMetrics(ctx, md))
 
-	// Above memAllocLimit.
-	currentMemAlloc = 1800
-	ml.checkMemLimits()
+	// Above the hard limit.
+	currentMemAlloc = 1800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.Equal(t, errForcedDrop, mp.ConsumeMetrics(ctx, md))
 
-	// Check ballast effect
-	ml.ballastSize = 1000
-
-	// Below memAllocLimit accounting for ballast.
-	currentMemAlloc = 800 + ml.ballastSize
-	ml.checkMemLimits()
-	assert.NoError(t, mp.ConsumeMetrics(ctx, md))
-
-	// Above memAllocLimit even accountiing for ballast.
-	currentMemAlloc = 1800 + ml.ballastSize
-	ml.checkMemLimits()
+	// Between the soft and hard limits.
+	currentMemAlloc = 900 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.Equal(t, errForcedDrop, mp.ConsumeMetrics(ctx, md))
 
-	// Restore ballast to default.
-	ml.ballastSize = 0
-
-	// Check spike limit
-	ml.usageChecker.memSpikeLimit = 512
-
-	// Below memSpikeLimit.
-	currentMemAlloc = 500
-	ml.checkMemLimits()
+	// Back below the soft limit.
+	currentMemAlloc = 800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.NoError(t, mp.ConsumeMetrics(ctx, md))
-
-	// Above memSpikeLimit.
-	currentMemAlloc = 550
-	ml.checkMemLimits()
-	assert.Equal(t, errForcedDrop, mp.ConsumeMetrics(ctx, md))
-
 }
 
 // TestTraceMemoryPressureResponse manipulates results from querying memory and
 // check expected side effects.
 func TestTraceMemoryPressureResponse(t *testing.T) {
 	var currentMemAlloc uint64
-	ml := &memoryLimiter{
-		usageChecker: memUsageChecker{
-			memAllocLimit: 1024,
-		},
-		forceDrop: atomic.NewBool(false),
-		readMemStatsFn: func(ms *runtime.MemStats) {
-			ms.Alloc = currentMemAlloc
-		},
-		obsrep: newObsReport(t),
-		logger: zap.NewNop(),
-	}
+	ml := newTestMemoryLimiter(t, &currentMemAlloc)
 	tp, err := processorhelper.NewTracesProcessor(
 		context.Background(),
 		processortest.NewNopCreateSettings(),
@@ -201,62 +175,32 @@ func TestTraceMemoryPressureResponse(t *testing.T) {
 	ctx := context.Background()
 	td := ptrace.NewTraces()
 
-	// Below memAllocLimit.
-	currentMemAlloc = 800
-	ml.checkMemLimits()
+	// Below the soft limit.
+	currentMemAlloc = 800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.NoError(t, tp.ConsumeTraces(ctx, td))
 
-	// Above memAllocLimit.
-	currentMemAlloc = 1800
-	ml.checkMemLimits()
+	// Above the hard limit.
+	currentMemAlloc = 1800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.Equal(t, errForcedDrop, tp.ConsumeTraces(ctx, td))
 
-	// Check ballast effect
-	ml.ballastSize = 1000
-
-	// Below memAllocLimit accounting for ballast.
-	currentMemAlloc = 800 + ml.ballastSize
-	ml.checkMemLimits()
-	assert.NoError(t, tp.ConsumeTraces(ctx, td))
-
-	// Above memAllocLimit even accountiing for ballast.
-	currentMemAlloc = 1800 + ml.ballastSize
-	ml.checkMemLimits()
+	// Between the soft and hard limits.
+	currentMemAlloc = 900 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.Equal(t, errForcedDrop, tp.ConsumeTraces(ctx, td))
 
-	// Restore ballast to default.
-	ml.ballastSize = 0
-
-	// Check spike limit
-	ml.usageChecker.memSpikeLimit = 512
-
-	// Below memSpikeLimit.
-	currentMemAlloc = 500
-	ml.checkMemLimits()
+	// Back below the soft limit.
+	currentMemAlloc = 800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.NoError(t, tp.ConsumeTraces(ctx, td))
-
-	// Above memSpikeLimit.
-	currentMemAlloc = 550
-	ml.checkMemLimits()
-	assert.Equal(t, errForcedDrop, tp.ConsumeTraces(ctx, td))
-
 }
 
 // TestLogMemoryPressureResponse manipulates results from querying memory and
 // check expected side effects.
 func TestLogMemoryPressureResponse(t *testing.T) {
 	var currentMemAlloc uint64
-	ml := &memoryLimiter{
-		usageChecker: memUsageChecker{
-			memAllocLimit: 1024,
-		},
-		forceDrop: atomic.NewBool(false),
-		readMemStatsFn: func(ms *runtime.MemStats) {
-			ms.Alloc = currentMemAlloc
-		},
-		obsrep: newObsReport(t),
-		logger: zap.NewNop(),
-	}
+	ml := newTestMemoryLimiter(t, &currentMemAlloc)
 	lp, err := processorhelper.NewLogsProcessor(
 		context.Background(),
 		processortest.NewNopCreateSettings(),
@@ -270,185 +214,23 @@ func TestLogMemoryPressureResponse(t *testing.T) {
 	ctx := context.Background()
 	ld := plog.NewLogs()
 
-	// Below memAllocLimit.
-	currentMemAlloc = 800
-	ml.checkMemLimits()
+	// Below the soft limit.
+	currentMemAlloc = 800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.NoError(t, lp.ConsumeLogs(ctx, ld))
 
-	// Above memAllocLimit.
-	currentMemAlloc = 1800
-	ml.checkMemLimits()
+	// Above the hard limit.
+	currentMemAlloc = 1800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.Equal(t, errForcedDrop, lp.ConsumeLogs(ctx, ld))
 
-	// Check ballast effect
-	ml.ballastSize = 1000
-
-	// Below memAllocLimit accounting for ballast.
-	currentMemAlloc = 800 + ml.ballastSize
-	ml.checkMemLimits()
-	assert.NoError(t, lp.ConsumeLogs(ctx, ld))
-
-	// Above memAllocLimit even accountiing for ballast.
-	currentMemAlloc = 1800 + ml.ballastSize
-	ml.checkMemLimits()
+	// Between the soft and hard limits.
+	currentMemAlloc = 900 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.Equal(t, errForcedDrop, lp.ConsumeLogs(ctx, ld))
 
-	// Restore ballast to default.
-	ml.ballastSize = 0
-
-	// Check spike limit
-	ml.usageChecker.memSpikeLimit = 512
-
-	// Below memSpikeLimit.
-	currentMemAlloc = 500
-	ml.checkMemLimits()
+	// Back below the soft limit.
+	currentMemAlloc = 800 * mibBytes
+	ml.memLimiter.CheckMemLimits()
 	assert.NoError(t, lp.ConsumeLogs(ctx, ld))
-
-	// Above memSpikeLimit.
-	currentMemAlloc = 550
-	ml.checkMemLimits()
-	assert.Equal(t, errForcedDrop, lp.ConsumeLogs(ctx, ld))
-}
-
-func TestGetDecision(t *testing.T) {
-	t.Run("fixed_limit", func(t *testing.T) {
-		d, err := getMemUsageChecker(&Config{MemoryLimitMiB: 100, MemorySpikeLimitMiB: 20}, zap.NewNop())
-		require.NoError(t, err)
-		assert.Equal(t, &memUsageChecker{
-			memAllocLimit: 100 * mibBytes,
-			memSpikeLimit: 20 * mibBytes,
-		}, d)
-	})
-	t.Run("fixed_limit_error", func(t *testing.T) {
-		d, err := getMemUsageChecker(&Config{MemoryLimitMiB: 20, MemorySpikeLimitMiB: 100}, zap.NewNop())
-		require.Error(t, err)
-		assert.Nil(t, d)
-	})
-
-	t.Cleanup(func() {
-		getMemoryFn = iruntime.TotalMemory
-	})
-	getMemoryFn = func() (uint64, error) {
-		return 100 * mibBytes, nil
-	}
-	t.Run("percentage_limit", func(t *testing.T) {
-		d, err := getMemUsageChecker(&Config{MemoryLimitPercentage: 50, MemorySpikePercentage: 10}, zap.NewNop())
-		require.NoError(t, err)
-		assert.Equal(t, &memUsageChecker{
-			memAllocLimit: 50 * mibBytes,
-			memSpikeLimit: 10 * mibBytes,
-		}, d)
-	})
-	t.Run("percentage_limit_error", func(t *testing.T) {
-		d, err := getMemUsageChecker(&Config{MemoryLimitPercentage: 101, MemorySpikePercentage: 10}, zap.NewNop())
-		require.Error(t, err)
-		assert.Nil(t, d)
-		d, err = getMemUsageChecker(&Config{MemoryLimitPercentage: 99, MemorySpikePercentage: 101}, zap.NewNop())
-		require.Error(t, err)
-		assert.Nil(t, d)
-	})
-}
-
-func TestDropDecision(t *testing.T) {
-	decison1000Limit30Spike30, err := newPercentageMemUsageChecker(1000, 60, 30)
-	require.NoError(t, err)
-	decison1000Limit60Spike50, err := newPercentageMemUsageChecker(1000, 60, 50)
-	require.NoError(t, err)
-	decison1000Limit40Spike20, err := newPercentageMemUsageChecker(1000, 40, 20)
-	require.NoError(t, err)
-	decison1000Limit40Spike60, err := newPercentageMemUsageChecker(1000, 40, 60)
-	require.Error(t, err)
-	assert.Nil(t, decison1000Limit40Spike60)
-
-	tests := []struct {
-		name         string
-		usageChecker memUsageChecker
-		ms           *runtime.MemStats
-		shouldDrop   bool
-	}{
-		{
-			name:         "should drop over limit",
-			usageChecker: *decison1000Limit30Spike30,
-			ms:           &runtime.MemStats{Alloc: 600},
-			shouldDrop:   true,
-		},
-		{
-			name:         "should not drop",
-			usageChecker: *decison1000Limit30Spike30,
-			ms:           &runtime.MemStats{Alloc: 100},
-			shouldDrop:   false,
-		},
-		{
-			name: "should not drop spike, fixed usageChecker",
-			usageChecker: memUsageChecker{
-				memAllocLimit: 600,
-				memSpikeLimit: 500,
-			},
-			ms:         &runtime.MemStats{Alloc: 300},
-			shouldDrop: true,
-		},
-		{
-			name:         "should drop, spike, percentage usageChecker",
-			usageChecker: *decison1000Limit60Spike50,
-			ms:           &runtime.MemStats{Alloc: 300},
-			shouldDrop:   true,
-		},
-		{
-			name:         "should drop, spike, percentage usageChecker",
-			usageChecker: *decison1000Limit40Spike20,
-			ms:           &runtime.MemStats{Alloc: 250},
-			shouldDrop:   true,
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			shouldDrop := test.usageChecker.aboveSoftLimit(test.ms)
-			assert.Equal(t, test.shouldDrop, shouldDrop)
-		})
-	}
-}
-
-func TestBallastSize(t *testing.T) {
-	cfg := createDefaultConfig().(*Config)
-	cfg.CheckInterval = 10 * time.Second
-	cfg.MemoryLimitMiB = 1024
-	got, err := newMemoryLimiter(processortest.NewNopCreateSettings(), cfg)
-	require.NoError(t, err)
-	require.NoError(t, got.start(context.Background(), &host{ballastSize: 113}))
-	assert.Equal(t, uint64(113), got.ballastSize)
-	require.NoError(t, got.shutdown(context.Background()))
-}
-
-type host struct {
-	ballastSize uint64
-	component.Host
-}
-
-func (h *host) GetExtensions() map[component.ID]component.Component {
-	ret := make(map[component.ID]component.Component)
-	ret[component.NewID("ballast")] = &ballastExtension{ballastSize: h.ballastSize}
-	return ret
-}
-
-type ballastExtension struct {
-	ballastSize uint64
-	component.StartFunc
-	component.ShutdownFunc
-}
-
-func (be *ballastExtension) GetBallastSize() uint64 {
-	return be.ballastSize
-}
-
-func newObsReport(t *testing.T) *obsreport.Processor {
-	set := obsreport.ProcessorSettings{
-		ProcessorID:             component.NewID(typeStr),
-		ProcessorCreateSettings: processortest.NewNopCreateSettings(),
-	}
-	set.ProcessorCreateSettings.MetricsLevel = configtelemetry.LevelNone
-
-	proc, err := obsreport.NewProcessor(set)
-	require.NoError(t, err)
-
-	return proc
 }
@@ -48,6 +48,9 @@ type Config struct {
 	config.ReceiverSettings `mapstructure:",squash"`
 	// Protocols is the configuration for the supported protocols, currently gRPC and HTTP (Proto and JSON).
 	Protocols `mapstructure:"protocols"`
+	// MemoryLimiter is the ID of the extension queried before reading each request, so that the data is
+	// refused early when the memory usage is too high. Disabled if not set.
+	MemoryLimiter *component.ID `mapstructure:"memory_limiter"`
 }
 
 var _ component.Config = (*Config)(nil)
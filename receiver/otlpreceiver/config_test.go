@@ -52,6 +52,19 @@ func TestUnmarshalConfigOnlyGRPC(t *testing.T) {
 	assert.Equal(t, defaultOnlyGRPC, cfg)
 }
 
+func TestUnmarshalConfigMemoryLimiter(t *testing.T) {
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "memory_limiter.yaml"))
+	require.NoError(t, err)
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	assert.NoError(t, component.UnmarshalConfig(cm, cfg))
+
+	memoryLimiterID := component.NewIDWithName("memory_limiter", "receivers")
+	defaultMemoryLimiter := factory.CreateDefaultConfig().(*Config)
+	defaultMemoryLimiter.MemoryLimiter = &memoryLimiterID
+	assert.Equal(t, defaultMemoryLimiter, cfg)
+}
+
 func TestUnmarshalConfigOnlyHTTP(t *testing.T) {
 	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "only_http.yaml"))
 	require.NoError(t, err)
@@ -26,6 +26,7 @@ import (
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/errors"
 )
 
 const (
@@ -37,6 +38,7 @@ var (
 	ErrNoLogsConsumer      = fmt.Errorf("no logs consumer")
 	ErrNoTracesConsumer    = fmt.Errorf("no traces consumer")
 	ErrUnrecognizedPayload = fmt.Errorf("unrecognized OTLP payload")
+	ErrMemoryLimited       = fmt.Errorf("data refused due to high memory usage")
 )
 
 type Consumers interface {
@@ -52,14 +54,17 @@ type Receiver struct {
 	telemetry   component.TelemetrySettings
 	obsrecv     *obsreport.Receiver
 	newConsumer func() arrowRecord.ConsumerAPI
+	mustRefuse  func() bool
 }
 
-// New creates a new Receiver reference.
+// New creates a new Receiver reference. The batches are refused, before their
+// records are decoded, when mustRefuse returns true; nil never refuses them.
 func New(
 	id component.ID,
 	cs Consumers,
 	set component.ReceiverCreateSettings,
 	newConsumer func() arrowRecord.ConsumerAPI,
+	mustRefuse func() bool,
 ) (*Receiver, error) {
 	obs, err := obsreport.NewReceiver(obsreport.ReceiverSettings{
 		ReceiverID:             id,
@@ -69,11 +74,15 @@ func New(
 	if err != nil {
 		return nil, err
 	}
+	if mustRefuse == nil {
+		mustRefuse = func() bool { return false }
+	}
 	return &Receiver{
 		Consumers:   cs,
 		obsrecv:     obs,
 		telemetry:   set.TelemetrySettings,
 		newConsumer: newConsumer,
+		mustRefuse:  mustRefuse,
 	}, nil
 }
 
@@ -101,8 +110,14 @@ func (r *Receiver) ArrowStream(serverStream arrowpb.ArrowStreamService_ArrowStre
 		}
 
 		// Process records: an error in this code path does
-		// not necessarily break the stream.
-		err = r.processRecords(ctx, ac, req)
+		// not necessarily break the stream. When the memory usage
+		// is too high the records are not even decoded.
+		refused := r.mustRefuse()
+		if refused {
+			err = consumererror.NewRetryable(ErrMemoryLimited, errors.MemoryLimitedRetryDelay)
+		} else {
+			err = r.processRecords(ctx, ac, req)
+		}
 
 		// Note: Statuses can be batched: TODO: should we?
 		resp := &arrowpb.BatchStatus{}
@@ -119,6 +134,11 @@ func (r *Receiver) ArrowStream(serverStream arrowpb.ArrowStreamService_ArrowStre
 				status.ErrorCode = arrowpb.ErrorCode_INVALID_ARGUMENT
 			} else {
 				status.ErrorCode = arrowpb.ErrorCode_UNAVAILABLE
+				// The retryable errors, e.g. when the sending queue of an exporter is full, tell the client when
+				// to send the batch again.
+				if retryAfter := consumererror.RetryAfter(err); retryAfter > 0 {
+					status.RetryInfo = &arrowpb.RetryInfo{RetryDelay: int64(retryAfter)}
+				}
 			}
 		}
 		resp.Statuses = append(resp.Statuses, status)
@@ -127,6 +147,12 @@ func (r *Receiver) ArrowStream(serverStream arrowpb.ArrowStreamService_ArrowStre
 		if err != nil {
 			return err
 		}
+
+		if refused {
+			// The following batches may depend on the dictionaries of the
+			// refused one, end the stream so that the client starts over.
+			return errors.NewMemoryLimitedStatus().Err()
+		}
 	}
 }
 
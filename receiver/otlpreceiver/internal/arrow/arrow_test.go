@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	arrowpb "github.com/f5/otel-arrow-adapter/api/collector/arrow/v1"
 	arrowCollectorMock "github.com/f5/otel-arrow-adapter/api/collector/arrow/v1/mock"
@@ -30,6 +31,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	otelAssert "github.com/f5/otel-arrow-adapter/pkg/otel/assert"
 
@@ -76,6 +79,8 @@ type commonTestCase struct {
 
 	ctxCall  *gomock.Call
 	recvCall *gomock.Call
+
+	mustRefuse func() bool
 }
 
 type testChannel interface {
@@ -280,6 +285,20 @@ func statusInvalidFor(batchID string, msg string) *arrowpb.BatchStatus {
 	}
 }
 
+func statusMemoryLimitedFor(batchID string) *arrowpb.BatchStatus {
+	return &arrowpb.BatchStatus{
+		Statuses: []*arrowpb.StatusMessage{
+			{
+				BatchId:      batchID,
+				StatusCode:   arrowpb.StatusCode_ERROR,
+				ErrorCode:    arrowpb.ErrorCode_UNAVAILABLE,
+				ErrorMessage: ErrMemoryLimited.Error(),
+				RetryInfo:    &arrowpb.RetryInfo{RetryDelay: int64(time.Second)},
+			},
+		},
+	}
+}
+
 func (ctc *commonTestCase) newRealConsumer() arrowRecord.ConsumerAPI {
 	cons := arrowRecordMock.NewMockConsumerAPI(ctc.ctrl)
 	real := arrowRecord.NewConsumer()
@@ -312,6 +331,7 @@ func (ctc *commonTestCase) start(newConsumer func() arrowRecord.ConsumerAPI) {
 			BuildInfo:         component.NewDefaultBuildInfo(),
 		},
 		newConsumer,
+		ctc.mustRefuse,
 	)
 	if err != nil {
 		// it would be because obsreport.NewReceiver failed, this is
@@ -387,6 +407,31 @@ func TestReceiverMetrics(t *testing.T) {
 	require.True(t, errors.Is(err, context.Canceled), "for %v", err)
 }
 
+func TestReceiverMemoryLimited(t *testing.T) {
+	tc := healthyTestChannel{}
+	ctc := newCommonTestCase(t, tc)
+	ctc.mustRefuse = func() bool { return true }
+
+	td := testdata.GenerateTraces(2)
+	batch, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
+	require.NoError(t, err)
+
+	ctc.stream.EXPECT().Send(statusMemoryLimitedFor(batch.BatchId)).Times(1).Return(nil)
+
+	// The records of the refused batch are never decoded.
+	ctc.start(func() arrowRecord.ConsumerAPI {
+		cons := arrowRecordMock.NewMockConsumerAPI(ctc.ctrl)
+		cons.EXPECT().Close().Times(1).Return(nil)
+		return cons
+	})
+	ctc.putBatch(batch, nil)
+
+	err = ctc.wait()
+	s, ok := status.FromError(err)
+	require.True(t, ok, "for %v", err)
+	assert.Equal(t, codes.ResourceExhausted, s.Code())
+}
+
 func TestReceiverRecvError(t *testing.T) {
 	tc := healthyTestChannel{}
 	ctc := newCommonTestCase(t, tc)
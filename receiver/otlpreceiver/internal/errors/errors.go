@@ -0,0 +1,75 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors // import "go.opentelemetry.io/collector/receiver/otlpreceiver/internal/errors"
+
+import (
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+// GetStatusFromError returns the error to be sent back to the client for the error returned by the next consumer.
+// The retryable errors, e.g. returned when the sending queue of an exporter is full, are converted to an UNAVAILABLE
+// status with the retry hint in its details, so that the client sends the data again later. The permanent errors take
+// precedence: retrying them is pointless. Other errors are returned unchanged.
+func GetStatusFromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if !consumererror.IsRetryable(err) || consumererror.IsPermanent(err) {
+		return err
+	}
+	s := status.New(codes.Unavailable, err.Error())
+	if retryAfter := consumererror.RetryAfter(err); retryAfter > 0 {
+		// Adding the details only fails if they cannot be marshaled, keep the status without them then.
+		if sd, detailsErr := s.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); detailsErr == nil {
+			s = sd
+		}
+	}
+	return s.Err()
+}
+
+// GetRetryAfter returns the retry hint found in the details of the status, 0 if there is none.
+func GetRetryAfter(s *status.Status) time.Duration {
+	for _, detail := range s.Details() {
+		if ri, ok := detail.(*errdetails.RetryInfo); ok && ri.GetRetryDelay() != nil {
+			return ri.GetRetryDelay().AsDuration()
+		}
+	}
+	return 0
+}
+
+// MemoryLimitedRetryDelay is the retry hint sent to the client when the data is refused because of high memory usage.
+const MemoryLimitedRetryDelay = time.Second
+
+// NewMemoryLimitedStatus returns the RESOURCE_EXHAUSTED status sent back to the client when the data is refused,
+// before being read, because the memory usage is above the limit. The retry hint tells the client that the refusal is
+// temporary and when to send the data again.
+func NewMemoryLimitedStatus() *status.Status {
+	s := status.New(codes.ResourceExhausted, "data refused due to high memory usage")
+	// Adding the details only fails if they cannot be marshaled, keep the status without them then.
+	if sd, err := s.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(MemoryLimitedRetryDelay)}); err == nil {
+		s = sd
+	}
+	return s
+}
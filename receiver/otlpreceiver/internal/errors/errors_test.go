@@ -0,0 +1,60 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+func TestGetStatusFromError(t *testing.T) {
+	assert.NoError(t, GetStatusFromError(nil))
+
+	err := errors.New("my error")
+	assert.Equal(t, err, GetStatusFromError(err))
+
+	permanent := consumererror.NewPermanent(consumererror.NewRetryable(err, time.Second))
+	assert.Equal(t, permanent, GetStatusFromError(permanent))
+
+	grpcErr := status.Error(codes.ResourceExhausted, "exhausted")
+	assert.Equal(t, grpcErr, GetStatusFromError(grpcErr))
+
+	s, ok := status.FromError(GetStatusFromError(consumererror.NewRetryable(err, 5*time.Second)))
+	require.True(t, ok)
+	assert.Equal(t, codes.Unavailable, s.Code())
+	assert.Equal(t, "my error", s.Message())
+	assert.Equal(t, 5*time.Second, GetRetryAfter(s))
+
+	s, ok = status.FromError(GetStatusFromError(consumererror.NewRetryable(err, 0)))
+	require.True(t, ok)
+	assert.Equal(t, codes.Unavailable, s.Code())
+	assert.Empty(t, s.Details())
+	assert.Equal(t, time.Duration(0), GetRetryAfter(s))
+}
+
+func TestNewMemoryLimitedStatus(t *testing.T) {
+	s := NewMemoryLimitedStatus()
+	assert.Equal(t, codes.ResourceExhausted, s.Code())
+	assert.Equal(t, "data refused due to high memory usage", s.Message())
+	assert.Equal(t, MemoryLimitedRetryDelay, GetRetryAfter(s))
+}
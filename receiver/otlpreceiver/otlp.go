@@ -26,6 +26,7 @@ import (
 	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/tap"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configgrpc"
@@ -37,11 +38,18 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/arrow"
+	internalerrors "go.opentelemetry.io/collector/receiver/otlpreceiver/internal/errors"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/logs"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/metrics"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/trace"
 )
 
+// memoryLimiterExtension is implemented by the extensions telling the receivers
+// to refuse the data because the memory usage is too high.
+type memoryLimiterExtension interface {
+	MustRefuse() bool
+}
+
 // otlpReceiver is the type that exposes Trace and Metrics reception.
 type otlpReceiver struct {
 	cfg        *Config
@@ -55,6 +63,8 @@ type otlpReceiver struct {
 	arrowReceiver   *arrow.Receiver
 	shutdownWG      sync.WaitGroup
 
+	memLimiter memoryLimiterExtension
+
 	obsrepGRPC *obsreport.Receiver
 	obsrepHTTP *obsreport.Receiver
 
@@ -133,7 +143,11 @@ func (r *otlpReceiver) startHTTPServer(cfg *confighttp.HTTPServerSettings, host
 func (r *otlpReceiver) startProtocolServers(host component.Host) error {
 	var err error
 	if r.cfg.GRPC != nil {
-		r.serverGRPC, err = r.cfg.GRPC.ToServer(host, r.settings.TelemetrySettings)
+		var opts []grpc.ServerOption
+		if r.memLimiter != nil {
+			opts = append(opts, grpc.InTapHandle(r.tapHandle))
+		}
+		r.serverGRPC, err = r.cfg.GRPC.ToServer(host, r.settings.TelemetrySettings, opts...)
 		if err != nil {
 			return err
 		}
@@ -153,7 +167,7 @@ func (r *otlpReceiver) startProtocolServers(host component.Host) error {
 		if r.cfg.Arrow != nil && r.cfg.Arrow.Enabled {
 			r.arrowReceiver, err = arrow.New(r.settings.ID, arrow.Consumers(r), r.settings, func() arrowRecord.ConsumerAPI {
 				return arrowRecord.NewConsumer()
-			})
+			}, r.mustRefuse)
 			if err != nil {
 				return err
 			}
@@ -166,10 +180,14 @@ func (r *otlpReceiver) startProtocolServers(host component.Host) error {
 		}
 	}
 	if r.cfg.HTTP != nil {
+		var handler http.Handler = r.httpMux
+		if r.memLimiter != nil {
+			handler = r.memoryLimiterHandler(handler)
+		}
 		r.serverHTTP, err = r.cfg.HTTP.ToServer(
 			host,
 			r.settings.TelemetrySettings,
-			r.httpMux,
+			handler,
 			confighttp.WithErrorHandler(errorHandler),
 		)
 		if err != nil {
@@ -188,9 +206,53 @@ func (r *otlpReceiver) startProtocolServers(host component.Host) error {
 // Start runs the trace receiver on the gRPC server. Currently
 // it also enables the metrics receiver too.
 func (r *otlpReceiver) Start(_ context.Context, host component.Host) error {
+	if r.cfg.MemoryLimiter != nil {
+		memLimiter, err := getMemoryLimiterExtension(host, *r.cfg.MemoryLimiter)
+		if err != nil {
+			return err
+		}
+		r.memLimiter = memLimiter
+	}
 	return r.startProtocolServers(host)
 }
 
+func getMemoryLimiterExtension(host component.Host, id component.ID) (memoryLimiterExtension, error) {
+	ext, ok := host.GetExtensions()[id]
+	if !ok {
+		return nil, fmt.Errorf("memory limiter extension %q not found", id)
+	}
+	memLimiter, ok := ext.(memoryLimiterExtension)
+	if !ok {
+		return nil, fmt.Errorf("extension %q is not a memory limiter", id)
+	}
+	return memLimiter, nil
+}
+
+// mustRefuse returns true when the data must be refused, before being read, because the memory usage is too high.
+func (r *otlpReceiver) mustRefuse() bool {
+	return r.memLimiter != nil && r.memLimiter.MustRefuse()
+}
+
+// tapHandle refuses the new gRPC streams, hence the export requests, before their messages are read
+// when the memory usage is too high.
+func (r *otlpReceiver) tapHandle(ctx context.Context, _ *tap.Info) (context.Context, error) {
+	if r.mustRefuse() {
+		return ctx, internalerrors.NewMemoryLimitedStatus().Err()
+	}
+	return ctx, nil
+}
+
+// memoryLimiterHandler refuses the HTTP requests before their body is read when the memory usage is too high.
+func (r *otlpReceiver) memoryLimiterHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if r.mustRefuse() {
+			writeMemoryLimited(resp, req)
+			return
+		}
+		next.ServeHTTP(resp, req)
+	})
+}
+
 // Shutdown is a method to turn off receiving.
 func (r *otlpReceiver) Shutdown(ctx context.Context) error {
 	var err error
@@ -32,6 +32,8 @@ import (
 	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -47,6 +49,7 @@ import (
 	"go.opentelemetry.io/collector/config/configtelemetry"
 	"go.opentelemetry.io/collector/config/configtls"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/internal/testdata"
 	"go.opentelemetry.io/collector/internal/testutil"
@@ -754,6 +757,171 @@ func TestOTLPReceiverHTTPTracesIngestTest(t *testing.T) {
 	require.NoError(t, tt.CheckReceiverTraces("http", int64(expectedReceivedBatches), int64(expectedIngestionBlockedRPCs)))
 }
 
+// TestOTLPReceiverRetryableErrors checks that the retryable errors of the next consumer, e.g. when the sending queue
+// of an exporter is full, ask the clients to retry later.
+func TestOTLPReceiverRetryableErrors(t *testing.T) {
+	sink := &errOrSinkConsumer{TracesSink: new(consumertest.TracesSink)}
+	sink.SetConsumeError(consumererror.NewRetryable(errors.New("sending_queue is full"), 2500*time.Millisecond))
+	td := testdata.GenerateTraces(1)
+
+	t.Run("grpc", func(t *testing.T) {
+		addr := testutil.GetAvailableLocalAddress(t)
+		ocr := newGRPCReceiver(t, addr, sink, nil)
+		require.NoError(t, ocr.Start(context.Background(), componenttest.NewNopHost()))
+		t.Cleanup(func() { require.NoError(t, ocr.Shutdown(context.Background())) })
+
+		cc, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, cc.Close())
+		}()
+
+		_, err = ptraceotlp.NewGRPCClient(cc).Export(context.Background(), ptraceotlp.NewExportRequestFromTraces(td))
+		errStatus, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.Unavailable, errStatus.Code())
+		require.Len(t, errStatus.Details(), 1)
+		assert.Equal(t, 2500*time.Millisecond, errStatus.Details()[0].(*errdetails.RetryInfo).GetRetryDelay().AsDuration())
+	})
+
+	t.Run("http", func(t *testing.T) {
+		addr := testutil.GetAvailableLocalAddress(t)
+		ocr := newHTTPReceiver(t, addr, sink, nil)
+		require.NoError(t, ocr.Start(context.Background(), componenttest.NewNopHost()))
+		t.Cleanup(func() { require.NoError(t, ocr.Shutdown(context.Background())) })
+
+		pbBytes, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/v1/traces", bytes.NewReader(pbBytes))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", pbContentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		respBytes, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "3", resp.Header.Get("Retry-After"))
+		errStatus := &spb.Status{}
+		require.NoError(t, proto.Unmarshal(respBytes, errStatus))
+		assert.Equal(t, codes.Unavailable, codes.Code(errStatus.Code))
+	})
+
+	assert.Empty(t, sink.AllTraces())
+}
+
+var memoryLimiterID = component.NewID("memory_limiter")
+
+type memoryLimiterExt struct {
+	component.StartFunc
+	component.ShutdownFunc
+	mustRefuse *atomic.Bool
+}
+
+func (ml *memoryLimiterExt) MustRefuse() bool {
+	return ml.mustRefuse.Load()
+}
+
+type memoryLimiterHost struct {
+	component.Host
+	ext component.Component
+}
+
+func (h *memoryLimiterHost) GetExtensions() map[component.ID]component.Component {
+	return map[component.ID]component.Component{memoryLimiterID: h.ext}
+}
+
+// TestOTLPReceiverMemoryLimited checks that the requests are refused, before being read, while the memory
+// limiter extension says the memory usage is too high.
+func TestOTLPReceiverMemoryLimited(t *testing.T) {
+	sink := new(consumertest.TracesSink)
+	ext := &memoryLimiterExt{mustRefuse: atomic.NewBool(true)}
+	host := &memoryLimiterHost{Host: componenttest.NewNopHost(), ext: ext}
+	td := testdata.GenerateTraces(1)
+
+	t.Run("grpc", func(t *testing.T) {
+		addr := testutil.GetAvailableLocalAddress(t)
+		factory := NewFactory()
+		cfg := factory.CreateDefaultConfig().(*Config)
+		cfg.GRPC.NetAddr.Endpoint = addr
+		cfg.HTTP = nil
+		cfg.MemoryLimiter = &memoryLimiterID
+		ocr := newReceiver(t, factory, cfg, otlpReceiverID, sink, nil)
+		require.NoError(t, ocr.Start(context.Background(), host))
+		t.Cleanup(func() { require.NoError(t, ocr.Shutdown(context.Background())) })
+
+		cc, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, cc.Close())
+		}()
+
+		_, err = ptraceotlp.NewGRPCClient(cc).Export(context.Background(), ptraceotlp.NewExportRequestFromTraces(td))
+		errStatus, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.ResourceExhausted, errStatus.Code())
+	})
+
+	t.Run("http", func(t *testing.T) {
+		addr := testutil.GetAvailableLocalAddress(t)
+		factory := NewFactory()
+		cfg := factory.CreateDefaultConfig().(*Config)
+		cfg.HTTP.Endpoint = addr
+		cfg.GRPC = nil
+		cfg.MemoryLimiter = &memoryLimiterID
+		ocr := newReceiver(t, factory, cfg, otlpReceiverID, sink, nil)
+		require.NoError(t, ocr.Start(context.Background(), host))
+		t.Cleanup(func() { require.NoError(t, ocr.Shutdown(context.Background())) })
+
+		pbBytes, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/v1/traces", bytes.NewReader(pbBytes))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", pbContentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		respBytes, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+		errStatus := &spb.Status{}
+		require.NoError(t, proto.Unmarshal(respBytes, errStatus))
+		assert.Equal(t, codes.ResourceExhausted, codes.Code(errStatus.Code))
+
+		// The requests are accepted again once the memory usage is back within the limits.
+		ext.mustRefuse.Store(false)
+		req, err = http.NewRequest(http.MethodPost, "http://"+addr+"/v1/traces", bytes.NewReader(pbBytes))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", pbContentType)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		ext.mustRefuse.Store(true)
+	})
+
+	assert.Len(t, sink.AllTraces(), 1)
+}
+
+func TestOTLPReceiverMemoryLimiterNotFound(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.GRPC.NetAddr.Endpoint = testutil.GetAvailableLocalAddress(t)
+	cfg.HTTP = nil
+	cfg.MemoryLimiter = &memoryLimiterID
+	ocr := newReceiver(t, factory, cfg, otlpReceiverID, new(consumertest.TracesSink), nil)
+	assert.EqualError(t, ocr.Start(context.Background(), componenttest.NewNopHost()), `memory limiter extension "memory_limiter" not found`)
+
+	host := &memoryLimiterHost{Host: componenttest.NewNopHost(), ext: &struct {
+		component.StartFunc
+		component.ShutdownFunc
+	}{}}
+	assert.EqualError(t, ocr.Start(context.Background(), host), `extension "memory_limiter" is not a memory limiter`)
+}
+
 func TestGRPCInvalidTLSCredentials(t *testing.T) {
 	cfg := &Config{
 		Protocols: Protocols{
@@ -16,12 +16,15 @@ package otlpreceiver // import "go.opentelemetry.io/collector/receiver/otlprecei
 
 import (
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 
 	spb "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/errors"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/logs"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/metrics"
 	"go.opentelemetry.io/collector/receiver/otlpreceiver/internal/trace"
@@ -124,14 +127,39 @@ func readAndCloseBody(resp http.ResponseWriter, req *http.Request, encoder encod
 }
 
 // writeError encodes the HTTP error inside a rpc.Status message as required by the OTLP protocol.
+// An UNAVAILABLE status is sent with the 503 status code and a RESOURCE_EXHAUSTED one with the 429 status code,
+// both with their retry hint as the Retry-After header.
 func writeError(w http.ResponseWriter, encoder encoder, err error, statusCode int) {
 	s, ok := status.FromError(err)
 	if !ok {
 		s = errorMsgToStatus(err.Error(), statusCode)
 	}
+	switch s.Code() {
+	case codes.Unavailable:
+		statusCode = http.StatusServiceUnavailable
+		setRetryAfter(w, s)
+	case codes.ResourceExhausted:
+		statusCode = http.StatusTooManyRequests
+		setRetryAfter(w, s)
+	}
 	writeStatusResponse(w, encoder, statusCode, s.Proto())
 }
 
+func setRetryAfter(w http.ResponseWriter, s *status.Status) {
+	if retryAfter := errors.GetRetryAfter(s); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+}
+
+// writeMemoryLimited refuses the request, without reading its body, because the memory usage is too high.
+func writeMemoryLimited(w http.ResponseWriter, req *http.Request) {
+	var enc encoder = pbEncoder
+	if req.Header.Get("Content-Type") == jsonContentType {
+		enc = jsEncoder
+	}
+	writeError(w, enc, errors.NewMemoryLimitedStatus().Err(), http.StatusTooManyRequests)
+}
+
 // errorHandler encodes the HTTP error message inside a rpc.Status message as required
 // by the OTLP protocol.
 func errorHandler(w http.ResponseWriter, r *http.Request, errMsg string, statusCode int) {
@@ -0,0 +1,320 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service // import "go.opentelemetry.io/collector/service"
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/service/internal/components"
+)
+
+// connectorKey identifies a built connector. A connector is built once for each pair of
+// data types it connects, the data type of the pipelines using it as exporter and the
+// data type of the pipelines using it as receiver.
+type connectorKey struct {
+	id           component.ID
+	exporterType component.DataType
+	receiverType component.DataType
+}
+
+type builtConnector struct {
+	connectorKey
+	comp component.Component
+}
+
+// connectorsGraph links the pipelines through the connectors: a connector used as exporter
+// in a pipeline sends the data to all the pipelines using it as receiver, provided that the
+// connector supports the data types of both pipelines.
+type connectorsGraph struct {
+	connectors      *connector.Builder
+	factories       map[component.Type]connector.Factory
+	pipelineConfigs map[component.ID]*PipelineConfig
+
+	// pipelineIDs are the IDs of all the pipelines, sorted to keep the errors and the build order stable.
+	pipelineIDs []component.ID
+
+	// exporterPipelines and receiverPipelines map each connector to the sorted IDs of the
+	// pipelines using it as exporter and as receiver respectively.
+	exporterPipelines map[component.ID][]component.ID
+	receiverPipelines map[component.ID][]component.ID
+}
+
+func newConnectorsGraph(set pipelinesSettings) (*connectorsGraph, error) {
+	g := &connectorsGraph{
+		connectors:        set.Connectors,
+		factories:         make(map[component.Type]connector.Factory),
+		pipelineConfigs:   set.PipelineConfigs,
+		pipelineIDs:       make([]component.ID, 0, len(set.PipelineConfigs)),
+		exporterPipelines: make(map[component.ID][]component.ID),
+		receiverPipelines: make(map[component.ID][]component.ID),
+	}
+	for pipelineID := range set.PipelineConfigs {
+		g.pipelineIDs = append(g.pipelineIDs, pipelineID)
+	}
+	sort.Slice(g.pipelineIDs, func(i, j int) bool {
+		return g.pipelineIDs[i].String() < g.pipelineIDs[j].String()
+	})
+
+	for _, pipelineID := range g.pipelineIDs {
+		pipeline := set.PipelineConfigs[pipelineID]
+		for _, expID := range pipeline.Exporters {
+			if g.isConnector(expID) {
+				g.exporterPipelines[expID] = append(g.exporterPipelines[expID], pipelineID)
+			}
+		}
+		for _, recvID := range pipeline.Receivers {
+			if g.isConnector(recvID) {
+				g.receiverPipelines[recvID] = append(g.receiverPipelines[recvID], pipelineID)
+			}
+		}
+	}
+
+	for connID := range g.exporterPipelines {
+		if err := g.addFactory(connID); err != nil {
+			return nil, err
+		}
+	}
+	for connID := range g.receiverPipelines {
+		if err := g.addFactory(connID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Every use of a connector must be paired with at least one use of the other kind,
+	// in a pipeline of a data type the connector supports.
+	for _, pipelineID := range g.pipelineIDs {
+		pipeline := set.PipelineConfigs[pipelineID]
+		for _, expID := range pipeline.Exporters {
+			if g.isConnector(expID) && len(g.downstream(expID, pipelineID.Type())) == 0 {
+				return nil, fmt.Errorf("connector %q used as exporter in pipeline %q but not used in any supported receiver pipeline", expID, pipelineID)
+			}
+		}
+		for _, recvID := range pipeline.Receivers {
+			if g.isConnector(recvID) && len(g.upstream(recvID, pipelineID.Type())) == 0 {
+				return nil, fmt.Errorf("connector %q used as receiver in pipeline %q but not used in any supported exporter pipeline", recvID, pipelineID)
+			}
+		}
+	}
+	return g, nil
+}
+
+func (g *connectorsGraph) isConnector(id component.ID) bool {
+	return g.connectors.IsConfigured(id)
+}
+
+func (g *connectorsGraph) addFactory(connID component.ID) error {
+	f, ok := g.connectors.Factory(connID.Type()).(connector.Factory)
+	if !ok {
+		return fmt.Errorf("connector factory not available for: %q", connID)
+	}
+	g.factories[connID.Type()] = f
+	return nil
+}
+
+// supports returns true if the connector can consume the exporterType data and emit receiverType data.
+func (g *connectorsGraph) supports(connID component.ID, exporterType, receiverType component.DataType) bool {
+	return connectorStability(g.factories[connID.Type()], exporterType, receiverType) != component.StabilityLevelUndefined
+}
+
+// downstream returns the pipelines receiving from the connector the data it consumes as
+// exporter in a pipeline of the given data type.
+func (g *connectorsGraph) downstream(connID component.ID, exporterType component.DataType) []component.ID {
+	var pipelineIDs []component.ID
+	for _, pipelineID := range g.receiverPipelines[connID] {
+		if g.supports(connID, exporterType, pipelineID.Type()) {
+			pipelineIDs = append(pipelineIDs, pipelineID)
+		}
+	}
+	return pipelineIDs
+}
+
+// upstream returns the pipelines exporting to the connector the data it emits as receiver
+// in a pipeline of the given data type.
+func (g *connectorsGraph) upstream(connID component.ID, receiverType component.DataType) []component.ID {
+	var pipelineIDs []component.ID
+	for _, pipelineID := range g.exporterPipelines[connID] {
+		if g.supports(connID, pipelineID.Type(), receiverType) {
+			pipelineIDs = append(pipelineIDs, pipelineID)
+		}
+	}
+	return pipelineIDs
+}
+
+// pipelineStep is a step of a path through the pipelines, entering the pipeline from the connector.
+type pipelineStep struct {
+	connID     component.ID
+	pipelineID component.ID
+}
+
+// buildOrder returns the IDs of the pipelines ordered so that every pipeline comes after
+// all the pipelines it sends data to through connectors, or an error naming the pipelines
+// and the connectors of a cycle if there is one.
+func (g *connectorsGraph) buildOrder() ([]component.ID, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[component.ID]int, len(g.pipelineIDs))
+	order := make([]component.ID, 0, len(g.pipelineIDs))
+	var path []pipelineStep
+
+	var visit func(step pipelineStep) error
+	visit = func(step pipelineStep) error {
+		state[step.pipelineID] = visiting
+		path = append(path, step)
+		for _, expID := range g.pipelineConfigs[step.pipelineID].Exporters {
+			if !g.isConnector(expID) {
+				continue
+			}
+			for _, nextID := range g.downstream(expID, step.pipelineID.Type()) {
+				next := pipelineStep{connID: expID, pipelineID: nextID}
+				switch state[nextID] {
+				case visiting:
+					return cycleError(path, next)
+				case unvisited:
+					if err := visit(next); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[step.pipelineID] = visited
+		order = append(order, step.pipelineID)
+		return nil
+	}
+
+	for _, pipelineID := range g.pipelineIDs {
+		if state[pipelineID] != unvisited {
+			continue
+		}
+		if err := visit(pipelineStep{pipelineID: pipelineID}); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// cycleError returns the error for the cycle closed by reaching next from the end of the path.
+func cycleError(path []pipelineStep, next pipelineStep) error {
+	start := 0
+	for i, step := range path {
+		if step.pipelineID == next.pipelineID {
+			start = i
+			break
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "pipeline %q", path[start].pipelineID)
+	for _, step := range path[start+1:] {
+		fmt.Fprintf(&b, " -> connector %q -> pipeline %q", step.connID, step.pipelineID)
+	}
+	fmt.Fprintf(&b, " -> connector %q -> pipeline %q", next.connID, next.pipelineID)
+	return fmt.Errorf("cycle detected: %s", b.String())
+}
+
+func connectorStability(f connector.Factory, exporterType, receiverType component.DataType) component.StabilityLevel {
+	switch exporterType {
+	case component.DataTypeTraces:
+		switch receiverType {
+		case component.DataTypeTraces:
+			return f.TracesToTracesStability()
+		case component.DataTypeMetrics:
+			return f.TracesToMetricsStability()
+		case component.DataTypeLogs:
+			return f.TracesToLogsStability()
+		}
+	case component.DataTypeMetrics:
+		switch receiverType {
+		case component.DataTypeTraces:
+			return f.MetricsToTracesStability()
+		case component.DataTypeMetrics:
+			return f.MetricsToMetricsStability()
+		case component.DataTypeLogs:
+			return f.MetricsToLogsStability()
+		}
+	case component.DataTypeLogs:
+		switch receiverType {
+		case component.DataTypeTraces:
+			return f.LogsToTracesStability()
+		case component.DataTypeMetrics:
+			return f.LogsToMetricsStability()
+		case component.DataTypeLogs:
+			return f.LogsToLogsStability()
+		}
+	}
+	return component.StabilityLevelUndefined
+}
+
+func buildConnector(ctx context.Context,
+	set connector.CreateSettings,
+	builder *connector.Builder,
+	key connectorKey,
+	nexts []baseConsumer,
+) (conn component.Component, err error) {
+	switch key.exporterType {
+	case component.DataTypeTraces:
+		switch key.receiverType {
+		case component.DataTypeTraces:
+			conn, err = builder.CreateTracesToTraces(ctx, set, buildFanOutTracesConsumer(nexts))
+		case component.DataTypeMetrics:
+			conn, err = builder.CreateTracesToMetrics(ctx, set, buildFanOutMetricsConsumer(nexts))
+		case component.DataTypeLogs:
+			conn, err = builder.CreateTracesToLogs(ctx, set, buildFanOutLogsConsumer(nexts))
+		}
+	case component.DataTypeMetrics:
+		switch key.receiverType {
+		case component.DataTypeTraces:
+			conn, err = builder.CreateMetricsToTraces(ctx, set, buildFanOutTracesConsumer(nexts))
+		case component.DataTypeMetrics:
+			conn, err = builder.CreateMetricsToMetrics(ctx, set, buildFanOutMetricsConsumer(nexts))
+		case component.DataTypeLogs:
+			conn, err = builder.CreateMetricsToLogs(ctx, set, buildFanOutLogsConsumer(nexts))
+		}
+	case component.DataTypeLogs:
+		switch key.receiverType {
+		case component.DataTypeTraces:
+			conn, err = builder.CreateLogsToTraces(ctx, set, buildFanOutTracesConsumer(nexts))
+		case component.DataTypeMetrics:
+			conn, err = builder.CreateLogsToMetrics(ctx, set, buildFanOutMetricsConsumer(nexts))
+		case component.DataTypeLogs:
+			conn, err = builder.CreateLogsToLogs(ctx, set, buildFanOutLogsConsumer(nexts))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %q connector from %s to %s: %w", set.ID, key.exporterType, key.receiverType, err)
+	}
+	if conn == nil {
+		return nil, fmt.Errorf("error creating connector %q, connection from %s to %s is not supported", set.ID, key.exporterType, key.receiverType)
+	}
+	return conn, nil
+}
+
+func connectorLogger(logger *zap.Logger, key connectorKey) *zap.Logger {
+	return logger.With(
+		zap.String(components.ZapKindKey, components.ZapKindConnector),
+		zap.String(components.ZapNameKey, key.id.String()),
+		zap.String(components.ZapExporterInPipelineKey, string(key.exporterType)),
+		zap.String(components.ZapReceiverInPipelineKey, string(key.receiverType)))
+}
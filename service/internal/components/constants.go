@@ -15,13 +15,16 @@
 package components // import "go.opentelemetry.io/collector/service/internal/components"
 
 const (
-	ZapKindKey       = "kind"
-	ZapKindReceiver  = "receiver"
-	ZapKindProcessor = "processor"
-	ZapKindExporter  = "exporter"
-	ZapKindExtension = "extension"
-	ZapKindPipeline  = "pipeline"
-	ZapNameKey       = "name"
-	ZapDataTypeKey   = "data_type"
-	ZapStabilityKey  = "stability"
+	ZapKindKey               = "kind"
+	ZapKindReceiver          = "receiver"
+	ZapKindProcessor         = "processor"
+	ZapKindExporter          = "exporter"
+	ZapKindExtension         = "extension"
+	ZapKindConnector         = "connector"
+	ZapKindPipeline          = "pipeline"
+	ZapNameKey               = "name"
+	ZapDataTypeKey           = "data_type"
+	ZapStabilityKey          = "stability"
+	ZapExporterInPipelineKey = "exporter_in_pipeline"
+	ZapReceiverInPipelineKey = "receiver_in_pipeline"
 )
@@ -0,0 +1,48 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fanoutconsumer // import "go.opentelemetry.io/collector/service/internal/fanoutconsumer"
+
+import (
+	"time"
+
+	"go.uber.org/multierr"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+// withRetryHint marks the errors combined from several consumers as retryable if any of them is, with the longest
+// retry hint, so that the data is sent again once all the overloaded consumers can take it.
+// The consumers that accepted the data receive it again as well.
+func withRetryHint(errs error) error {
+	combined := multierr.Errors(errs)
+	if len(combined) < 2 {
+		return errs
+	}
+	retryable := false
+	var retryAfter time.Duration
+	for _, err := range combined {
+		if !consumererror.IsRetryable(err) {
+			continue
+		}
+		retryable = true
+		if ra := consumererror.RetryAfter(err); ra > retryAfter {
+			retryAfter = ra
+		}
+	}
+	if !retryable {
+		return errs
+	}
+	return consumererror.NewRetryable(errs, retryAfter)
+}
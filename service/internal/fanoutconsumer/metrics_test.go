@@ -18,10 +18,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/internal/testdata"
 )
@@ -186,6 +188,23 @@ func TestMetricsWhenErrors(t *testing.T) {
 	assert.EqualValues(t, md, p3.AllMetrics()[1])
 }
 
+func TestMetricsWhenRetryableErrors(t *testing.T) {
+	p1 := consumertest.NewErr(consumererror.NewRetryable(errors.New("queue full"), time.Second))
+	p2 := consumertest.NewErr(consumererror.NewRetryable(errors.New("queue full"), 5*time.Second))
+	p3 := new(consumertest.MetricsSink)
+
+	mfc := NewMetrics([]consumer.Metrics{p1, p2, p3})
+	err := mfc.ConsumeMetrics(context.Background(), testdata.GenerateMetrics(1))
+	assert.True(t, consumererror.IsRetryable(err))
+	assert.Equal(t, 5*time.Second, consumererror.RetryAfter(err))
+	assert.Len(t, p3.AllMetrics(), 1)
+
+	mfc = NewMetrics([]consumer.Metrics{p1, consumertest.NewErr(errors.New("my error"))})
+	err = mfc.ConsumeMetrics(context.Background(), testdata.GenerateMetrics(1))
+	assert.True(t, consumererror.IsRetryable(err))
+	assert.Equal(t, time.Second, consumererror.RetryAfter(err))
+}
+
 type mutatingMetricsSink struct {
 	*consumertest.MetricsSink
 }
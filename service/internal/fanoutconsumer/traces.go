@@ -76,5 +76,5 @@ func (tsc *tracesConsumer) ConsumeTraces(ctx context.Context, td ptrace.Traces)
 	for _, tc := range tsc.pass {
 		errs = multierr.Append(errs, tc.ConsumeTraces(ctx, td))
 	}
-	return errs
+	return withRetryHint(errs)
 }
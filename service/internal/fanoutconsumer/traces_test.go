@@ -18,10 +18,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/internal/testdata"
 )
@@ -186,6 +188,23 @@ func TestTracesWhenErrors(t *testing.T) {
 	assert.EqualValues(t, td, p3.AllTraces()[1])
 }
 
+func TestTracesWhenRetryableErrors(t *testing.T) {
+	p1 := consumertest.NewErr(consumererror.NewRetryable(errors.New("queue full"), time.Second))
+	p2 := consumertest.NewErr(consumererror.NewRetryable(errors.New("queue full"), 5*time.Second))
+	p3 := new(consumertest.TracesSink)
+
+	tfc := NewTraces([]consumer.Traces{p1, p2, p3})
+	err := tfc.ConsumeTraces(context.Background(), testdata.GenerateTraces(1))
+	assert.True(t, consumererror.IsRetryable(err))
+	assert.Equal(t, 5*time.Second, consumererror.RetryAfter(err))
+	assert.Len(t, p3.AllTraces(), 1)
+
+	tfc = NewTraces([]consumer.Traces{p1, consumertest.NewErr(errors.New("my error"))})
+	err = tfc.ConsumeTraces(context.Background(), testdata.GenerateTraces(1))
+	assert.True(t, consumererror.IsRetryable(err))
+	assert.Equal(t, time.Second, consumererror.RetryAfter(err))
+}
+
 type mutatingTracesSink struct {
 	*consumertest.TracesSink
 }
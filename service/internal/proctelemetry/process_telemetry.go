@@ -186,3 +186,39 @@ func (pm *processMetrics) readMemStatsIfNeeded() {
 		pm.ms.HeapInuse -= pm.ballastSizeBytes
 	}
 }
+
+// GoMemoryLimiter is implemented by the components applying a memory limit to the Go runtime.
+type GoMemoryLimiter interface {
+	// GetMemoryLimit returns the memory limit applied, in bytes, 0 if none is applied.
+	GetMemoryLimit() uint64
+	// GetGCPercent returns the garbage collection target percentage applied.
+	GetGCPercent() int
+}
+
+// RegisterGoMemoryLimitMetrics creates the metrics reporting the settings applied to the Go runtime
+// by the memory limiter, so that the effective memory limit can be checked.
+func RegisterGoMemoryLimitMetrics(registry *metric.Registry, limiter GoMemoryLimiter) error {
+	memoryLimit, err := registry.AddInt64DerivedGauge(
+		"process/runtime/memory_limit_bytes",
+		metric.WithDescription("Memory limit applied to the Go runtime (see 'go doc runtime/debug.SetMemoryLimit'), 0 if none"),
+		metric.WithUnit(stats.UnitBytes))
+	if err != nil {
+		return err
+	}
+	if err = memoryLimit.UpsertEntry(func() int64 {
+		return int64(limiter.GetMemoryLimit())
+	}); err != nil {
+		return err
+	}
+
+	gcPercent, err := registry.AddInt64DerivedGauge(
+		"process/runtime/gc_percent",
+		metric.WithDescription("Garbage collection target percentage applied to the Go runtime (see 'go doc runtime/debug.SetGCPercent')"),
+		metric.WithUnit(stats.UnitDimensionless))
+	if err != nil {
+		return err
+	}
+	return gcPercent.UpsertEntry(func() int64 {
+		return int64(limiter.GetGCPercent())
+	})
+}
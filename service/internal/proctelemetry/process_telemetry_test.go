@@ -82,6 +82,43 @@ func TestProcessTelemetryFailToRegister(t *testing.T) {
 	}
 }
 
+type goMemoryLimiter struct {
+	memoryLimit uint64
+	gcPercent   int
+}
+
+func (ml *goMemoryLimiter) GetMemoryLimit() uint64 {
+	return ml.memoryLimit
+}
+
+func (ml *goMemoryLimiter) GetGCPercent() int {
+	return ml.gcPercent
+}
+
+func TestGoMemoryLimitTelemetry(t *testing.T) {
+	registry := metric.NewRegistry()
+	require.NoError(t, RegisterGoMemoryLimitMetrics(registry, &goMemoryLimiter{memoryLimit: 1 << 30, gcPercent: 400}))
+
+	metrics := registry.Read()
+	for metricName, expected := range map[string]int64{
+		"process/runtime/memory_limit_bytes": 1 << 30,
+		"process/runtime/gc_percent":         400,
+	} {
+		m := findMetric(metrics, metricName)
+		require.NotNil(t, m, metricName)
+		require.Len(t, m.TimeSeries, 1)
+		require.Len(t, m.TimeSeries[0].Points, 1)
+		assert.Equal(t, expected, m.TimeSeries[0].Points[0].Value.(int64), metricName)
+	}
+
+	for _, metricName := range []string{"process/runtime/memory_limit_bytes", "process/runtime/gc_percent"} {
+		registry = metric.NewRegistry()
+		_, err := registry.AddFloat64Gauge(metricName)
+		require.NoError(t, err)
+		assert.Error(t, RegisterGoMemoryLimitMetrics(registry, &goMemoryLimiter{}), metricName)
+	}
+}
+
 func findMetric(metrics []*metricdata.Metric, name string) *metricdata.Metric {
 	for _, m := range metrics {
 		if m.Descriptor.Name == name {
@@ -16,6 +16,8 @@ package testcomponents // import "go.opentelemetry.io/collector/service/internal
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
@@ -98,3 +100,26 @@ func (exp *ExampleExporter) Shutdown(context.Context) error {
 	exp.Stopped = true
 	return nil
 }
+
+// ZPagesProperties returns the status of the exporter displayed on the pipelines zPage.
+func (exp *ExampleExporter) ZPagesProperties() [][2]string {
+	return [][2]string{
+		{"Started", strconv.FormatBool(exp.Started)},
+		{"Stopped", strconv.FormatBool(exp.Stopped)},
+	}
+}
+
+// ZPagesActions returns the actions of the exporter that can be triggered from the pipelines zPage.
+func (exp *ExampleExporter) ZPagesActions() []string {
+	return []string{"clear"}
+}
+
+// HandleZPagesAction runs an action triggered from the pipelines zPage.
+func (exp *ExampleExporter) HandleZPagesAction(_ context.Context, action string, _ string) (string, error) {
+	if action != "clear" {
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+	cleared := len(exp.Traces) + len(exp.Metrics) + len(exp.Logs)
+	exp.Traces, exp.Metrics, exp.Logs = nil, nil, nil
+	return fmt.Sprintf("cleared %d batches", cleared), nil
+}
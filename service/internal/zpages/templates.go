@@ -52,6 +52,10 @@ var (
 	propertiesTableBytes    []byte
 	propertiesTableTemplate = parseTemplate("properties_table", propertiesTableBytes)
 
+	//go:embed templates/actions_form.html
+	actionsFormBytes    []byte
+	actionsFormTemplate = parseTemplate("actions_form", actionsFormBytes)
+
 	//go:embed templates/features_table.html
 	featuresTableBytes    []byte
 	featuresTableTemplate = parseTemplate("features_table", featuresTableBytes)
@@ -142,6 +146,23 @@ func WriteHTMLPropertiesTable(w io.Writer, chd PropertiesTableData) {
 	}
 }
 
+// ActionsFormData contains data for the actions form template.
+type ActionsFormData struct {
+	Name    string
+	Actions []string
+	// Result is the outcome of the last action, if any.
+	Result string
+	// Token is posted with the actions, to check that they were triggered from the form.
+	Token string
+}
+
+// WriteHTMLActionsForm writes the HTML for the form triggering the actions of a component.
+func WriteHTMLActionsForm(w io.Writer, afd ActionsFormData) {
+	if err := actionsFormTemplate.Execute(w, afd); err != nil {
+		log.Printf("zpages: executing template: %v", err)
+	}
+}
+
 // WriteHTMLPageFooter writes the footer.
 func WriteHTMLPageFooter(w io.Writer) {
 	if err := footerTemplate.Execute(w, nil); err != nil {
@@ -89,6 +89,9 @@ func TestNoCrash(t *testing.T) {
 			},
 		}})
 	})
+	assert.NotPanics(t, func() {
+		WriteHTMLActionsForm(buf, ActionsFormData{Name: "Bar", Actions: []string{"purge"}, Result: "done", Token: "token"})
+	})
 	assert.NotPanics(t, func() { WriteHTMLPageFooter(buf) })
 	assert.NotPanics(t, func() { WriteHTMLPageFooter(buf) })
 }
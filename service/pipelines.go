@@ -16,6 +16,9 @@ package service // import "go.opentelemetry.io/collector/service"
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"sort"
@@ -24,6 +27,7 @@ import (
 	"go.uber.org/zap"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/processor"
@@ -38,6 +42,9 @@ const (
 	zPipelineName  = "zpipelinename"
 	zComponentName = "zcomponentname"
 	zComponentKind = "zcomponentkind"
+	zAction        = "zaction"
+	zValue         = "zvalue"
+	zToken         = "ztoken"
 )
 
 // baseConsumer redeclared here since not public in consumer package. May consider to make that public.
@@ -56,6 +63,11 @@ type builtPipeline struct {
 	receivers  []builtComponent
 	processors []builtComponent
 	exporters  []builtComponent
+
+	// connectorsAsReceivers are the connectors feeding this pipeline, and connectorsAsExporters
+	// the connectors fed by this pipeline, one per pair of data types the connector is used with.
+	connectorsAsReceivers []builtConnector
+	connectorsAsExporters []builtConnector
 }
 
 // builtPipelines is set of all pipelines created from exporter configs.
@@ -66,6 +78,13 @@ type builtPipelines struct {
 	allExporters map[component.DataType]map[component.ID]component.Component
 
 	pipelines map[component.ID]*builtPipeline
+
+	// buildOrder lists the pipelines after all the pipelines they send data to through connectors.
+	buildOrder []component.ID
+
+	// zPagesToken is the random token embedded in the zPages actions form, which the action requests must
+	// include so that they cannot be forged by other sites.
+	zPagesToken string
 }
 
 // StartAll starts all pipelines.
@@ -73,6 +92,8 @@ type builtPipelines struct {
 // Start with exporters, processors (in reverse configured order), then receivers.
 // This is important so that components that are earlier in the pipeline and reference components that are
 // later in the pipeline do not start sending data to later components which are not yet started.
+// For the same reason, a connector is started after the processors of the pipelines it feeds, and before
+// the processors of the pipelines feeding it.
 func (bps *builtPipelines) StartAll(ctx context.Context, host component.Host) error {
 	bps.telemetry.Logger.Info("Starting exporters...")
 	for dt, expByID := range bps.allExporters {
@@ -86,8 +107,23 @@ func (bps *builtPipelines) StartAll(ctx context.Context, host component.Host) er
 		}
 	}
 
-	bps.telemetry.Logger.Info("Starting processors...")
-	for pipelineID, bp := range bps.pipelines {
+	bps.telemetry.Logger.Info("Starting processors and connectors...")
+	startedConnectors := make(map[connectorKey]struct{})
+	for _, pipelineID := range bps.buildOrder {
+		bp := bps.pipelines[pipelineID]
+		for _, conn := range bp.connectorsAsExporters {
+			if _, ok := startedConnectors[conn.connectorKey]; ok {
+				continue
+			}
+			startedConnectors[conn.connectorKey] = struct{}{}
+			connLogger := connectorLogger(bps.telemetry.Logger, conn.connectorKey)
+			connLogger.Info("Connector is starting...")
+			if err := conn.comp.Start(ctx, components.NewHostWrapper(host, connLogger)); err != nil {
+				return err
+			}
+			connLogger.Info("Connector started.")
+		}
+
 		for i := len(bp.processors) - 1; i >= 0; i-- {
 			procLogger := processorLogger(bps.telemetry.Logger, bp.processors[i].id, pipelineID)
 			procLogger.Info("Processor is starting...")
@@ -114,7 +150,7 @@ func (bps *builtPipelines) StartAll(ctx context.Context, host component.Host) er
 
 // ShutdownAll stops all pipelines.
 //
-// Shutdown order is the reverse of starting: receivers, processors, then exporters.
+// Shutdown order is the reverse of starting: receivers, processors and connectors, then exporters.
 // This gives senders a chance to send all their data to a not "shutdown" component.
 func (bps *builtPipelines) ShutdownAll(ctx context.Context) error {
 	var errs error
@@ -125,8 +161,18 @@ func (bps *builtPipelines) ShutdownAll(ctx context.Context) error {
 		}
 	}
 
-	bps.telemetry.Logger.Info("Stopping processors...")
-	for _, bp := range bps.pipelines {
+	bps.telemetry.Logger.Info("Stopping processors and connectors...")
+	stoppedConnectors := make(map[connectorKey]struct{})
+	for i := len(bps.buildOrder) - 1; i >= 0; i-- {
+		bp := bps.pipelines[bps.buildOrder[i]]
+		for _, conn := range bp.connectorsAsReceivers {
+			if _, ok := stoppedConnectors[conn.connectorKey]; ok {
+				continue
+			}
+			stoppedConnectors[conn.connectorKey] = struct{}{}
+			errs = multierr.Append(errs, conn.comp.Shutdown(ctx))
+		}
+
 		for _, p := range bp.processors {
 			errs = multierr.Append(errs, p.comp.Shutdown(ctx))
 		}
@@ -160,6 +206,24 @@ func (bps *builtPipelines) GetExporters() map[component.DataType]map[component.I
 
 func (bps *builtPipelines) HandleZPages(w http.ResponseWriter, r *http.Request) {
 	qValues := r.URL.Query()
+	// The actions change the state of the components, they are only run on POST requests with the token of the form.
+	switch {
+	case r.Method == http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.PostForm.Get(zToken)), []byte(bps.zPagesToken)) != 1 {
+			http.Error(w, "Invalid or missing action token", http.StatusForbidden)
+			return
+		}
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && qValues.Get(zAction) == "":
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "Actions must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+
 	pipelineName := qValues.Get(zPipelineName)
 	componentName := qValues.Get(zComponentName)
 	componentKind := qValues.Get(zComponentKind)
@@ -175,11 +239,83 @@ func (bps *builtPipelines) HandleZPages(w http.ResponseWriter, r *http.Request)
 		zpages.WriteHTMLComponentHeader(w, zpages.ComponentHeaderData{
 			Name: componentKind + ": " + fullName,
 		})
-		// TODO: Add config + status info.
+		comp := bps.getComponent(pipelineName, componentName, componentKind)
+		// Components can expose administrative actions by implementing the optional ZPagesActions and
+		// HandleZPagesAction funcs, the actions are run on POST requests before reporting the status.
+		var actionsForm *zpages.ActionsFormData
+		if ac, ok := comp.(interface {
+			ZPagesActions() []string
+			HandleZPagesAction(ctx context.Context, action string, value string) (string, error)
+		}); ok {
+			if actions := ac.ZPagesActions(); len(actions) > 0 {
+				actionsForm = &zpages.ActionsFormData{Name: "Actions", Actions: actions, Token: bps.zPagesToken}
+				if r.Method == http.MethodPost {
+					actionsForm.Result = handleZPagesAction(r, ac.HandleZPagesAction)
+				}
+			}
+		}
+		// Components can report their status by implementing the optional ZPagesProperties func.
+		if zc, ok := comp.(interface {
+			ZPagesProperties() [][2]string
+		}); ok {
+			if props := zc.ZPagesProperties(); len(props) > 0 {
+				zpages.WriteHTMLPropertiesTable(w, zpages.PropertiesTableData{Name: "Status", Properties: props})
+			}
+		}
+		if actionsForm != nil {
+			zpages.WriteHTMLActionsForm(w, *actionsForm)
+		}
+		// TODO: Add config info.
 	}
 	zpages.WriteHTMLPageFooter(w)
 }
 
+// handleZPagesAction runs the action posted in the request, whose form is already parsed, and returns its outcome.
+func handleZPagesAction(r *http.Request, handle func(ctx context.Context, action string, value string) (string, error)) string {
+	action := r.PostForm.Get(zAction)
+	if action == "" {
+		return "No action requested"
+	}
+	result, err := handle(r.Context(), action, r.PostForm.Get(zValue))
+	if err != nil {
+		return fmt.Sprintf("Action %q failed: %v", action, err)
+	}
+	return fmt.Sprintf("Action %q succeeded: %s", action, result)
+}
+
+// newZPagesToken returns a random token for the zPages actions form.
+func newZPagesToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate the zPages token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// getComponent returns the component of the given kind and name in the pipeline, or nil if not found.
+func (bps *builtPipelines) getComponent(pipelineName, componentName, componentKind string) component.Component {
+	for pipelineID, bp := range bps.pipelines {
+		if pipelineID.String() != pipelineName {
+			continue
+		}
+		var comps []builtComponent
+		switch componentKind {
+		case "receiver":
+			comps = append(connectorComponents(bp.connectorsAsReceivers), bp.receivers...)
+		case "processor":
+			comps = bp.processors
+		case "exporter":
+			comps = append(connectorComponents(bp.connectorsAsExporters), bp.exporters...)
+		}
+		for _, c := range comps {
+			if c.id.String() == componentName {
+				return c.comp
+			}
+		}
+	}
+	return nil
+}
+
 // pipelinesSettings holds configuration for building builtPipelines.
 type pipelinesSettings struct {
 	Telemetry component.TelemetrySettings
@@ -188,6 +324,7 @@ type pipelinesSettings struct {
 	Receivers  *receiver.Builder
 	Processors *processor.Builder
 	Exporters  *exporter.Builder
+	Connectors *connector.Builder
 
 	// PipelineConfigs is a map of component.ID to PipelineConfig.
 	PipelineConfigs map[component.ID]*PipelineConfig
@@ -195,19 +332,42 @@ type pipelinesSettings struct {
 
 // buildPipelines builds all pipelines from config.
 func buildPipelines(ctx context.Context, set pipelinesSettings) (*builtPipelines, error) {
+	if set.Connectors == nil {
+		set.Connectors = connector.NewBuilder(nil, nil)
+	}
+	graph, err := newConnectorsGraph(set)
+	if err != nil {
+		return nil, err
+	}
+	buildOrder, err := graph.buildOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	zPagesToken, err := newZPagesToken()
+	if err != nil {
+		return nil, err
+	}
+
 	exps := &builtPipelines{
 		telemetry:    set.Telemetry,
 		allReceivers: make(map[component.DataType]map[component.ID]component.Component),
 		allExporters: make(map[component.DataType]map[component.ID]component.Component),
 		pipelines:    make(map[component.ID]*builtPipeline, len(set.PipelineConfigs)),
+		buildOrder:   buildOrder,
+		zPagesToken:  zPagesToken,
 	}
 
 	receiversConsumers := make(map[component.DataType]map[component.ID][]baseConsumer)
+	allConnectors := make(map[connectorKey]builtConnector)
 
-	// Iterate over all pipelines, and create exporters, then processors.
+	// Iterate over all pipelines, and create exporters and connectors, then processors.
+	// The pipelines sending data to a connector are built after the pipelines receiving from it,
+	// since the connector needs the first consumer of these pipelines.
 	// Receivers cannot be created since we need to know all consumers, a.k.a. we need all pipelines build up to the
 	// first processor.
-	for pipelineID, pipeline := range set.PipelineConfigs {
+	for _, pipelineID := range buildOrder {
+		pipeline := set.PipelineConfigs[pipelineID]
 		// The data type of the pipeline defines what data type each exporter is expected to receive.
 		if _, ok := exps.allExporters[pipelineID.Type()]; !ok {
 			exps.allExporters[pipelineID.Type()] = make(map[component.ID]component.Component)
@@ -215,17 +375,60 @@ func buildPipelines(ctx context.Context, set pipelinesSettings) (*builtPipelines
 		expByID := exps.allExporters[pipelineID.Type()]
 
 		bp := &builtPipeline{
-			receivers:  make([]builtComponent, len(pipeline.Receivers)),
+			receivers:  make([]builtComponent, 0, len(pipeline.Receivers)),
 			processors: make([]builtComponent, len(pipeline.Processors)),
-			exporters:  make([]builtComponent, len(pipeline.Exporters)),
+			exporters:  make([]builtComponent, 0, len(pipeline.Exporters)),
 		}
 		exps.pipelines[pipelineID] = bp
 
 		// Iterate over all Exporters for this pipeline.
-		for i, expID := range pipeline.Exporters {
+		for _, expID := range pipeline.Exporters {
+			if graph.isConnector(expID) {
+				for _, recvType := range []component.DataType{component.DataTypeTraces, component.DataTypeMetrics, component.DataTypeLogs} {
+					key := connectorKey{id: expID, exporterType: pipelineID.Type(), receiverType: recvType}
+					// If already created a connector for this [ComponentID, DataType, DataType] will reuse this instance.
+					conn, ok := allConnectors[key]
+					if !ok {
+						var recvPipelineIDs []component.ID
+						for _, recvPipelineID := range graph.downstream(expID, pipelineID.Type()) {
+							if recvPipelineID.Type() == recvType {
+								recvPipelineIDs = append(recvPipelineIDs, recvPipelineID)
+							}
+						}
+						if len(recvPipelineIDs) == 0 {
+							continue
+						}
+
+						nexts := make([]baseConsumer, 0, len(recvPipelineIDs))
+						for _, recvPipelineID := range recvPipelineIDs {
+							nexts = append(nexts, exps.pipelines[recvPipelineID].lastConsumer)
+						}
+						cSet := connector.CreateSettings{
+							ID:                expID,
+							TelemetrySettings: set.Telemetry,
+							BuildInfo:         set.BuildInfo,
+						}
+						cSet.TelemetrySettings.Logger = connectorLogger(set.Telemetry.Logger, key)
+						comp, err := buildConnector(ctx, cSet, set.Connectors, key, nexts)
+						if err != nil {
+							return nil, err
+						}
+
+						conn = builtConnector{connectorKey: key, comp: comp}
+						allConnectors[key] = conn
+						for _, recvPipelineID := range recvPipelineIDs {
+							recvBP := exps.pipelines[recvPipelineID]
+							recvBP.connectorsAsReceivers = append(recvBP.connectorsAsReceivers, conn)
+						}
+					}
+					bp.connectorsAsExporters = append(bp.connectorsAsExporters, conn)
+				}
+				continue
+			}
+
 			// If already created an exporter for this [DataType, ComponentID] nothing to do, will reuse this instance.
 			if exp, ok := expByID[expID]; ok {
-				bp.exporters[i] = builtComponent{id: expID, comp: exp}
+				bp.exporters = append(bp.exporters, builtComponent{id: expID, comp: exp})
 				continue
 			}
 
@@ -240,22 +443,28 @@ func buildPipelines(ctx context.Context, set pipelinesSettings) (*builtPipelines
 				return nil, err
 			}
 
-			bp.exporters[i] = builtComponent{id: expID, comp: exp}
+			bp.exporters = append(bp.exporters, builtComponent{id: expID, comp: exp})
 			expByID[expID] = exp
 		}
 
-		// Build a fan out consumer to all exporters.
+		// Build a fan out consumer to all exporters and connectors.
+		nexts := make([]baseConsumer, 0, len(bp.exporters)+len(bp.connectorsAsExporters))
+		for _, exp := range bp.exporters {
+			nexts = append(nexts, exp.comp.(baseConsumer))
+		}
+		for _, conn := range bp.connectorsAsExporters {
+			nexts = append(nexts, conn.comp.(baseConsumer))
+		}
 		switch pipelineID.Type() {
 		case component.DataTypeTraces:
-			bp.lastConsumer = buildFanOutExportersTracesConsumer(bp.exporters)
+			bp.lastConsumer = buildFanOutTracesConsumer(nexts)
 		case component.DataTypeMetrics:
-			bp.lastConsumer = buildFanOutExportersMetricsConsumer(bp.exporters)
+			bp.lastConsumer = buildFanOutMetricsConsumer(nexts)
 		case component.DataTypeLogs:
-			bp.lastConsumer = buildFanOutExportersLogsConsumer(bp.exporters)
+			bp.lastConsumer = buildFanOutLogsConsumer(nexts)
 		default:
 			return nil, fmt.Errorf("create fan-out exporter in pipeline %q, data type %q is not supported", pipelineID, pipelineID.Type())
 		}
-
 		mutatesConsumedData := bp.lastConsumer.Capabilities().MutatesData
 		// Build the processors backwards, starting from the last one.
 		// The last processor points to fan out consumer to all Exporters, then the processor itself becomes a
@@ -298,7 +507,11 @@ func buildPipelines(ctx context.Context, set pipelinesSettings) (*builtPipelines
 		}
 		recvConsByID := receiversConsumers[pipelineID.Type()]
 		// Iterate over all Receivers for this pipeline and just append the lastConsumer as a consumer for the receiver.
+		// The connectors feeding this pipeline already got the lastConsumer when they were built.
 		for _, recvID := range pipeline.Receivers {
+			if graph.isConnector(recvID) {
+				continue
+			}
 			recvConsByID[recvID] = append(recvConsByID[recvID], bp.lastConsumer)
 		}
 	}
@@ -313,10 +526,14 @@ func buildPipelines(ctx context.Context, set pipelinesSettings) (*builtPipelines
 		bp := exps.pipelines[pipelineID]
 
 		// Iterate over all Receivers for this pipeline.
-		for i, recvID := range pipeline.Receivers {
+		for _, recvID := range pipeline.Receivers {
+			if graph.isConnector(recvID) {
+				continue
+			}
+
 			// If already created a receiver for this [DataType, ComponentID] nothing to do.
 			if exp, ok := recvByID[recvID]; ok {
-				bp.receivers[i] = builtComponent{id: recvID, comp: exp}
+				bp.receivers = append(bp.receivers, builtComponent{id: recvID, comp: exp})
 				continue
 			}
 
@@ -331,7 +548,7 @@ func buildPipelines(ctx context.Context, set pipelinesSettings) (*builtPipelines
 				return nil, err
 			}
 
-			bp.receivers[i] = builtComponent{id: recvID, comp: recv}
+			bp.receivers = append(bp.receivers, builtComponent{id: recvID, comp: recv})
 			recvByID[recvID] = recv
 		}
 	}
@@ -360,30 +577,30 @@ func buildExporter(
 	return exp, nil
 }
 
-func buildFanOutExportersTracesConsumer(exporters []builtComponent) consumer.Traces {
-	consumers := make([]consumer.Traces, 0, len(exporters))
-	for _, exp := range exporters {
-		consumers = append(consumers, exp.comp.(consumer.Traces))
+func buildFanOutTracesConsumer(nexts []baseConsumer) consumer.Traces {
+	consumers := make([]consumer.Traces, 0, len(nexts))
+	for _, next := range nexts {
+		consumers = append(consumers, next.(consumer.Traces))
 	}
-	// Create a junction point that fans out to all allExporters.
+	// Create a junction point that fans out to all consumers.
 	return fanoutconsumer.NewTraces(consumers)
 }
 
-func buildFanOutExportersMetricsConsumer(exporters []builtComponent) consumer.Metrics {
-	consumers := make([]consumer.Metrics, 0, len(exporters))
-	for _, exp := range exporters {
-		consumers = append(consumers, exp.comp.(consumer.Metrics))
+func buildFanOutMetricsConsumer(nexts []baseConsumer) consumer.Metrics {
+	consumers := make([]consumer.Metrics, 0, len(nexts))
+	for _, next := range nexts {
+		consumers = append(consumers, next.(consumer.Metrics))
 	}
-	// Create a junction point that fans out to all allExporters.
+	// Create a junction point that fans out to all consumers.
 	return fanoutconsumer.NewMetrics(consumers)
 }
 
-func buildFanOutExportersLogsConsumer(exporters []builtComponent) consumer.Logs {
-	consumers := make([]consumer.Logs, 0, len(exporters))
-	for _, exp := range exporters {
-		consumers = append(consumers, exp.comp.(consumer.Logs))
+func buildFanOutLogsConsumer(nexts []baseConsumer) consumer.Logs {
+	consumers := make([]consumer.Logs, 0, len(nexts))
+	for _, next := range nexts {
+		consumers = append(consumers, next.(consumer.Logs))
 	}
-	// Create a junction point that fans out to all allExporters.
+	// Create a junction point that fans out to all consumers.
 	return fanoutconsumer.NewLogs(consumers)
 }
 
@@ -431,23 +648,11 @@ func buildReceiver(ctx context.Context,
 ) (recv component.Component, err error) {
 	switch pipelineID.Type() {
 	case component.DataTypeTraces:
-		var consumers []consumer.Traces
-		for _, next := range nexts {
-			consumers = append(consumers, next.(consumer.Traces))
-		}
-		recv, err = builder.CreateTraces(ctx, set, fanoutconsumer.NewTraces(consumers))
+		recv, err = builder.CreateTraces(ctx, set, buildFanOutTracesConsumer(nexts))
 	case component.DataTypeMetrics:
-		var consumers []consumer.Metrics
-		for _, next := range nexts {
-			consumers = append(consumers, next.(consumer.Metrics))
-		}
-		recv, err = builder.CreateMetrics(ctx, set, fanoutconsumer.NewMetrics(consumers))
+		recv, err = builder.CreateMetrics(ctx, set, buildFanOutMetricsConsumer(nexts))
 	case component.DataTypeLogs:
-		var consumers []consumer.Logs
-		for _, next := range nexts {
-			consumers = append(consumers, next.(consumer.Logs))
-		}
-		recv, err = builder.CreateLogs(ctx, set, fanoutconsumer.NewLogs(consumers))
+		recv, err = builder.CreateLogs(ctx, set, buildFanOutLogsConsumer(nexts))
 	default:
 		return nil, fmt.Errorf("error creating receiver %q in pipeline %q, data type %q is not supported", set.ID, pipelineID, pipelineID.Type())
 	}
@@ -473,6 +678,7 @@ func (bps *builtPipelines) getPipelinesSummaryTableData() zpages.SummaryPipeline
 		for _, bRecv := range p.receivers {
 			recvs = append(recvs, bRecv.id.String())
 		}
+		recvs = append(recvs, connectorNames(p.connectorsAsReceivers)...)
 		var procs []string
 		for _, bProc := range p.processors {
 			procs = append(procs, bProc.id.String())
@@ -481,6 +687,7 @@ func (bps *builtPipelines) getPipelinesSummaryTableData() zpages.SummaryPipeline
 		for _, bExp := range p.exporters {
 			exps = append(exps, bExp.id.String())
 		}
+		exps = append(exps, connectorNames(p.connectorsAsExporters)...)
 		row := zpages.SummaryPipelinesTableRowData{
 			FullName:    c.String(),
 			InputType:   string(c.Type()),
@@ -497,3 +704,26 @@ func (bps *builtPipelines) getPipelinesSummaryTableData() zpages.SummaryPipeline
 	})
 	return sumData
 }
+
+// connectorComponents returns the connectors as builtComponent, a connector appearing once per pair of data types.
+func connectorComponents(conns []builtConnector) []builtComponent {
+	comps := make([]builtComponent, 0, len(conns))
+	for _, conn := range conns {
+		comps = append(comps, builtComponent{id: conn.id, comp: conn.comp})
+	}
+	return comps
+}
+
+// connectorNames returns the names of the connectors, each connector listed once.
+func connectorNames(conns []builtConnector) []string {
+	var names []string
+	seen := make(map[component.ID]struct{}, len(conns))
+	for _, conn := range conns {
+		if _, ok := seen[conn.id]; ok {
+			continue
+		}
+		seen[conn.id] = struct{}{}
+		names = append(names, conn.id.String())
+	}
+	return names
+}
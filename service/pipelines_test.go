@@ -17,6 +17,10 @@ package service
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,11 +28,15 @@ import (
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/exportertest"
 	"go.opentelemetry.io/collector/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/processor"
 	"go.opentelemetry.io/collector/processor/processortest"
 	"go.opentelemetry.io/collector/receiver"
@@ -294,6 +302,266 @@ func TestBuildPipelines(t *testing.T) {
 	}
 }
 
+func TestBuildPipelinesWithConnectors(t *testing.T) {
+	// Traces and logs are sent through the connector to pipelines of each data type.
+	pipelineConfigs := map[component.ID]*PipelineConfig{
+		component.NewIDWithName("traces", "in"): {
+			Receivers:  []component.ID{component.NewID("examplereceiver")},
+			Processors: []component.ID{component.NewID("exampleprocessor")},
+			Exporters:  []component.ID{component.NewID("exampleconnector")},
+		},
+		component.NewIDWithName("logs", "in"): {
+			Receivers: []component.ID{component.NewID("examplereceiver")},
+			Exporters: []component.ID{component.NewID("exampleconnector")},
+		},
+		component.NewIDWithName("traces", "out"): {
+			Receivers:  []component.ID{component.NewID("exampleconnector")},
+			Processors: []component.ID{component.NewID("exampleprocessor")},
+			Exporters:  []component.ID{component.NewID("exampleexporter")},
+		},
+		component.NewIDWithName("metrics", "out"): {
+			Receivers: []component.ID{component.NewID("exampleconnector")},
+			Exporters: []component.ID{component.NewID("exampleexporter")},
+		},
+		component.NewIDWithName("logs", "out"): {
+			Receivers: []component.ID{component.NewID("exampleconnector")},
+			Exporters: []component.ID{component.NewID("exampleexporter")},
+		},
+	}
+
+	pipelines, err := buildPipelines(context.Background(), pipelinesSettings{
+		Telemetry: componenttest.NewNopTelemetrySettings(),
+		BuildInfo: component.NewDefaultBuildInfo(),
+		Receivers: receiver.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewID("examplereceiver"): testcomponents.ExampleReceiverFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]receiver.Factory{
+				testcomponents.ExampleReceiverFactory.Type(): testcomponents.ExampleReceiverFactory,
+			}),
+		Processors: processor.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewID("exampleprocessor"): testcomponents.ExampleProcessorFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]processor.Factory{
+				testcomponents.ExampleProcessorFactory.Type(): testcomponents.ExampleProcessorFactory,
+			}),
+		Exporters: exporter.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewID("exampleexporter"): testcomponents.ExampleExporterFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]exporter.Factory{
+				testcomponents.ExampleExporterFactory.Type(): testcomponents.ExampleExporterFactory,
+			}),
+		Connectors: connector.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewID("exampleconnector"): testcomponents.ExampleConnectorFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]connector.Factory{
+				testcomponents.ExampleConnectorFactory.Type(): testcomponents.ExampleConnectorFactory,
+			}),
+		PipelineConfigs: pipelineConfigs,
+	})
+	require.NoError(t, err)
+
+	// One connector is built for each pair of data types, and shared by the pipelines.
+	inTraces := pipelines.pipelines[component.NewIDWithName("traces", "in")]
+	require.Len(t, inTraces.connectorsAsExporters, 3)
+	require.Len(t, pipelines.pipelines[component.NewIDWithName("logs", "in")].connectorsAsExporters, 3)
+	outTraces := pipelines.pipelines[component.NewIDWithName("traces", "out")]
+	require.Len(t, outTraces.connectorsAsReceivers, 2)
+	assert.Contains(t, outTraces.connectorsAsReceivers, inTraces.connectorsAsExporters[0])
+	assert.Len(t, inTraces.receivers, 1)
+	assert.Empty(t, inTraces.exporters)
+	assert.Empty(t, outTraces.receivers)
+
+	require.NoError(t, pipelines.StartAll(context.Background(), componenttest.NewNopHost()))
+	for _, bp := range pipelines.pipelines {
+		for _, conn := range bp.connectorsAsExporters {
+			assert.True(t, conn.comp.(*testcomponents.ExampleConnector).Started)
+		}
+	}
+
+	recv := pipelines.allReceivers[component.DataTypeTraces][component.NewID("examplereceiver")].(*testcomponents.ExampleReceiver)
+	assert.NoError(t, recv.ConsumeTraces(context.Background(), testdata.GenerateTraces(1)))
+	recv = pipelines.allReceivers[component.DataTypeLogs][component.NewID("examplereceiver")].(*testcomponents.ExampleReceiver)
+	assert.NoError(t, recv.ConsumeLogs(context.Background(), testdata.GenerateLogs(1)))
+
+	assert.NoError(t, pipelines.ShutdownAll(context.Background()))
+	for _, bp := range pipelines.pipelines {
+		for _, conn := range bp.connectorsAsExporters {
+			assert.True(t, conn.comp.(*testcomponents.ExampleConnector).Stopped)
+		}
+	}
+
+	// Each output pipeline got the data of both input pipelines.
+	exps := pipelines.GetExporters()
+	tracesExp := exps[component.DataTypeTraces][component.NewID("exampleexporter")].(*testcomponents.ExampleExporter)
+	assert.Equal(t, []ptrace.Traces{testdata.GenerateTraces(1), testdata.GenerateTraces(1)}, tracesExp.Traces)
+	metricsExp := exps[component.DataTypeMetrics][component.NewID("exampleexporter")].(*testcomponents.ExampleExporter)
+	assert.Equal(t, []pmetric.Metrics{testdata.GenerateMetrics(1), testdata.GenerateMetrics(1)}, metricsExp.Metrics)
+	logsExp := exps[component.DataTypeLogs][component.NewID("exampleexporter")].(*testcomponents.ExampleExporter)
+	assert.Equal(t, []plog.Logs{testdata.GenerateLogs(1), testdata.GenerateLogs(1)}, logsExp.Logs)
+
+	// The connectors are listed once per pipeline in the zPages.
+	for _, row := range pipelines.getPipelinesSummaryTableData().Rows {
+		switch row.FullName {
+		case "traces/in", "logs/in":
+			assert.Equal(t, []string{"exampleconnector"}, row.Exporters)
+		default:
+			assert.Equal(t, []string{"exampleconnector"}, row.Receivers)
+		}
+	}
+}
+
+func TestConnectorsStartAndShutdownOrder(t *testing.T) {
+	var events []string
+	record := func(event string) {
+		events = append(events, event)
+	}
+	newComponent := func(name string) *recordingComponent {
+		return &recordingComponent{Consumer: consumertest.NewNop(), name: name, record: record}
+	}
+
+	recvFactory := receiver.NewFactory("recorder",
+		func() component.Config { return &struct{}{} },
+		receiver.WithTraces(func(_ context.Context, set receiver.CreateSettings, _ component.Config, _ consumer.Traces) (receiver.Traces, error) {
+			return newComponent("receiver " + set.ID.String()), nil
+		}, component.StabilityLevelDevelopment))
+	procFactory := processor.NewFactory("recorder",
+		func() component.Config { return &struct{}{} },
+		processor.WithTraces(func(_ context.Context, set processor.CreateSettings, _ component.Config, _ consumer.Traces) (processor.Traces, error) {
+			return newComponent("processor " + set.ID.String()), nil
+		}, component.StabilityLevelDevelopment),
+		processor.WithMetrics(func(_ context.Context, set processor.CreateSettings, _ component.Config, _ consumer.Metrics) (processor.Metrics, error) {
+			return newComponent("processor " + set.ID.String()), nil
+		}, component.StabilityLevelDevelopment))
+	expFactory := exporter.NewFactory("recorder",
+		func() component.Config { return &struct{}{} },
+		exporter.WithTraces(func(_ context.Context, set exporter.CreateSettings, _ component.Config) (exporter.Traces, error) {
+			return newComponent("exporter " + set.ID.String()), nil
+		}, component.StabilityLevelDevelopment),
+		exporter.WithMetrics(func(_ context.Context, set exporter.CreateSettings, _ component.Config) (exporter.Metrics, error) {
+			return newComponent("exporter " + set.ID.String()), nil
+		}, component.StabilityLevelDevelopment))
+	connFactory := connector.NewFactory("recorder",
+		func() component.Config { return &struct{}{} },
+		connector.WithTracesToTraces(func(_ context.Context, set connector.CreateSettings, _ component.Config, _ consumer.Traces) (connector.Traces, error) {
+			return newComponent("connector " + set.ID.String() + " traces to traces"), nil
+		}, component.StabilityLevelDevelopment),
+		connector.WithTracesToMetrics(func(_ context.Context, set connector.CreateSettings, _ component.Config, _ consumer.Metrics) (connector.Traces, error) {
+			return newComponent("connector " + set.ID.String() + " traces to metrics"), nil
+		}, component.StabilityLevelDevelopment))
+
+	// traces/in -> recorder/first -> traces/middle -> recorder/second -> metrics/out
+	//                             -> metrics/out
+	pipelines, err := buildPipelines(context.Background(), pipelinesSettings{
+		Telemetry: componenttest.NewNopTelemetrySettings(),
+		BuildInfo: component.NewDefaultBuildInfo(),
+		Receivers: receiver.NewBuilder(
+			map[component.ID]component.Config{component.NewID("recorder"): recvFactory.CreateDefaultConfig()},
+			map[component.Type]receiver.Factory{recvFactory.Type(): recvFactory}),
+		Processors: processor.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewIDWithName("recorder", "in"):     procFactory.CreateDefaultConfig(),
+				component.NewIDWithName("recorder", "middle"): procFactory.CreateDefaultConfig(),
+				component.NewIDWithName("recorder", "out"):    procFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]processor.Factory{procFactory.Type(): procFactory}),
+		Exporters: exporter.NewBuilder(
+			map[component.ID]component.Config{component.NewID("recorder"): expFactory.CreateDefaultConfig()},
+			map[component.Type]exporter.Factory{expFactory.Type(): expFactory}),
+		Connectors: connector.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewIDWithName("recorder", "first"):  connFactory.CreateDefaultConfig(),
+				component.NewIDWithName("recorder", "second"): connFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]connector.Factory{connFactory.Type(): connFactory}),
+		PipelineConfigs: map[component.ID]*PipelineConfig{
+			component.NewIDWithName("traces", "in"): {
+				Receivers:  []component.ID{component.NewID("recorder")},
+				Processors: []component.ID{component.NewIDWithName("recorder", "in")},
+				Exporters:  []component.ID{component.NewIDWithName("recorder", "first")},
+			},
+			component.NewIDWithName("traces", "middle"): {
+				Receivers:  []component.ID{component.NewIDWithName("recorder", "first")},
+				Processors: []component.ID{component.NewIDWithName("recorder", "middle")},
+				Exporters:  []component.ID{component.NewID("recorder"), component.NewIDWithName("recorder", "second")},
+			},
+			component.NewIDWithName("metrics", "out"): {
+				Receivers:  []component.ID{component.NewIDWithName("recorder", "first"), component.NewIDWithName("recorder", "second")},
+				Processors: []component.ID{component.NewIDWithName("recorder", "out")},
+				Exporters:  []component.ID{component.NewID("recorder")},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, pipelines.StartAll(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, pipelines.ShutdownAll(context.Background()))
+
+	index := func(event string) int {
+		for i, e := range events {
+			if e == event {
+				return i
+			}
+		}
+		require.Failf(t, "missing event", "%q not found in %v", event, events)
+		return -1
+	}
+	assertOrder := func(ordered ...string) {
+		for i := 1; i < len(ordered); i++ {
+			assert.Less(t, index(ordered[i-1]), index(ordered[i]), "%q must happen before %q", ordered[i-1], ordered[i])
+		}
+	}
+
+	assertOrder(
+		"start exporter recorder",
+		"start processor recorder/out",
+		"start connector recorder/second traces to metrics",
+		"start processor recorder/middle",
+		"start connector recorder/first traces to traces",
+		"start processor recorder/in",
+		"start receiver recorder",
+	)
+	assertOrder(
+		"start processor recorder/out",
+		"start connector recorder/first traces to metrics",
+		"start processor recorder/in",
+	)
+	assertOrder(
+		"stop receiver recorder",
+		"stop processor recorder/in",
+		"stop connector recorder/first traces to traces",
+		"stop processor recorder/middle",
+		"stop connector recorder/second traces to metrics",
+		"stop processor recorder/out",
+		"stop exporter recorder",
+	)
+	assertOrder(
+		"stop processor recorder/in",
+		"stop connector recorder/first traces to metrics",
+		"stop processor recorder/out",
+	)
+}
+
+// recordingComponent records when it is started and shut down.
+type recordingComponent struct {
+	consumertest.Consumer
+	name   string
+	record func(event string)
+}
+
+func (rc *recordingComponent) Start(context.Context, component.Host) error {
+	rc.record("start " + rc.name)
+	return nil
+}
+
+func (rc *recordingComponent) Shutdown(context.Context) error {
+	rc.record("stop " + rc.name)
+	return nil
+}
+
 func TestBuildErrors(t *testing.T) {
 	nopReceiverFactory := receivertest.NewNopFactory()
 	nopProcessorFactory := processortest.NewNopFactory()
@@ -301,12 +569,14 @@ func TestBuildErrors(t *testing.T) {
 	badReceiverFactory := newBadReceiverFactory()
 	badProcessorFactory := newBadProcessorFactory()
 	badExporterFactory := newBadExporterFactory()
+	badConnectorFactory := newBadConnectorFactory()
 
 	tests := []struct {
 		name             string
 		ReceiverConfigs  map[component.ID]component.Config
 		ProcessorConfigs map[component.ID]component.Config
 		ExporterConfigs  map[component.ID]component.Config
+		ConnectorConfigs map[component.ID]component.Config
 		PipelineConfigs  map[component.ID]*PipelineConfig
 		expected         string
 	}{
@@ -570,6 +840,119 @@ func TestBuildErrors(t *testing.T) {
 			},
 			expected: "failed to create \"unknown\" receiver, in pipeline \"logs\": receiver factory not available for: \"unknown\"",
 		},
+		{
+			name: "unknown_connector_factory",
+			ReceiverConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopReceiverFactory.CreateDefaultConfig(),
+			},
+			ExporterConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopExporterFactory.CreateDefaultConfig(),
+			},
+			ConnectorConfigs: map[component.ID]component.Config{
+				component.NewID("unknown"): testcomponents.ExampleConnectorFactory.CreateDefaultConfig(),
+			},
+			PipelineConfigs: map[component.ID]*PipelineConfig{
+				component.NewIDWithName("traces", "in"): {
+					Receivers: []component.ID{component.NewID("nop")},
+					Exporters: []component.ID{component.NewID("unknown")},
+				},
+				component.NewIDWithName("traces", "out"): {
+					Receivers: []component.ID{component.NewID("unknown")},
+					Exporters: []component.ID{component.NewID("nop")},
+				},
+			},
+			expected: "connector factory not available for: \"unknown\"",
+		},
+		{
+			name: "not_supported_connector_as_exporter",
+			ReceiverConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopReceiverFactory.CreateDefaultConfig(),
+			},
+			ExporterConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopExporterFactory.CreateDefaultConfig(),
+			},
+			ConnectorConfigs: map[component.ID]component.Config{
+				component.NewID("bf"): badConnectorFactory.CreateDefaultConfig(),
+			},
+			PipelineConfigs: map[component.ID]*PipelineConfig{
+				component.NewIDWithName("traces", "in"): {
+					Receivers: []component.ID{component.NewID("nop")},
+					Exporters: []component.ID{component.NewID("bf")},
+				},
+				component.NewIDWithName("traces", "out"): {
+					Receivers: []component.ID{component.NewID("bf")},
+					Exporters: []component.ID{component.NewID("nop")},
+				},
+			},
+			expected: "connector \"bf\" used as exporter in pipeline \"traces/in\" but not used in any supported receiver pipeline",
+		},
+		{
+			name: "not_supported_connector_as_receiver",
+			ReceiverConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopReceiverFactory.CreateDefaultConfig(),
+			},
+			ExporterConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopExporterFactory.CreateDefaultConfig(),
+			},
+			ConnectorConfigs: map[component.ID]component.Config{
+				component.NewID("bf"): badConnectorFactory.CreateDefaultConfig(),
+			},
+			PipelineConfigs: map[component.ID]*PipelineConfig{
+				component.NewIDWithName("metrics", "out"): {
+					Receivers: []component.ID{component.NewID("bf")},
+					Exporters: []component.ID{component.NewID("nop")},
+				},
+				component.NewIDWithName("traces", "in"): {
+					Receivers: []component.ID{component.NewID("nop")},
+					Exporters: []component.ID{component.NewID("bf")},
+				},
+			},
+			expected: "connector \"bf\" used as receiver in pipeline \"metrics/out\" but not used in any supported exporter pipeline",
+		},
+		{
+			name: "connector_cycle_in_pipeline",
+			ReceiverConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopReceiverFactory.CreateDefaultConfig(),
+			},
+			ConnectorConfigs: map[component.ID]component.Config{
+				component.NewID("exampleconnector"): testcomponents.ExampleConnectorFactory.CreateDefaultConfig(),
+			},
+			PipelineConfigs: map[component.ID]*PipelineConfig{
+				component.NewID("logs"): {
+					Receivers: []component.ID{component.NewID("nop"), component.NewID("exampleconnector")},
+					Exporters: []component.ID{component.NewID("exampleconnector")},
+				},
+			},
+			expected: "cycle detected: pipeline \"logs\" -> connector \"exampleconnector\" -> pipeline \"logs\"",
+		},
+		{
+			name: "connector_cycle_between_pipelines",
+			ReceiverConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopReceiverFactory.CreateDefaultConfig(),
+			},
+			ExporterConfigs: map[component.ID]component.Config{
+				component.NewID("nop"): nopExporterFactory.CreateDefaultConfig(),
+			},
+			ConnectorConfigs: map[component.ID]component.Config{
+				component.NewID("exampleconnector"):              testcomponents.ExampleConnectorFactory.CreateDefaultConfig(),
+				component.NewIDWithName("exampleconnector", "1"): testcomponents.ExampleConnectorFactory.CreateDefaultConfig(),
+			},
+			PipelineConfigs: map[component.ID]*PipelineConfig{
+				component.NewIDWithName("logs", "in"): {
+					Receivers: []component.ID{component.NewID("nop")},
+					Exporters: []component.ID{component.NewID("exampleconnector")},
+				},
+				component.NewIDWithName("metrics", "middle"): {
+					Receivers: []component.ID{component.NewID("exampleconnector")},
+					Exporters: []component.ID{component.NewID("nop"), component.NewIDWithName("exampleconnector", "1")},
+				},
+				component.NewIDWithName("traces", "out"): {
+					Receivers: []component.ID{component.NewIDWithName("exampleconnector", "1")},
+					Exporters: []component.ID{component.NewID("exampleconnector")},
+				},
+			},
+			expected: "cycle detected: pipeline \"metrics/middle\" -> connector \"exampleconnector/1\" -> pipeline \"traces/out\" -> connector \"exampleconnector\" -> pipeline \"metrics/middle\"",
+		},
 	}
 
 	for _, test := range tests {
@@ -595,6 +978,12 @@ func TestBuildErrors(t *testing.T) {
 						nopExporterFactory.Type(): nopExporterFactory,
 						badExporterFactory.Type(): badExporterFactory,
 					}),
+				Connectors: connector.NewBuilder(
+					test.ConnectorConfigs,
+					map[component.Type]connector.Factory{
+						testcomponents.ExampleConnectorFactory.Type(): testcomponents.ExampleConnectorFactory,
+						badConnectorFactory.Type():                    badConnectorFactory,
+					}),
 				PipelineConfigs: test.PipelineConfigs,
 			}
 
@@ -707,6 +1096,12 @@ func newBadExporterFactory() exporter.Factory {
 	})
 }
 
+func newBadConnectorFactory() connector.Factory {
+	return connector.NewFactory("bf", func() component.Config {
+		return &struct{}{}
+	})
+}
+
 func newErrReceiverFactory() receiver.Factory {
 	return receiver.NewFactory("err",
 		func() component.Config { return &struct{}{} },
@@ -767,3 +1162,87 @@ func (e errComponent) Start(context.Context, component.Host) error {
 func (e errComponent) Shutdown(context.Context) error {
 	return errors.New("my error")
 }
+
+func TestPipelinesZPagesComponentStatus(t *testing.T) {
+	pipelines, err := buildPipelines(context.Background(), pipelinesSettings{
+		Telemetry: componenttest.NewNopTelemetrySettings(),
+		BuildInfo: component.NewDefaultBuildInfo(),
+		Receivers: receiver.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewID("examplereceiver"): testcomponents.ExampleReceiverFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]receiver.Factory{
+				testcomponents.ExampleReceiverFactory.Type(): testcomponents.ExampleReceiverFactory,
+			}),
+		Processors: processor.NewBuilder(map[component.ID]component.Config{}, map[component.Type]processor.Factory{}),
+		Exporters: exporter.NewBuilder(
+			map[component.ID]component.Config{
+				component.NewID("exampleexporter"): testcomponents.ExampleExporterFactory.CreateDefaultConfig(),
+			},
+			map[component.Type]exporter.Factory{
+				testcomponents.ExampleExporterFactory.Type(): testcomponents.ExampleExporterFactory,
+			}),
+		PipelineConfigs: map[component.ID]*PipelineConfig{
+			component.NewID("traces"): {
+				Receivers: []component.ID{component.NewID("examplereceiver")},
+				Exporters: []component.ID{component.NewID("exampleexporter")},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, pipelines.StartAll(context.Background(), componenttest.NewNopHost()))
+
+	rr := httptest.NewRecorder()
+	pipelines.HandleZPages(rr, httptest.NewRequest("GET", "/debug/pipelinez?zpipelinename=traces&zcomponentname=exampleexporter&zcomponentkind=exporter", nil))
+	assert.Contains(t, rr.Body.String(), "<b>Status:</b>")
+	assert.Contains(t, rr.Body.String(), "Started")
+
+	rr = httptest.NewRecorder()
+	pipelines.HandleZPages(rr, httptest.NewRequest("GET", "/debug/pipelinez?zpipelinename=traces&zcomponentname=examplereceiver&zcomponentkind=receiver", nil))
+	assert.NotContains(t, rr.Body.String(), "<b>Status:</b>")
+	assert.NotContains(t, rr.Body.String(), "<b>Actions:</b>")
+
+	exp := pipelines.GetExporters()[component.DataTypeTraces][component.NewID("exampleexporter")].(*testcomponents.ExampleExporter)
+	exp.Traces = append(exp.Traces, testdata.GenerateTraces(1))
+	rr = httptest.NewRecorder()
+	pipelines.HandleZPages(rr, httptest.NewRequest("GET", "/debug/pipelinez?zpipelinename=traces&zcomponentname=exampleexporter&zcomponentkind=exporter", nil))
+	assert.Contains(t, rr.Body.String(), "<b>Actions:</b>")
+	assert.Contains(t, rr.Body.String(), `value="clear"`)
+	assert.Contains(t, rr.Body.String(), `name="ztoken" value="`+pipelines.zPagesToken+`"`)
+	assert.Len(t, exp.Traces, 1, "actions are only run on POST requests")
+
+	const exporterPage = "/debug/pipelinez?zpipelinename=traces&zcomponentname=exampleexporter&zcomponentkind=exporter"
+	postAction := func(values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", exporterPage, strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		pipelines.HandleZPages(rr, req)
+		return rr
+	}
+
+	// The actions require the token of the form.
+	rr = postAction(url.Values{"zaction": {"clear"}})
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = postAction(url.Values{"zaction": {"clear"}, "ztoken": {"forged"}})
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Len(t, exp.Traces, 1)
+
+	// The actions must be posted.
+	rr = httptest.NewRecorder()
+	pipelines.HandleZPages(rr, httptest.NewRequest("GET", exporterPage+"&zaction=clear&ztoken="+pipelines.zPagesToken, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	rr = httptest.NewRecorder()
+	pipelines.HandleZPages(rr, httptest.NewRequest("PUT", exporterPage, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "GET, HEAD, POST", rr.Header().Get("Allow"))
+	assert.Len(t, exp.Traces, 1)
+
+	rr = postAction(url.Values{"zaction": {"clear"}, "ztoken": {pipelines.zPagesToken}})
+	assert.Contains(t, rr.Body.String(), "Action &#34;clear&#34; succeeded: cleared 1 batches")
+	assert.Empty(t, exp.Traces)
+
+	rr = postAction(url.Values{"zaction": {"unknown"}, "ztoken": {pipelines.zPagesToken}})
+	assert.Contains(t, rr.Body.String(), "Action &#34;unknown&#34; failed")
+
+	assert.NoError(t, pipelines.ShutdownAll(context.Background()))
+}
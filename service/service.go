@@ -25,6 +25,7 @@ import (
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configtelemetry"
+	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/extension"
 	"go.opentelemetry.io/collector/featuregate"
@@ -49,6 +50,9 @@ type Settings struct {
 	// Exporters builder for exporters.
 	Exporters *exporter.Builder
 
+	// Connectors builder for connectors.
+	Connectors *connector.Builder
+
 	// Extensions builder for extensions.
 	Extensions *extension.Builder
 
@@ -189,6 +193,7 @@ func (srv *Service) initExtensionsAndPipeline(ctx context.Context, set Settings,
 		Receivers:       set.Receivers,
 		Processors:      set.Processors,
 		Exporters:       set.Exporters,
+		Connectors:      set.Connectors,
 		PipelineConfigs: cfg.Pipelines,
 	}
 	if srv.host.pipelines, err = buildPipelines(ctx, pSet); err != nil {
@@ -200,6 +205,11 @@ func (srv *Service) initExtensionsAndPipeline(ctx context.Context, set Settings,
 		if err = proctelemetry.RegisterProcessMetrics(srv.telemetryInitializer.ocRegistry, getBallastSize(srv.host)); err != nil {
 			return fmt.Errorf("failed to register process metrics: %w", err)
 		}
+		if memLimiter := getGoMemoryLimiter(srv.host); memLimiter != nil {
+			if err = proctelemetry.RegisterGoMemoryLimitMetrics(srv.telemetryInitializer.ocRegistry, memLimiter); err != nil {
+				return fmt.Errorf("failed to register memory limit metrics: %w", err)
+			}
+		}
 	}
 
 	return nil
@@ -219,3 +229,50 @@ func getBallastSize(host component.Host) uint64 {
 	}
 	return 0
 }
+
+// getGoMemoryLimiter returns the memory limiter extensions and processors, which report the settings applied
+// to the Go runtime in the gomemlimit mode, or nil if there is none.
+func getGoMemoryLimiter(host *serviceHost) proctelemetry.GoMemoryLimiter {
+	var limiters goMemoryLimiters
+	for _, ext := range host.GetExtensions() {
+		if mlExt, ok := ext.(proctelemetry.GoMemoryLimiter); ok {
+			limiters = append(limiters, mlExt)
+		}
+	}
+	for _, bp := range host.pipelines.pipelines {
+		for _, proc := range bp.processors {
+			if mlProc, ok := proc.comp.(proctelemetry.GoMemoryLimiter); ok {
+				limiters = append(limiters, mlProc)
+			}
+		}
+	}
+	switch len(limiters) {
+	case 0:
+		return nil
+	case 1:
+		return limiters[0]
+	}
+	return limiters
+}
+
+// goMemoryLimiters reports the settings of the memory limiter applying its limit to the Go runtime,
+// at most one memory limiter can apply it at a time.
+type goMemoryLimiters []proctelemetry.GoMemoryLimiter
+
+func (gml goMemoryLimiters) GetMemoryLimit() uint64 {
+	for _, ml := range gml {
+		if limit := ml.GetMemoryLimit(); limit != 0 {
+			return limit
+		}
+	}
+	return 0
+}
+
+func (gml goMemoryLimiters) GetGCPercent() int {
+	for _, ml := range gml {
+		if ml.GetMemoryLimit() != 0 {
+			return ml.GetGCPercent()
+		}
+	}
+	return 0
+}
@@ -41,6 +41,7 @@ import (
 	"go.opentelemetry.io/collector/internal/testutil"
 	"go.opentelemetry.io/collector/processor/processortest"
 	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/collector/service/extensions"
 	"go.opentelemetry.io/collector/service/telemetry"
 )
 
@@ -317,6 +318,55 @@ func TestServiceTelemetryRestart(t *testing.T) {
 	require.NoError(t, srvTwo.Shutdown(context.Background()))
 }
 
+type fakeGoMemoryLimiter struct {
+	component.StartFunc
+	component.ShutdownFunc
+	memoryLimit uint64
+	gcPercent   int
+}
+
+func (f *fakeGoMemoryLimiter) GetMemoryLimit() uint64 {
+	return f.memoryLimit
+}
+
+func (f *fakeGoMemoryLimiter) GetGCPercent() int {
+	return f.gcPercent
+}
+
+func TestGetGoMemoryLimiter(t *testing.T) {
+	host := &serviceHost{
+		serviceExtensions: &extensions.Extensions{},
+		pipelines:         &builtPipelines{pipelines: map[component.ID]*builtPipeline{}},
+	}
+	assert.Nil(t, getGoMemoryLimiter(host))
+
+	// The memory limiter processor in the gomemlimit mode is reported as well as the extension.
+	gcModeProc := &fakeGoMemoryLimiter{}
+	goMemLimitProc := &fakeGoMemoryLimiter{memoryLimit: 1024, gcPercent: 200}
+	host.pipelines.pipelines[component.NewID("traces")] = &builtPipeline{
+		processors: []builtComponent{
+			{id: component.NewID("memory_limiter"), comp: gcModeProc},
+			{id: component.NewID("batch"), comp: &struct {
+				component.StartFunc
+				component.ShutdownFunc
+			}{}},
+		},
+	}
+	assert.Same(t, gcModeProc, getGoMemoryLimiter(host))
+
+	host.pipelines.pipelines[component.NewID("metrics")] = &builtPipeline{
+		processors: []builtComponent{{id: component.NewIDWithName("memory_limiter", "gomemlimit"), comp: goMemLimitProc}},
+	}
+	memLimiter := getGoMemoryLimiter(host)
+	require.NotNil(t, memLimiter)
+	assert.Equal(t, uint64(1024), memLimiter.GetMemoryLimit())
+	assert.Equal(t, 200, memLimiter.GetGCPercent())
+
+	goMemLimitProc.memoryLimit = 0
+	assert.Equal(t, uint64(0), memLimiter.GetMemoryLimit())
+	assert.Equal(t, 0, memLimiter.GetGCPercent())
+}
+
 func assertMetrics(t *testing.T, metricsAddr string, expectedLabels map[string]labelValue) {
 	client := &http.Client{}
 	resp, err := client.Get("http://" + metricsAddr + "/metrics")